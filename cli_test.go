@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -128,6 +129,67 @@ func TestCLIScanNonexistentDir(t *testing.T) {
 	}
 }
 
+func TestCLIScanSQLiteStore(t *testing.T) {
+	dir := makeTestDir(t)
+	if _, stderr, err := runBinaryInDir(dir, "scan", ".", "--store", "sqlite"); err != nil {
+		t.Fatalf("scan --store sqlite failed: %v\n%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "swarm", "index", "index.db")); err != nil {
+		t.Fatalf("index.db not created: %v", err)
+	}
+	stdout, _, err := runBinaryInDir(dir, "lookup", "Helper")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if !strings.Contains(stdout, "helper.go") {
+		t.Errorf("expected 'helper.go' in lookup output, got: %s", stdout)
+	}
+}
+
+func TestCLIScanIncremental(t *testing.T) {
+	dir := makeTestDir(t)
+	if _, stderr, err := runBinaryInDir(dir, "scan", ".", "--store", "sqlite"); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "extra.go"), []byte("package pkg\n\nfunc Extra() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, err := runBinaryInDir(dir, "scan", ".", "--incremental", "--json")
+	if err != nil {
+		t.Fatalf("scan --incremental failed: %v\n%s", err, stderr)
+	}
+	var result struct {
+		Store   string `json:"store"`
+		Changes struct {
+			Added     []string `json:"added"`
+			Unchanged int      `json:"unchanged"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\noutput: %s", err, stdout)
+	}
+	if result.Store != "sqlite" {
+		t.Errorf("store = %q, want sqlite to be kept from the previous index", result.Store)
+	}
+	if len(result.Changes.Added) != 1 || result.Changes.Added[0] != filepath.Join("pkg", "extra.go") {
+		t.Errorf("added = %v, want [pkg/extra.go]", result.Changes.Added)
+	}
+	if result.Changes.Unchanged != 3 {
+		t.Errorf("unchanged = %d, want 3", result.Changes.Unchanged)
+	}
+}
+
+func TestCLIScanUnknownStore(t *testing.T) {
+	dir := makeTestDir(t)
+	_, stderr, err := runBinaryInDir(dir, "scan", ".", "--store", "yaml")
+	if err == nil {
+		t.Fatal("expected non-zero exit for unknown store")
+	}
+	if !strings.Contains(stderr, "unknown store") {
+		t.Errorf("expected 'unknown store' on stderr, got: %s", stderr)
+	}
+}
+
 // --- lookup command ---
 
 func TestCLILookupText(t *testing.T) {
@@ -341,10 +403,24 @@ func TestCLIOutlineNonexistentFile(t *testing.T) {
 	}
 }
 
+func TestCLIOutlineMarkdown(t *testing.T) {
+	dir := makeTestDir(t)
+	stdout, stderr, err := runBinary("outline", filepath.Join(dir, "README.md"))
+	if err != nil {
+		t.Fatalf("outline failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Test") {
+		t.Errorf("expected the README heading in outline, got:\n%s", stdout)
+	}
+}
+
 func TestCLIOutlineUnsupportedExt(t *testing.T) {
 	dir := makeTestDir(t)
-	mdFile := filepath.Join(dir, "README.md")
-	_, stderr, err := runBinary("outline", mdFile)
+	txtFile := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(txtFile, []byte("notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err := runBinary("outline", txtFile)
 	if err == nil {
 		t.Fatal("expected non-zero exit for outline on unsupported file type")
 	}
@@ -352,3 +428,493 @@ func TestCLIOutlineUnsupportedExt(t *testing.T) {
 		t.Errorf("expected 'no parser' error on stderr, got: %s", stderr)
 	}
 }
+
+func TestCLIOutlineParserPlugin(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho '[{\"name\":\"greeting\",\"kind\":\"rule\",\"line\":1,\"signature\":\"rule greeting\"}]'\n"
+	if err := os.WriteFile(filepath.Join(dir, "rules.sh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"parsers": [{"extensions": [".rules"], "command": ["./rules.sh"]}]}`
+	if err := os.WriteFile(filepath.Join(dir, ".swarmindex.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.rules"), []byte("rule greeting\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runBinary("outline", filepath.Join(dir, "a.rules"))
+	if err != nil {
+		t.Fatalf("outline failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "rule greeting") {
+		t.Errorf("expected plugin symbol in outline output, got: %s", stdout)
+	}
+}
+
+// --- serve command ---
+
+func TestCLIServeStdio(t *testing.T) {
+	dir := makeTestDir(t)
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	cmd := exec.Command(binaryPath, "serve")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"lookup","params":{"query":"Helper"}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"symbols","params":{"query":"main"}}` + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d response lines, want 2:\n%s", len(lines), out)
+	}
+	var resp struct {
+		ID     int               `json:"id"`
+		Result []json.RawMessage `json:"result"`
+		Error  any               `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &resp); err != nil {
+		t.Fatalf("invalid response: %v\n%s", err, lines[0])
+	}
+	if resp.ID != 1 || resp.Error != nil || len(resp.Result) == 0 {
+		t.Errorf("lookup response = %s", lines[0])
+	}
+}
+
+func TestCLIServeNoIndex(t *testing.T) {
+	dir := t.TempDir()
+	_, stderr, err := runBinaryInDir(dir, "serve")
+	if err == nil {
+		t.Fatal("expected non-zero exit without an index")
+	}
+	if !strings.Contains(stderr, "no index found") {
+		t.Errorf("expected 'no index found' on stderr, got: %s", stderr)
+	}
+}
+
+// --- mcp command ---
+
+func TestCLIMCPToolsList(t *testing.T) {
+	dir := makeTestDir(t)
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	cmd := exec.Command(binaryPath, "mcp")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"lookup","arguments":{"query":"Helper"}}}` + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("mcp failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d response lines, want 2:\n%s", len(lines), out)
+	}
+	if !strings.Contains(lines[0], `"version":"v0.1.0"`) {
+		t.Errorf("initialize should report the CLI version, got: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "Helper") {
+		t.Errorf("lookup tool result should mention Helper, got: %s", lines[1])
+	}
+}
+
+// --- lsp command ---
+
+func TestCLILSPInitialize(t *testing.T) {
+	dir := makeTestDir(t)
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	frame := func(s string) string { return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(s), s) }
+	cmd := exec.Command(binaryPath, "lsp")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(
+		frame(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`) +
+			frame(`{"jsonrpc":"2.0","id":2,"method":"workspace/symbol","params":{"query":"Helper"}}`) +
+			frame(`{"jsonrpc":"2.0","method":"exit"}`))
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("lsp failed: %v", err)
+	}
+	if got := strings.Count(string(out), "Content-Length:"); got != 2 {
+		t.Fatalf("got %d framed responses, want 2:\n%s", got, out)
+	}
+	if !strings.Contains(string(out), `"name":"Helper"`) {
+		t.Errorf("workspace/symbol should find Helper, got:\n%s", out)
+	}
+}
+
+// --- watch command ---
+
+func TestCLIWatch(t *testing.T) {
+	dir := makeTestDir(t)
+	cmd := exec.Command(binaryPath, "watch", ".", "--interval", "20ms", "--json")
+	cmd.Dir = dir
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("watch failed to start: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	lines := bufio.NewScanner(stdout)
+	type save struct {
+		FilesIndexed int `json:"filesIndexed"`
+		Changes      struct {
+			Added []string `json:"added"`
+		} `json:"changes"`
+	}
+	next := func() save {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("watch exited early: %v", lines.Err())
+		}
+		var s save
+		if err := json.Unmarshal(lines.Bytes(), &s); err != nil {
+			t.Fatalf("invalid JSON line: %v\n%s", err, lines.Text())
+		}
+		return s
+	}
+
+	if first := next(); first.FilesIndexed != 3 {
+		t.Errorf("first save indexed %d files, want 3", first.FilesIndexed)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if second := next(); len(second.Changes.Added) != 1 || second.Changes.Added[0] != "new.go" {
+		t.Errorf("second save added %v, want [new.go]", second.Changes.Added)
+	}
+
+	cmd.Process.Signal(os.Interrupt)
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("watch did not exit cleanly on interrupt: %v", err)
+	}
+}
+
+func TestCLIWatchInvalidInterval(t *testing.T) {
+	dir := makeTestDir(t)
+	_, stderr, err := runBinaryInDir(dir, "watch", "--interval", "soon")
+	if err == nil {
+		t.Fatal("expected non-zero exit for invalid interval")
+	}
+	if !strings.Contains(stderr, "--interval") {
+		t.Errorf("expected interval error on stderr, got: %s", stderr)
+	}
+}
+
+// --- doctor command ---
+
+func TestCLIDoctor(t *testing.T) {
+	dir := makeTestDir(t)
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	stdout, stderr, err := runBinaryInDir(dir, "doctor")
+	if err != nil {
+		t.Fatalf("doctor on a fresh index failed: %v\n%s%s", err, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "Index is healthy") {
+		t.Errorf("expected healthy report, got:\n%s", stdout)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "pkg", "helper.go")); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = runBinaryInDir(dir, "doctor", "--json")
+	if err == nil {
+		t.Fatal("expected non-zero exit when indexed files are missing")
+	}
+	var result struct {
+		Healthy bool `json:"healthy"`
+		Checks  []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Healthy {
+		t.Error("healthy = true, want false")
+	}
+	for _, c := range result.Checks {
+		if c.Name == "files" && c.Status != "error" {
+			t.Errorf("files check = %q, want error", c.Status)
+		}
+	}
+}
+
+func TestCLIScanReport(t *testing.T) {
+	dir := makeTestDir(t)
+	if err := os.WriteFile(filepath.Join(dir, "broken.go"), []byte("package main\n\nfunc Broken( {\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, err := runBinaryInDir(dir, "scan", ".")
+	if err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Diagnostics: 1 parse-error") || !strings.Contains(stdout, "broken.go:3") {
+		t.Errorf("scan output should summarize diagnostics, got:\n%s", stdout)
+	}
+
+	stdout, stderr, err = runBinaryInDir(dir, "scan-report", "--kind", "parse-error", "--json")
+	if err != nil {
+		t.Fatalf("scan-report failed: %v\n%s", err, stderr)
+	}
+	var report struct {
+		Total       int `json:"total"`
+		Diagnostics []struct {
+			Path string `json:"path"`
+			Line int    `json:"line"`
+		} `json:"diagnostics"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if report.Total != 1 || report.Diagnostics[0].Path != "broken.go" || report.Diagnostics[0].Line != 3 {
+		t.Errorf("report = %+v, want broken.go at line 3", report)
+	}
+
+	if _, _, err := runBinaryInDir(dir, "scan-report", "--kind", "bogus"); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
+
+func TestCLIServices(t *testing.T) {
+	dir := makeTestDir(t)
+	compose := "services:\n  web:\n    image: nginx:1.25\n    ports:\n      - \"8080:80\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte(compose), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	stdout, stderr, err := runBinaryInDir(dir, "services")
+	if err != nil {
+		t.Fatalf("services failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "web (compose, compose.yaml:2)") || !strings.Contains(stdout, "8080:80") {
+		t.Errorf("unexpected services output:\n%s", stdout)
+	}
+
+	stdout, stderr, err = runBinaryInDir(dir, "services", "--json")
+	if err != nil {
+		t.Fatalf("services --json failed: %v\n%s", err, stderr)
+	}
+	var result struct {
+		Total    int `json:"total"`
+		Services []struct {
+			Name  string `json:"name"`
+			Image string `json:"image"`
+		} `json:"services"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Total != 1 || result.Services[0].Name != "web" || result.Services[0].Image != "nginx:1.25" {
+		t.Errorf("services = %+v", result)
+	}
+}
+
+func TestCLIDocRefs(t *testing.T) {
+	dir := makeTestDir(t)
+	readme := "# Project\n\nSee [the guide](docs/guide.md) and `OldHelper`.\n"
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(readme), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	stdout, stderr, err := runBinaryInDir(dir, "doc-refs")
+	if err != nil {
+		t.Fatalf("doc-refs failed: %v\n%s", err, stderr)
+	}
+	for _, s := range []string{"[file] README.md:3 — docs/guide.md: no such file", "[symbol] README.md:3 — OldHelper"} {
+		if !strings.Contains(stdout, s) {
+			t.Errorf("doc-refs output missing %q:\n%s", s, stdout)
+		}
+	}
+
+	stdout, stderr, err = runBinaryInDir(dir, "doc-refs", "--kind", "file", "--json")
+	if err != nil {
+		t.Fatalf("doc-refs --json failed: %v\n%s", err, stderr)
+	}
+	var result struct {
+		Total  int `json:"total"`
+		Broken []struct {
+			Ref string `json:"ref"`
+		} `json:"broken"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Total != 1 || result.Broken[0].Ref != "docs/guide.md" {
+		t.Errorf("doc-refs = %+v", result)
+	}
+}
+
+func TestCLICallers(t *testing.T) {
+	dir := makeTestDir(t)
+	src := "package main\n\nfunc main() {\n\trun()\n}\n\nfunc run() {\n\thelper()\n\thelper()\n}\n\nfunc helper() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	stdout, stderr, err := runBinaryInDir(dir, "callers", "helper")
+	if err != nil {
+		t.Fatalf("callers failed: %v\n%s", err, stderr)
+	}
+	for _, s := range []string{"Callers of main.helper", "main.go:8  main.run", "main.go:9  main.run", "2 callers"} {
+		if !strings.Contains(stdout, s) {
+			t.Errorf("callers output missing %q:\n%s", s, stdout)
+		}
+	}
+
+	stdout, stderr, err = runBinaryInDir(dir, "callees", "run", "--json")
+	if err != nil {
+		t.Fatalf("callees --json failed: %v\n%s", err, stderr)
+	}
+	var result struct {
+		Total int `json:"total"`
+		Calls []struct {
+			Callee string `json:"callee"`
+		} `json:"calls"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Total != 2 || result.Calls[0].Callee != "main.helper" {
+		t.Errorf("callees = %+v", result)
+	}
+
+	if _, _, err := runBinaryInDir(dir, "callers", "missing"); err == nil {
+		t.Error("callers of an unknown function should fail")
+	}
+}
+
+func TestCLIImplementations(t *testing.T) {
+	dir := makeTestDir(t)
+	src := "package main\n\ntype Runner interface {\n\tRun() error\n\tStop()\n}\n\ntype job struct{}\n\nfunc (j *job) Run() error { return nil }\nfunc (j *job) Stop()      {}\n\nfunc main() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	stdout, stderr, err := runBinaryInDir(dir, "implementations", "Runner")
+	if err != nil {
+		t.Fatalf("implementations failed: %v\n%s", err, stderr)
+	}
+	for _, s := range []string{"Implementations of main.Runner (main.go:3)", "main.go:8  main.job (struct, implements, pointer receiver)", "1 implementation\n"} {
+		if !strings.Contains(stdout, s) {
+			t.Errorf("implementations output missing %q:\n%s", s, stdout)
+		}
+	}
+
+	stdout, stderr, err = runBinaryInDir(dir, "supertypes", "job", "--json")
+	if err != nil {
+		t.Fatalf("supertypes --json failed: %v\n%s", err, stderr)
+	}
+	var result struct {
+		Total   int `json:"total"`
+		Related []struct {
+			Name    string `json:"name"`
+			Pointer bool   `json:"pointer"`
+		} `json:"related"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Total != 1 || result.Related[0].Name != "main.Runner" || !result.Related[0].Pointer {
+		t.Errorf("supertypes = %+v", result)
+	}
+
+	if _, _, err := runBinaryInDir(dir, "implementations", "missing"); err == nil {
+		t.Error("implementations of an unknown type should fail")
+	}
+}
+
+func TestCLIRefsTypes(t *testing.T) {
+	dir := makeTestDir(t)
+	src := `package main
+
+type Index struct{}
+
+func (i *Index) Save() {}
+
+type Cache struct{}
+
+func (c *Cache) Save() {}
+
+func run(i *Index, c *Cache) {
+	i.Save()
+	c.Save()
+}
+
+func main() {
+	run(&Index{}, &Cache{})
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/t\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runBinaryInDir(dir, "scan", "."); err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, stderr)
+	}
+
+	stdout, stderr, err := runBinaryInDir(dir, "refs", "Index.Save", "--types")
+	if err != nil {
+		t.Fatalf("refs --types failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "main.go:12  [call main.Index.Save] i.Save()") || strings.Contains(stdout, "c.Save()") {
+		t.Errorf("refs --types should only list the call of Index.Save:\n%s", stdout)
+	}
+
+	stdout, stderr, err = runBinaryInDir(dir, "context", "run", "main.go", "--types")
+	if err != nil {
+		t.Fatalf("context --types failed: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Uses:") || !strings.Contains(stdout, "call   main.Cache.Save  main.go:9") {
+		t.Errorf("context --types output missing uses:\n%s", stdout)
+	}
+
+	stdout, stderr, err = runBinaryInDir(dir, "impact", "Cache.Save", "--types", "--json")
+	if err != nil {
+		t.Fatalf("impact --types failed: %v\n%s", err, stderr)
+	}
+	var result struct {
+		Layers []struct {
+			Refs []struct {
+				Kind            string `json:"kind"`
+				EnclosingSymbol string `json:"enclosingSymbol"`
+			} `json:"refs"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.Layers) != 2 || result.Layers[0].Refs[0].Kind != "call" || result.Layers[1].Refs[0].EnclosingSymbol != "main.main" {
+		t.Errorf("impact --types = %+v", result)
+	}
+}
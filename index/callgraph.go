@@ -0,0 +1,1005 @@
+package index
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoFunc is a Go function or method in the call graph.
+type GoFunc struct {
+	Name      string `json:"name"`               // package.Func or package.Type.Method
+	Func      string `json:"func"`               // the bare name
+	Receiver  string `json:"receiver,omitempty"` // the receiver's type, for methods
+	Package   string `json:"package"`            // directory of the package
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	EndLine   int    `json:"endLine"`
+	Signature string `json:"signature"`
+
+	decl *ast.FuncDecl
+	file *goFile
+}
+
+// CallSite is a call of a Go function, or a use of one as a value.
+type CallSite struct {
+	Caller  string `json:"caller"` // the enclosing function, or package-level variable
+	Callee  string `json:"callee"`
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Content string `json:"content"`
+	// Resolved is false when the callee is one of several the call may
+	// reach: the receiver is an interface, or its type could not be
+	// determined and the callee was matched by method name.
+	Resolved bool `json:"resolved"`
+	Value    bool `json:"value,omitempty"`    // the function is passed or stored rather than called
+	External bool `json:"external,omitempty"` // the callee is outside the indexed modules
+
+	caller, callee *GoFunc
+}
+
+// CallGraphResult holds the callers or callees of the functions a query
+// names.
+type CallGraphResult struct {
+	Query     string     `json:"query"`
+	Direction string     `json:"direction"` // "callers" or "callees"
+	Functions []GoFunc   `json:"functions"`
+	Calls     []CallSite `json:"calls"`
+	Total     int        `json:"total"`
+}
+
+// goCallGraph is the static call graph of the Go code in the index.
+type goCallGraph struct {
+	funcs   []*GoFunc
+	calls   []CallSite
+	callers map[*GoFunc][]int // indexes into calls
+	callees map[*GoFunc][]int
+	byPos   map[string]*GoFunc // "path:line" of the declaration
+
+	// ifaceMethods holds the method names of the interfaces declared in
+	// the indexed code.
+	ifaceMethods map[string]bool
+}
+
+// goCalls returns the Go call graph, building it on first use.
+func (idx *Index) goCalls() *goCallGraph {
+	if idx.callGraph == nil {
+		idx.callGraph = buildGoCallGraph(idx.Root, idx.FilePaths(), idx.Workers)
+		signatures := make(map[string]string)
+		for _, e := range idx.entries() {
+			if e.Kind == "func" || e.Kind == "method" {
+				signatures[fmt.Sprintf("%s:%d", e.Path, e.Line)] = e.Signature
+			}
+		}
+		for key, fn := range idx.callGraph.byPos {
+			if sig := signatures[key]; sig != "" {
+				fn.Signature = sig
+			}
+		}
+	}
+	return idx.callGraph
+}
+
+// Callers returns the call sites of the Go functions and methods query
+// names, such as "Scan", "Index.Refs" or "index.Index.Refs". At most max
+// are returned when max is positive.
+func (idx *Index) Callers(query string, max int) (*CallGraphResult, error) {
+	return idx.callGraphQuery(query, "callers", max)
+}
+
+// Callees returns the calls made by the Go functions and methods query
+// names, in source order. At most max are returned when max is positive.
+func (idx *Index) Callees(query string, max int) (*CallGraphResult, error) {
+	return idx.callGraphQuery(query, "callees", max)
+}
+
+func (idx *Index) callGraphQuery(query, direction string, max int) (*CallGraphResult, error) {
+	g := idx.goCalls()
+	fns := g.match(query)
+	if len(fns) == 0 {
+		return nil, fmt.Errorf("no Go function or method named %q in the index", query)
+	}
+	result := &CallGraphResult{Query: query, Direction: direction, Functions: []GoFunc{}, Calls: []CallSite{}}
+	var calls []CallSite
+	for _, fn := range fns {
+		result.Functions = append(result.Functions, *fn)
+		edges := g.callees[fn]
+		if direction == "callers" {
+			edges = g.callers[fn]
+		}
+		for _, i := range edges {
+			calls = append(calls, g.calls[i])
+		}
+	}
+	if direction == "callers" {
+		sort.SliceStable(calls, func(i, j int) bool {
+			if calls[i].Path != calls[j].Path {
+				return calls[i].Path < calls[j].Path
+			}
+			return calls[i].Line < calls[j].Line
+		})
+	}
+	result.Total = len(calls)
+	if max > 0 && len(calls) > max {
+		calls = calls[:max]
+	}
+	result.Calls = append(result.Calls, calls...)
+	return result, nil
+}
+
+// match returns the functions whose qualified name ends with query. The
+// receiver may be written as a pointer, as in "(*Index).Refs".
+func (g *goCallGraph) match(query string) []*GoFunc {
+	query = strings.NewReplacer("(", "", ")", "", "*", "").Replace(query)
+	want := strings.Split(query, ".")
+	var matched []*GoFunc
+	for _, fn := range g.funcs {
+		have := strings.Split(fn.Name, ".")
+		if len(want) > len(have) {
+			continue
+		}
+		ok := true
+		for i := range want {
+			if want[len(want)-1-i] != have[len(have)-1-i] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, fn)
+		}
+	}
+	return matched
+}
+
+// hasCallers reports whether fn is called or used as a value anywhere but
+// in its own body.
+func (g *goCallGraph) hasCallers(fn *GoFunc) bool {
+	for _, i := range g.callers[fn] {
+		if g.calls[i].caller != fn {
+			return true
+		}
+	}
+	return false
+}
+
+// mayBeCalledDynamically reports whether a method may be called through
+// an interface: one declared in the indexed code with a method of its
+// name, or, per the type checker, any interface its type satisfies.
+// satisfying holds the "path:line" of the methods of the latter kind.
+func (g *goCallGraph) mayBeCalledDynamically(fn *GoFunc, satisfying map[string]bool) bool {
+	return fn.Receiver != "" && (g.ifaceMethods[fn.Func] || satisfying[fmt.Sprintf("%s:%d", fn.Path, fn.Line)])
+}
+
+// goFile is a parsed Go source file.
+type goFile struct {
+	relPath string
+	fset    *token.FileSet
+	ast     *ast.File
+	lines   []string
+	pkg     *goPackage
+	imports map[string]string // local name to import path
+}
+
+// goPackage is the declarations of a Go package, by directory and name.
+type goPackage struct {
+	dir, name string
+	funcs     map[string]*GoFunc
+	methods   map[string]map[string]*GoFunc // receiver type to method name
+	types     map[string]goTypeExpr         // the type declared by each name
+	vars      map[string]goVar
+}
+
+// goTypeExpr is a type expression with the file it appears in, which
+// resolves the package names it uses. The zero value is an unknown type.
+type goTypeExpr struct {
+	expr ast.Expr
+	file *goFile
+}
+
+// goVar is a package-level variable: its declared type, or the value it
+// is initialized with.
+type goVar struct {
+	typ   ast.Expr
+	value ast.Expr
+	index int // position of the variable among the results of value
+	file  *goFile
+}
+
+// goNamed is a named type: declared in an indexed package, or by an
+// imported package outside them.
+type goNamed struct {
+	pkg  *goPackage
+	ext  string // local name of the external package
+	name string
+}
+
+// goGraphBuilder holds the indexed packages while the call graph is built.
+type goGraphBuilder struct {
+	packages     []*goPackage
+	importPkg    map[string]*goPackage // import path to package
+	methodsNamed map[string][]*GoFunc
+	funcOf       map[*ast.FuncDecl]*GoFunc
+}
+
+var goModuleRe = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// buildGoCallGraph parses the Go files among paths and resolves the calls
+// between their functions. Types are inferred from the syntax alone:
+// parameters, composite literals, the results of known functions, struct
+// fields, range variables and type switches give a variable its type, and
+// imports are mapped to directories through the module paths in go.mod
+// files. Files are parsed and walked by workers goroutines.
+func buildGoCallGraph(root string, paths []string, workers int) *goCallGraph {
+	modules := make(map[string]string) // module path to directory
+	var goPaths []string
+	for _, p := range paths {
+		if filepath.Base(p) == "go.mod" {
+			if data, err := os.ReadFile(filepath.Join(root, p)); err == nil {
+				if m := goModuleRe.FindSubmatch(data); m != nil {
+					modules[string(m[1])] = filepath.Dir(p)
+				}
+			}
+		} else if filepath.Ext(p) == ".go" {
+			goPaths = append(goPaths, p)
+		}
+	}
+	sort.Strings(goPaths)
+
+	parsed := parallelMap(goPaths, workers, func(relPath string) *goFile {
+		content, err := os.ReadFile(filepath.Join(root, relPath))
+		if err != nil {
+			return nil
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, relPath, content, parser.SkipObjectResolution)
+		if err != nil {
+			return nil
+		}
+		return &goFile{relPath: relPath, fset: fset, ast: file, lines: strings.Split(string(content), "\n")}
+	})
+
+	b := &goGraphBuilder{
+		importPkg:    make(map[string]*goPackage),
+		methodsNamed: make(map[string][]*GoFunc),
+		funcOf:       make(map[*ast.FuncDecl]*GoFunc),
+	}
+	g := &goCallGraph{
+		callers:      make(map[*GoFunc][]int),
+		callees:      make(map[*GoFunc][]int),
+		byPos:        make(map[string]*GoFunc),
+		ifaceMethods: make(map[string]bool),
+	}
+	byKey := make(map[string]*goPackage)
+	var files []*goFile
+	for _, f := range parsed {
+		if f == nil {
+			continue
+		}
+		dir := filepath.Dir(f.relPath)
+		key := dir + "\x00" + f.ast.Name.Name
+		pkg := byKey[key]
+		if pkg == nil {
+			pkg = &goPackage{
+				dir:     dir,
+				name:    f.ast.Name.Name,
+				funcs:   make(map[string]*GoFunc),
+				methods: make(map[string]map[string]*GoFunc),
+				types:   make(map[string]goTypeExpr),
+				vars:    make(map[string]goVar),
+			}
+			byKey[key] = pkg
+			b.packages = append(b.packages, pkg)
+			if !strings.HasSuffix(pkg.name, "_test") {
+				if path := goImportPath(modules, dir); path != "" {
+					b.importPkg[path] = pkg
+				}
+			}
+		}
+		f.pkg = pkg
+		files = append(files, f)
+	}
+
+	for _, f := range files {
+		f.imports = make(map[string]string)
+		for _, spec := range f.ast.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			name := goDefaultImportName(path)
+			if p := b.importPkg[path]; p != nil {
+				name = p.name
+			}
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if name != "_" && name != "." {
+				f.imports[name] = path
+			}
+		}
+		b.collect(f, g)
+	}
+	for _, fns := range b.methodsNamed {
+		sort.Slice(fns, func(i, j int) bool { return fns[i].Name < fns[j].Name })
+	}
+
+	perFile := parallelMap(files, workers, b.walkFile)
+	for _, calls := range perFile {
+		for _, c := range calls {
+			i := len(g.calls)
+			g.calls = append(g.calls, c)
+			if c.caller != nil {
+				g.callees[c.caller] = append(g.callees[c.caller], i)
+			}
+			if c.callee != nil {
+				g.callers[c.callee] = append(g.callers[c.callee], i)
+			}
+		}
+	}
+	return g
+}
+
+// goImportPath returns the import path of the package in dir, or "" if no
+// go.mod above it declares a module.
+func goImportPath(modules map[string]string, dir string) string {
+	best, bestDir := "", ""
+	for path, modDir := range modules {
+		rel, err := filepath.Rel(modDir, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if best == "" || len(modDir) > len(bestDir) {
+			best, bestDir = path, modDir
+			if rel != "." {
+				best = path + "/" + filepath.ToSlash(rel)
+			}
+		}
+	}
+	return best
+}
+
+// goDefaultImportName guesses the package name of an import path outside
+// the index from its last element, skipping a major version suffix.
+func goDefaultImportName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i] // gopkg.in/yaml.v3
+	}
+	return strings.TrimPrefix(name, "go-")
+}
+
+// collect records the top-level declarations of f in its package.
+func (b *goGraphBuilder) collect(f *goFile, g *goCallGraph) {
+	pkg := f.pkg
+	for _, decl := range f.ast.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			fn := &GoFunc{
+				Func:      d.Name.Name,
+				Package:   pkg.dir,
+				Path:      f.relPath,
+				Line:      f.fset.Position(d.Pos()).Line,
+				EndLine:   f.fset.Position(d.End()).Line,
+				Signature: goFuncSignature(d),
+				decl:      d,
+				file:      f,
+			}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				fn.Receiver = goReceiverName(d.Recv.List[0].Type)
+				fn.Name = pkg.name + "." + fn.Receiver + "." + fn.Func
+				if pkg.methods[fn.Receiver] == nil {
+					pkg.methods[fn.Receiver] = make(map[string]*GoFunc)
+				}
+				pkg.methods[fn.Receiver][fn.Func] = fn
+				b.methodsNamed[fn.Func] = append(b.methodsNamed[fn.Func], fn)
+			} else {
+				fn.Name = pkg.name + "." + fn.Func
+				if fn.Func != "init" && fn.Func != "_" {
+					pkg.funcs[fn.Func] = fn
+				}
+			}
+			b.funcOf[d] = fn
+			g.funcs = append(g.funcs, fn)
+			g.byPos[fmt.Sprintf("%s:%d", fn.Path, fn.Line)] = fn
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					pkg.types[s.Name.Name] = goTypeExpr{s.Type, f}
+					if iface, ok := s.Type.(*ast.InterfaceType); ok {
+						for _, m := range iface.Methods.List {
+							for _, name := range m.Names {
+								g.ifaceMethods[name.Name] = true
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for i, name := range s.Names {
+						v := goVar{typ: s.Type, index: i, file: f}
+						if len(s.Values) == len(s.Names) {
+							v.value, v.index = s.Values[i], 0
+						} else if len(s.Values) == 1 {
+							v.value = s.Values[0]
+						}
+						pkg.vars[name.Name] = v
+					}
+				}
+			}
+		}
+	}
+}
+
+// named returns the named type t refers to, looking through pointers and
+// type arguments.
+func (b *goGraphBuilder) named(t goTypeExpr) goNamed {
+	switch x := t.expr.(type) {
+	case *ast.ParenExpr:
+		return b.named(goTypeExpr{x.X, t.file})
+	case *ast.StarExpr:
+		return b.named(goTypeExpr{x.X, t.file})
+	case *ast.IndexExpr:
+		return b.named(goTypeExpr{x.X, t.file})
+	case *ast.IndexListExpr:
+		return b.named(goTypeExpr{x.X, t.file})
+	case *ast.Ident:
+		if _, ok := t.file.pkg.types[x.Name]; ok {
+			return goNamed{pkg: t.file.pkg, name: x.Name}
+		}
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if path, ok := t.file.imports[id.Name]; ok {
+				if p := b.importPkg[path]; p != nil {
+					return goNamed{pkg: p, name: x.Sel.Name}
+				}
+				return goNamed{ext: id.Name, name: x.Sel.Name}
+			}
+		}
+	}
+	return goNamed{}
+}
+
+// underlying returns the type expression t stands for once named types
+// declared in the index are replaced by their definitions.
+func (b *goGraphBuilder) underlying(t goTypeExpr) goTypeExpr {
+	for i := 0; i < 8 && t.expr != nil; i++ {
+		if star, ok := t.expr.(*ast.StarExpr); ok {
+			t.expr = star.X
+			continue
+		}
+		n := b.named(t)
+		if n.pkg == nil {
+			return t
+		}
+		def, ok := n.pkg.types[n.name]
+		if !ok {
+			return t
+		}
+		t = def
+	}
+	return t
+}
+
+// field returns the type of the field name of the struct type t,
+// including fields promoted from embedded structs.
+func (b *goGraphBuilder) field(t goTypeExpr, name string, depth int) goTypeExpr {
+	def := b.underlying(t)
+	st, ok := def.expr.(*ast.StructType)
+	if !ok || depth > 4 {
+		return goTypeExpr{}
+	}
+	file := def.file
+	for _, f := range st.Fields.List {
+		for _, n := range f.Names {
+			if n.Name == name {
+				return goTypeExpr{f.Type, file}
+			}
+		}
+	}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			embedded := goTypeExpr{f.Type, file}
+			if b.named(embedded).name == name {
+				return embedded
+			}
+			if ft := b.field(embedded, name, depth+1); ft.expr != nil {
+				return ft
+			}
+		}
+	}
+	return goTypeExpr{}
+}
+
+// method returns the method name of the named type n, including methods
+// promoted from embedded fields.
+func (b *goGraphBuilder) method(n goNamed, name string, depth int) *GoFunc {
+	if n.pkg == nil || depth > 4 {
+		return nil
+	}
+	if m := n.pkg.methods[n.name][name]; m != nil {
+		return m
+	}
+	def := n.pkg.types[n.name]
+	if st, ok := def.expr.(*ast.StructType); ok {
+		for _, f := range st.Fields.List {
+			if len(f.Names) == 0 {
+				if m := b.method(b.named(goTypeExpr{f.Type, def.file}), name, depth+1); m != nil {
+					return m
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// implementations returns the methods called name of the indexed types
+// that have every method of the interface n, by name.
+func (b *goGraphBuilder) implementations(n goNamed, name string) []*GoFunc {
+	iface, ok := b.underlying(n.pkg.types[n.name]).expr.(*ast.InterfaceType)
+	if !ok {
+		return nil
+	}
+	var required []string
+	for _, m := range iface.Methods.List {
+		for _, id := range m.Names {
+			required = append(required, id.Name)
+		}
+	}
+	var impls []*GoFunc
+	for _, fn := range b.methodsNamed[name] {
+		methods := fn.file.pkg.methods[fn.Receiver]
+		ok := true
+		for _, r := range required {
+			if methods[r] == nil {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			impls = append(impls, fn)
+		}
+	}
+	return impls
+}
+
+// isInterface reports whether n is an interface type declared in the index.
+func (b *goGraphBuilder) isInterface(n goNamed) bool {
+	if n.pkg == nil {
+		return false
+	}
+	_, ok := b.underlying(n.pkg.types[n.name]).expr.(*ast.InterfaceType)
+	return ok
+}
+
+// goResolution is what the function expression of a call refers to.
+type goResolution struct {
+	targets  []*GoFunc
+	resolved bool
+	external string // name of a callee outside the index
+}
+
+// resolve returns the functions fun refers to in file f, where scope maps
+// local variables to their types.
+func (b *goGraphBuilder) resolve(fun ast.Expr, f *goFile, scope map[string]goTypeExpr) goResolution {
+	switch x := fun.(type) {
+	case *ast.ParenExpr:
+		return b.resolve(x.X, f, scope)
+	case *ast.IndexExpr:
+		return b.resolve(x.X, f, scope) // an instantiation of a generic function
+	case *ast.IndexListExpr:
+		return b.resolve(x.X, f, scope)
+	case *ast.Ident:
+		if _, local := scope[x.Name]; !local {
+			if fn := f.pkg.funcs[x.Name]; fn != nil {
+				return goResolution{targets: []*GoFunc{fn}, resolved: true}
+			}
+		}
+		return goResolution{} // a builtin, a conversion or a function variable
+	case *ast.SelectorExpr:
+		name := x.Sel.Name
+		if id, ok := x.X.(*ast.Ident); ok {
+			if _, local := scope[id.Name]; !local {
+				if path, ok := f.imports[id.Name]; ok {
+					if p := b.importPkg[path]; p != nil {
+						if fn := p.funcs[name]; fn != nil {
+							return goResolution{targets: []*GoFunc{fn}, resolved: true}
+						}
+						return goResolution{}
+					}
+					return goResolution{resolved: true, external: id.Name + "." + name}
+				}
+				if _, ok := f.pkg.types[id.Name]; ok {
+					// A method expression, T.Method.
+					if m := b.method(goNamed{pkg: f.pkg, name: id.Name}, name, 0); m != nil {
+						return goResolution{targets: []*GoFunc{m}, resolved: true}
+					}
+				}
+			}
+		}
+		n := b.named(b.exprType(x.X, f, scope, 0))
+		switch {
+		case n.pkg != nil:
+			if m := b.method(n, name, 0); m != nil {
+				return goResolution{targets: []*GoFunc{m}, resolved: true}
+			}
+			if b.isInterface(n) {
+				return goResolution{targets: b.implementations(n, name)}
+			}
+			return goResolution{} // a field of function type
+		case n.ext != "":
+			return goResolution{resolved: true, external: n.ext + "." + n.name + "." + name}
+		}
+		if fns := b.methodsNamed[name]; len(fns) > 0 {
+			return goResolution{targets: fns}
+		}
+		return goResolution{external: name}
+	}
+	return goResolution{}
+}
+
+// exprType infers the type of e in file f, where scope maps local
+// variables to their types. It returns the zero goTypeExpr when the type
+// cannot be told from the syntax.
+func (b *goGraphBuilder) exprType(e ast.Expr, f *goFile, scope map[string]goTypeExpr, depth int) goTypeExpr {
+	if depth > 8 {
+		return goTypeExpr{}
+	}
+	switch x := e.(type) {
+	case *ast.ParenExpr:
+		return b.exprType(x.X, f, scope, depth+1)
+	case *ast.StarExpr:
+		return b.exprType(x.X, f, scope, depth+1)
+	case *ast.UnaryExpr:
+		if x.Op == token.AND {
+			return b.exprType(x.X, f, scope, depth+1)
+		}
+	case *ast.CompositeLit:
+		if x.Type != nil {
+			return goTypeExpr{x.Type, f}
+		}
+	case *ast.FuncLit:
+		return goTypeExpr{x.Type, f}
+	case *ast.TypeAssertExpr:
+		if x.Type != nil {
+			return goTypeExpr{x.Type, f}
+		}
+	case *ast.SliceExpr:
+		return b.exprType(x.X, f, scope, depth+1)
+	case *ast.Ident:
+		if t, ok := scope[x.Name]; ok {
+			return t
+		}
+		if v, ok := f.pkg.vars[x.Name]; ok {
+			return b.varType(v, depth+1)
+		}
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok {
+			if _, local := scope[id.Name]; !local {
+				if p := b.importPkg[f.imports[id.Name]]; p != nil {
+					if v, ok := p.vars[x.Sel.Name]; ok {
+						return b.varType(v, depth+1)
+					}
+					return goTypeExpr{}
+				}
+			}
+		}
+		return b.field(b.exprType(x.X, f, scope, depth+1), x.Sel.Name, 0)
+	case *ast.IndexExpr:
+		switch t := b.underlying(b.exprType(x.X, f, scope, depth+1)); c := t.expr.(type) {
+		case *ast.ArrayType:
+			return goTypeExpr{c.Elt, t.file}
+		case *ast.MapType:
+			return goTypeExpr{c.Value, t.file}
+		}
+	case *ast.CallExpr:
+		return b.resultType(x, 0, f, scope, depth)
+	}
+	return goTypeExpr{}
+}
+
+// resultType returns the type of the i-th result of call.
+func (b *goGraphBuilder) resultType(call *ast.CallExpr, i int, f *goFile, scope map[string]goTypeExpr, depth int) goTypeExpr {
+	if id, ok := call.Fun.(*ast.Ident); ok && id.Name == "new" && len(call.Args) == 1 {
+		return goTypeExpr{call.Args[0], f}
+	}
+	var ft *ast.FuncType
+	var file *goFile
+	if r := b.resolve(call.Fun, f, scope); r.resolved && len(r.targets) == 1 {
+		ft, file = r.targets[0].decl.Type, r.targets[0].file
+	} else if n := b.named(goTypeExpr{call.Fun, f}); n.pkg != nil {
+		return goTypeExpr{call.Fun, f} // a conversion
+	} else if t := b.underlying(b.exprType(call.Fun, f, scope, depth+1)); t.expr != nil {
+		ft, _ = t.expr.(*ast.FuncType)
+		file = t.file
+	}
+	if ft == nil || ft.Results == nil {
+		return goTypeExpr{}
+	}
+	for _, field := range ft.Results.List {
+		n := max(len(field.Names), 1)
+		if i < n {
+			return goTypeExpr{field.Type, file}
+		}
+		i -= n
+	}
+	return goTypeExpr{}
+}
+
+// varType returns the type of a package-level variable.
+func (b *goGraphBuilder) varType(v goVar, depth int) goTypeExpr {
+	if v.typ != nil {
+		return goTypeExpr{v.typ, v.file}
+	}
+	if call, ok := v.value.(*ast.CallExpr); ok {
+		return b.resultType(call, v.index, v.file, nil, depth)
+	}
+	if v.value != nil {
+		return b.exprType(v.value, v.file, nil, depth)
+	}
+	return goTypeExpr{}
+}
+
+// goWalker collects the call sites of one file.
+type goWalker struct {
+	b          *goGraphBuilder
+	file       *goFile
+	scope      map[string]goTypeExpr
+	caller     *GoFunc
+	callerName string
+	handled    map[ast.Node]bool // function expressions already resolved as part of a call
+	calls      []CallSite
+}
+
+// walkFile returns the call sites in the functions and package-level
+// variables of f.
+func (b *goGraphBuilder) walkFile(f *goFile) []CallSite {
+	w := &goWalker{b: b, file: f, handled: make(map[ast.Node]bool)}
+	for _, decl := range f.ast.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Body == nil {
+				continue
+			}
+			w.scope = make(map[string]goTypeExpr)
+			w.caller = b.funcOf[d]
+			w.callerName = w.caller.Name
+			w.declare(d.Recv)
+			w.declare(d.Type.Params)
+			w.declare(d.Type.Results)
+			ast.Inspect(d.Body, w.visit)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok || len(vs.Values) == 0 {
+					continue
+				}
+				w.scope = make(map[string]goTypeExpr)
+				w.caller = nil
+				w.callerName = f.pkg.name + "." + vs.Names[0].Name
+				for _, v := range vs.Values {
+					ast.Inspect(v, w.visit)
+				}
+			}
+		}
+	}
+	return w.calls
+}
+
+// declare adds the named fields of a parameter list to the scope.
+func (w *goWalker) declare(fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			w.scope[name.Name] = goTypeExpr{field.Type, w.file}
+		}
+	}
+}
+
+// assign gives the variables defined by lhs the types of rhs.
+func (w *goWalker) assign(lhs []ast.Expr, rhs []ast.Expr) {
+	for i, l := range lhs {
+		id, ok := l.(*ast.Ident)
+		if !ok || id.Name == "_" {
+			continue
+		}
+		var t goTypeExpr
+		switch {
+		case len(rhs) == len(lhs):
+			t = w.b.exprType(rhs[i], w.file, w.scope, 0)
+		case len(rhs) == 1:
+			switch r := rhs[0].(type) {
+			case *ast.CallExpr:
+				t = w.b.resultType(r, i, w.file, w.scope, 0)
+			case *ast.IndexExpr, *ast.TypeAssertExpr:
+				if i == 0 { // v, ok := m[k] or x.(T)
+					t = w.b.exprType(r, w.file, w.scope, 0)
+				}
+			}
+		}
+		w.scope[id.Name] = t
+	}
+}
+
+func (w *goWalker) visit(n ast.Node) bool {
+	switch x := n.(type) {
+	case *ast.FuncLit:
+		w.declare(x.Type.Params)
+		w.declare(x.Type.Results)
+	case *ast.AssignStmt:
+		if x.Tok == token.DEFINE {
+			w.assign(x.Lhs, x.Rhs)
+		}
+	case *ast.ValueSpec:
+		if x.Type != nil {
+			for _, name := range x.Names {
+				w.scope[name.Name] = goTypeExpr{x.Type, w.file}
+			}
+		} else {
+			lhs := make([]ast.Expr, len(x.Names))
+			for i, name := range x.Names {
+				lhs[i] = name
+			}
+			w.assign(lhs, x.Values)
+		}
+	case *ast.RangeStmt:
+		if x.Tok == token.DEFINE {
+			var key, value goTypeExpr
+			switch t := w.b.underlying(w.b.exprType(x.X, w.file, w.scope, 0)); c := t.expr.(type) {
+			case *ast.ArrayType:
+				value = goTypeExpr{c.Elt, t.file}
+			case *ast.MapType:
+				key, value = goTypeExpr{c.Key, t.file}, goTypeExpr{c.Value, t.file}
+			}
+			if id, ok := x.Key.(*ast.Ident); ok {
+				w.scope[id.Name] = key
+			}
+			if id, ok := x.Value.(*ast.Ident); ok {
+				w.scope[id.Name] = value
+			}
+		}
+	case *ast.TypeSwitchStmt:
+		// In a clause naming one type, the switch variable has that type.
+		if x.Init != nil {
+			ast.Inspect(x.Init, w.visit)
+		}
+		ast.Inspect(x.Assign, w.visit)
+		name := ""
+		if assign, ok := x.Assign.(*ast.AssignStmt); ok {
+			if id, ok := assign.Lhs[0].(*ast.Ident); ok {
+				name = id.Name
+			}
+		}
+		for _, stmt := range x.Body.List {
+			clause := stmt.(*ast.CaseClause)
+			if name != "" {
+				w.scope[name] = goTypeExpr{}
+				if len(clause.List) == 1 {
+					w.scope[name] = goTypeExpr{clause.List[0], w.file}
+				}
+			}
+			ast.Inspect(clause, w.visit)
+		}
+		return false
+	case *ast.CompositeLit:
+		// Keys of struct literals are field names, not references.
+		for _, elt := range x.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if id, ok := kv.Key.(*ast.Ident); ok {
+					w.handled[id] = true
+				}
+			}
+		}
+	case *ast.CallExpr:
+		w.record(x.Fun, false)
+	case *ast.SelectorExpr:
+		if !w.handled[x] {
+			w.record(x, true)
+		}
+		w.handled[x.Sel] = true
+	case *ast.Ident:
+		if !w.handled[x] {
+			w.record(x, true)
+		}
+	}
+	return true
+}
+
+// record adds the call sites of fun, a called function expression or,
+// when value is set, a function used as a value.
+func (w *goWalker) record(fun ast.Expr, value bool) {
+	for e := fun; e != nil; {
+		w.handled[e] = true
+		switch x := e.(type) {
+		case *ast.SelectorExpr:
+			w.handled[x.Sel] = true
+			e = nil
+		case *ast.ParenExpr:
+			e = x.X
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.IndexListExpr:
+			e = x.X
+		default:
+			e = nil
+		}
+	}
+	r := w.b.resolve(fun, w.file, w.scope)
+	if value && !r.resolved {
+		return // only a known function is a function value
+	}
+	pos := fun.Pos()
+	if sel, ok := fun.(*ast.SelectorExpr); ok {
+		pos = sel.Sel.Pos()
+	}
+	line := w.file.fset.Position(pos).Line
+	site := CallSite{
+		Caller:   w.callerName,
+		Path:     w.file.relPath,
+		Line:     line,
+		Content:  strings.TrimSpace(w.file.lines[line-1]),
+		Resolved: r.resolved,
+		Value:    value,
+		caller:   w.caller,
+	}
+	if r.external != "" && !value {
+		site.Callee, site.External = r.external, true
+		w.calls = append(w.calls, site)
+	}
+	for _, target := range r.targets {
+		site.Callee, site.callee = target.Name, target
+		w.calls = append(w.calls, site)
+	}
+}
+
+// FormatCallGraph returns a human-readable rendering of the callers or
+// callees of the functions a query names.
+func FormatCallGraph(r *CallGraphResult) string {
+	var b strings.Builder
+	for _, fn := range r.Functions {
+		b.WriteString(fmt.Sprintf("%s of %s (%s:%d)\n", strings.ToUpper(r.Direction[:1])+r.Direction[1:], fn.Name, fn.Path, fn.Line))
+	}
+	if r.Total == 0 {
+		b.WriteString(fmt.Sprintf("\nNo %s found\n", r.Direction))
+		return b.String()
+	}
+	b.WriteString("\n")
+	for _, c := range r.Calls {
+		name := c.Caller
+		if r.Direction == "callees" {
+			name = c.Callee
+		}
+		var notes []string
+		if c.Value {
+			notes = append(notes, "as value")
+		}
+		if c.External {
+			notes = append(notes, "external")
+		}
+		if !c.Resolved {
+			notes = append(notes, "by name")
+		}
+		note := ""
+		if len(notes) > 0 {
+			note = " (" + strings.Join(notes, ", ") + ")"
+		}
+		b.WriteString(fmt.Sprintf("  %s:%d  %s%s\n      %s\n", c.Path, c.Line, name, note, c.Content))
+	}
+	if len(r.Calls) < r.Total {
+		b.WriteString(fmt.Sprintf("\n... and %d more (use --max to see more)\n", r.Total-len(r.Calls)))
+	}
+	b.WriteString(fmt.Sprintf("\n%d %s\n", r.Total, r.Direction))
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package index
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCallGraphFixture(t *testing.T) string {
+	t.Helper()
+	tmp := t.TempDir()
+	mkFile(t, tmp, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	mkFile(t, tmp, "store/store.go", `package store
+
+import "fmt"
+
+type Closer interface {
+	Close() error
+}
+
+type File struct{ name string }
+
+func (f *File) Close() error { return nil }
+
+type Conn struct{}
+
+func (c *Conn) Close() error { return nil }
+
+func (c *Conn) Query() []*File {
+	fmt.Println("query")
+	return nil
+}
+
+func Open(name string) *File { return &File{name: name} }
+
+func Dial() (*Conn, error) { return &Conn{}, nil }
+
+func (f *File) Query() string { return f.name }
+`)
+	mkFile(t, tmp, "app/app.go", `package app
+
+import (
+	"sort"
+
+	"example.com/app/store"
+)
+
+type App struct {
+	conn *store.Conn
+}
+
+func (a *App) Run() {
+	f := store.Open("x")
+	defer f.Close()
+	for _, r := range a.conn.Query() {
+		r.Close()
+	}
+	var c store.Closer = f
+	c.Close()
+	sort.Slice(nil, less)
+}
+
+func less(i, j int) bool { return i < j }
+
+func New() *App {
+	conn, _ := store.Dial()
+	return &App{conn: conn}
+}
+
+func shutdown(x interface{ Shutdown() }) {
+	x.Close()
+}
+`)
+	return tmp
+}
+
+func TestCallGraph(t *testing.T) {
+	idx, err := Scan(writeCallGraphFixture(t))
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	callers, err := idx.Callers("File.Close", 0)
+	if err != nil {
+		t.Fatalf("Callers() error: %v", err)
+	}
+	type site struct {
+		caller   string
+		line     int
+		resolved bool
+	}
+	var got []site
+	for _, c := range callers.Calls {
+		got = append(got, site{c.Caller, c.Line, c.Resolved})
+	}
+	// The deferred call and the range variable resolve to File; the call
+	// through the interface may reach it; the call on an unknown receiver
+	// matches by name.
+	want := []site{
+		{"app.App.Run", 15, true},
+		{"app.App.Run", 17, true},
+		{"app.App.Run", 20, false},
+		{"app.shutdown", 32, false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Callers(File.Close) = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("caller %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Conn.Close shares the name but is only reached through the interface
+	// and by name.
+	conn, err := idx.Callers("(*Conn).Close", 0)
+	if err != nil {
+		t.Fatalf("Callers() error: %v", err)
+	}
+	for _, c := range conn.Calls {
+		if c.Resolved {
+			t.Errorf("Callers(Conn.Close) has resolved call %+v", c)
+		}
+	}
+
+	callees, err := idx.Callees("app.App.Run", 0)
+	if err != nil {
+		t.Fatalf("Callees() error: %v", err)
+	}
+	var names []string
+	for _, c := range callees.Calls {
+		if c.Resolved {
+			names = append(names, c.Callee)
+		}
+	}
+	wantCallees := "store.Open store.File.Close store.Conn.Query store.File.Close sort.Slice app.less"
+	if strings.Join(names, " ") != wantCallees {
+		t.Errorf("resolved callees = %v, want %s", names, wantCallees)
+	}
+	last := callees.Calls[len(callees.Calls)-1]
+	if !last.Value || last.Path != filepath.FromSlash("app/app.go") || last.Line != 21 {
+		t.Errorf("last callee = %+v, want less used as a value on line 21", last)
+	}
+
+	out := FormatCallGraph(callers)
+	for _, s := range []string{"Callers of store.File.Close (" + filepath.FromSlash("store/store.go") + ":11)", "app.shutdown (by name)", "4 callers"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("FormatCallGraph() missing %q:\n%s", s, out)
+		}
+	}
+
+	if _, err := idx.Callers("Missing", 0); err == nil {
+		t.Error("Callers(Missing) should fail")
+	}
+}
+
+func TestCallGraphImpactAndDeadCode(t *testing.T) {
+	idx, err := Scan(writeCallGraphFixture(t))
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	// A qualified method name is a symbol, not a file.
+	impact, err := idx.Impact("Conn.Query", 3, 100)
+	if err != nil {
+		t.Fatalf("Impact() error: %v", err)
+	}
+	if impact.Target.Kind != "method" || impact.Target.File != filepath.FromSlash("store/store.go") {
+		t.Errorf("Impact target = %+v", impact.Target)
+	}
+	if len(impact.Layers) != 1 || len(impact.Layers[0].Refs) != 1 || impact.Layers[0].Refs[0].EnclosingSymbol != "app.App.Run" {
+		t.Errorf("Impact layers = %+v, want one call from app.App.Run", impact.Layers)
+	}
+
+	dead, err := idx.DeadCode("", "", 0)
+	if err != nil {
+		t.Fatalf("DeadCode() error: %v", err)
+	}
+	var names []string
+	for _, c := range dead.Candidates {
+		names = append(names, c.Name)
+	}
+	// File.Query shares its name with the called Conn.Query; Close may be
+	// called through Closer.
+	if got := strings.Join(names, " "); got != "Run New Query" {
+		t.Errorf("DeadCode() = %s, want Run New Query", got)
+	}
+}
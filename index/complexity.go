@@ -49,8 +49,7 @@ func (idx *Index) Complexity(file string, maxResults int, minComplexity int) (*C
 		allFuncs = append(allFuncs, funcs...)
 	} else {
 		for _, relPath := range idx.FilePaths() {
-			ext := filepath.Ext(relPath)
-			p := parsers.ForExtension(ext)
+			p := parsers.ForFile(relPath)
 			if p == nil {
 				continue
 			}
@@ -306,7 +305,7 @@ var (
 // the parsers package for function boundaries and regex for branch counting.
 func analyzeHeuristicComplexity(displayPath string, content []byte, branchPatterns []*regexp.Regexp) ([]FunctionComplexity, error) {
 	ext := filepath.Ext(displayPath)
-	p := parsers.ForExtension(ext)
+	p := parsers.ForFile(displayPath)
 	if p == nil {
 		return nil, nil
 	}
@@ -33,7 +33,7 @@ func (idx *Index) Config() (*ConfigResult, error) {
 	// Build a set of indexed filenames for quick lookup.
 	// Maps base filename -> first relative path.
 	pathSet := make(map[string]string)
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Kind != "file" {
 			continue
 		}
@@ -74,8 +74,8 @@ func (idx *Index) detectLanguage() string {
 	// Only count source code extensions (not config/data files).
 	sourceExts := map[string]bool{
 		".go": true, ".js": true, ".ts": true, ".tsx": true, ".jsx": true,
-		".py": true, ".rs": true, ".java": true, ".rb": true,
-		".c": true, ".h": true, ".cpp": true, ".hpp": true,
+		".py": true, ".rs": true, ".java": true, ".rb": true, ".php": true,
+		".c": true, ".h": true, ".cpp": true, ".hpp": true, ".cc": true,
 		".cs": true, ".swift": true, ".kt": true, ".sh": true,
 	}
 
@@ -218,7 +218,7 @@ var toolDetectors = []toolDetector{
 // detectTools checks for config files in the index and populates build/test/lint/format.
 func (idx *Index) detectTools(result *ConfigResult, pathSet map[string]string) {
 	// Check exact filename matches and prefix matches.
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Kind != "file" {
 			continue
 		}
@@ -322,7 +322,7 @@ func (idx *Index) detectTools(result *ConfigResult, pathSet map[string]string) {
 	}
 
 	// Add CI config files.
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Kind != "file" {
 			continue
 		}
@@ -24,6 +24,12 @@ func extractFileImports(content []byte, ext string) []string {
 		imports = extractJSImports(scanner)
 	case ".py":
 		imports = extractPyImports(scanner)
+	case ".rs":
+		imports = extractRustImports(scanner)
+	case ".java", ".kt":
+		imports = extractJVMImports(scanner)
+	case ".c", ".h", ".cpp", ".hpp", ".cc":
+		imports = extractCIncludes(scanner)
 	}
 
 	if imports == nil {
@@ -43,6 +49,24 @@ type ContextResult struct {
 	Imports    []string `json:"imports"`
 	DocComment string   `json:"docComment"`
 	Body       string   `json:"body"`
+	// Implementation is the definition of a C/C++ function or method whose
+	// prototype was matched, found in the same file or its counterparts.
+	Implementation *Implementation `json:"implementation,omitempty"`
+	// Schema is the .proto definition a symbol in a generated protobuf or
+	// gRPC stub was generated from; edit it rather than the stub.
+	Schema *Implementation `json:"schema,omitempty"`
+	// Uses are the Go declarations the definition refers to, see
+	// TypedContext.
+	Uses []GoUse `json:"uses,omitempty"`
+}
+
+// Implementation locates the definition of a declared function.
+type Implementation struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	EndLine   int    `json:"endLine"`
+	Signature string `json:"signature"`
+	Body      string `json:"body"`
 }
 
 // Context extracts the full definition context for a symbol in a file:
@@ -54,7 +78,7 @@ func Context(filePath string, symbolName string) (*ContextResult, error) {
 	}
 
 	ext := filepath.Ext(filePath)
-	p := parsers.ForExtension(ext)
+	p := parsers.ForFile(filePath)
 	if p == nil {
 		return nil, fmt.Errorf("no parser available for %s files", ext)
 	}
@@ -78,21 +102,11 @@ func Context(filePath string, symbolName string) (*ContextResult, error) {
 	}
 
 	lines := strings.Split(string(content), "\n")
-
-	// Extract body (Line and EndLine are 1-indexed).
 	startIdx := match.Line - 1
-	endIdx := match.EndLine - 1
 	if startIdx < 0 {
 		startIdx = 0
 	}
-	if endIdx >= len(lines) {
-		endIdx = len(lines) - 1
-	}
-	if match.EndLine == 0 {
-		// If EndLine is not set, use just the start line.
-		endIdx = startIdx
-	}
-	body := strings.Join(lines[startIdx:endIdx+1], "\n")
+	body := symbolBody(lines, *match)
 
 	// Extract doc comment: contiguous comment lines immediately before the symbol.
 	docComment := extractDocComment(lines, startIdx, ext)
@@ -100,34 +114,116 @@ func Context(filePath string, symbolName string) (*ContextResult, error) {
 	// Extract imports.
 	imports := extractFileImports(content, ext)
 
+	var impl *Implementation
+	if match.Kind == "prototype" || match.Kind == "overload" {
+		impl = findImplementation(filePath, content, symbols, *match)
+	}
+	var schema *Implementation
+	if ext != ".proto" {
+		schema = findSchemaDefinition(filePath, content, *match)
+	}
+
 	return &ContextResult{
-		File:       filePath,
-		Symbol:     match.Name,
-		Kind:       match.Kind,
-		Line:       match.Line,
-		EndLine:    match.EndLine,
-		Signature:  match.Signature,
-		Imports:    imports,
-		DocComment: docComment,
-		Body:       body,
+		File:           filePath,
+		Symbol:         match.Name,
+		Kind:           match.Kind,
+		Line:           match.Line,
+		EndLine:        match.EndLine,
+		Signature:      match.Signature,
+		Imports:        imports,
+		DocComment:     docComment,
+		Body:           body,
+		Implementation: impl,
+		Schema:         schema,
 	}, nil
 }
 
+// symbolBody returns the source lines of sym (Line and EndLine are
+// 1-indexed). If EndLine is not set, just the start line is returned.
+func symbolBody(lines []string, sym parsers.Symbol) string {
+	startIdx := sym.Line - 1
+	endIdx := sym.EndLine - 1
+	if startIdx < 0 {
+		startIdx = 0
+	}
+	if endIdx >= len(lines) {
+		endIdx = len(lines) - 1
+	}
+	if sym.EndLine == 0 {
+		endIdx = startIdx
+	}
+	return strings.Join(lines[startIdx:endIdx+1], "\n")
+}
+
+// findImplementation looks for the definition of the C/C++ prototype proto,
+// declared in filePath, first among symbols later in the same file and then
+// in the file's header/source counterparts.
+func findImplementation(filePath string, content []byte, symbols []parsers.Symbol, proto parsers.Symbol) *Implementation {
+	isDefinition := func(s parsers.Symbol) bool {
+		return s.Name == proto.Name && s.Parent == proto.Parent && (s.Kind == "func" || s.Kind == "method")
+	}
+	for _, s := range symbols {
+		if isDefinition(s) {
+			return newImplementation(filePath, content, s)
+		}
+	}
+
+	exists := func(p string) bool {
+		info, err := os.Stat(p)
+		return err == nil && !info.IsDir()
+	}
+	for _, path := range findCounterparts(filePath, exists) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		p := parsers.ForFile(path)
+		if p == nil {
+			continue
+		}
+		defs, err := p.Parse(path, content)
+		if err != nil {
+			continue
+		}
+		for _, s := range defs {
+			if isDefinition(s) {
+				return newImplementation(path, content, s)
+			}
+		}
+	}
+	return nil
+}
+
+func newImplementation(path string, content []byte, sym parsers.Symbol) *Implementation {
+	return &Implementation{
+		File:      path,
+		Line:      sym.Line,
+		EndLine:   sym.EndLine,
+		Signature: sym.Signature,
+		Body:      symbolBody(strings.Split(string(content), "\n"), sym),
+	}
+}
+
 // extractDocComment walks backwards from the line before the symbol's definition,
-// collecting contiguous comment lines.
+// collecting contiguous comment lines. Attributes directly above the
+// definition are skipped.
 func extractDocComment(lines []string, symbolLineIdx int, ext string) string {
 	if symbolLineIdx <= 0 {
 		return ""
 	}
 
 	var commentLines []string
+	parens := 0 // annotation arguments still open while walking upwards
 	for i := symbolLineIdx - 1; i >= 0; i-- {
 		trimmed := strings.TrimSpace(lines[i])
-		if isCommentLine(trimmed, ext) {
-			commentLines = append(commentLines, lines[i])
-		} else {
+		if len(commentLines) == 0 && (parens > 0 || isAttributeLine(trimmed, ext)) {
+			parens += strings.Count(trimmed, ")") - strings.Count(trimmed, "(")
+			continue
+		}
+		if !isCommentLine(trimmed, ext) {
 			break
 		}
+		commentLines = append(commentLines, lines[i])
 	}
 
 	if len(commentLines) == 0 {
@@ -142,6 +238,73 @@ func extractDocComment(lines []string, symbolLineIdx int, ext string) string {
 	return strings.Join(commentLines, "\n")
 }
 
+// docSummary returns the first sentence of the documentation for the symbol
+// defined on line (1-based): the comment block above it, or for Python a
+// docstring on the following line. Comment markers are stripped.
+func docSummary(lines []string, line int, ext string) string {
+	if line <= 0 || line > len(lines) {
+		return ""
+	}
+	doc := extractDocComment(lines, line-1, ext)
+	if doc == "" && ext == ".py" {
+		doc = pythonDocstring(lines, line)
+	}
+
+	var words []string
+	for _, l := range strings.Split(doc, "\n") {
+		l = strings.TrimSpace(l)
+		l = strings.TrimSuffix(l, "*/")
+		l = strings.TrimLeft(l, "/*#")
+		l = strings.TrimSpace(l)
+		if l == "" {
+			if len(words) > 0 {
+				break // first paragraph only
+			}
+			continue
+		}
+		words = append(words, l)
+	}
+	summary := strings.Join(words, " ")
+	if i := strings.Index(summary, ". "); i >= 0 {
+		summary = summary[:i+1]
+	}
+	return summary
+}
+
+// pythonDocstring returns the docstring that opens the body of the def or
+// class on line (1-based), or "" if the body does not start with one.
+func pythonDocstring(lines []string, line int) string {
+	for i := line; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" {
+			continue
+		}
+		quote := ""
+		for _, q := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(trimmed, q) {
+				quote = q
+			}
+		}
+		if quote == "" {
+			return ""
+		}
+		rest := trimmed[len(quote):]
+		if end := strings.Index(rest, quote); end >= 0 {
+			return rest[:end]
+		}
+		doc := []string{rest}
+		for j := i + 1; j < len(lines); j++ {
+			if end := strings.Index(lines[j], quote); end >= 0 {
+				doc = append(doc, lines[j][:end])
+				break
+			}
+			doc = append(doc, lines[j])
+		}
+		return strings.Join(doc, "\n")
+	}
+	return ""
+}
+
 // isCommentLine checks if a trimmed line is a comment in the given language.
 func isCommentLine(trimmed string, ext string) bool {
 	if trimmed == "" {
@@ -150,12 +313,36 @@ func isCommentLine(trimmed string, ext string) bool {
 	switch ext {
 	case ".go":
 		return strings.HasPrefix(trimmed, "//")
-	case ".py":
+	case ".py", ".rb", ".graphql", ".gql", ".graphqls", ".yaml", ".yml":
 		return strings.HasPrefix(trimmed, "#")
-	case ".js", ".jsx", ".ts", ".tsx":
+	case ".php":
+		return strings.HasPrefix(trimmed, "//") ||
+			strings.HasPrefix(trimmed, "/*") ||
+			strings.HasPrefix(trimmed, "*") ||
+			strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "#[")
+	case ".js", ".jsx", ".ts", ".tsx", ".rs", ".java", ".kt", ".c", ".h", ".cpp", ".hpp", ".cc", ".proto":
 		return strings.HasPrefix(trimmed, "//") ||
 			strings.HasPrefix(trimmed, "/*") ||
 			strings.HasPrefix(trimmed, "*")
+	case ".tf":
+		return strings.HasPrefix(trimmed, "#") ||
+			strings.HasPrefix(trimmed, "//") ||
+			strings.HasPrefix(trimmed, "/*") ||
+			strings.HasPrefix(trimmed, "*")
+	}
+	return false
+}
+
+// isAttributeLine reports whether a trimmed line is an attribute or
+// annotation that may sit between a doc comment and the item it documents,
+// such as #[derive(Debug)] or @Override.
+func isAttributeLine(trimmed string, ext string) bool {
+	switch ext {
+	case ".rs", ".php":
+		return strings.HasPrefix(trimmed, "#[")
+	case ".java", ".kt":
+		// The last line of a multi-line annotation closes its arguments.
+		return strings.HasPrefix(trimmed, "@") || strings.Count(trimmed, ")") > strings.Count(trimmed, "(")
 	}
 	return false
 }
@@ -182,5 +369,28 @@ func FormatContext(r *ContextResult) string {
 	b.WriteString(r.Body)
 	b.WriteString("\n")
 
+	if r.Implementation != nil {
+		b.WriteString(fmt.Sprintf("\nImplementation: %s:%d\n", r.Implementation.File, r.Implementation.Line))
+		b.WriteString(r.Implementation.Body)
+		b.WriteString("\n")
+	}
+
+	if r.Schema != nil {
+		b.WriteString(fmt.Sprintf("\nGenerated from %s:%d (edit the schema and regenerate, not this file)\n", r.Schema.File, r.Schema.Line))
+		b.WriteString(r.Schema.Body)
+		b.WriteString("\n")
+	}
+
+	if len(r.Uses) > 0 {
+		b.WriteString("\nUses:\n")
+		for _, u := range r.Uses {
+			b.WriteString(fmt.Sprintf("  %-6s %s  %s:%d", u.Kind, u.Name, u.Path, u.Line))
+			if u.Count > 1 {
+				b.WriteString(fmt.Sprintf("  (%d times)", u.Count))
+			}
+			b.WriteString("\n")
+		}
+	}
+
 	return b.String()
 }
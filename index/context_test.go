@@ -142,6 +142,224 @@ function internal() {}
 	}
 }
 
+func TestContextRustFunction(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "lib.rs", `use std::fmt;
+use std::collections::{
+    HashMap,
+    HashSet,
+};
+
+/// A point in space.
+///
+/// Used everywhere.
+#[derive(Debug)]
+pub struct Point {
+    x: i32,
+}
+
+impl Point {
+    /// Creates a point.
+    pub fn new(x: i32) -> Self {
+        Point { x }
+    }
+}
+`)
+
+	result, err := Context(filepath.Join(tmp, "lib.rs"), "Point")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Kind != "struct" || result.Line != 11 || result.EndLine != 13 {
+		t.Errorf("got %s at %d-%d, want struct at 11-13", result.Kind, result.Line, result.EndLine)
+	}
+	want := []string{"std::fmt", "std::collections::{ HashMap, HashSet, }"}
+	if strings.Join(result.Imports, "|") != strings.Join(want, "|") {
+		t.Errorf("Imports = %q, want %q", result.Imports, want)
+	}
+	if !strings.HasPrefix(result.DocComment, "/// A point in space.") || strings.Contains(result.DocComment, "derive") {
+		t.Errorf("DocComment = %q, want the /// block without attributes", result.DocComment)
+	}
+
+	lines := strings.Split(`/// A point in space.
+#[derive(Debug)]
+pub struct Point {`, "\n")
+	if got := docSummary(lines, 3, ".rs"); got != "A point in space." {
+		t.Errorf("docSummary = %q", got)
+	}
+}
+
+func TestContextJavaMethod(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "UserController.java", `package com.example;
+
+import java.util.List;
+import static java.util.Objects.requireNonNull;
+
+public class UserController {
+    /**
+     * Lists users.
+     *
+     * @return all users
+     */
+    @GetMapping(
+        value = "/users",
+        produces = "application/json")
+    @ResponseBody
+    public List<User> list() {
+        return List.of();
+    }
+}
+`)
+
+	result, err := Context(filepath.Join(tmp, "UserController.java"), "list")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Kind != "method" || result.Line != 16 || result.EndLine != 18 {
+		t.Errorf("got %s at %d-%d, want method at 16-18", result.Kind, result.Line, result.EndLine)
+	}
+	want := []string{"java.util.List", "java.util.Objects.requireNonNull"}
+	if strings.Join(result.Imports, "|") != strings.Join(want, "|") {
+		t.Errorf("Imports = %q, want %q", result.Imports, want)
+	}
+	if !strings.Contains(result.DocComment, "Lists users.") || strings.Contains(result.DocComment, "GetMapping") {
+		t.Errorf("DocComment = %q, want the Javadoc without annotations", result.DocComment)
+	}
+	if !strings.HasPrefix(result.Signature, "@GetMapping( value = \"/users\"") {
+		t.Errorf("Signature = %q, want it to keep the annotations", result.Signature)
+	}
+}
+
+func TestContextCPrototypeImplementation(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "include/shape.hpp", `#pragma once
+#include <string>
+
+class Shape {
+public:
+    // Returns the area of the shape.
+    double area() const;
+};
+
+int shape_count(void);
+`)
+	mkFile(t, tmp, "src/shape.cpp", `#include "shape.hpp"
+
+double Shape::area() const {
+    return 0;
+}
+`)
+	mkFile(t, tmp, "src/count.c", `int shape_count(void);
+
+int shape_count(void) {
+    return 1;
+}
+`)
+
+	result, err := Context(filepath.Join(tmp, "include", "shape.hpp"), "area")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Kind != "prototype" || result.DocComment != "    // Returns the area of the shape." {
+		t.Errorf("got %s with doc %q, want the documented prototype", result.Kind, result.DocComment)
+	}
+	if len(result.Imports) != 1 || result.Imports[0] != "string" {
+		t.Errorf("Imports = %q, want [string]", result.Imports)
+	}
+	impl := result.Implementation
+	if impl == nil {
+		t.Fatal("Implementation = nil, want the definition in src/shape.cpp")
+	}
+	if impl.File != filepath.Join(tmp, "src", "shape.cpp") || impl.Line != 3 || impl.EndLine != 5 {
+		t.Errorf("Implementation at %s:%d-%d, want src/shape.cpp:3-5", impl.File, impl.Line, impl.EndLine)
+	}
+	if !strings.Contains(impl.Body, "return 0;") || impl.Signature != "double Shape::area() const" {
+		t.Errorf("Implementation = %+v", impl)
+	}
+	if out := FormatContext(result); !strings.Contains(out, "Implementation: ") {
+		t.Errorf("FormatContext() missing implementation:\n%s", out)
+	}
+
+	// A forward declaration finds the definition later in the same file.
+	result, err = Context(filepath.Join(tmp, "src", "count.c"), "shape_count")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Implementation == nil || result.Implementation.Line != 3 {
+		t.Errorf("Implementation = %+v, want line 3 of count.c", result.Implementation)
+	}
+}
+
+func TestContextTSOverloadImplementation(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "convert.ts", `/** Converts between strings and numbers. */
+export function convert(value: string): number;
+export function convert(value: number): string;
+export function convert(value: string | number): string | number {
+  return typeof value === "string" ? Number(value) : String(value);
+}
+`)
+
+	result, err := Context(filepath.Join(tmp, "convert.ts"), "convert")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Kind != "overload" || result.Line != 2 {
+		t.Errorf("got %s at line %d, want the first overload at line 2", result.Kind, result.Line)
+	}
+	impl := result.Implementation
+	if impl == nil || impl.Line != 4 || impl.EndLine != 6 || !strings.Contains(impl.Body, "Number(value)") {
+		t.Errorf("Implementation = %+v, want lines 4-6", impl)
+	}
+}
+
+func TestContextRubyAndPHPDocComments(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "invoice.rb", `class Invoice
+  # Marks the invoice as paid.
+  def pay!
+    update(status: "paid")
+  end
+end
+`)
+	mkFile(t, tmp, "InvoiceController.php", `<?php
+class InvoiceController
+{
+    /**
+     * Shows an invoice.
+     */
+    #[Get('/invoices/{id}')]
+    public function show(int $id)
+    {
+        return $id;
+    }
+}
+`)
+
+	result, err := Context(filepath.Join(tmp, "invoice.rb"), "pay!")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Kind != "method" || result.Line != 3 || result.EndLine != 5 {
+		t.Errorf("got %s at %d-%d, want method at 3-5", result.Kind, result.Line, result.EndLine)
+	}
+	if result.DocComment != "  # Marks the invoice as paid." {
+		t.Errorf("DocComment = %q", result.DocComment)
+	}
+
+	result, err = Context(filepath.Join(tmp, "InvoiceController.php"), "show")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Line != 8 || result.EndLine != 11 {
+		t.Errorf("got lines %d-%d, want 8-11", result.Line, result.EndLine)
+	}
+	if !strings.Contains(result.DocComment, "Shows an invoice.") || strings.Contains(result.DocComment, "#[Get") {
+		t.Errorf("DocComment = %q, want the docblock without attributes", result.DocComment)
+	}
+}
+
 func TestContextUnknownSymbol(t *testing.T) {
 	tmp := t.TempDir()
 	mkFile(t, tmp, "main.go", `package main
@@ -270,3 +488,27 @@ func TestFormatContextNoImportsNoDoc(t *testing.T) {
 		t.Errorf("output missing body: %s", out)
 	}
 }
+
+func TestDocSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+		ext  string
+		want string
+	}{
+		{"go line comments", "// Foo does a thing. More detail.\n// Even more.\nfunc Foo() {}", 3, ".go", "Foo does a thing."},
+		{"go first paragraph", "// Foo does a thing\n// across lines\n//\n// Second paragraph.\nfunc Foo() {}", 5, ".go", "Foo does a thing across lines"},
+		{"js block comment", "/**\n * Adds numbers.\n */\nfunction add() {}", 4, ".js", "Adds numbers."},
+		{"python docstring", "def foo():\n    \"\"\"Return foo. Really.\"\"\"\n    pass", 1, ".py", "Return foo."},
+		{"no comment", "func Foo() {}", 1, ".go", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(tt.src, "\n")
+			if got := docSummary(lines, tt.line, tt.ext); got != tt.want {
+				t.Errorf("docSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -42,10 +42,24 @@ func isExcludedSymbol(name string) bool {
 	return false
 }
 
+// isDeclarationFile reports whether the file at relPath declares what tools
+// outside the repository consume, such as API specs, manifests and
+// Dockerfiles, rather than code that can go unused.
+func isDeclarationFile(relPath string) bool {
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return parsers.IsDockerfile(filepath.Base(relPath))
+}
+
 // DeadCode finds exported symbols that have zero references outside their
-// definition file/line. kind filters by symbol kind (empty = all). pathPrefix
-// limits analysis to files whose path starts with the given prefix. At most
-// max candidates are returned.
+// definition file/line. Go functions and methods are checked against the
+// call graph instead, so a method is only used if a call reaches it rather
+// than any method of the same name; a method that may satisfy an interface,
+// by name or per the type checker, is assumed to be called through it. kind filters by symbol kind (empty =
+// all). pathPrefix limits analysis to files whose path starts with the given
+// prefix. At most max candidates are returned.
 func (idx *Index) DeadCode(kind string, pathPrefix string, max int) (*DeadCodeResult, error) {
 	kindLower := strings.ToLower(kind)
 	allPaths := idx.FilePaths()
@@ -59,33 +73,35 @@ func (idx *Index) DeadCode(kind string, pathPrefix string, max int) (*DeadCodeRe
 		signature string
 		exported  bool
 	}
-	var symbols []symbolInfo
-
-	for _, relPath := range allPaths {
+	perFile := parallelMap(allPaths, idx.Workers, func(relPath string) []symbolInfo {
 		if testFilePattern.MatchString(relPath) {
-			continue
+			return nil
 		}
 		if pathPrefix != "" && !strings.HasPrefix(relPath, pathPrefix) {
-			continue
+			return nil
 		}
 
-		ext := filepath.Ext(relPath)
-		p := parsers.ForExtension(ext)
+		if isDeclarationFile(relPath) || isDocFile(relPath) {
+			return nil
+		}
+
+		p := parsers.ForFile(relPath)
 		if p == nil {
-			continue
+			return nil
 		}
 
 		absPath := filepath.Join(idx.Root, relPath)
 		content, err := os.ReadFile(absPath)
 		if err != nil {
-			continue
+			return nil
 		}
 
 		parsed, err := p.Parse(absPath, content)
 		if err != nil {
-			continue
+			return nil
 		}
 
+		var found []symbolInfo
 		for _, sym := range parsed {
 			if !sym.Exported {
 				continue
@@ -93,10 +109,16 @@ func (idx *Index) DeadCode(kind string, pathPrefix string, max int) (*DeadCodeRe
 			if isExcludedSymbol(sym.Name) {
 				continue
 			}
+			// API operations and Terraform outputs are used by clients
+			// outside the repository, and directives are not words a
+			// reference can match.
+			if isAPIOperation(sym.Kind, sym.Parent) || sym.Kind == "output" || sym.Kind == "directive" {
+				continue
+			}
 			if kindLower != "" && strings.ToLower(sym.Kind) != kindLower {
 				continue
 			}
-			symbols = append(symbols, symbolInfo{
+			found = append(found, symbolInfo{
 				name:      sym.Name,
 				kind:      sym.Kind,
 				path:      relPath,
@@ -105,19 +127,46 @@ func (idx *Index) DeadCode(kind string, pathPrefix string, max int) (*DeadCodeRe
 				exported:  sym.Exported,
 			})
 		}
+		return found
+	})
+	var symbols []symbolInfo
+	for _, found := range perFile {
+		symbols = append(symbols, found...)
 	}
 
-	// For each symbol, search for references across all indexed files.
-	candidates := []DeadCodeCandidate{}
+	// Go functions and methods are used if the call graph has a caller
+	// for them. For other symbols, search for references across the files
+	// that can contain their name.
+	var calls *goCallGraph
+	var satisfying map[string]bool
+	for _, sym := range symbols {
+		if filepath.Ext(sym.path) == ".go" && (sym.kind == "func" || sym.kind == "method") {
+			calls = idx.goCalls()
+			break
+		}
+	}
 	for _, sym := range symbols {
+		if filepath.Ext(sym.path) == ".go" && sym.kind == "method" {
+			satisfying = idx.goTypes().interfaceMethods()
+			break
+		}
+	}
+	filter := idx.contentFilter()
+	unused := parallelMap(symbols, idx.Workers, func(sym symbolInfo) bool {
+		if calls != nil {
+			if fn := calls.byPos[fmt.Sprintf("%s:%d", sym.path, sym.line)]; fn != nil {
+				return !calls.hasCallers(fn) && !calls.mayBeCalledDynamically(fn, satisfying)
+			}
+		}
 		wordRe, err := regexp.Compile(`\b` + regexp.QuoteMeta(sym.name) + `\b`)
 		if err != nil {
-			continue
+			return false
 		}
-
-		refCount := countExternalRefs(idx, sym.path, sym.line, wordRe, allPaths)
-
-		if refCount == 0 {
+		return countExternalRefs(idx, sym.path, sym.line, wordRe, filter.candidates(wordRe)) == 0
+	})
+	candidates := []DeadCodeCandidate{}
+	for i, sym := range symbols {
+		if unused[i] {
 			candidates = append(candidates, DeadCodeCandidate{
 				Name:       sym.name,
 				Kind:       sym.kind,
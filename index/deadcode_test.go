@@ -44,6 +44,48 @@ func caller() {
 	}
 }
 
+func TestDeadCodeInterfaceMethods(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "go.mod", "module example.com/blob\n\ngo 1.21\n")
+	// ReadAt satisfies io.ReaderAt, which the type is only ever passed
+	// as; Checksum satisfies nothing and is never called.
+	mkFile(t, tmp, "blob.go", `package blob
+
+import "io"
+
+type Blob struct{ data []byte }
+
+func (b *Blob) ReadAt(p []byte, off int64) (int, error) {
+	return copy(p, b.data[off:]), nil
+}
+
+func (b *Blob) Checksum() int { return len(b.data) }
+
+func Section(b *Blob) *io.SectionReader {
+	return io.NewSectionReader(b, 0, 1)
+}
+`)
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	result, err := idx.DeadCode("method", "", 50)
+	if err != nil {
+		t.Fatalf("DeadCode() error: %v", err)
+	}
+	names := map[string]bool{}
+	for _, c := range result.Candidates {
+		names[c.Name] = true
+	}
+	if names["ReadAt"] {
+		t.Error("ReadAt satisfies io.ReaderAt and should not be reported as dead code")
+	}
+	if !names["Checksum"] {
+		t.Error("Checksum should be reported as dead code")
+	}
+}
+
 func TestDeadCodeMainExcluded(t *testing.T) {
 	tmp := t.TempDir()
 	mkFile(t, tmp, "main.go", `package main
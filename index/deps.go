@@ -49,7 +49,7 @@ func (idx *Index) Deps() (*DepsResult, error) {
 	var manifests []ManifestDeps
 	totalDeps := 0
 
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Kind != "file" {
 			continue
 		}
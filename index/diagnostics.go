@@ -0,0 +1,243 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/scanner"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Kinds of scan diagnostics, each explaining why a file has fewer index
+// entries than expected.
+const (
+	DiagParseError = "parse-error" // the parser failed, so the file has no symbols
+	DiagUnreadable = "unreadable"  // the file or directory could not be read
+	DiagBinary     = "binary"      // binary content is not parsed
+	DiagTooLarge   = "too-large"   // over --max-file-size, indexed by name only
+	DiagIgnored    = "ignored"     // excluded by an ignore file rule
+)
+
+// diagnosticKinds lists the kinds in the order reports show them.
+var diagnosticKinds = []string{DiagParseError, DiagUnreadable, DiagBinary, DiagTooLarge, DiagIgnored}
+
+// diagnosticsFile holds the scan diagnostics next to the store.
+const diagnosticsFile = "diagnostics.json"
+
+// Diagnostic records a file or directory that a scan skipped or could not
+// fully index.
+type Diagnostic struct {
+	Path    string `json:"path"`             // path relative to the scanned root
+	Kind    string `json:"kind"`             // one of the Diag* kinds
+	Line    int    `json:"line,omitempty"`   // position of a parse error, if known
+	Column  int    `json:"column,omitempty"` // column of a parse error, if known
+	Message string `json:"message"`
+	Rule    string `json:"rule,omitempty"` // ignore pattern that matched, for ignored paths
+}
+
+func (d Diagnostic) String() string {
+	loc := d.Path
+	if d.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", loc, d.Line)
+		if d.Column > 0 {
+			loc = fmt.Sprintf("%s:%d", loc, d.Column)
+		}
+	}
+	return fmt.Sprintf("[%s] %s — %s", d.Kind, loc, d.Message)
+}
+
+// ScanReport is the diagnostics recorded by the last scan, optionally
+// filtered by kind and path prefix.
+type ScanReport struct {
+	Root        string         `json:"root"`
+	ScannedAt   string         `json:"scannedAt"`
+	Counts      map[string]int `json:"counts"` // all diagnostics by kind, before filtering
+	Total       int            `json:"total"`  // diagnostics matching the filters
+	Diagnostics []Diagnostic   `json:"diagnostics"`
+}
+
+// diagnostics returns the scan diagnostics, reading them from the index
+// directory on first use. Indexes saved before diagnostics were recorded
+// return nil.
+func (idx *Index) diagnostics() []Diagnostic {
+	if idx.dir != "" && !idx.diagnosticsLoaded {
+		idx.diagnosticsLoaded = true
+		if data, err := os.ReadFile(filepath.Join(idx.dir, diagnosticsFile)); err == nil {
+			var diags []Diagnostic
+			if json.Unmarshal(data, &diags) == nil {
+				idx.Diagnostics = diags
+			}
+		}
+	}
+	return idx.Diagnostics
+}
+
+// DiagnosticCounts returns the number of diagnostics of each kind.
+func (idx *Index) DiagnosticCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, d := range idx.diagnostics() {
+		counts[d.Kind]++
+	}
+	return counts
+}
+
+// ScanReport returns the diagnostics recorded by the last scan. kind and
+// pathPrefix filter them when non-empty; at most max are returned when max
+// is positive.
+func (idx *Index) ScanReport(kind, pathPrefix string, max int) (*ScanReport, error) {
+	if kind != "" && !isDiagnosticKind(kind) {
+		return nil, fmt.Errorf("unknown diagnostic kind %q (want one of %s)", kind, strings.Join(diagnosticKinds, ", "))
+	}
+	pathPrefix = filepath.Clean(pathPrefix)
+	report := &ScanReport{
+		Root:        idx.Root,
+		ScannedAt:   idx.ScannedAt,
+		Counts:      idx.DiagnosticCounts(),
+		Diagnostics: []Diagnostic{},
+	}
+	for _, d := range idx.diagnostics() {
+		if kind != "" && d.Kind != kind {
+			continue
+		}
+		if pathPrefix != "." && d.Path != pathPrefix && !strings.HasPrefix(d.Path, pathPrefix+string(filepath.Separator)) {
+			continue
+		}
+		report.Total++
+		if max <= 0 || len(report.Diagnostics) < max {
+			report.Diagnostics = append(report.Diagnostics, d)
+		}
+	}
+	return report, nil
+}
+
+// FormatScanReport returns a human-readable text rendering of the report.
+func FormatScanReport(r *ScanReport) string {
+	var b strings.Builder
+	if summary := diagnosticSummary(r.Counts); summary != "" {
+		b.WriteString(fmt.Sprintf("Scan diagnostics: %s\n", summary))
+	} else {
+		b.WriteString("No scan diagnostics: every file was read and parsed.\n")
+		return b.String()
+	}
+	if r.Total == 0 {
+		b.WriteString("\nNo diagnostics match the filters.\n")
+		return b.String()
+	}
+	b.WriteString("\n")
+	for _, d := range r.Diagnostics {
+		b.WriteString(d.String() + "\n")
+	}
+	if len(r.Diagnostics) < r.Total {
+		b.WriteString(fmt.Sprintf("\n... and %d more (use --max to see more)\n", r.Total-len(r.Diagnostics)))
+	}
+	return b.String()
+}
+
+// diagnosticSummary renders counts by kind, e.g. "2 parse-error, 1 ignored",
+// or "" if there are none.
+func diagnosticSummary(counts map[string]int) string {
+	var parts []string
+	for _, kind := range diagnosticKinds {
+		if n := counts[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DiagnosticSummary renders the index's diagnostic counts by kind, or ""
+// if the scan recorded none.
+func (idx *Index) DiagnosticSummary() string {
+	return diagnosticSummary(idx.DiagnosticCounts())
+}
+
+func isDiagnosticKind(kind string) bool {
+	for _, k := range diagnosticKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDiagnostic describes a parser failure, with the position of the
+// first syntax error when the parser reports one.
+func parseDiagnostic(relPath string, err error) Diagnostic {
+	d := Diagnostic{Path: relPath, Kind: DiagParseError, Message: err.Error()}
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		d.Line, d.Column = list[0].Pos.Line, list[0].Pos.Column
+		d.Message = list[0].Msg
+		if len(list) > 1 {
+			d.Message += fmt.Sprintf(" (and %d more errors)", len(list)-1)
+		}
+	}
+	return d
+}
+
+// unreadableDiagnostic describes a read error without repeating the path.
+func unreadableDiagnostic(relPath string, err error) Diagnostic {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+	return Diagnostic{Path: relPath, Kind: DiagUnreadable, Message: err.Error()}
+}
+
+// isBinary reports whether content looks binary: a NUL byte in its first
+// 512 bytes, the same test openTextFile applies.
+func isBinary(content []byte) bool {
+	for _, b := range content[:min(len(content), 512)] {
+		if b == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sortDiagnostics orders diagnostics by path, then kind.
+func sortDiagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Path != diags[j].Path {
+			return diags[i].Path < diags[j].Path
+		}
+		return diags[i].Kind < diags[j].Kind
+	})
+}
+
+// ignoredDiagnostic describes a path excluded by an ignore pattern, naming
+// the ignore file that declares it.
+func ignoredDiagnostic(relPath, rule string, sources map[string]string) Diagnostic {
+	return Diagnostic{
+		Path:    relPath,
+		Kind:    DiagIgnored,
+		Message: fmt.Sprintf("matches %q in %s", rule, sources[rule]),
+		Rule:    rule,
+	}
+}
+
+// tooLargeDiagnostic describes a file over the scan's size cutoff.
+func tooLargeDiagnostic(relPath string, size, maxSize int64) Diagnostic {
+	return Diagnostic{
+		Path:    relPath,
+		Kind:    DiagTooLarge,
+		Message: fmt.Sprintf("%d bytes exceeds the %d-byte limit; indexed by name only", size, maxSize),
+	}
+}
+
+// fileDiagnostics groups the per-file diagnostics by path, for a later scan
+// to carry over along with unchanged entries. Ignored and unreadable paths
+// are found again by the walk.
+func (idx *Index) fileDiagnostics() map[string][]Diagnostic {
+	byPath := make(map[string][]Diagnostic)
+	for _, d := range idx.diagnostics() {
+		if d.Kind != DiagIgnored && d.Kind != DiagUnreadable {
+			byPath[d.Path] = append(byPath[d.Path], d)
+		}
+	}
+	return byPath
+}
@@ -0,0 +1,213 @@
+package index
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+func diagnosticFor(idx *Index, path, kind string) *Diagnostic {
+	for _, d := range idx.diagnostics() {
+		if d.Path == path && d.Kind == kind {
+			return &d
+		}
+	}
+	return nil
+}
+
+func TestScanDiagnostics(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "ok.go", "package main\n\nfunc OK() {}\n")
+	mkFile(t, tmp, "broken.go", "package main\n\nfunc Broken( {\n")
+	mkFile(t, tmp, "blob.bin", "ELF\x00\x01\x02")
+	mkFile(t, tmp, "big.go", "package main\n\nfunc Big() {}\n"+strings.Repeat("// padding\n", 100))
+	mkFile(t, tmp, ".swarmignore", "generated/\n*.log\n")
+	mkFile(t, tmp, "generated/out.go", "package generated\n")
+	mkFile(t, tmp, "debug.log", "log line\n")
+
+	idx, _, err := ScanWithOptions(tmp, nil, ScanOptions{MaxFileSize: 200})
+	if err != nil {
+		t.Fatalf("ScanWithOptions() error: %v", err)
+	}
+
+	d := diagnosticFor(idx, "broken.go", DiagParseError)
+	if d == nil {
+		t.Fatal("missing parse-error diagnostic for broken.go")
+	}
+	if d.Line != 3 || d.Column == 0 {
+		t.Errorf("parse error position = %d:%d, want line 3 with a column", d.Line, d.Column)
+	}
+	if d.Message == "" || strings.Contains(d.Message, "broken.go") {
+		t.Errorf("parse error message = %q, want the error without the path", d.Message)
+	}
+
+	if diagnosticFor(idx, "blob.bin", DiagBinary) == nil {
+		t.Error("missing binary diagnostic for blob.bin")
+	}
+	if d := diagnosticFor(idx, "big.go", DiagTooLarge); d == nil || !strings.Contains(d.Message, "200-byte limit") {
+		t.Errorf("too-large diagnostic for big.go = %+v", d)
+	}
+
+	d = diagnosticFor(idx, "generated", DiagIgnored)
+	if d == nil || d.Rule != "generated/" || !strings.Contains(d.Message, ".swarmignore") {
+		t.Errorf("ignored diagnostic for generated = %+v, want rule generated/ from .swarmignore", d)
+	}
+	if d := diagnosticFor(idx, "debug.log", DiagIgnored); d == nil || d.Rule != "*.log" {
+		t.Errorf("ignored diagnostic for debug.log = %+v, want rule *.log", d)
+	}
+	if diagnosticFor(idx, "generated/out.go", DiagIgnored) != nil {
+		t.Error("files inside an ignored directory should not be reported separately")
+	}
+
+	for _, d := range idx.diagnostics() {
+		if d.Path == "ok.go" {
+			t.Errorf("unexpected diagnostic for ok.go: %+v", d)
+		}
+	}
+
+	counts := idx.DiagnosticCounts()
+	if counts[DiagParseError] != 1 || counts[DiagBinary] != 1 || counts[DiagTooLarge] != 1 || counts[DiagIgnored] != 2 {
+		t.Errorf("DiagnosticCounts() = %v", counts)
+	}
+}
+
+func TestScanDiagnosticsSaveLoad(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "broken.go", "package main\n\nfunc Broken( {\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	defer loaded.Close()
+
+	d := diagnosticFor(loaded, "broken.go", DiagParseError)
+	if d == nil || d.Line != 3 {
+		t.Errorf("loaded parse-error diagnostic = %+v, want broken.go at line 3", d)
+	}
+}
+
+func TestScanDiagnosticsIncremental(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "broken.go", "package main\n\nfunc Broken( {\n")
+	mkFile(t, tmp, "fixed.go", "package main\n\nfunc Fixed( {\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	mkFile(t, tmp, "fixed.go", "package main\n\nfunc Fixed() {}\n")
+
+	next, changes, err := ScanIncremental(tmp, idx)
+	if err != nil {
+		t.Fatalf("ScanIncremental() error: %v", err)
+	}
+	if changes.Unchanged != 1 {
+		t.Fatalf("changes = %+v, want broken.go unchanged", changes)
+	}
+	if diagnosticFor(next, "broken.go", DiagParseError) == nil {
+		t.Error("diagnostic for unchanged broken.go should be carried over")
+	}
+	if diagnosticFor(next, "fixed.go", DiagParseError) != nil {
+		t.Error("diagnostic for fixed.go should be dropped after the fix")
+	}
+}
+
+func TestScanReportFilters(t *testing.T) {
+	idx := &Index{
+		Root: "/project",
+		Diagnostics: []Diagnostic{
+			{Path: "a/bad.go", Kind: DiagParseError, Line: 3, Column: 14, Message: "expected ')'"},
+			{Path: "a/blob.bin", Kind: DiagBinary, Message: "binary content is not parsed"},
+			{Path: "ab/x.bin", Kind: DiagBinary, Message: "binary content is not parsed"},
+			{Path: "vendor", Kind: DiagIgnored, Rule: "vendor/", Message: `matches "vendor/" in .swarmignore`},
+		},
+	}
+
+	r, err := idx.ScanReport("", "", 0)
+	if err != nil {
+		t.Fatalf("ScanReport() error: %v", err)
+	}
+	if r.Total != 4 || len(r.Diagnostics) != 4 {
+		t.Errorf("unfiltered total = %d, want 4", r.Total)
+	}
+
+	r, _ = idx.ScanReport(DiagBinary, "a", 0)
+	if r.Total != 1 || r.Diagnostics[0].Path != "a/blob.bin" {
+		t.Errorf("kind+path filter = %+v, want only a/blob.bin", r.Diagnostics)
+	}
+	if r.Counts[DiagBinary] != 2 {
+		t.Errorf("Counts should cover all diagnostics, got %v", r.Counts)
+	}
+
+	r, _ = idx.ScanReport("", "", 2)
+	if r.Total != 4 || len(r.Diagnostics) != 2 {
+		t.Errorf("max 2: total %d, returned %d; want 4 and 2", r.Total, len(r.Diagnostics))
+	}
+	out := FormatScanReport(r)
+	for _, want := range []string{"1 parse-error, 2 binary, 1 ignored", "[parse-error] a/bad.go:3:14 — expected ')'", "... and 2 more"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatScanReport() missing %q:\n%s", want, out)
+		}
+	}
+
+	if _, err := idx.ScanReport("bogus", "", 0); err == nil {
+		t.Error("ScanReport() with an unknown kind should fail")
+	}
+}
+
+func TestFormatScanReportEmpty(t *testing.T) {
+	r, err := (&Index{}).ScanReport("", "", 0)
+	if err != nil {
+		t.Fatalf("ScanReport() error: %v", err)
+	}
+	if out := FormatScanReport(r); !strings.Contains(out, "No scan diagnostics") {
+		t.Errorf("FormatScanReport() = %q", out)
+	}
+}
+
+// panicParser stands in for a parser with a bug that unusual input hits.
+type panicParser struct{}
+
+func (panicParser) Extensions() []string { return []string{".boom"} }
+
+func (panicParser) Parse(string, []byte) ([]parsers.Symbol, error) {
+	var s []int
+	_ = s[1]
+	return nil, nil
+}
+
+func init() {
+	parsers.Register(panicParser{})
+}
+
+func TestScanRecoversFromParserPanic(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "a.boom", "anything\n")
+	mkFile(t, tmp, "ok.go", "package main\n\nfunc OK() {}\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	d := diagnosticFor(idx, "a.boom", DiagParseError)
+	if d == nil || !strings.Contains(d.Message, "panicked") || !strings.Contains(d.Message, "index out of range") {
+		t.Errorf("parse-error diagnostic for a.boom = %+v, want the panic message", d)
+	}
+	var file, ok bool
+	for _, e := range idx.entries() {
+		file = file || e.Path == "a.boom" && e.Kind == "file"
+		ok = ok || e.Name == "OK"
+	}
+	if !file || !ok {
+		t.Errorf("file entry for a.boom = %v, symbol OK = %v; want both", file, ok)
+	}
+}
@@ -15,7 +15,8 @@ import (
 type DiffFile struct {
 	Path    string   `json:"path"`
 	Status  string   `json:"status"`            // "added", "modified", "deleted", "renamed"
-	Symbols []string `json:"symbols,omitempty"`  // affected symbol names (for added/modified files)
+	Symbols []string `json:"symbols,omitempty"` // affected symbol names (for added/modified files)
+	Error   string   `json:"error,omitempty"`   // why the file's symbols could not be parsed
 }
 
 // DiffSummaryResult holds the result of comparing against a git ref.
@@ -61,11 +62,11 @@ func (idx *Index) DiffSummary(root string, ref string) (*DiffSummaryResult, erro
 		switch parsed.status {
 		case "A":
 			df := DiffFile{Path: parsed.path, Status: "added"}
-			df.Symbols = extractSymbols(root, parsed.path)
+			df.Symbols, df.Error = extractSymbols(root, parsed.path)
 			added = append(added, df)
 		case "M":
 			df := DiffFile{Path: parsed.path, Status: "modified"}
-			df.Symbols = extractSymbols(root, parsed.path)
+			df.Symbols, df.Error = extractSymbols(root, parsed.path)
 			modified = append(modified, df)
 		case "D":
 			deleted = append(deleted, DiffFile{Path: parsed.path, Status: "deleted"})
@@ -75,7 +76,7 @@ func (idx *Index) DiffSummary(root string, ref string) (*DiffSummaryResult, erro
 				deleted = append(deleted, DiffFile{Path: parsed.oldPath, Status: "deleted"})
 			}
 			df := DiffFile{Path: parsed.path, Status: "added"}
-			df.Symbols = extractSymbols(root, parsed.path)
+			df.Symbols, df.Error = extractSymbols(root, parsed.path)
 			added = append(added, df)
 		}
 	}
@@ -150,30 +151,30 @@ func parseDiffLine(line string) *diffLineInfo {
 	}
 }
 
-// extractSymbols parses a file and returns the names of its symbols.
-func extractSymbols(root, relPath string) []string {
+// extractSymbols parses a file and returns the names of its symbols, or the
+// parser's error message if it failed.
+func extractSymbols(root, relPath string) ([]string, string) {
 	absPath := filepath.Join(root, relPath)
 	content, err := os.ReadFile(absPath)
 	if err != nil {
-		return nil
+		return nil, ""
 	}
 
-	ext := filepath.Ext(relPath)
-	p := parsers.ForExtension(ext)
+	p := parsers.ForFile(relPath)
 	if p == nil {
-		return nil
+		return nil, ""
 	}
 
 	symbols, err := p.Parse(relPath, content)
 	if err != nil {
-		return nil
+		return nil, err.Error()
 	}
 
 	names := make([]string, 0, len(symbols))
 	for _, s := range symbols {
 		names = append(names, s.Name)
 	}
-	return names
+	return names, ""
 }
 
 // shouldSkipDiffPath checks if a path should be skipped based on directory
@@ -206,6 +207,9 @@ func FormatDiffSummary(result *DiffSummaryResult) string {
 			if len(f.Symbols) > 0 {
 				b.WriteString(fmt.Sprintf("    Symbols: %s\n", strings.Join(f.Symbols, ", ")))
 			}
+			if f.Error != "" {
+				b.WriteString(fmt.Sprintf("    Parse error: %s\n", f.Error))
+			}
 		}
 	}
 
@@ -216,6 +220,9 @@ func FormatDiffSummary(result *DiffSummaryResult) string {
 			if len(f.Symbols) > 0 {
 				b.WriteString(fmt.Sprintf("    Symbols: %s\n", strings.Join(f.Symbols, ", ")))
 			}
+			if f.Error != "" {
+				b.WriteString(fmt.Sprintf("    Parse error: %s\n", f.Error))
+			}
 		}
 	}
 
@@ -184,7 +184,7 @@ func TestFormatDiffSummaryModifiedNoSymbols(t *testing.T) {
 }
 
 func TestExtractSymbolsNonexistentFile(t *testing.T) {
-	syms := extractSymbols("/nonexistent/root", "no/such/file.go")
+	syms, _ := extractSymbols("/nonexistent/root", "no/such/file.go")
 	if syms != nil {
 		t.Errorf("extractSymbols for nonexistent file = %v, want nil", syms)
 	}
@@ -193,7 +193,7 @@ func TestExtractSymbolsNonexistentFile(t *testing.T) {
 func TestExtractSymbolsUnsupportedExtension(t *testing.T) {
 	tmp := t.TempDir()
 	mkFile(t, tmp, "data.csv", "a,b,c\n1,2,3")
-	syms := extractSymbols(tmp, "data.csv")
+	syms, _ := extractSymbols(tmp, "data.csv")
 	if syms != nil {
 		t.Errorf("extractSymbols for .csv file = %v, want nil", syms)
 	}
@@ -207,7 +207,7 @@ func HelloWorld() {}
 
 type Config struct {}
 `)
-	syms := extractSymbols(tmp, "example.go")
+	syms, _ := extractSymbols(tmp, "example.go")
 	if len(syms) == 0 {
 		t.Fatal("extractSymbols returned no symbols for Go file")
 	}
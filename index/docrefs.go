@@ -0,0 +1,518 @@
+package index
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+// Kinds of broken doc references.
+const (
+	DocRefSymbol = "symbol" // a backticked identifier no indexed symbol has
+	DocRefFile   = "file"   // a relative link or backticked path to a missing file
+	DocRefAnchor = "anchor" // a link to a heading a Markdown document lacks
+)
+
+// docRefKinds lists the kinds in the order reports show them.
+var docRefKinds = []string{DocRefSymbol, DocRefFile, DocRefAnchor}
+
+func isDocRefKind(kind string) bool {
+	for _, k := range docRefKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// DocRef is a reference in a document to code or a file that does not
+// resolve.
+type DocRef struct {
+	Path   string `json:"path"` // the document, relative to the root
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"` // one of the DocRef* kinds
+	Ref    string `json:"ref"`  // the reference as written
+	Reason string `json:"reason"`
+}
+
+func (r DocRef) String() string {
+	return fmt.Sprintf("[%s] %s:%d — %s: %s", r.Kind, r.Path, r.Line, r.Ref, r.Reason)
+}
+
+// DocRefsResult holds the broken references found in the project's
+// documents, optionally filtered by kind and path prefix.
+type DocRefsResult struct {
+	Docs    int            `json:"docs"`    // documents checked
+	Checked int            `json:"checked"` // references resolved against the index
+	Counts  map[string]int `json:"counts"`  // broken references by kind, before filtering by kind
+	Total   int            `json:"total"`   // broken references matching the filters
+	Broken  []DocRef       `json:"broken"`
+}
+
+// isDocFile reports whether the file at relPath is Markdown, MDX or
+// reStructuredText documentation.
+func isDocFile(relPath string) bool {
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".md", ".mdx", ".markdown", ".rst":
+		return true
+	}
+	return false
+}
+
+var (
+	docFenceRe      = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	docMdCodeRe     = regexp.MustCompile("`+([^`]+)`+")
+	docRSTCodeRe    = regexp.MustCompile("``([^`]+)``")
+	docMdLinkRe     = regexp.MustCompile(`\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	docMdRefDefRe   = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*<?([^\s>]+)`)
+	docHTMLLinkRe   = regexp.MustCompile(`(?:href|src)\s*=\s*"([^"]+)"`)
+	docRSTLinkRe    = regexp.MustCompile("`[^`<]*<([^>`]+)>`__?")
+	docRSTPathRe    = regexp.MustCompile(`^\s*\.\. (?:include|literalinclude|image|figure)::\s*(\S+)`)
+	docSchemeRe     = regexp.MustCompile(`^[A-Za-z][\w+.-]*:`)
+	docIdentRe      = regexp.MustCompile(`^[A-Za-z_$][\w$]*(?:(?:\.|::|#|->)[A-Za-z_$][\w$]*)*$`)
+	docIdentSepRe   = regexp.MustCompile(`\.|::|#|->`)
+	docPathRe       = regexp.MustCompile(`^(?:\.{1,2}/)*[\w.@-]+(?:/[\w.@-]+)*/(?:[\w@-]+\.\w+|[\w.@-]+/)$`)
+	docAnchorAttrRe = regexp.MustCompile(`\{#([\w-]+)\}|<a\s+(?:name|id)="([^"]+)"`)
+	docWordRe       = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+)
+
+// dataExtensions are extensions of files that documents name but that are
+// often generated or ignored, and so missing from the index.
+var dataExtensions = map[string]bool{
+	"bin": true, "csv": true, "db": true, "env": true, "json": true, "lock": true, "log": true,
+	"toml": true, "txt": true, "xml": true, "yaml": true, "yml": true, "zip": true,
+}
+
+// docSymbols is what backticked identifiers in documents resolve against.
+type docSymbols struct {
+	names      map[string]bool // symbol names, plain and qualified
+	qualifiers map[string]bool // names that qualify others: parents, types, packages, file stems
+	extensions map[string]bool // extensions of indexed files, without the dot
+}
+
+// DocRefs checks the references in the project's Markdown and
+// reStructuredText documents outside code blocks. Backticked identifiers
+// that look like code, such as `parseEntries`, `Index`, `Index.Scan` or
+// `load_config()`, must name an indexed symbol; a qualified one is only
+// checked when its qualifier names something in the project, so references
+// to libraries are left alone. Relative links and backticked paths must
+// name existing files, and links to a heading of a Markdown document must
+// match one of its headings. kind and pathPrefix filter the broken
+// references when non-empty; at most max are returned when max is positive.
+func (idx *Index) DocRefs(kind, pathPrefix string, max int) (*DocRefsResult, error) {
+	if kind != "" && !isDocRefKind(kind) {
+		return nil, fmt.Errorf("unknown reference kind %q (want one of %s)", kind, strings.Join(docRefKinds, ", "))
+	}
+	pathPrefix = filepath.Clean(pathPrefix)
+
+	syms := docSymbols{names: map[string]bool{}, qualifiers: map[string]bool{}, extensions: map[string]bool{}}
+	for _, e := range idx.entries() {
+		if e.Package != "" {
+			syms.qualifiers[filepath.Base(e.Package)] = true
+		}
+		if e.Kind == "file" {
+			ext := filepath.Ext(e.Name)
+			syms.qualifiers[strings.TrimSuffix(e.Name, ext)] = true
+			syms.extensions[strings.TrimPrefix(ext, ".")] = true
+			continue
+		}
+		if isDocFile(e.Path) {
+			continue // a heading does not define the code a document names
+		}
+		syms.names[e.Name] = true
+		syms.names[e.QualifiedName()] = true
+		syms.qualifiers[e.Name] = true
+		if e.Parent != "" {
+			syms.qualifiers[e.Parent] = true
+		}
+	}
+
+	var docs []string
+	for _, p := range idx.FilePaths() {
+		if isDocFile(p) && (pathPrefix == "." || p == pathPrefix || strings.HasPrefix(p, pathPrefix+string(filepath.Separator))) {
+			docs = append(docs, p)
+		}
+	}
+	sort.Strings(docs)
+
+	anchors := newAnchorCache(idx.Root)
+	type docResult struct {
+		checked int
+		broken  []DocRef
+	}
+	perDoc := parallelMap(docs, idx.Workers, func(relPath string) docResult {
+		content, err := os.ReadFile(filepath.Join(idx.Root, relPath))
+		if err != nil {
+			return docResult{}
+		}
+		checked, broken := checkDocRefs(idx.Root, relPath, content, syms, anchors)
+		return docResult{checked, broken}
+	})
+
+	// A name no symbol has may still be a field, key or variable the code
+	// uses; only names the code never mentions are stale.
+	missing := map[string]bool{}
+	for _, r := range perDoc {
+		for _, ref := range r.broken {
+			if ref.Kind == DocRefSymbol {
+				missing[docIdentName(ref.Ref)] = true
+			}
+		}
+	}
+	mentioned := idx.mentionedInCode(missing)
+
+	result := &DocRefsResult{Docs: len(docs), Counts: map[string]int{}, Broken: []DocRef{}}
+	for _, r := range perDoc {
+		result.Checked += r.checked
+		for _, ref := range r.broken {
+			if ref.Kind == DocRefSymbol && mentioned[docIdentName(ref.Ref)] {
+				continue
+			}
+			result.Counts[ref.Kind]++
+			if kind != "" && ref.Kind != kind {
+				continue
+			}
+			result.Total++
+			if max <= 0 || len(result.Broken) < max {
+				result.Broken = append(result.Broken, ref)
+			}
+		}
+	}
+	return result, nil
+}
+
+// checkDocRefs returns the number of references in the document at relPath
+// that were checked, and those that are broken.
+func checkDocRefs(root, relPath string, content []byte, syms docSymbols, anchors *anchorCache) (int, []DocRef) {
+	rst := strings.EqualFold(filepath.Ext(relPath), ".rst")
+	codeRe := docMdCodeRe
+	if rst {
+		codeRe = docRSTCodeRe
+	}
+	checked := 0
+	var broken []DocRef
+	report := func(line int, kind, ref, reason string) {
+		broken = append(broken, DocRef{Path: relPath, Line: line, Kind: kind, Ref: ref, Reason: reason})
+	}
+
+	fence := ""         // the open Markdown code fence, if any
+	literalIndent := -1 // indent of the line opening an RST literal block
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if rst {
+			if literalIndent >= 0 {
+				if trimmed == "" || lineIndentCount(line) > literalIndent {
+					continue
+				}
+				literalIndent = -1
+			}
+			// A paragraph ending in "::" or a code directive introduces an
+			// indented literal block; other directives hold checked text.
+			if strings.HasSuffix(trimmed, "::") && !strings.HasPrefix(trimmed, ".. ") || strings.HasPrefix(trimmed, ".. code") {
+				literalIndent = lineIndentCount(line)
+			}
+		} else if m := docFenceRe.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) && trimmed == m[1] {
+				fence = ""
+			}
+			continue
+		} else if fence != "" {
+			continue
+		}
+
+		for _, m := range codeRe.FindAllStringSubmatch(line, -1) {
+			code := strings.TrimSpace(m[1])
+			if docPathRe.MatchString(code) {
+				// Paths in code are usually written from the root.
+				checked++
+				if checkDocPath(root, relPath, code, "", anchors) != "" && checkDocPath(root, relPath, "/"+code, "", anchors) != "" {
+					report(i+1, DocRefFile, code, "no such file")
+				}
+			} else if ok, reason := syms.check(code); ok {
+				checked++
+				if reason != "" {
+					report(i+1, DocRefSymbol, code, reason)
+				}
+			}
+		}
+
+		// Links inside code spans are examples, not links.
+		text := codeRe.ReplaceAllString(line, "")
+		var targets []string
+		if rst {
+			targets = append(firstGroups(docRSTLinkRe, text), firstGroups(docRSTPathRe, text)...)
+		} else {
+			targets = append(firstGroups(docMdLinkRe, text), firstGroups(docMdRefDefRe, text)...)
+			targets = append(targets, firstGroups(docHTMLLinkRe, text)...)
+		}
+		for _, target := range targets {
+			if docSchemeRe.MatchString(target) || strings.HasPrefix(target, "//") || strings.HasSuffix(target, "_") {
+				continue // a URL, or an RST reference name
+			}
+			target, _, _ = strings.Cut(target, "?")
+			path, anchor, _ := strings.Cut(target, "#")
+			checked++
+			if reason := checkDocPath(root, relPath, path, anchor, anchors); reason != "" {
+				kind := DocRefFile
+				if strings.HasPrefix(reason, "no heading") {
+					kind = DocRefAnchor
+				}
+				report(i+1, kind, target, reason)
+			}
+		}
+	}
+	return checked, broken
+}
+
+// firstGroups returns the first submatch of each match of re in s.
+func firstGroups(re *regexp.Regexp, s string) []string {
+	var groups []string
+	for _, m := range re.FindAllStringSubmatch(s, -1) {
+		groups = append(groups, m[1])
+	}
+	return groups
+}
+
+// mentionedInCode returns the names in names that appear as a word in
+// any indexed file other than documentation.
+func (idx *Index) mentionedInCode(names map[string]bool) map[string]bool {
+	mentioned := map[string]bool{}
+	if len(names) == 0 {
+		return mentioned
+	}
+	var paths []string
+	for _, p := range idx.FilePaths() {
+		if !isDocFile(p) {
+			paths = append(paths, p)
+		}
+	}
+	perFile := parallelMap(paths, idx.Workers, func(relPath string) []string {
+		content, err := os.ReadFile(filepath.Join(idx.Root, relPath))
+		if err != nil {
+			return nil
+		}
+		var found []string
+		for _, word := range docWordRe.FindAll(content, -1) {
+			if names[string(word)] {
+				found = append(found, string(word))
+			}
+		}
+		return found
+	})
+	for _, found := range perFile {
+		for _, name := range found {
+			mentioned[name] = true
+		}
+	}
+	return mentioned
+}
+
+// docIdentName returns the name a backticked identifier refers to: the
+// last segment of a qualified name, without call parentheses.
+func docIdentName(code string) string {
+	if call := strings.IndexByte(code, '('); call > 0 {
+		code = code[:call]
+	}
+	segs := docIdentSepRe.Split(code, -1)
+	return segs[len(segs)-1]
+}
+
+// check resolves a backticked code span. ok reports whether it is a
+// reference to check at all; reason is why it does not resolve, or "" if
+// it does.
+func (s docSymbols) check(code string) (ok bool, reason string) {
+	call := strings.IndexByte(code, '(')
+	isCall := call > 0 && strings.HasSuffix(code, ")")
+	if isCall {
+		code = code[:call]
+	}
+	if !docIdentRe.MatchString(code) {
+		return false, ""
+	}
+	segs := docIdentSepRe.Split(code, -1)
+	name := segs[len(segs)-1]
+	if len(segs) == 1 {
+		// A bare word is only code if it is spelled like an identifier or
+		// written as a call.
+		if !isCall && !looksLikeIdentifier(name) {
+			return false, ""
+		}
+		if s.names[name] {
+			return true, ""
+		}
+		return true, fmt.Sprintf("%s is not defined or used in the code", name)
+	}
+	qualifier := segs[len(segs)-2]
+	if !s.qualifiers[qualifier] || len(segs) == 2 && strings.Contains(code, ".") && (s.extensions[name] || dataExtensions[name]) {
+		// A library or a variable, which the index does not know, or a
+		// file name such as main.go.
+		return false, ""
+	}
+	if s.names[code] || s.names[name] {
+		return true, ""
+	}
+	return true, fmt.Sprintf("%s has no %s", qualifier, name)
+}
+
+// looksLikeIdentifier reports whether a single word is written the way
+// code names things, in camelCase, PascalCase such as Index or Load, or
+// snake_case, rather than as a lower-case or all-caps word. Capitalised
+// words are what Go, Java and TypeScript types and exported functions
+// look like, so they are checked even without an inner capital.
+func looksLikeIdentifier(word string) bool {
+	if strings.Trim(word, "_") != word {
+		return false
+	}
+	if strings.Contains(word, "_") {
+		return true
+	}
+	runes := []rune(word)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]) {
+			return true
+		}
+		if unicode.IsUpper(runes[0]) && unicode.IsLower(runes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDocPath resolves a link target, relative to the document at docPath
+// or, with a leading slash, to the root, and returns why it is broken, or
+// "" if the file exists and has the anchor, if any. Paths outside the root
+// are not checked.
+func checkDocPath(root, docPath, target, anchor string, anchors *anchorCache) string {
+	if decoded, err := url.PathUnescape(target); err == nil {
+		target = decoded
+	}
+	rel := docPath
+	if target != "" {
+		if strings.HasPrefix(target, "/") {
+			rel = filepath.Clean(strings.TrimPrefix(target, "/"))
+		} else {
+			rel = filepath.Join(filepath.Dir(docPath), target)
+		}
+		if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return ""
+		}
+		if _, err := os.Stat(filepath.Join(root, rel)); err != nil {
+			return "no such file"
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(rel))
+	if anchor == "" || (ext != ".md" && ext != ".mdx" && ext != ".markdown") {
+		return ""
+	}
+	if !anchors.has(rel, strings.ToLower(anchor)) {
+		return fmt.Sprintf("no heading #%s in %s", anchor, rel)
+	}
+	return ""
+}
+
+// anchorCache holds the heading anchors of the Markdown documents links
+// point into, computed once per document.
+type anchorCache struct {
+	root    string
+	mu      sync.Mutex
+	anchors map[string]map[string]bool
+}
+
+func newAnchorCache(root string) *anchorCache {
+	return &anchorCache{root: root, anchors: map[string]map[string]bool{}}
+}
+
+// has reports whether the Markdown document at relPath has anchor.
+func (c *anchorCache) has(relPath, anchor string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set, ok := c.anchors[relPath]
+	if !ok {
+		set = markdownAnchors(c.root, relPath)
+		c.anchors[relPath] = set
+	}
+	return set[anchor]
+}
+
+// markdownAnchors returns the anchors of a Markdown document: the slugs
+// GitHub gives its headings, numbered when repeated, and explicit {#id}
+// attributes and <a name> or <a id> tags.
+func markdownAnchors(root, relPath string) map[string]bool {
+	set := map[string]bool{}
+	content, err := os.ReadFile(filepath.Join(root, relPath))
+	if err != nil {
+		return set
+	}
+	if p := parsers.ForFile(relPath); p != nil {
+		symbols, _ := p.Parse(relPath, content)
+		seen := map[string]int{}
+		for _, s := range symbols {
+			slug := headingSlug(s.Name)
+			if n := seen[slug]; n > 0 {
+				set[fmt.Sprintf("%s-%d", slug, n)] = true
+			} else {
+				set[slug] = true
+			}
+			seen[slug]++
+		}
+	}
+	for _, m := range docAnchorAttrRe.FindAllStringSubmatch(string(content), -1) {
+		set[strings.ToLower(m[1]+m[2])] = true
+	}
+	return set
+}
+
+// headingSlug returns the anchor GitHub generates for a heading: lower
+// case, punctuation other than hyphens and underscores dropped, and spaces
+// replaced by hyphens.
+func headingSlug(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// FormatDocRefs returns a human-readable rendering of the broken
+// references.
+func FormatDocRefs(r *DocRefsResult) string {
+	var b strings.Builder
+	var parts []string
+	for _, kind := range docRefKinds {
+		if n := r.Counts[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	if len(parts) == 0 {
+		b.WriteString(fmt.Sprintf("No broken references (%d checked in %d docs)\n", r.Checked, r.Docs))
+		return b.String()
+	}
+	b.WriteString(fmt.Sprintf("Broken doc references: %s (%d checked in %d docs)\n", strings.Join(parts, ", "), r.Checked, r.Docs))
+	if r.Total == 0 {
+		b.WriteString("\nNo broken references match the filters.\n")
+		return b.String()
+	}
+	b.WriteString("\n")
+	for _, ref := range r.Broken {
+		b.WriteString(ref.String() + "\n")
+	}
+	if len(r.Broken) < r.Total {
+		b.WriteString(fmt.Sprintf("\n... and %d more (use --max to see more)\n", r.Total-len(r.Broken)))
+	}
+	return b.String()
+}
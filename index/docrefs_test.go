@@ -0,0 +1,122 @@
+package index
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocRefs(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "store/store.go", `package store
+
+type Store struct{}
+
+func (s *Store) Load() error { return nil }
+
+func OpenStore() *Store { return nil }
+
+const endKey = "endLine"
+`)
+	mkFile(t, tmp, "README.md", "# Project\n\n"+
+		"Call `OpenStore()` then `Store.Load`, or `store.Save`.\n"+
+		"`OldHelper` is gone; `endLine` is used; `json.Marshal` and `scan` are not checked.\n"+
+		"See [the guide](docs/guide.md#setup), [missing](docs/old.md),\n"+
+		"[bad anchor](docs/guide.md#teardown) and [site](https://example.com).\n"+
+		"Edit `store/store.go` or `store/gone.go`.\n\n"+
+		"```go\n"+
+		"`Unchecked` [x](nowhere.md)\n"+
+		"```\n\n"+
+		"## Usage\n\nSee [below](#usage) or [nothing](#nothing).\n")
+	mkFile(t, tmp, "docs/guide.md", "# Guide\n\n## Setup\n\nBack to the [readme](../README.md).\n")
+	mkFile(t, tmp, "docs/index.rst", "Index\n=====\n\nSee ``OpenStore`` and ``MissingThing`` in `the guide <guide.md>`_.\n\n"+
+		".. image:: diagram.png\n\n::\n\n    ``NotChecked``\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	// Headings are indexed so that lookup works on docs.
+	var heading bool
+	for _, e := range idx.MatchExact("Setup") {
+		heading = heading || e.Kind == "heading" && e.Parent == "Guide"
+	}
+	if !heading {
+		t.Error("MatchExact(Setup) missing the docs/guide.md heading")
+	}
+
+	result, err := idx.DocRefs("", "", 0)
+	if err != nil {
+		t.Fatalf("DocRefs() error: %v", err)
+	}
+	want := []DocRef{
+		{Path: "README.md", Line: 3, Kind: DocRefSymbol, Ref: "store.Save", Reason: "store has no Save"},
+		{Path: "README.md", Line: 4, Kind: DocRefSymbol, Ref: "OldHelper", Reason: "OldHelper is not defined or used in the code"},
+		{Path: "README.md", Line: 5, Kind: DocRefFile, Ref: "docs/old.md", Reason: "no such file"},
+		{Path: "README.md", Line: 6, Kind: DocRefAnchor, Ref: "docs/guide.md#teardown", Reason: "no heading #teardown in " + filepath.FromSlash("docs/guide.md")},
+		{Path: "README.md", Line: 7, Kind: DocRefFile, Ref: "store/gone.go", Reason: "no such file"},
+		{Path: "README.md", Line: 15, Kind: DocRefAnchor, Ref: "#nothing", Reason: "no heading #nothing in README.md"},
+		{Path: filepath.FromSlash("docs/index.rst"), Line: 4, Kind: DocRefSymbol, Ref: "MissingThing", Reason: "MissingThing is not defined or used in the code"},
+		{Path: filepath.FromSlash("docs/index.rst"), Line: 6, Kind: DocRefFile, Ref: "diagram.png", Reason: "no such file"},
+	}
+	if !reflect.DeepEqual(result.Broken, want) {
+		t.Errorf("DocRefs() broken =\n%+v\nwant\n%+v", result.Broken, want)
+	}
+	if result.Docs != 3 || result.Total != len(want) {
+		t.Errorf("Docs = %d, Total = %d, want 3 and %d", result.Docs, result.Total, len(want))
+	}
+	if result.Checked != 17 {
+		t.Errorf("Checked = %d, want 17", result.Checked)
+	}
+
+	filtered, err := idx.DocRefs(DocRefAnchor, "README.md", 1)
+	if err != nil {
+		t.Fatalf("DocRefs(anchor) error: %v", err)
+	}
+	if filtered.Total != 2 || len(filtered.Broken) != 1 || filtered.Counts[DocRefSymbol] != 2 {
+		t.Errorf("DocRefs(anchor, README.md, 1) = %+v", filtered)
+	}
+	out := FormatDocRefs(filtered)
+	for _, s := range []string{"Broken doc references: 2 symbol, 2 file, 2 anchor", "[anchor] README.md:6", "... and 1 more"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("FormatDocRefs() missing %q:\n%s", s, out)
+		}
+	}
+
+	if _, err := idx.DocRefs("bogus", "", 0); err == nil {
+		t.Error("DocRefs(bogus) should fail")
+	}
+}
+
+func TestDocRefsSingleWords(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "store/store.go", `package store
+
+func Load() error { return nil }
+
+func Found() bool { return true }
+`)
+	mkFile(t, tmp, "README.md", "# Store\n\n"+
+		"`Load` reads it and `Found` reports a hit; `Missing` was renamed.\n"+
+		"Call `cleanup()` when done. `JSON`, `scan` and `TODO` are not checked.\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	result, err := idx.DocRefs(DocRefSymbol, "", 0)
+	if err != nil {
+		t.Fatalf("DocRefs() error: %v", err)
+	}
+	want := []DocRef{
+		{Path: "README.md", Line: 3, Kind: DocRefSymbol, Ref: "Missing", Reason: "Missing is not defined or used in the code"},
+		{Path: "README.md", Line: 4, Kind: DocRefSymbol, Ref: "cleanup()", Reason: "cleanup is not defined or used in the code"},
+	}
+	if !reflect.DeepEqual(result.Broken, want) {
+		t.Errorf("DocRefs() broken =\n%+v\nwant\n%+v", result.Broken, want)
+	}
+	if result.Checked != 4 {
+		t.Errorf("Checked = %d, want 4", result.Checked)
+	}
+}
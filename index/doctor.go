@@ -0,0 +1,314 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// Doctor check outcomes.
+const (
+	CheckOK      = "ok"
+	CheckWarning = "warning"
+	CheckError   = "error"
+)
+
+// maxCheckDetails bounds how many offending items a check lists.
+const maxCheckDetails = 20
+
+// DoctorCheck is the outcome of one integrity check.
+type DoctorCheck struct {
+	Name    string   `json:"name"`
+	Status  string   `json:"status"` // "ok", "warning" or "error"
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"` // up to 20 offending items
+}
+
+// DoctorResult holds every check run against a saved index.
+type DoctorResult struct {
+	IndexDir      string        `json:"indexDir"`
+	Root          string        `json:"root"`
+	SchemaVersion int           `json:"schemaVersion"`
+	Backend       string        `json:"backend"`
+	Healthy       bool          `json:"healthy"` // true when no check reported an error
+	Checks        []DoctorCheck `json:"checks"`
+}
+
+// Doctor checks the index saved under <dir>/swarm/index/ without migrating or
+// rewriting it: the schema version, whether the scanned root still exists,
+// entries pointing at missing files, symbol lines past the end of their file,
+// and whether the counts in meta.json, the file records and the trigram index
+// agree with the entries.
+func Doctor(dir string) (*DoctorResult, error) {
+	indexDir := filepath.Join(dir, "swarm", "index")
+	meta, err := readMeta(indexDir)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := openIndex(indexDir, meta)
+	if err != nil {
+		return nil, err
+	}
+	defer idx.Close()
+
+	result := &DoctorResult{
+		IndexDir:      indexDir,
+		Root:          meta.Root,
+		SchemaVersion: meta.Schema,
+		Backend:       idx.Backend,
+	}
+	add := func(c DoctorCheck) {
+		result.Checks = append(result.Checks, c)
+	}
+
+	add(checkSchema(meta.Schema))
+	root, rootCheck := checkRoot(meta.Root, dir, idx.FilePaths())
+	add(rootCheck)
+	if rootCheck.Status == CheckOK {
+		add(checkFiles(idx, root))
+		add(checkLines(idx, root))
+	}
+	add(checkCounts(idx, meta))
+	add(checkRecords(idx))
+	add(checkTrigrams(idx))
+	add(checkPlugins(dir))
+
+	result.Healthy = true
+	for _, c := range result.Checks {
+		if c.Status == CheckError {
+			result.Healthy = false
+		}
+	}
+	return result, nil
+}
+
+func checkSchema(schema int) DoctorCheck {
+	c := DoctorCheck{Name: "schema", Status: CheckOK}
+	switch {
+	case schema > SchemaVersion:
+		c.Status = CheckError
+		c.Message = fmt.Sprintf("schema version %d is newer than this binary supports (%d); upgrade swarm-index", schema, SchemaVersion)
+	case schema < SchemaVersion:
+		c.Status = CheckError
+		c.Message = fmt.Sprintf("schema version %d is older than %d; the next command will rescan, or run 'swarm-index scan'", schema, SchemaVersion)
+	default:
+		c.Message = fmt.Sprintf("schema version %d is current", schema)
+	}
+	return c
+}
+
+// checkRoot verifies the scanned root still exists. When it does not but the
+// indexed files exist under dir, the project most likely moved there.
+func checkRoot(root, dir string, paths []string) (string, DoctorCheck) {
+	c := DoctorCheck{Name: "root", Status: CheckOK}
+	if info, err := os.Stat(root); err == nil && info.IsDir() {
+		c.Message = fmt.Sprintf("%s exists", root)
+		return root, c
+	}
+	c.Status = CheckError
+	c.Message = fmt.Sprintf("%s no longer exists; re-run 'swarm-index scan'", root)
+	if abs, err := filepath.Abs(dir); err == nil && abs != root && len(paths) > 0 {
+		found := 0
+		for _, p := range paths {
+			if _, err := os.Stat(filepath.Join(abs, p)); err == nil {
+				found++
+			}
+		}
+		if found*2 > len(paths) {
+			c.Message = fmt.Sprintf("%s no longer exists; the project appears to have moved to %s — re-run 'swarm-index scan' there", root, abs)
+		}
+	}
+	return "", c
+}
+
+// checkFiles reports indexed files that are missing from disk.
+func checkFiles(idx *Index, root string) DoctorCheck {
+	paths := idx.FilePaths()
+	exists := parallelMap(paths, idx.Workers, func(p string) bool {
+		_, err := os.Stat(filepath.Join(root, p))
+		return err == nil
+	})
+	var missing []string
+	for i, p := range paths {
+		if !exists[i] {
+			missing = append(missing, p)
+		}
+	}
+	c := DoctorCheck{Name: "files", Status: CheckOK, Message: fmt.Sprintf("all %d indexed files exist", len(paths))}
+	if len(missing) > 0 {
+		c.Status = CheckError
+		c.Message = fmt.Sprintf("%d of %d indexed files are missing from disk; run 'swarm-index scan'", len(missing), len(paths))
+		c.Details = capDetails(missing)
+	}
+	return c
+}
+
+// checkLines reports symbol entries whose line or end line is past the end
+// of their file, which happens when a file shrinks after the scan.
+func checkLines(idx *Index, root string) DoctorCheck {
+	byPath := make(map[string][]Entry)
+	var paths []string
+	symbols := 0
+	for _, e := range idx.entries() {
+		if e.Kind == "file" || e.Line <= 0 {
+			continue
+		}
+		if _, ok := byPath[e.Path]; !ok {
+			paths = append(paths, e.Path)
+		}
+		byPath[e.Path] = append(byPath[e.Path], e)
+		symbols++
+	}
+
+	perFile := parallelMap(paths, idx.Workers, func(p string) []string {
+		content, err := os.ReadFile(filepath.Join(root, p))
+		if err != nil {
+			return nil // missing files are reported by checkFiles
+		}
+		lines := bytes.Count(content, []byte("\n"))
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			lines++
+		}
+		var bad []string
+		for _, e := range byPath[p] {
+			if e.Line > lines || e.EndLine > lines {
+				bad = append(bad, fmt.Sprintf("%s:%d %s (file has %d lines)", e.Path, max(e.Line, e.EndLine), e.QualifiedName(), lines))
+			}
+		}
+		return bad
+	})
+	var bad []string
+	for _, b := range perFile {
+		bad = append(bad, b...)
+	}
+
+	c := DoctorCheck{Name: "lines", Status: CheckOK, Message: fmt.Sprintf("all %d symbol lines are within their files", symbols)}
+	if len(bad) > 0 {
+		c.Status = CheckError
+		c.Message = fmt.Sprintf("%d symbols point past the end of their file; run 'swarm-index scan'", len(bad))
+		c.Details = capDetails(bad)
+	}
+	return c
+}
+
+// checkCounts compares the counts cached in meta.json with the entries.
+func checkCounts(idx *Index, meta *indexMeta) DoctorCheck {
+	var diffs []string
+	if n := idx.FileCount(); n != meta.FileCount {
+		diffs = append(diffs, fmt.Sprintf("fileCount: meta.json says %d, index has %d", meta.FileCount, n))
+	}
+	if n := idx.PackageCount(); n != meta.PackageCount {
+		diffs = append(diffs, fmt.Sprintf("packageCount: meta.json says %d, index has %d", meta.PackageCount, n))
+	}
+	if ext := idx.ExtensionCounts(); !reflect.DeepEqual(ext, meta.Extensions) && !(len(ext) == 0 && len(meta.Extensions) == 0) {
+		diffs = append(diffs, "extensions: meta.json does not match the indexed files")
+	}
+	c := DoctorCheck{Name: "counts", Status: CheckOK, Message: "meta.json counts match the index"}
+	if len(diffs) > 0 {
+		c.Status = CheckError
+		c.Message = "meta.json counts do not match the index; run 'swarm-index scan'"
+		c.Details = diffs
+	}
+	return c
+}
+
+// checkRecords compares the per-file records with the file entries.
+func checkRecords(idx *Index) DoctorCheck {
+	records := idx.fileRecords()
+	paths := idx.FilePaths()
+	var missing []string
+	for _, p := range paths {
+		if _, ok := records[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	c := DoctorCheck{Name: "records", Status: CheckOK, Message: fmt.Sprintf("all %d files have size, mtime and hash records", len(paths))}
+	if len(missing) > 0 {
+		c.Status = CheckWarning
+		c.Message = fmt.Sprintf("%d files have no scan record, so staleness and incremental scans fall back to rereading them", len(missing))
+		c.Details = capDetails(missing)
+	}
+	if extra := len(records) - (len(paths) - len(missing)); extra > 0 {
+		c.Status = CheckWarning
+		c.Message = fmt.Sprintf("%d scan records have no matching file entry", extra)
+		c.Details = nil
+	}
+	return c
+}
+
+// checkTrigrams verifies the full-text index covers exactly the indexed files.
+func checkTrigrams(idx *Index) DoctorCheck {
+	c := DoctorCheck{Name: "trigrams", Status: CheckOK}
+	t := idx.trigrams()
+	if t == nil {
+		c.Status = CheckWarning
+		c.Message = trigramFile + " is missing or unreadable, so content commands read every file; run 'swarm-index scan'"
+		return c
+	}
+	paths := idx.FilePaths()
+	if !reflect.DeepEqual(t.paths, paths) && !(len(t.paths) == 0 && len(paths) == 0) {
+		c.Status = CheckError
+		c.Message = fmt.Sprintf("%s covers %d files but the index has %d; run 'swarm-index scan'", trigramFile, len(t.paths), len(paths))
+		return c
+	}
+	c.Message = fmt.Sprintf("%s covers all %d files", trigramFile, len(paths))
+	return c
+}
+
+// checkPlugins verifies the parser plugin config, if any, is valid. Queries
+// fall back to the built-in parsers when it is not, but scans fail.
+func checkPlugins(dir string) DoctorCheck {
+	c := DoctorCheck{Name: "plugins", Status: CheckOK}
+	path, err := findProjectConfig(dir)
+	if err != nil || path == "" {
+		c.Message = "no " + ProjectConfigFile + ", using the built-in parsers"
+		return c
+	}
+	ps, err := readParserPlugins(path)
+	if err != nil {
+		c.Status = CheckWarning
+		c.Message = fmt.Sprintf("%s: %v; queries use the built-in parsers and scans will fail", path, err)
+		return c
+	}
+	c.Message = fmt.Sprintf("%s configures %d parser plugin(s)", path, len(ps))
+	return c
+}
+
+func capDetails(items []string) []string {
+	if len(items) > maxCheckDetails {
+		return append(items[:maxCheckDetails:maxCheckDetails], fmt.Sprintf("... and %d more", len(items)-maxCheckDetails))
+	}
+	return items
+}
+
+// FormatDoctor returns a human-readable text rendering of the doctor result.
+func FormatDoctor(r *DoctorResult) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Index %s (schema %d, %s store)\n", r.IndexDir, r.SchemaVersion, r.Backend))
+	b.WriteString(fmt.Sprintf("Root  %s\n\n", r.Root))
+
+	problems := 0
+	for _, c := range r.Checks {
+		b.WriteString(fmt.Sprintf("  %-8s %-9s %s\n", c.Name, c.Status, c.Message))
+		for _, d := range c.Details {
+			b.WriteString(fmt.Sprintf("             %s\n", d))
+		}
+		if c.Status != CheckOK {
+			problems++
+		}
+	}
+
+	switch {
+	case problems == 0:
+		b.WriteString("\nIndex is healthy.\n")
+	case r.Healthy:
+		b.WriteString(fmt.Sprintf("\n%d warning(s); the index is usable.\n", problems))
+	default:
+		b.WriteString(fmt.Sprintf("\n%d problem(s) found.\n", problems))
+	}
+	return b.String()
+}
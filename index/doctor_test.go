@@ -0,0 +1,153 @@
+package index
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// doctorFixture scans and saves a small project, returning its directory.
+func doctorFixture(t *testing.T) string {
+	t.Helper()
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main\n\nfunc main() {}\n")
+	mkFile(t, tmp, "lib/lib.go", "package lib\n\n// Helper helps.\nfunc Helper() {\n\treturn\n}\n")
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatal(err)
+	}
+	return tmp
+}
+
+func doctorCheck(t *testing.T, r *DoctorResult, name string) DoctorCheck {
+	t.Helper()
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no %q check in %+v", name, r.Checks)
+	return DoctorCheck{}
+}
+
+func TestDoctorHealthy(t *testing.T) {
+	tmp := doctorFixture(t)
+	r, err := Doctor(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Healthy {
+		t.Errorf("expected healthy index, got %+v", r.Checks)
+	}
+	for _, c := range r.Checks {
+		if c.Status != CheckOK {
+			t.Errorf("check %s = %s (%s), want ok", c.Name, c.Status, c.Message)
+		}
+	}
+	if r.SchemaVersion != SchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", r.SchemaVersion, SchemaVersion)
+	}
+	if !strings.Contains(FormatDoctor(r), "Index is healthy") {
+		t.Errorf("unexpected text:\n%s", FormatDoctor(r))
+	}
+}
+
+func TestDoctorMissingFileAndShortenedFile(t *testing.T) {
+	tmp := doctorFixture(t)
+	os.Remove(filepath.Join(tmp, "main.go"))
+	mkFile(t, tmp, "lib/lib.go", "package lib\n")
+
+	r, err := Doctor(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Healthy {
+		t.Error("expected unhealthy index")
+	}
+	files := doctorCheck(t, r, "files")
+	if files.Status != CheckError || len(files.Details) != 1 || files.Details[0] != "main.go" {
+		t.Errorf("files check = %+v", files)
+	}
+	lines := doctorCheck(t, r, "lines")
+	if lines.Status != CheckError || len(lines.Details) != 1 || !strings.Contains(lines.Details[0], "Helper") {
+		t.Errorf("lines check = %+v", lines)
+	}
+}
+
+func TestDoctorCountsMismatch(t *testing.T) {
+	tmp := doctorFixture(t)
+	metaPath := filepath.Join(tmp, "swarm", "index", "meta.json")
+	var meta map[string]any
+	data, _ := os.ReadFile(metaPath)
+	json.Unmarshal(data, &meta)
+	meta["fileCount"] = 7
+	data, _ = json.Marshal(meta)
+	os.WriteFile(metaPath, data, 0o644)
+
+	r, err := Doctor(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := doctorCheck(t, r, "counts")
+	if counts.Status != CheckError || len(counts.Details) != 1 || !strings.Contains(counts.Details[0], "fileCount") {
+		t.Errorf("counts check = %+v", counts)
+	}
+}
+
+func TestDoctorRootMoved(t *testing.T) {
+	tmp := doctorFixture(t)
+	moved := filepath.Join(t.TempDir(), "moved")
+	if err := os.Rename(tmp, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Doctor(moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := doctorCheck(t, r, "root")
+	if root.Status != CheckError || !strings.Contains(root.Message, "moved to "+moved) {
+		t.Errorf("root check = %+v", root)
+	}
+	for _, c := range r.Checks {
+		if c.Name == "files" || c.Name == "lines" {
+			t.Errorf("%s check should be skipped without a root", c.Name)
+		}
+	}
+}
+
+func TestDoctorOlderSchemaAndMissingTrigrams(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "a.go", "package a\n")
+	writeLegacyIndex(t, tmp, tmp)
+
+	r, err := Doctor(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := doctorCheck(t, r, "schema"); c.Status != CheckError {
+		t.Errorf("schema check = %+v", c)
+	}
+	if c := doctorCheck(t, r, "records"); c.Status != CheckWarning {
+		t.Errorf("records check = %+v", c)
+	}
+	if c := doctorCheck(t, r, "trigrams"); c.Status != CheckWarning {
+		t.Errorf("trigrams check = %+v", c)
+	}
+	// Doctor only reports; it must not migrate the index.
+	meta, _ := readMeta(filepath.Join(tmp, "swarm", "index"))
+	if meta.Schema != 0 {
+		t.Error("Doctor migrated the index")
+	}
+}
+
+func TestDoctorNoIndex(t *testing.T) {
+	if _, err := Doctor(t.TempDir()); err == nil {
+		t.Error("expected error without an index")
+	}
+}
@@ -13,7 +13,7 @@ import (
 type EntryPoint struct {
 	Path      string `json:"path"`
 	Line      int    `json:"line"`
-	Kind      string `json:"kind"`      // "main", "route", "cli", "init"
+	Kind      string `json:"kind"`      // "main", "route", "cli", "init", "api"
 	Signature string `json:"signature"` // the matching line, trimmed
 }
 
@@ -30,7 +30,7 @@ type entryPointPattern struct {
 }
 
 // testFilePattern matches common test file naming conventions.
-var testFilePattern = regexp.MustCompile(`(?i)(_test\.go|\.test\.[jt]sx?|\.spec\.[jt]sx?|test_[^/]*\.py)$`)
+var testFilePattern = regexp.MustCompile(`(?i)(_test\.go|\.test\.[jt]sx?|\.spec\.[jt]sx?|test_[^/]*\.py|(?-i:Tests?)\.(?:java|kt))$`)
 
 // patterns grouped by file extension.
 var entryPointPatterns = map[string][]entryPointPattern{
@@ -91,6 +91,12 @@ var entryPointPatterns = map[string][]entryPointPattern{
 	".java": {
 		{regexp.MustCompile(`public\s+static\s+void\s+main\s*\(`), "main"},
 	},
+	".kt": {
+		{regexp.MustCompile(`^\s*fun\s+main\s*\(`), "main"},
+	},
+	".c": {
+		{regexp.MustCompile(`^\s*int\s+main\s*\(`), "main"},
+	},
 }
 
 func init() {
@@ -98,10 +104,15 @@ func init() {
 	entryPointPatterns[".ts"] = entryPointPatterns[".js"]
 	entryPointPatterns[".tsx"] = entryPointPatterns[".js"]
 	entryPointPatterns[".jsx"] = entryPointPatterns[".js"]
+	// C++ sources share the C patterns
+	entryPointPatterns[".cpp"] = entryPointPatterns[".c"]
+	entryPointPatterns[".cc"] = entryPointPatterns[".c"]
 }
 
-// EntryPoints scans indexed files for executable entry points.
-// kind filters by entry-point kind ("main", "route", "cli", "init"); empty means all.
+// EntryPoints scans indexed files for executable entry points, and lists the
+// operations declared in indexed API specs (OpenAPI paths and GraphQL root
+// fields) as "api" entry points.
+// kind filters by entry-point kind ("main", "route", "cli", "init", "api"); empty means all.
 // max limits the returned results; 0 means default of 100.
 func (idx *Index) EntryPoints(kind string, max int) (*EntryPointsResult, error) {
 	if max <= 0 {
@@ -114,25 +125,38 @@ func (idx *Index) EntryPoints(kind string, max int) (*EntryPointsResult, error)
 	paths := idx.FilePaths()
 	sort.Strings(paths)
 
-	for _, relPath := range paths {
+	perFile := parallelMap(paths, idx.Workers, func(relPath string) []EntryPoint {
 		// Skip test files
 		if testFilePattern.MatchString(relPath) {
-			continue
+			return nil
 		}
 
 		ext := strings.ToLower(filepath.Ext(relPath))
 		patterns, ok := entryPointPatterns[ext]
 		if !ok {
-			continue
+			return nil
 		}
 
 		absPath := filepath.Join(idx.Root, relPath)
-		found := entryPointsInFile(absPath, relPath, patterns)
+		return entryPointsInFile(absPath, relPath, patterns)
+	})
+	for _, found := range perFile {
 		all = append(all, found...)
 	}
+	for _, e := range idx.entries() {
+		if !isAPIOperation(e.Kind, e.Parent) || testFilePattern.MatchString(e.Path) {
+			continue
+		}
+		all = append(all, EntryPoint{
+			Path:      e.Path,
+			Line:      e.Line,
+			Kind:      "api",
+			Signature: apiSignature(e),
+		})
+	}
 
 	// Sort by kind then path then line
-	kindOrder := map[string]int{"main": 0, "route": 1, "cli": 2, "init": 3}
+	kindOrder := map[string]int{"main": 0, "route": 1, "cli": 2, "init": 3, "api": 4}
 	sort.Slice(all, func(i, j int) bool {
 		ki, kj := kindOrder[all[i].Kind], kindOrder[all[j].Kind]
 		if ki != kj {
@@ -201,6 +225,31 @@ func entryPointsInFile(fullPath, relPath string, patterns []entryPointPattern) [
 	return results
 }
 
+// isAPIOperation reports whether a symbol of the given kind and parent is an
+// operation of an API contract: an OpenAPI operation, or a field of a
+// GraphQL root type (named GraphQL operations have no parent).
+func isAPIOperation(kind, parent string) bool {
+	switch kind {
+	case "operation":
+		return true
+	case "query", "mutation", "subscription":
+		return parent != ""
+	}
+	return false
+}
+
+// apiSignature describes an API operation entry, e.g. "GET /pets (listPets)"
+// or "query user(id: ID!): User".
+func apiSignature(e Entry) string {
+	if e.Kind != "operation" {
+		return e.Kind + " " + e.Signature
+	}
+	if e.Name != e.Signature {
+		return fmt.Sprintf("%s (%s)", e.Signature, e.Name)
+	}
+	return e.Signature
+}
+
 // FormatEntryPoints returns a human-readable rendering of entry points.
 func FormatEntryPoints(r *EntryPointsResult) string {
 	var b strings.Builder
@@ -221,10 +270,11 @@ func FormatEntryPoints(r *EntryPointsResult) string {
 		"route": "Route handlers",
 		"cli":   "CLI commands",
 		"init":  "Init functions",
+		"api":   "API operations",
 	}
 
 	first := true
-	for _, kind := range []string{"main", "route", "cli", "init"} {
+	for _, kind := range []string{"main", "route", "cli", "init", "api"} {
 		eps, ok := groups[kind]
 		if !ok {
 			continue
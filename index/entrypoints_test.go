@@ -499,3 +499,48 @@ func init() {
 		t.Errorf("expected 2 cli entries (cobra.Command + AddCommand), got %d", len(result.EntryPoints))
 	}
 }
+
+func TestEntryPointsAPIOperations(t *testing.T) {
+	dir := t.TempDir()
+	mkFile(t, dir, "api/openapi.yaml", `openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+    post:
+      summary: Create a pet
+components:
+  schemas:
+    Pet:
+      type: object
+`)
+	mkFile(t, dir, "schema.graphql", "type Query {\n  pet(id: ID!): Pet\n}\n\nquery GetPet {\n  pet(id: 1) { id }\n}\n")
+	mkFile(t, dir, "config.yaml", "paths:\n  /tmp:\n    get: true\n")
+
+	idx, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	result, err := idx.EntryPoints("api", 0)
+	if err != nil {
+		t.Fatalf("EntryPoints() error: %v", err)
+	}
+
+	want := []EntryPoint{
+		{Path: filepath.Join("api", "openapi.yaml"), Line: 4, Kind: "api", Signature: "GET /pets (listPets)"},
+		{Path: filepath.Join("api", "openapi.yaml"), Line: 6, Kind: "api", Signature: "POST /pets"},
+		{Path: "schema.graphql", Line: 2, Kind: "api", Signature: "query pet(id: ID!): Pet"},
+	}
+	if len(result.EntryPoints) != len(want) {
+		t.Fatalf("got %d API entry points, want %d: %+v", len(result.EntryPoints), len(want), result.EntryPoints)
+	}
+	for i, ep := range result.EntryPoints {
+		if ep != want[i] {
+			t.Errorf("entry point %d = %+v, want %+v", i, ep, want[i])
+		}
+	}
+
+	if out := FormatEntryPoints(result); !strings.Contains(out, "API operations:") {
+		t.Errorf("FormatEntryPoints() missing API header:\n%s", out)
+	}
+}
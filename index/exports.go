@@ -24,6 +24,7 @@ type ExportsResult struct {
 	Scope   string           `json:"scope"`
 	Symbols []ExportedSymbol `json:"symbols"`
 	Count   int              `json:"count"`
+	Errors  []string         `json:"errors,omitempty"` // files that failed to parse, as "path: error"
 }
 
 // Exports returns the public API surface for a given file or directory scope.
@@ -37,7 +38,7 @@ func (idx *Index) Exports(scope string) (*ExportsResult, error) {
 	// Normalize scope — remove trailing slash.
 	scope = strings.TrimRight(scope, "/"+string(filepath.Separator))
 
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Kind != "file" {
 			continue
 		}
@@ -49,7 +50,7 @@ func (idx *Index) Exports(scope string) (*ExportsResult, error) {
 
 	if len(filePaths) == 0 {
 		// Check if scope matches a directory (package).
-		for _, e := range idx.Entries {
+		for _, e := range idx.entries() {
 			if e.Kind != "file" {
 				continue
 			}
@@ -70,19 +71,20 @@ func (idx *Index) Exports(scope string) (*ExportsResult, error) {
 	sort.Strings(filePaths)
 
 	var symbols []ExportedSymbol
+	var parseErrors []string
 	for _, relPath := range filePaths {
 		absPath := filepath.Join(idx.Root, relPath)
 		content, err := os.ReadFile(absPath)
 		if err != nil {
 			continue
 		}
-		ext := filepath.Ext(relPath)
-		p := parsers.ForExtension(ext)
+		p := parsers.ForFile(relPath)
 		if p == nil {
 			continue
 		}
 		parsed, err := p.Parse(absPath, content)
 		if err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("%s: %v", relPath, err))
 			continue
 		}
 		for _, sym := range parsed {
@@ -102,6 +104,7 @@ func (idx *Index) Exports(scope string) (*ExportsResult, error) {
 		Scope:   scope,
 		Symbols: symbols,
 		Count:   len(symbols),
+		Errors:  parseErrors,
 	}, nil
 }
 
@@ -111,6 +114,7 @@ func FormatExports(r *ExportsResult) string {
 
 	if len(r.Symbols) == 0 {
 		b.WriteString(fmt.Sprintf("No exported symbols found for %s\n", r.Scope))
+		writeParseErrors(&b, r.Errors)
 		return b.String()
 	}
 
@@ -144,6 +148,18 @@ func FormatExports(r *ExportsResult) string {
 	}
 
 	b.WriteString(fmt.Sprintf("\n%d exported symbols\n", r.Count))
+	writeParseErrors(&b, r.Errors)
 
 	return b.String()
 }
+
+// writeParseErrors appends a section listing files that failed to parse.
+func writeParseErrors(b *strings.Builder, errs []string) {
+	if len(errs) == 0 {
+		return
+	}
+	b.WriteString(fmt.Sprintf("\nParse errors (%d):\n", len(errs)))
+	for _, e := range errs {
+		b.WriteString("  " + e + "\n")
+	}
+}
@@ -299,6 +299,36 @@ _internal = 42
 	}
 }
 
+func TestExportsPythonDunderAll(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "pkg/__init__.py", `from .client import Client, _connect as connect
+
+__all__ = ["Client", "connect", "VERSION"]
+
+VERSION = "1.0"
+DEBUG = False
+
+def helper():
+    pass
+`)
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	result, err := idx.Exports("pkg/__init__.py")
+	if err != nil {
+		t.Fatalf("Exports() error: %v", err)
+	}
+
+	names := symbolNames(result)
+	want := []string{"Client", "connect", "VERSION"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("exports = %v, want %v", names, want)
+	}
+}
+
 func TestFormatExportsEmpty(t *testing.T) {
 	result := &ExportsResult{
 		Scope:   "missing.go",
@@ -122,7 +122,7 @@ func scoreName(query, name, path string) float64 {
 // score 0 are excluded. Tie-breaking: shorter paths rank higher.
 func (idx *Index) matchFuzzy(query string) []ScoredEntry {
 	var scored []ScoredEntry
-	for _, e := range idx.Entries {
+	for _, e := range idx.matchCandidates(query) {
 		s := scoreName(query, e.Name, e.Path)
 		if s > 0 {
 			scored = append(scored, ScoredEntry{Entry: e, Score: s})
@@ -136,3 +136,14 @@ func (idx *Index) matchFuzzy(query string) []ScoredEntry {
 	})
 	return scored
 }
+
+// matchCandidates returns the entries worth scoring for query, narrowed by the
+// backing store when it can answer queries directly.
+func (idx *Index) matchCandidates(query string) []Entry {
+	if q := idx.querier(); q != nil {
+		if candidates, err := q.MatchCandidates(query); err == nil {
+			return candidates
+		}
+	}
+	return idx.entries()
+}
@@ -0,0 +1,898 @@
+package index
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of type-checked references.
+const (
+	GoRefCall   = "call"   // a call of a function or method
+	GoRefType   = "type"   // a use of a type, including conversions
+	GoRefField  = "field"  // a struct field access or composite literal key
+	GoRefEmbed  = "embed"  // a type embedded in a struct or interface
+	GoRefImport = "import" // an import of a package
+	GoRefValue  = "value"  // any other use, such as a function taken as a value
+)
+
+// GoUse is a declaration in the indexed Go modules that a symbol's
+// definition refers to.
+type GoUse struct {
+	Name  string `json:"name"` // qualified, as in "index.Index.Refs"
+	Kind  string `json:"kind"` // see GoRefCall and friends
+	Path  string `json:"path"`
+	Line  int    `json:"line"`
+	Count int    `json:"count"`
+}
+
+// goTypedDecl is a declaration in the indexed Go modules that references
+// can resolve to: a package-level object, a method, or a field or method
+// of a named struct or interface.
+type goTypedDecl struct {
+	name string // qualified, as in "index.Index.Refs"; the import path for packages
+	kind string // func, method, type, field, var, const or package
+	path string
+	line int
+}
+
+// goTypedRef is a use of a declaration, resolved by the type checker.
+type goTypedRef struct {
+	path      string
+	line      int
+	kind      string
+	target    *goTypedDecl
+	enclosing *goTypedDecl // the top-level declaration the use is in
+}
+
+// goTypedProgram is the type-checked Go code of the index: its
+// declarations and every use of them.
+type goTypedProgram struct {
+	decls  []*goTypedDecl
+	refs   []goTypedRef
+	refsTo map[*goTypedDecl][]int // indexes into refs
+	lines  map[string][]string
+
+	// named holds the package-level types of the non-test files, for
+	// matching method sets; loader imports standard interfaces.
+	named  []goTypedNamed
+	loader *goTypesLoader
+}
+
+// goTypedNamed is a named type declared in the indexed modules.
+type goTypedNamed struct {
+	obj  *types.TypeName
+	decl *goTypedDecl
+}
+
+// goTypes returns the type-checked Go code, loading it on first use.
+func (idx *Index) goTypes() *goTypedProgram {
+	if idx.typedGo == nil {
+		idx.typedGo = loadGoTypes(idx.Root, idx.FilePaths())
+	}
+	return idx.typedGo
+}
+
+// match returns the declarations whose qualified name ends with query,
+// in the style of goCallGraph.match. Packages match by import path.
+func (g *goTypedProgram) match(query string) []*goTypedDecl {
+	query = strings.NewReplacer("(", "", ")", "", "*", "").Replace(query)
+	var found []*goTypedDecl
+	for _, d := range g.decls {
+		sep := "."
+		if d.kind == "package" {
+			sep = "/"
+		}
+		if d.name == query || strings.HasSuffix(d.name, sep+query) {
+			found = append(found, d)
+		}
+	}
+	return found
+}
+
+// refsOf returns the uses of decls, sorted by path and line.
+func (g *goTypedProgram) refsOf(decls []*goTypedDecl) []goTypedRef {
+	var refs []goTypedRef
+	for _, d := range decls {
+		for _, i := range g.refsTo[d] {
+			refs = append(refs, g.refs[i])
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].path != refs[j].path {
+			return refs[i].path < refs[j].path
+		}
+		return refs[i].line < refs[j].line
+	})
+	return refs
+}
+
+// content returns the trimmed source line at path:line.
+func (g *goTypedProgram) content(path string, line int) string {
+	lines := g.lines[path]
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
+
+// TypedRefs finds the definitions and references of a Go symbol with the
+// type checker, so that only uses of the declarations symbol names are
+// returned, each classified as a call, type use, field access, embedding,
+// import or other value use. symbol may be qualified by package and
+// receiver type, as in "Index.Save", and names packages by import path.
+// Symbols that name no Go declaration fall back to Refs.
+func (idx *Index) TypedRefs(symbol string, maxResults int) (*RefsResult, error) {
+	g := idx.goTypes()
+	decls := g.match(symbol)
+	if len(decls) == 0 {
+		return idx.Refs(symbol, maxResults)
+	}
+
+	result := &RefsResult{
+		Symbol:     symbol,
+		References: []RefMatch{},
+		Typed:      true,
+	}
+	add := func(m RefMatch) {
+		if result.TotalRefs < maxResults {
+			result.References = append(result.References, m)
+			result.TotalRefs++
+		}
+	}
+	for i, d := range decls {
+		def := RefMatch{Path: d.path, Line: d.line, Content: g.content(d.path, d.line), IsDefinition: true, Target: d.name}
+		if i == 0 {
+			result.Definition = &def
+		} else {
+			add(def)
+		}
+	}
+	for _, r := range g.refsOf(decls) {
+		add(RefMatch{Path: r.path, Line: r.line, Content: g.content(r.path, r.line), Kind: r.kind, Target: r.target.name})
+	}
+	return result, nil
+}
+
+// TypedContext is Context for a file in the index that, for Go files, also
+// lists the declarations of the indexed modules the symbol's definition
+// uses, resolved by the type checker.
+func (idx *Index) TypedContext(filePath string, symbolName string) (*ContextResult, error) {
+	result, err := Context(filePath, symbolName)
+	if err != nil || filepath.Ext(filePath) != ".go" {
+		return result, err
+	}
+	relPath, err := filepath.Rel(idx.Root, filePath)
+	if err != nil {
+		return result, nil
+	}
+	endLine := result.EndLine
+	if endLine == 0 {
+		endLine = result.Line
+	}
+	inside := func(path string, line int) bool {
+		return path == relPath && line >= result.Line && line <= endLine
+	}
+
+	g := idx.goTypes()
+	seen := make(map[string]int)
+	for _, r := range g.refs {
+		if !inside(r.path, r.line) || inside(r.target.path, r.target.line) {
+			continue
+		}
+		key := r.target.name + "\x00" + r.kind
+		if i, ok := seen[key]; ok {
+			result.Uses[i].Count++
+			continue
+		}
+		seen[key] = len(result.Uses)
+		result.Uses = append(result.Uses, GoUse{Name: r.target.name, Kind: r.kind, Path: r.target.path, Line: r.target.line, Count: 1})
+	}
+	return result, nil
+}
+
+// TypedImpact is Impact with Go symbols traced through references resolved
+// by the type checker: each layer holds the uses of the top-level
+// declarations the previous layer's uses are in. Files, and symbols that
+// name no Go declaration, are analyzed as by Impact.
+func (idx *Index) TypedImpact(target string, maxDepth, maxResults int) (*ImpactResult, error) {
+	if strings.Contains(target, "/") || idx.hasFile(target) {
+		return idx.Impact(target, maxDepth, maxResults)
+	}
+	g := idx.goTypes()
+	decls := g.match(target)
+	if len(decls) == 0 {
+		return idx.Impact(target, maxDepth, maxResults)
+	}
+
+	t := ImpactTarget{Name: target, File: decls[0].path, Line: decls[0].line, Kind: decls[0].kind}
+	visited := make(map[*goTypedDecl]bool)
+	for _, d := range decls {
+		visited[d] = true
+	}
+	layers := []ImpactLayer{}
+	totalRefs := 0
+	frontier := decls
+	for depth := 1; depth <= maxDepth && totalRefs < maxResults; depth++ {
+		var refs []ImpactRef
+		var next []*goTypedDecl
+		for _, r := range g.refsOf(frontier) {
+			if r.enclosing == r.target {
+				continue // recursion
+			}
+			if totalRefs >= maxResults {
+				break
+			}
+			ref := ImpactRef{File: r.path, Line: r.line, Content: g.content(r.path, r.line), Kind: r.kind}
+			if r.enclosing != nil {
+				ref.EnclosingSymbol = r.enclosing.name
+				if !visited[r.enclosing] {
+					visited[r.enclosing] = true
+					next = append(next, r.enclosing)
+				}
+			}
+			refs = append(refs, ref)
+			totalRefs++
+		}
+		if len(refs) == 0 && depth > 1 {
+			break
+		}
+
+		label := "direct references"
+		if depth == 2 {
+			label = "transitive dependents"
+		} else if depth > 2 {
+			label = fmt.Sprintf("depth-%d dependents", depth)
+		}
+		layers = append(layers, ImpactLayer{Depth: depth, Label: label, Refs: refs})
+		frontier = next
+	}
+
+	return &ImpactResult{
+		Target:  t,
+		Layers:  layers,
+		Summary: computeImpactSummary(layers),
+	}, nil
+}
+
+// goTypedFile is a parsed Go file of an indexed package.
+type goTypedFile struct {
+	relPath string
+	ast     *ast.File
+}
+
+// goTypedPackage is an indexed Go package. It is type-checked once for
+// its importers and, when it has tests, again with its in-package tests;
+// external tests are checked as a package of their own.
+type goTypedPackage struct {
+	path, dir string
+	files     []goTypedFile // non-test files
+	tests     []goTypedFile // in-package tests
+	xtests    []goTypedFile // tests in package <name>_test
+	pkg       *types.Package
+	info      *types.Info // uses in files, when they are checked alone
+	checking  bool
+	decl      *goTypedDecl
+}
+
+// goTypesLoader type-checks the indexed Go packages. Standard library
+// packages are imported from the Go installation and other dependencies
+// from vendor directories or the local module cache, without touching the
+// network; packages that can't be found leave the types that depend on
+// them unresolved rather than failing the load.
+type goTypesLoader struct {
+	fset     *token.FileSet
+	packages map[string]*goTypedPackage // import path to package
+	deps     map[string]string          // module path to directory
+	vendor   []string
+	checked  map[string]*types.Package // dependencies by import path
+	local    map[*types.Package]bool
+	std, src types.Importer
+	exports  map[string]string // export data files of standard packages
+}
+
+// loadGoTypes parses and type-checks the Go files among paths, then
+// resolves every use of an identifier in them to its declaration.
+func loadGoTypes(root string, paths []string) *goTypedProgram {
+	l := &goTypesLoader{
+		fset:     token.NewFileSet(),
+		packages: make(map[string]*goTypedPackage),
+		deps:     make(map[string]string),
+		checked:  make(map[string]*types.Package),
+		local:    make(map[*types.Package]bool),
+		exports:  make(map[string]string),
+	}
+	modules := make(map[string]string) // module path to directory
+	var goPaths []string
+	for _, p := range paths {
+		if filepath.Base(p) == "go.mod" {
+			data, err := os.ReadFile(filepath.Join(root, p))
+			if err != nil {
+				continue
+			}
+			if m := goModuleRe.FindSubmatch(data); m != nil {
+				modules[string(m[1])] = filepath.Dir(p)
+			}
+			modDir := filepath.Join(root, filepath.Dir(p))
+			for mod, dir := range goModRequirements(data, modDir) {
+				if _, ok := l.deps[mod]; !ok {
+					l.deps[mod] = dir
+				}
+			}
+			if info, err := os.Stat(filepath.Join(modDir, "vendor")); err == nil && info.IsDir() {
+				l.vendor = append(l.vendor, filepath.Join(modDir, "vendor"))
+			}
+		} else if filepath.Ext(p) == ".go" {
+			goPaths = append(goPaths, p)
+		}
+	}
+	sort.Strings(goPaths)
+
+	g := &goTypedProgram{
+		refsTo: make(map[*goTypedDecl][]int),
+		lines:  make(map[string][]string),
+		loader: l,
+	}
+	var pkgs []*goTypedPackage
+	byDir := make(map[string]*goTypedPackage)
+	names := make(map[string]string) // package name of each directory's files
+	for _, relPath := range goPaths {
+		dir, base := filepath.Split(relPath)
+		dir = filepath.Clean(dir)
+		if ok, err := build.Default.MatchFile(filepath.Join(root, dir), base); err != nil || !ok {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(root, relPath))
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(l.fset, relPath, content, parser.SkipObjectResolution)
+		if err != nil && file == nil {
+			continue
+		}
+		g.lines[relPath] = strings.Split(string(content), "\n")
+
+		p := byDir[dir]
+		if p == nil {
+			path := goImportPath(modules, dir)
+			if path == "" {
+				path = filepath.ToSlash(dir)
+			}
+			p = &goTypedPackage{path: path, dir: dir}
+			byDir[dir] = p
+			pkgs = append(pkgs, p)
+			l.packages[path] = p
+		}
+		f := goTypedFile{relPath: relPath, ast: file}
+		name := file.Name.Name
+		isTest := strings.HasSuffix(base, "_test.go")
+		switch {
+		case isTest && strings.HasSuffix(name, "_test") && name != names[dir]:
+			p.xtests = append(p.xtests, f)
+		case names[dir] != "" && name != names[dir]:
+			continue // a file of another package, such as a generator
+		case isTest:
+			p.tests = append(p.tests, f)
+			names[dir] = name
+		default:
+			p.files = append(p.files, f)
+			names[dir] = name
+		}
+	}
+
+	// Find the export data of the standard packages with one run of the
+	// go command rather than one per package.
+	std := make(map[string]bool)
+	for _, p := range pkgs {
+		for _, files := range [][]goTypedFile{p.files, p.tests, p.xtests} {
+			for _, f := range files {
+				for _, spec := range f.ast.Imports {
+					if path, err := strconv.Unquote(spec.Path.Value); err == nil && goIsStd(path) {
+						std[path] = true
+					}
+				}
+			}
+		}
+	}
+	l.listExports(std)
+
+	// Declarations first, so that uses can resolve to declarations in
+	// packages checked later.
+	byPos := make(map[token.Pos]*goTypedDecl)
+	for _, p := range pkgs {
+		all := append(append(append([]goTypedFile{}, p.files...), p.tests...), p.xtests...)
+		if len(p.files) > 0 {
+			pos := l.fset.Position(p.files[0].ast.Name.Pos())
+			p.decl = &goTypedDecl{name: p.path, kind: "package", path: pos.Filename, line: pos.Line}
+			g.decls = append(g.decls, p.decl)
+		}
+		for _, f := range all {
+			g.decls = append(g.decls, l.collect(f.ast, byPos)...)
+		}
+	}
+
+	for _, p := range pkgs {
+		l.load(p)
+		files, info, test := p.files, p.info, p.pkg
+		if len(p.tests) > 0 {
+			files = append(append([]goTypedFile{}, p.files...), p.tests...)
+			info = &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+			test = l.check(p.path, files, false, info, l)
+			l.local[test] = true
+		}
+		l.walk(g, files, info, byPos)
+		if len(p.xtests) > 0 {
+			// External tests see the package together with its in-package
+			// tests, which may export helpers for them.
+			imp := goImporterFunc(func(path string) (*types.Package, error) {
+				if path == p.path {
+					return test, nil
+				}
+				return l.Import(path)
+			})
+			xinfo := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+			l.local[l.check(p.path+"_test", p.xtests, false, xinfo, imp)] = true
+			l.walk(g, p.xtests, xinfo, byPos)
+		}
+	}
+	for i, r := range g.refs {
+		g.refsTo[r.target] = append(g.refsTo[r.target], i)
+	}
+	for _, p := range pkgs {
+		if p.pkg == nil {
+			continue
+		}
+		scope := p.pkg.Scope()
+		for _, name := range scope.Names() {
+			if obj, ok := scope.Lookup(name).(*types.TypeName); ok && byPos[obj.Pos()] != nil {
+				g.named = append(g.named, goTypedNamed{obj: obj, decl: byPos[obj.Pos()]})
+			}
+		}
+	}
+	return g
+}
+
+// goModRequirements returns the directory of each module a go.mod
+// requires: in the module cache, or the local directory a replace
+// directive points to.
+func goModRequirements(data []byte, modDir string) map[string]string {
+	cache := goModCache()
+	deps := make(map[string]string)
+	replaced := make(map[string]bool)
+	block := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		verb := block
+		switch {
+		case fields[0] == ")":
+			block = ""
+			continue
+		case len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+		switch verb {
+		case "require":
+			if len(fields) >= 2 && !replaced[fields[0]] {
+				deps[fields[0]] = filepath.Join(cache, goModCachePath(fields[0])+"@"+goModCachePath(fields[1]))
+			}
+		case "replace":
+			arrow := -1
+			for i, f := range fields {
+				if f == "=>" {
+					arrow = i
+				}
+			}
+			if arrow < 1 || arrow+1 >= len(fields) {
+				continue
+			}
+			mod, to := fields[0], fields[arrow+1:]
+			replaced[mod] = true
+			if len(to) >= 2 {
+				deps[mod] = filepath.Join(cache, goModCachePath(to[0])+"@"+goModCachePath(to[1]))
+			} else if filepath.IsAbs(to[0]) {
+				deps[mod] = to[0]
+			} else {
+				deps[mod] = filepath.Join(modDir, to[0])
+			}
+		}
+	}
+	return deps
+}
+
+// goModCache returns the module cache directory the go command uses.
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		home, _ := os.UserHomeDir()
+		gopath = filepath.Join(home, "go")
+	}
+	return filepath.Join(filepath.SplitList(gopath)[0], "pkg", "mod")
+}
+
+// goModCachePath escapes a module path or version for the module cache,
+// which writes upper-case letters as '!' and the lower-case letter.
+func goModCachePath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// goImporterFunc adapts a function to types.Importer.
+type goImporterFunc func(path string) (*types.Package, error)
+
+func (f goImporterFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// Import implements types.Importer for the packages the indexed ones
+// import.
+func (l *goTypesLoader) Import(path string) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+	if p := l.packages[path]; p != nil {
+		if p.checking {
+			return nil, fmt.Errorf("import cycle through %s", path)
+		}
+		l.load(p)
+		return p.pkg, nil
+	}
+	if pkg, ok := l.checked[path]; ok {
+		return pkg, nil
+	}
+	if goIsStd(path) {
+		if l.std == nil {
+			l.std = importer.ForCompiler(l.fset, "gc", l.lookupExport)
+		}
+		pkg, err := l.std.Import(path)
+		if err != nil {
+			// No export data: type-check the standard library's sources.
+			if l.src == nil {
+				l.src = importer.ForCompiler(l.fset, "source", nil)
+			}
+			pkg, err = l.src.Import(path)
+		}
+		l.checked[path] = pkg
+		return pkg, err
+	}
+
+	dir := l.depDir(path)
+	var files []goTypedFile
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+				continue
+			}
+			file, err := parser.ParseFile(l.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+			if err == nil {
+				files = append(files, goTypedFile{ast: file})
+			}
+		}
+	}
+	l.checked[path] = nil
+	if len(files) == 0 {
+		return nil, fmt.Errorf("cannot find package %s in vendor directories or the module cache", path)
+	}
+	pkg := l.check(path, files, true, nil, l)
+	l.checked[path] = pkg
+	return pkg, nil
+}
+
+// goIsStd reports whether path is in the standard library, whose import
+// paths have no dot in their first element.
+func goIsStd(path string) bool {
+	return path != "C" && path != "unsafe" && !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// listExports asks the go command for the export data of the standard
+// packages paths and their dependencies, compiling them into the build
+// cache if needed.
+func (l *goTypesLoader) listExports(paths map[string]bool) {
+	if len(paths) == 0 {
+		return
+	}
+	args := []string{"list", "-export", "-deps", "-e", "-f", "{{.ImportPath}}\t{{.Export}}"}
+	for path := range paths {
+		args = append(args, path)
+	}
+	cmd := exec.Command("go", args...)
+	cmd.Dir = filepath.Join(build.Default.GOROOT, "src")
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if path, file, ok := strings.Cut(line, "\t"); ok && file != "" {
+			l.exports[path] = file
+		}
+	}
+}
+
+// lookupExport opens the export data of a standard package for the gc
+// importer.
+func (l *goTypesLoader) lookupExport(path string) (io.ReadCloser, error) {
+	if _, ok := l.exports[path]; !ok {
+		l.listExports(map[string]bool{path: true})
+	}
+	file, ok := l.exports[path]
+	if !ok {
+		return nil, fmt.Errorf("no export data for %s", path)
+	}
+	return os.Open(file)
+}
+
+// depDir returns the directory holding the sources of the dependency
+// with the given import path, which may not exist.
+func (l *goTypesLoader) depDir(path string) string {
+	for _, vendor := range l.vendor {
+		dir := filepath.Join(vendor, filepath.FromSlash(path))
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	best, bestDir := "", ""
+	for mod, dir := range l.deps {
+		if (path == mod || strings.HasPrefix(path, mod+"/")) && len(mod) > len(best) {
+			best, bestDir = mod, dir
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return filepath.Join(bestDir, filepath.FromSlash(strings.TrimPrefix(path, best)))
+}
+
+// load type-checks the non-test files of p for its importers. Function
+// bodies are only checked when p has no tests, whose check covers them.
+func (l *goTypesLoader) load(p *goTypedPackage) {
+	if p.pkg != nil || p.checking {
+		return
+	}
+	p.checking = true
+	if len(p.tests) == 0 {
+		p.info = &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	}
+	p.pkg = l.check(p.path, p.files, len(p.tests) > 0, p.info, l)
+	l.local[p.pkg] = true
+	p.checking = false
+}
+
+// check type-checks files as the package path. Type errors are ignored:
+// the checker still resolves everything that doesn't depend on them.
+func (l *goTypesLoader) check(path string, files []goTypedFile, ignoreBodies bool, info *types.Info, imp types.Importer) *types.Package {
+	asts := make([]*ast.File, len(files))
+	for i, f := range files {
+		asts[i] = f.ast
+	}
+	conf := types.Config{
+		Importer:         imp,
+		FakeImportC:      true,
+		IgnoreFuncBodies: ignoreBodies,
+		Error:            func(error) {},
+	}
+	pkg, _ := conf.Check(path, l.fset, asts, info)
+	return pkg
+}
+
+// collect returns the declarations of file that uses can resolve to and
+// records them by the position of their name.
+func (l *goTypesLoader) collect(file *ast.File, byPos map[token.Pos]*goTypedDecl) []*goTypedDecl {
+	pkg := file.Name.Name
+	var decls []*goTypedDecl
+	add := func(id *ast.Ident, name, kind string) {
+		if id == nil || id.Name == "_" {
+			return
+		}
+		pos := l.fset.Position(id.Pos())
+		d := &goTypedDecl{name: name, kind: kind, path: pos.Filename, line: pos.Line}
+		byPos[id.Pos()] = d
+		decls = append(decls, d)
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				add(d.Name, pkg+"."+goReceiverName(d.Recv.List[0].Type)+"."+d.Name.Name, "method")
+			} else {
+				add(d.Name, pkg+"."+d.Name.Name, "func")
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					typeName := pkg + "." + s.Name.Name
+					add(s.Name, typeName, "type")
+					switch t := s.Type.(type) {
+					case *ast.StructType:
+						// Embedded fields are left out, so that a type's
+						// name doesn't match the fields embedding it.
+						for _, field := range t.Fields.List {
+							for _, id := range field.Names {
+								add(id, typeName+"."+id.Name, "field")
+							}
+						}
+					case *ast.InterfaceType:
+						for _, m := range t.Methods.List {
+							for _, id := range m.Names {
+								add(id, typeName+"."+id.Name, "method")
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for _, id := range s.Names {
+						add(id, pkg+"."+id.Name, d.Tok.String())
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// goEmbeddedIdent returns the type name of an embedded field: T in *T,
+// pkg.T or T[int].
+func goEmbeddedIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch t := expr.(type) {
+		case *ast.Ident:
+			return t
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.SelectorExpr:
+			return t.Sel
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		default:
+			return nil
+		}
+	}
+}
+
+// walk records the uses in files of the declarations in byPos.
+func (l *goTypesLoader) walk(g *goTypedProgram, files []goTypedFile, info *types.Info, byPos map[token.Pos]*goTypedDecl) {
+	if info == nil {
+		return
+	}
+	for _, f := range files {
+		for _, spec := range f.ast.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if p := l.packages[path]; err == nil && p != nil && p.decl != nil {
+				line := l.fset.Position(spec.Pos()).Line
+				g.refs = append(g.refs, goTypedRef{path: f.relPath, line: line, kind: GoRefImport, target: p.decl})
+			}
+		}
+		for _, decl := range f.ast.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				l.walkDecl(g, f.relPath, d, byPos[d.Name.Pos()], info, byPos)
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					var enclosing *goTypedDecl
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						enclosing = byPos[s.Name.Pos()]
+					case *ast.ValueSpec:
+						if len(s.Names) > 0 {
+							enclosing = byPos[s.Names[0].Pos()]
+						}
+					case *ast.ImportSpec:
+						continue
+					}
+					l.walkDecl(g, f.relPath, spec, enclosing, info, byPos)
+				}
+			}
+		}
+	}
+}
+
+// walkDecl records the uses within the top-level declaration node.
+func (l *goTypesLoader) walkDecl(g *goTypedProgram, relPath string, node ast.Node, enclosing *goTypedDecl, info *types.Info, byPos map[token.Pos]*goTypedDecl) {
+	embedded := make(map[*ast.Ident]bool)
+	var stack []ast.Node
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		stack = append(stack, n)
+		switch n := n.(type) {
+		case *ast.Field:
+			if len(n.Names) == 0 && len(stack) > 2 {
+				switch stack[len(stack)-3].(type) {
+				case *ast.StructType, *ast.InterfaceType:
+					if id := goEmbeddedIdent(n.Type); id != nil {
+						embedded[id] = true
+					}
+				}
+			}
+		case *ast.Ident:
+			obj := info.Uses[n]
+			if obj == nil || !l.local[obj.Pkg()] {
+				return true
+			}
+			target := byPos[obj.Pos()]
+			if target == nil {
+				return true
+			}
+			kind := goRefKind(stack, obj)
+			if embedded[n] {
+				kind = GoRefEmbed
+			}
+			line := l.fset.Position(n.Pos()).Line
+			g.refs = append(g.refs, goTypedRef{path: relPath, line: line, kind: kind, target: target, enclosing: enclosing})
+		}
+		return true
+	})
+}
+
+// goRefKind classifies the use of obj by the identifier on top of stack.
+func goRefKind(stack []ast.Node, obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		return GoRefType
+	case *types.Var:
+		if obj.IsField() {
+			return GoRefField
+		}
+	case *types.Func:
+		// Climb from the name to the called expression: x.F, F[T].
+		expr := stack[len(stack)-1]
+		i := len(stack) - 2
+		if i >= 0 {
+			if sel, ok := stack[i].(*ast.SelectorExpr); ok && sel.Sel == expr {
+				expr, i = sel, i-1
+			}
+		}
+		if i >= 0 {
+			switch ix := stack[i].(type) {
+			case *ast.IndexExpr:
+				if ix.X == expr {
+					expr, i = ix, i-1
+				}
+			case *ast.IndexListExpr:
+				if ix.X == expr {
+					expr, i = ix, i-1
+				}
+			}
+		}
+		if i >= 0 {
+			if call, ok := stack[i].(*ast.CallExpr); ok && call.Fun == expr {
+				return GoRefCall
+			}
+		}
+	}
+	return GoRefValue
+}
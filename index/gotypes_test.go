@@ -0,0 +1,208 @@
+package index
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func writeGoTypesFixture(t *testing.T) string {
+	t.Helper()
+	tmp := t.TempDir()
+
+	// A dependency in the module cache, with an upper-case module path.
+	cache := t.TempDir()
+	t.Setenv("GOMODCACHE", cache)
+	mkFile(t, cache, "example.com/!dep@v1.0.0/dep.go", `package dep
+
+func Get[T any](v T) T { return v }
+`)
+
+	mkFile(t, tmp, "go.mod", "module example.com/app\n\ngo 1.22\n\nrequire example.com/Dep v1.0.0\n")
+	mkFile(t, tmp, "store/store.go", `package store
+
+import "fmt"
+
+type Base struct{ ID int }
+
+type Index struct {
+	Base
+	Name string
+}
+
+func (i *Index) Save(dir string) error { return fmt.Errorf("save %s", dir) }
+
+type Cache struct{}
+
+func (c *Cache) Save(dir string) error { return nil }
+
+func Open() *Index { return &Index{Name: "x"} }
+`)
+	mkFile(t, tmp, "store/store_test.go", `package store
+
+import "testing"
+
+func TestSave(t *testing.T) {
+	if err := Open().Save("t"); err != nil {
+		t.Log(err)
+	}
+}
+`)
+	mkFile(t, tmp, "app/app.go", `package app
+
+import (
+	"example.com/Dep"
+	"example.com/app/store"
+)
+
+type wrapper struct {
+	*store.Index
+}
+
+func Run() error {
+	idx := store.Open()
+	idx.Name = "y"
+	save := idx.Save
+	_ = save
+	var c store.Cache
+	c.Save("z")
+	dep.Get(idx).Save("w")
+	return idx.Save("out")
+}
+
+func Main() { Run() }
+`)
+	return tmp
+}
+
+func TestTypedRefs(t *testing.T) {
+	idx, err := Scan(writeGoTypesFixture(t))
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	render := func(r *RefsResult) string {
+		var lines []string
+		for _, m := range r.References {
+			lines = append(lines, strings.Join([]string{filepath.ToSlash(m.Path), strconv.Itoa(m.Line), m.Kind, m.Target}, " "))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	tests := []struct {
+		symbol string
+		def    string
+		want   string
+	}{
+		{
+			// Cache.Save is a different method; the call through the
+			// dependency's generic function resolves to Index.Save.
+			symbol: "Index.Save",
+			def:    "store/store.go:12",
+			want: strings.Join([]string{
+				"app/app.go 15 value store.Index.Save",
+				"app/app.go 19 call store.Index.Save",
+				"app/app.go 20 call store.Index.Save",
+				"store/store_test.go 6 call store.Index.Save",
+			}, "\n"),
+		},
+		{
+			symbol: "Index",
+			def:    "store/store.go:7",
+			want: strings.Join([]string{
+				"app/app.go 9 embed store.Index",
+				"store/store.go 12 type store.Index",
+				"store/store.go 18 type store.Index",
+				"store/store.go 18 type store.Index",
+			}, "\n"),
+		},
+		{
+			symbol: "Base",
+			def:    "store/store.go:5",
+			want:   "store/store.go 8 embed store.Base",
+		},
+		{
+			symbol: "store.Index.Name",
+			def:    "store/store.go:9",
+			want: strings.Join([]string{
+				"app/app.go 14 field store.Index.Name",
+				"store/store.go 18 field store.Index.Name",
+			}, "\n"),
+		},
+		{
+			symbol: "app/store",
+			def:    "store/store.go:1",
+			want:   "app/app.go 5 import example.com/app/store",
+		},
+	}
+	for _, tt := range tests {
+		r, err := idx.TypedRefs(tt.symbol, 50)
+		if err != nil {
+			t.Fatalf("TypedRefs(%q) error: %v", tt.symbol, err)
+		}
+		if !r.Typed || r.Definition == nil {
+			t.Fatalf("TypedRefs(%q) = %+v, want a typed result with a definition", tt.symbol, r)
+		}
+		if got := filepath.ToSlash(r.Definition.Path) + ":" + strconv.Itoa(r.Definition.Line); got != tt.def {
+			t.Errorf("TypedRefs(%q) definition = %s, want %s", tt.symbol, got, tt.def)
+		}
+		if got := render(r); got != tt.want {
+			t.Errorf("TypedRefs(%q) =\n%s\nwant\n%s", tt.symbol, got, tt.want)
+		}
+	}
+
+	// An unqualified name covers every declaration, with the target of
+	// each reference telling them apart.
+	r, err := idx.TypedRefs("Save", 50)
+	if err != nil {
+		t.Fatalf("TypedRefs(Save) error: %v", err)
+	}
+	if !strings.Contains(render(r), "app/app.go 18 call store.Cache.Save") {
+		t.Errorf("TypedRefs(Save) missing the call of Cache.Save:\n%s", render(r))
+	}
+
+	// Names that are not Go declarations fall back to text matching.
+	r, err = idx.TypedRefs("fmt", 50)
+	if err != nil {
+		t.Fatalf("TypedRefs(fmt) error: %v", err)
+	}
+	if r.Typed {
+		t.Errorf("TypedRefs(fmt) should fall back to Refs, got %+v", r)
+	}
+}
+
+func TestTypedContextAndImpact(t *testing.T) {
+	tmp := writeGoTypesFixture(t)
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	ctx, err := idx.TypedContext(filepath.Join(tmp, "app", "app.go"), "Run")
+	if err != nil {
+		t.Fatalf("TypedContext() error: %v", err)
+	}
+	var uses []string
+	for _, u := range ctx.Uses {
+		uses = append(uses, u.Kind+" "+u.Name+" "+strconv.Itoa(u.Count))
+	}
+	want := "call store.Open 1, field store.Index.Name 1, value store.Index.Save 1, type store.Cache 1, call store.Cache.Save 1, call store.Index.Save 2"
+	if got := strings.Join(uses, ", "); got != want {
+		t.Errorf("TypedContext() uses = %s, want %s", got, want)
+	}
+
+	impact, err := idx.TypedImpact("Cache.Save", 3, 100)
+	if err != nil {
+		t.Fatalf("TypedImpact() error: %v", err)
+	}
+	if impact.Target.Kind != "method" || len(impact.Layers) != 2 {
+		t.Fatalf("TypedImpact() = %+v, want two layers for a method", impact)
+	}
+	if refs := impact.Layers[0].Refs; len(refs) != 1 || refs[0].EnclosingSymbol != "app.Run" || refs[0].Kind != GoRefCall {
+		t.Errorf("TypedImpact() depth 1 = %+v, want the call in app.Run", refs)
+	}
+	if refs := impact.Layers[1].Refs; len(refs) != 1 || refs[0].EnclosingSymbol != "app.Main" {
+		t.Errorf("TypedImpact() depth 2 = %+v, want the call of Run in app.Main", refs)
+	}
+}
@@ -0,0 +1,862 @@
+package index
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// TypeRelation is a type in the hierarchy of a queried type: one of its
+// implementations or one of its supertypes.
+type TypeRelation struct {
+	Name     string `json:"name"` // qualified for Go, as in "index.Index"
+	Kind     string `json:"kind"` // struct, class, interface, ...; empty if outside the index
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Relation string `json:"relation,omitempty"` // implements, extends, embeds or partial
+	// Via names the type the relation is inherited through, for
+	// implementations and supertypes that are not direct.
+	Via string `json:"via,omitempty"`
+	// Pointer is set when only the pointer type implements the interface,
+	// because some of the methods have pointer receivers.
+	Pointer bool `json:"pointer,omitempty"`
+	// Missing lists the interface methods a type lacks: for Go types that
+	// have most of an interface's methods, related as "partial", and for
+	// concrete classes that do not define every abstract method of an
+	// interface or base class they inherit.
+	Missing []string `json:"missing,omitempty"`
+}
+
+// HierarchyResult holds the implementations or supertypes of the types a
+// query names.
+type HierarchyResult struct {
+	Query     string         `json:"query"`
+	Direction string         `json:"direction"` // "implementations" or "supertypes"
+	Types     []TypeRelation `json:"types"`     // the types query names
+	Related   []TypeRelation `json:"related"`
+	Total     int            `json:"total"`
+}
+
+// hierarchyKinds are the symbol kinds that can take part in a type
+// hierarchy outside Go.
+var hierarchyKinds = map[string]bool{
+	"class": true, "interface": true, "trait": true, "struct": true,
+	"enum": true, "record": true, "object": true,
+}
+
+// Implementations returns the types that implement or extend the
+// interfaces and classes query names. Go types match when their method
+// set, or that of their pointer, includes the interface's methods; types
+// with at least half of an interface's methods are listed as partial, with
+// the ones they lack. In other languages the extends and implements
+// clauses and base class lists of class declarations are followed
+// transitively, and concrete classes are listed with the abstract methods
+// they do not define. At most max are returned when max is positive.
+func (idx *Index) Implementations(query string, max int) (*HierarchyResult, error) {
+	return idx.hierarchy(query, "implementations", max)
+}
+
+// Supertypes returns the interfaces and base types of the types query
+// names: for Go, the interfaces of the indexed modules and common
+// standard library interfaces the type implements, and the types it
+// embeds; elsewhere, its declared base types and theirs.
+func (idx *Index) Supertypes(query string, max int) (*HierarchyResult, error) {
+	return idx.hierarchy(query, "supertypes", max)
+}
+
+func (idx *Index) hierarchy(query, direction string, max int) (*HierarchyResult, error) {
+	result := &HierarchyResult{Query: query, Direction: direction, Types: []TypeRelation{}, Related: []TypeRelation{}}
+	var related []TypeRelation
+
+	hasGo := false
+	for _, p := range idx.FilePaths() {
+		if filepath.Ext(p) == ".go" {
+			hasGo = true
+			break
+		}
+	}
+	if hasGo {
+		g := idx.goTypes()
+		for _, t := range g.matchNamed(query) {
+			result.Types = append(result.Types, goTypeRelation(t, ""))
+			if direction == "implementations" {
+				related = append(related, g.implementations(t)...)
+			} else {
+				related = append(related, g.supertypes(t)...)
+			}
+		}
+	}
+
+	h := idx.declaredHierarchy()
+	for _, n := range h.match(query) {
+		result.Types = append(result.Types, n.relation("", ""))
+		if direction == "implementations" {
+			related = append(related, h.subtypes(n)...)
+		} else {
+			related = append(related, h.supertypes(n)...)
+		}
+	}
+
+	if len(result.Types) == 0 {
+		return nil, fmt.Errorf("no type or interface named %q in the index", query)
+	}
+	result.Total = len(related)
+	if max > 0 && len(related) > max {
+		related = related[:max]
+	}
+	result.Related = append(result.Related, related...)
+	return result, nil
+}
+
+// goStdInterfaces are the standard library interfaces Supertypes checks
+// Go types against, besides those of the indexed modules.
+var goStdInterfaces = []string{
+	"error", "fmt.Stringer", "io.Reader", "io.Writer", "io.Closer",
+	"sort.Interface", "encoding/json.Marshaler", "encoding/json.Unmarshaler",
+}
+
+// matchNamed returns the named types whose qualified name ends with query.
+// A query naming no indexed type may name a standard library type, as in
+// "io.Reader" or "error".
+func (g *goTypedProgram) matchNamed(query string) []goTypedNamed {
+	decls := make(map[*goTypedDecl]bool)
+	for _, d := range g.match(query) {
+		decls[d] = true
+	}
+	var found []goTypedNamed
+	for _, t := range g.named {
+		if decls[t.decl] && goNamedOf(t.obj) != nil {
+			found = append(found, t)
+		}
+	}
+	if len(found) == 0 {
+		if obj := g.lookupStd(query); obj != nil && goNamedOf(obj) != nil {
+			found = append(found, goTypedNamed{obj: obj})
+		}
+	}
+	return found
+}
+
+// lookupStd returns the type name, such as "io.Reader", from a package
+// outside the indexed modules, or nil.
+func (g *goTypedProgram) lookupStd(name string) *types.TypeName {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		obj, _ := types.Universe.Lookup(name).(*types.TypeName)
+		return obj
+	}
+	pkg, err := g.loader.Import(name[:i])
+	if err != nil || pkg == nil {
+		return nil
+	}
+	obj, _ := pkg.Scope().Lookup(name[i+1:]).(*types.TypeName)
+	return obj
+}
+
+// goNamedOf returns the named type obj declares, or nil for aliases and
+// generic types, whose method sets depend on their type arguments.
+func goNamedOf(obj *types.TypeName) *types.Named {
+	named, ok := obj.Type().(*types.Named)
+	if !ok || obj.IsAlias() || named.TypeParams().Len() > 0 {
+		return nil
+	}
+	return named
+}
+
+// goTypeRelation describes t, declared in the indexed modules or not.
+func goTypeRelation(t goTypedNamed, relation string) TypeRelation {
+	r := TypeRelation{Name: t.obj.Name(), Kind: "type", Relation: relation}
+	if t.obj.Pkg() != nil {
+		r.Name = t.obj.Pkg().Name() + "." + t.obj.Name()
+	}
+	switch t.obj.Type().Underlying().(type) {
+	case *types.Struct:
+		r.Kind = "struct"
+	case *types.Interface:
+		r.Kind = "interface"
+	}
+	if t.decl != nil {
+		r.Path, r.Line = t.decl.path, t.decl.line
+	} else {
+		r.Kind = ""
+	}
+	return r
+}
+
+// goInterface returns the method set interface of t, or nil if t is not
+// an interface with methods.
+func goInterface(t *types.TypeName) *types.Interface {
+	iface, ok := t.Type().Underlying().(*types.Interface)
+	if !ok || !iface.IsMethodSet() || iface.NumMethods() == 0 {
+		return nil
+	}
+	return iface
+}
+
+// implementations returns the concrete types in the indexed modules whose
+// method set includes that of the interface iface, and as partial those
+// that have at least half of its methods, with the ones they lack.
+func (g *goTypedProgram) implementations(iface goTypedNamed) []TypeRelation {
+	it := goInterface(iface.obj)
+	if it == nil {
+		return nil
+	}
+	var full, partial []TypeRelation
+	for _, t := range g.named {
+		named := goNamedOf(t.obj)
+		if named == nil {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Interface); ok {
+			continue
+		}
+		r := goTypeRelation(t, "implements")
+		switch {
+		case types.Implements(named, it):
+			full = append(full, r)
+		case types.Implements(types.NewPointer(named), it):
+			r.Pointer = true
+			full = append(full, r)
+		default:
+			missing := goMissingMethods(named, it)
+			if n := it.NumMethods(); n > 1 && len(missing)*2 <= n {
+				r.Relation = "partial"
+				r.Missing = missing
+				partial = append(partial, r)
+			}
+		}
+	}
+	return append(full, partial...)
+}
+
+// interfaceMethods returns the "path:line" of each method of the named
+// types in the indexed modules that implements a method of an interface
+// their type, or its pointer, satisfies. The interfaces are those of the
+// indexed packages and every package they import, directly or not, such
+// as types.Importer or http.Handler.
+func (g *goTypedProgram) interfaceMethods() map[string]bool {
+	var ifaces []*types.Interface
+	seen := make(map[*types.Package]bool)
+	var visit func(pkg *types.Package)
+	visit = func(pkg *types.Package) {
+		if pkg == nil || seen[pkg] {
+			return
+		}
+		seen[pkg] = true
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			if obj, ok := scope.Lookup(name).(*types.TypeName); ok && goNamedOf(obj) != nil {
+				if it := goInterface(obj); it != nil {
+					ifaces = append(ifaces, it)
+				}
+			}
+		}
+		for _, imp := range pkg.Imports() {
+			visit(imp)
+		}
+	}
+	for pkg := range g.loader.local {
+		visit(pkg)
+	}
+	for _, pkg := range g.loader.checked {
+		visit(pkg)
+	}
+
+	methods := make(map[string]bool)
+	for _, t := range g.named {
+		named := goNamedOf(t.obj)
+		if named == nil {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Interface); ok {
+			continue
+		}
+		ptr := types.NewPointer(named)
+		set := types.NewMethodSet(ptr)
+		if set.Len() == 0 {
+			continue
+		}
+		for _, it := range ifaces {
+			if !types.Implements(ptr, it) {
+				continue
+			}
+			for i := 0; i < it.NumMethods(); i++ {
+				m := it.Method(i)
+				if sel := set.Lookup(m.Pkg(), m.Name()); sel != nil {
+					pos := g.loader.fset.Position(sel.Obj().Pos())
+					methods[fmt.Sprintf("%s:%d", pos.Filename, pos.Line)] = true
+				}
+			}
+		}
+	}
+	return methods
+}
+
+// goMissingMethods returns the methods of iface that neither named nor its
+// pointer has with the same signature.
+func goMissingMethods(named *types.Named, iface *types.Interface) []string {
+	methods := types.NewMethodSet(types.NewPointer(named))
+	qualifier := func(p *types.Package) string { return p.Name() }
+	var missing []string
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		sig := m.Name() + strings.TrimPrefix(types.TypeString(m.Type(), qualifier), "func")
+		sel := methods.Lookup(m.Pkg(), m.Name())
+		if sel == nil {
+			missing = append(missing, sig)
+		} else if !types.Identical(sel.Obj().Type(), m.Type()) {
+			missing = append(missing, sig+" (wrong signature)")
+		}
+	}
+	return missing
+}
+
+// supertypes returns the interfaces t implements, or extends if t is an
+// interface itself, and the types it embeds.
+func (g *goTypedProgram) supertypes(t goTypedNamed) []TypeRelation {
+	named := goNamedOf(t.obj)
+	if named == nil {
+		return nil
+	}
+	relation := "implements"
+	if _, ok := named.Underlying().(*types.Interface); ok {
+		relation = "extends"
+	}
+
+	var related []TypeRelation
+	switch u := named.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Embedded() {
+				if r, ok := g.describe(f.Type(), "embeds"); ok {
+					related = append(related, r)
+				}
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumEmbeddeds(); i++ {
+			if r, ok := g.describe(u.EmbeddedType(i), "embeds"); ok {
+				related = append(related, r)
+			}
+		}
+	}
+
+	candidates := append([]goTypedNamed{}, g.named...)
+	for _, name := range goStdInterfaces {
+		if obj := g.lookupStd(name); obj != nil {
+			candidates = append(candidates, goTypedNamed{obj: obj})
+		}
+	}
+	for _, c := range candidates {
+		it := goInterface(c.obj)
+		if it == nil || c.obj == t.obj || goNamedOf(c.obj) == nil {
+			continue
+		}
+		r := goTypeRelation(c, relation)
+		switch {
+		case types.Implements(named, it):
+		case relation == "implements" && types.Implements(types.NewPointer(named), it):
+			r.Pointer = true
+		default:
+			continue
+		}
+		related = append(related, r)
+	}
+	return related
+}
+
+// describe returns the relation to the named type typ, which may be a
+// pointer to it.
+func (g *goTypedProgram) describe(typ types.Type, relation string) (TypeRelation, bool) {
+	if p, ok := typ.(*types.Pointer); ok {
+		typ = p.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return TypeRelation{}, false
+	}
+	obj := named.Origin().Obj()
+	for _, t := range g.named {
+		if t.obj == obj {
+			return goTypeRelation(t, relation), true
+		}
+	}
+	return goTypeRelation(goTypedNamed{obj: obj}, relation), true
+}
+
+// typeNode is a class, interface or other type declared outside Go, with
+// the supertypes its declaration names and the members declared in it.
+type typeNode struct {
+	entry    Entry
+	supers   []typeEdge
+	abstract map[string]bool // abstract methods implementers must define
+	defined  map[string]bool // members with a definition, including fields
+}
+
+// typeEdge is a supertype named in a declaration: resolved to a node in
+// the index, or only known by name.
+type typeEdge struct {
+	name     string
+	relation string
+	node     *typeNode
+}
+
+// typeHierarchy is the declared type hierarchy of the non-Go code.
+type typeHierarchy struct {
+	nodes []*typeNode
+	subs  map[*typeNode][]*typeNode
+}
+
+// declaredHierarchy links the non-Go types in the index to the supertypes
+// their declarations name, resolving names within the same language.
+func (idx *Index) declaredHierarchy() *typeHierarchy {
+	h := &typeHierarchy{subs: make(map[*typeNode][]*typeNode)}
+	byName := make(map[string][]*typeNode)
+	byParent := make(map[string]*typeNode) // "path\x00name"
+	entries := idx.entries()
+	for _, e := range entries {
+		if !hierarchyKinds[e.Kind] || filepath.Ext(e.Path) == ".go" {
+			continue
+		}
+		n := &typeNode{
+			entry:    e,
+			supers:   declaredSupertypes(e.Path, e.Kind, e.Name, e.Signature),
+			abstract: make(map[string]bool),
+			defined:  make(map[string]bool),
+		}
+		h.nodes = append(h.nodes, n)
+		byName[e.Name] = append(byName[e.Name], n)
+		byParent[e.Path+"\x00"+e.Name] = n
+	}
+	for _, e := range entries {
+		n := byParent[e.Path+"\x00"+e.Parent]
+		switch {
+		case n == nil || e.Parent == "":
+		case !e.Abstract:
+			n.defined[e.Name] = true
+		case e.Kind != "property" && e.Kind != "field":
+			// Abstract properties are not required: implementers may
+			// declare them as fields or constructor parameters the index
+			// does not record.
+			n.abstract[e.Name] = true
+		}
+	}
+
+	// Rust implements traits in impl blocks rather than in declarations.
+	var rustPaths []string
+	for _, p := range idx.FilePaths() {
+		if filepath.Ext(p) == ".rs" {
+			rustPaths = append(rustPaths, p)
+		}
+	}
+	for _, impls := range parallelMap(rustPaths, idx.Workers, func(p string) [][2]string {
+		return rustTraitImpls(filepath.Join(idx.Root, p))
+	}) {
+		for _, impl := range impls {
+			for _, n := range byName[impl[1]] {
+				if filepath.Ext(n.entry.Path) == ".rs" {
+					n.supers = append(n.supers, typeEdge{name: impl[0], relation: "implements"})
+				}
+			}
+		}
+	}
+
+	for _, n := range h.nodes {
+		for i, s := range n.supers {
+			for _, c := range byName[s.name] {
+				if c != n && sameLanguage(c.entry.Path, n.entry.Path) {
+					n.supers[i].node = c
+					h.subs[c] = append(h.subs[c], n)
+					break
+				}
+			}
+		}
+	}
+	return h
+}
+
+// match returns the nodes named by query; a qualified query matches by
+// its last element.
+func (h *typeHierarchy) match(query string) []*typeNode {
+	name := query
+	if i := strings.LastIndexAny(name, `.:\`); i >= 0 {
+		name = name[i+1:]
+	}
+	var found []*typeNode
+	for _, n := range h.nodes {
+		if n.entry.Name == name {
+			found = append(found, n)
+		}
+	}
+	return found
+}
+
+// relation describes n as related by relation, through via.
+func (n *typeNode) relation(relation, via string) TypeRelation {
+	return TypeRelation{Name: n.entry.Name, Kind: n.entry.Kind, Path: n.entry.Path, Line: n.entry.Line, Relation: relation, Via: via}
+}
+
+// subtypes returns the types that extend or implement n, directly or
+// through other types, in breadth-first order.
+func (h *typeHierarchy) subtypes(n *typeNode) []TypeRelation {
+	var related []TypeRelation
+	seen := map[*typeNode]bool{n: true}
+	queue := []*typeNode{n}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for _, sub := range h.subs[parent] {
+			if seen[sub] {
+				continue
+			}
+			seen[sub] = true
+			queue = append(queue, sub)
+			via := ""
+			if parent != n {
+				via = parent.entry.Name
+			}
+			for _, s := range sub.supers {
+				if s.node == parent {
+					r := sub.relation(s.relation, via)
+					if sub.concrete() {
+						r.Missing = missingMethods(sub, n)
+					}
+					related = append(related, r)
+					break
+				}
+			}
+		}
+	}
+	return related
+}
+
+// supertypes returns the types n extends or implements, then theirs.
+// Supertypes outside the index are listed by name.
+func (h *typeHierarchy) supertypes(n *typeNode) []TypeRelation {
+	var related []TypeRelation
+	seen := map[*typeNode]bool{n: true}
+	queue := []*typeNode{n}
+	for len(queue) > 0 {
+		child := queue[0]
+		queue = queue[1:]
+		via := ""
+		if child != n {
+			via = child.entry.Name
+		}
+		for _, s := range child.supers {
+			if s.node == nil {
+				related = append(related, TypeRelation{Name: s.name, Relation: s.relation, Via: via})
+				continue
+			}
+			if seen[s.node] {
+				continue
+			}
+			seen[s.node] = true
+			queue = append(queue, s.node)
+			r := s.node.relation(s.relation, via)
+			if n.concrete() {
+				r.Missing = missingMethods(n, s.node)
+			}
+			related = append(related, r)
+		}
+	}
+	return related
+}
+
+var abstractTypeRe = regexp.MustCompile(`\babstract\b`)
+
+// concrete reports whether n is a class that must define every abstract
+// method it inherits: not an interface or trait, not declared abstract,
+// and without abstract methods of its own, as Python ABCs and C++ classes
+// with pure virtual functions have.
+func (n *typeNode) concrete() bool {
+	switch n.entry.Kind {
+	case "interface", "trait":
+		return false
+	}
+	return len(n.abstract) == 0 && !abstractTypeRe.MatchString(n.entry.Signature)
+}
+
+// ancestors returns n and the resolved types it extends or implements,
+// directly or not.
+func (n *typeNode) ancestors() []*typeNode {
+	nodes := []*typeNode{n}
+	seen := map[*typeNode]bool{n: true}
+	for i := 0; i < len(nodes); i++ {
+		for _, s := range nodes[i].supers {
+			if s.node != nil && !seen[s.node] {
+				seen[s.node] = true
+				nodes = append(nodes, s.node)
+			}
+		}
+	}
+	return nodes
+}
+
+// missingMethods returns the abstract methods of iface and its supertypes
+// that are defined neither by n nor by any of its supertypes, such as a
+// base class or a default method of another interface.
+func missingMethods(n, iface *typeNode) []string {
+	have := make(map[string]bool)
+	for _, c := range n.ancestors() {
+		for name := range c.defined {
+			have[name] = true
+		}
+	}
+	var missing []string
+	for _, c := range iface.ancestors() {
+		for name := range c.abstract {
+			if !have[name] && !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// languageFamilies groups extensions whose types can extend each other.
+var languageFamilies = map[string]string{
+	".ts": "js", ".tsx": "js", ".js": "js", ".jsx": "js", ".mjs": "js", ".cjs": "js",
+	".java": "jvm", ".kt": "jvm",
+	".c": "c", ".h": "c", ".cc": "c", ".cpp": "c", ".hpp": "c",
+}
+
+// sameLanguage reports whether types in files a and b can be related.
+func sameLanguage(a, b string) bool {
+	extA, extB := filepath.Ext(a), filepath.Ext(b)
+	if fa, ok := languageFamilies[extA]; ok {
+		return fa == languageFamilies[extB]
+	}
+	return extA == extB
+}
+
+var (
+	extendsClauseRe    = regexp.MustCompile(`\bextends\s+(.+?)(?:\s+implements\b|\s*\{|$)`)
+	implementsClauseRe = regexp.MustCompile(`\bimplements\s+(.+?)(?:\s*\{|$)`)
+	rubySuperRe        = regexp.MustCompile(`^\s*class\s+[\w:]+\s*<\s*([\w:]+)`)
+	rustImplRe         = regexp.MustCompile(`^\s*(?:unsafe\s+)?impl\s*(?:<[^{]*?>)?\s*([\w:]+)(?:<[^{]*?>)?\s+for\s+([\w:]+)`)
+)
+
+// declaredSupertypes returns the supertypes named by the declaration
+// signature of a type: extends and implements clauses in JavaScript,
+// TypeScript, Java and PHP, the supertype list after ':' in Kotlin, C++
+// and Rust traits, base classes in Python and the superclass in Ruby.
+func declaredSupertypes(path, kind, name, signature string) []typeEdge {
+	var edges []typeEdge
+	add := func(list, relation, sep string) {
+		for _, s := range splitTopLevel(list, sep) {
+			if s = typeNameOf(s); s != "" && s != name {
+				edges = append(edges, typeEdge{name: s, relation: relation})
+			}
+		}
+	}
+
+	switch filepath.Ext(path) {
+	case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".java", ".php":
+		// Type parameters may have extends clauses of their own.
+		signature = stripTypeArgs(signature)
+		if m := extendsClauseRe.FindStringSubmatch(signature); m != nil {
+			add(m[1], "extends", ",")
+		}
+		if m := implementsClauseRe.FindStringSubmatch(signature); m != nil {
+			add(m[1], "implements", ",")
+		}
+	case ".kt":
+		// class Foo(val x: Int) : Bar(), Baz — a constructor call marks
+		// the superclass.
+		if list, ok := afterTopLevelColon(signature); ok {
+			for _, s := range splitTopLevel(list, ",") {
+				relation := "implements"
+				if strings.Contains(s, "(") || kind == "interface" {
+					relation = "extends"
+				}
+				add(s, relation, ",")
+			}
+		}
+	case ".cpp", ".hpp", ".cc", ".h":
+		if list, ok := afterTopLevelColon(signature); ok && (kind == "class" || kind == "struct") {
+			add(list, "extends", ",")
+		}
+	case ".rs":
+		if list, ok := afterTopLevelColon(signature); ok && kind == "trait" {
+			add(list, "extends", "+")
+		}
+	case ".py":
+		open := strings.IndexByte(signature, '(')
+		close := strings.LastIndexByte(signature, ')')
+		if open >= 0 && close > open {
+			for _, s := range splitTopLevel(signature[open+1:close], ",") {
+				if !strings.Contains(s, "=") && strings.TrimSpace(s) != "object" {
+					add(s, "extends", ",")
+				}
+			}
+		}
+	case ".rb":
+		if m := rubySuperRe.FindStringSubmatch(signature); m != nil {
+			add(m[1], "extends", ",")
+		}
+	}
+	return edges
+}
+
+// afterTopLevelColon returns what follows the first ':' outside brackets
+// that is not part of '::'.
+func afterTopLevelColon(s string) (string, bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '<', '[':
+			depth++
+		case ')', '>', ']':
+			depth--
+		case ':':
+			if depth > 0 {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == ':' {
+				i++
+				continue
+			}
+			if i > 0 && s[i-1] == ':' {
+				continue
+			}
+			return strings.TrimSuffix(strings.TrimSpace(s[i+1:]), "{"), true
+		}
+	}
+	return "", false
+}
+
+// stripTypeArgs removes the bracketed type parameters and arguments from
+// s, as in "Foo<T extends Bar>".
+func stripTypeArgs(s string) string {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '<':
+			depth++
+		case s[i] == '>' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// splitTopLevel splits s at sep outside brackets.
+func splitTopLevel(s, sep string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '<', '[', '{':
+			depth++
+		case ')', '>', ']', '}':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(s[i:], sep) {
+				parts = append(parts, s[start:i])
+				start = i + len(sep)
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// typeNameOf returns the simple name of a type expression in a supertype
+// list: "Base" in "public ns::Base<int>", "pkg.Base()" or "Base[T]".
+func typeNameOf(s string) string {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"public ", "protected ", "private ", "virtual "} {
+		s = strings.TrimSpace(strings.TrimPrefix(s, prefix))
+	}
+	if i := strings.IndexAny(s, "<([{ "); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.LastIndexAny(s, `.:\`); i >= 0 {
+		s = s[i+1:]
+	}
+	if s == "" || !isIdentStart(s[0]) {
+		return ""
+	}
+	return s
+}
+
+// isIdentStart reports whether c can start an identifier.
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// rustTraitImpls returns the trait and type of each "impl Trait for Type"
+// block in the Rust file at path.
+func rustTraitImpls(path string) [][2]string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var impls [][2]string
+	for _, line := range strings.Split(string(content), "\n") {
+		if m := rustImplRe.FindStringSubmatch(line); m != nil {
+			impls = append(impls, [2]string{typeNameOf(m[1]), typeNameOf(m[2])})
+		}
+	}
+	return impls
+}
+
+// FormatHierarchy returns a human-readable rendering of a hierarchy result.
+func FormatHierarchy(r *HierarchyResult) string {
+	var b strings.Builder
+	title := "Implementations of"
+	if r.Direction == "supertypes" {
+		title = "Supertypes of"
+	}
+	for _, t := range r.Types {
+		if t.Path == "" {
+			b.WriteString(fmt.Sprintf("%s %s\n", title, t.Name))
+		} else {
+			b.WriteString(fmt.Sprintf("%s %s (%s:%d)\n", title, t.Name, t.Path, t.Line))
+		}
+	}
+	if len(r.Related) == 0 {
+		b.WriteString(fmt.Sprintf("\nNo %s found\n", r.Direction))
+		return b.String()
+	}
+	b.WriteString("\n")
+	for _, t := range r.Related {
+		location := "(not indexed)"
+		if t.Path != "" {
+			location = fmt.Sprintf("%s:%d", t.Path, t.Line)
+		}
+		notes := []string{}
+		if t.Kind != "" {
+			notes = append(notes, t.Kind)
+		}
+		notes = append(notes, t.Relation)
+		if t.Via != "" {
+			notes = append(notes, "via "+t.Via)
+		}
+		if t.Pointer {
+			notes = append(notes, "pointer receiver")
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s (%s)\n", location, t.Name, strings.Join(notes, ", ")))
+		if len(t.Missing) > 0 {
+			b.WriteString(fmt.Sprintf("      missing: %s\n", strings.Join(t.Missing, ", ")))
+		}
+	}
+	if len(r.Related) < r.Total {
+		b.WriteString(fmt.Sprintf("\n... and %d more (use --max to see more)\n", r.Total-len(r.Related)))
+	}
+	noun := r.Direction
+	if r.Total == 1 {
+		noun = strings.TrimSuffix(noun, "s")
+	}
+	b.WriteString(fmt.Sprintf("\n%d %s\n", r.Total, noun))
+	return b.String()
+}
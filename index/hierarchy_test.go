@@ -0,0 +1,349 @@
+package index
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// renderHierarchy renders the related types of r one per line, as
+// "path:line name relation [via V] [pointer] [missing: ...]".
+func renderHierarchy(r *HierarchyResult) string {
+	var lines []string
+	for _, t := range r.Related {
+		parts := []string{filepath.ToSlash(t.Path) + ":" + strconv.Itoa(t.Line), t.Name, t.Relation}
+		if t.Via != "" {
+			parts = append(parts, "via "+t.Via)
+		}
+		if t.Pointer {
+			parts = append(parts, "pointer")
+		}
+		if len(t.Missing) > 0 {
+			parts = append(parts, "missing: "+strings.Join(t.Missing, ", "))
+		}
+		lines = append(lines, strings.Join(parts, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestGoHierarchy(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "go.mod", "module example.com/app\n\ngo 1.22\n")
+	mkFile(t, tmp, "store/store.go", `package store
+
+type Store interface {
+	Get(key string) (string, error)
+	Put(key, value string) error
+}
+
+type Closer interface {
+	Close() error
+}
+
+type StoreCloser interface {
+	Store
+	Close() error
+}
+
+type Mem struct{}
+
+func (m Mem) Get(key string) (string, error) { return "", nil }
+func (m Mem) Put(key, value string) error    { return nil }
+
+type Disk struct{ Mem }
+
+func (d *Disk) Close() error { return nil }
+
+type ReadOnly struct{}
+
+func (r ReadOnly) Get(key string) (string, error) { return "", nil }
+
+func (r ReadOnly) String() string { return "read-only" }
+`)
+	mkFile(t, tmp, "app/app.go", `package app
+
+type Cache struct{}
+
+func (c *Cache) Get(key string) (string, error) { return "", nil }
+func (c *Cache) Put(key string, value []byte) error { return nil }
+`)
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	tests := []struct {
+		query, direction, want string
+	}{
+		{"Store", "implementations", strings.Join([]string{
+			"store/store.go:22 store.Disk implements",
+			"store/store.go:17 store.Mem implements",
+			"app/app.go:3 app.Cache partial missing: Put(key string, value string) error (wrong signature)",
+			"store/store.go:26 store.ReadOnly partial missing: Put(key string, value string) error",
+		}, "\n")},
+		{"store.Closer", "implementations", "store/store.go:22 store.Disk implements pointer"},
+		{"Disk", "supertypes", strings.Join([]string{
+			"store/store.go:17 store.Mem embeds",
+			"store/store.go:8 store.Closer implements pointer",
+			"store/store.go:3 store.Store implements",
+			"store/store.go:12 store.StoreCloser implements pointer",
+			":0 io.Closer implements pointer",
+		}, "\n")},
+		{"StoreCloser", "supertypes", strings.Join([]string{
+			"store/store.go:3 store.Store embeds",
+			"store/store.go:8 store.Closer extends",
+			"store/store.go:3 store.Store extends",
+			":0 io.Closer extends",
+		}, "\n")},
+		{"ReadOnly", "supertypes", ":0 fmt.Stringer implements"},
+		{"fmt.Stringer", "implementations", "store/store.go:26 store.ReadOnly implements"},
+	}
+	for _, tt := range tests {
+		var r *HierarchyResult
+		if tt.direction == "implementations" {
+			r, err = idx.Implementations(tt.query, 0)
+		} else {
+			r, err = idx.Supertypes(tt.query, 0)
+		}
+		if err != nil {
+			t.Fatalf("%s(%q) error: %v", tt.direction, tt.query, err)
+		}
+		if got := renderHierarchy(r); got != tt.want {
+			t.Errorf("%s(%q) =\n%s\nwant\n%s", tt.direction, tt.query, got, tt.want)
+		}
+	}
+
+	if _, err := idx.Implementations("Missing", 0); err == nil {
+		t.Error("Implementations() of an unknown type should fail")
+	}
+}
+
+func TestDeclaredHierarchy(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "src/Shape.java", `package shapes;
+
+public interface Shape extends Named {
+    double area();
+    default String label() { return "shape"; }
+}
+`)
+	mkFile(t, tmp, "src/Named.java", `package shapes;
+
+public interface Named {
+    String name();
+}
+`)
+	mkFile(t, tmp, "src/Circle.java", `package shapes;
+
+public class Circle extends Base implements Shape, java.io.Serializable {
+    public double area() { return 0; }
+}
+`)
+	mkFile(t, tmp, "src/Base.java", `package shapes;
+
+public abstract class Base {
+    public String name() { return "base"; }
+}
+`)
+	mkFile(t, tmp, "src/Square.java", `package shapes;
+
+public class Square implements Shape {
+    public String name() { return "square"; }
+}
+`)
+	mkFile(t, tmp, "web/widget.ts", `export interface Widget<T extends object> {
+  render(): string;
+}
+
+export class Button<P extends Props> extends Base<P> implements Widget<P> {
+  render() { return "" }
+}
+`)
+	mkFile(t, tmp, "py/repo.py", `class Repo(Base, metaclass=ABCMeta):
+    pass
+
+
+class SqlRepo(Repo):
+    pass
+`)
+	mkFile(t, tmp, "rs/shape.rs", `pub trait Area: Named {
+    fn area(&self) -> f64;
+}
+
+pub struct Circle {
+    r: f64,
+}
+
+impl Area for Circle {
+    fn area(&self) -> f64 {
+        0.0
+    }
+}
+`)
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	tests := []struct {
+		query, direction, want string
+	}{
+		// Square lacks area(); the default method label() is not required,
+		// and Circle inherits name() from Base.
+		{"Named", "implementations", strings.Join([]string{
+			"src/Shape.java:3 Shape extends",
+			"src/Circle.java:3 Circle implements via Shape",
+			"src/Square.java:3 Square implements via Shape",
+		}, "\n")},
+		{"Shape", "implementations", strings.Join([]string{
+			"src/Circle.java:3 Circle implements",
+			"src/Square.java:3 Square implements missing: area",
+		}, "\n")},
+		{"Circle", "supertypes", strings.Join([]string{
+			"rs/shape.rs:1 Area implements",
+			":0 Named extends via Area",
+			"src/Base.java:3 Base extends",
+			"src/Shape.java:3 Shape implements",
+			":0 Serializable implements",
+			"src/Named.java:3 Named extends via Shape",
+		}, "\n")},
+		{"Button", "supertypes", strings.Join([]string{
+			":0 Base extends",
+			"web/widget.ts:1 Widget implements",
+		}, "\n")},
+		{"Repo", "implementations", "py/repo.py:5 SqlRepo extends"},
+	}
+	for _, tt := range tests {
+		var r *HierarchyResult
+		if tt.direction == "implementations" {
+			r, err = idx.Implementations(tt.query, 0)
+		} else {
+			r, err = idx.Supertypes(tt.query, 0)
+		}
+		if err != nil {
+			t.Fatalf("%s(%q) error: %v", tt.direction, tt.query, err)
+		}
+		if got := renderHierarchy(r); got != tt.want {
+			t.Errorf("%s(%q) =\n%s\nwant\n%s", tt.direction, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestHierarchyMissingMethods(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "web/animal.ts", `export interface Animal {
+  name: string;
+  speak(): string;
+  walk(): void;
+  fly?(): void;
+}
+
+export abstract class Pet implements Animal {
+  name = "pet";
+  speak() { return "..."; }
+}
+
+export class Dog extends Pet {
+}
+
+export class Cat implements Animal {
+  name = "cat";
+  speak() { return "meow"; }
+  walk() {}
+}
+`)
+	mkFile(t, tmp, "kt/Animal.kt", `package zoo
+
+interface Animal {
+    val legs: Int
+    fun speak(): String
+    fun walk()
+    fun describe() = "animal"
+}
+
+class Dog : Animal {
+    override val legs = 4
+    override fun speak() = "woof"
+}
+`)
+	mkFile(t, tmp, "py/animal.py", `from abc import ABC, abstractmethod
+
+
+class Animal(ABC):
+    @abstractmethod
+    def speak(self):
+        ...
+
+    @abstractmethod
+    def walk(self):
+        ...
+
+
+class Dog(Animal):
+    def speak(self):
+        return "woof"
+`)
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	tests := []struct {
+		query, direction, want string
+	}{
+		// Pet is abstract, so only Dog, which inherits speak() from it, is
+		// held to walk(); optional members and properties are not required.
+		{"Animal", "implementations", strings.Join([]string{
+			"kt/Animal.kt:10 Dog implements missing: walk",
+			"py/animal.py:14 Dog extends missing: walk",
+			"web/animal.ts:8 Pet implements",
+			"web/animal.ts:16 Cat implements",
+			"web/animal.ts:13 Dog extends via Pet missing: walk",
+		}, "\n")},
+		{"Dog", "supertypes", strings.Join([]string{
+			"kt/Animal.kt:3 Animal implements missing: walk",
+			"py/animal.py:4 Animal extends missing: walk",
+			":0 ABC extends via Animal",
+			"web/animal.ts:8 Pet extends missing: walk",
+			"web/animal.ts:1 Animal implements via Pet missing: walk",
+		}, "\n")},
+	}
+	for _, tt := range tests {
+		var r *HierarchyResult
+		if tt.direction == "implementations" {
+			r, err = idx.Implementations(tt.query, 0)
+		} else {
+			r, err = idx.Supertypes(tt.query, 0)
+		}
+		if err != nil {
+			t.Fatalf("%s(%q) error: %v", tt.direction, tt.query, err)
+		}
+		if got := renderHierarchy(r); got != tt.want {
+			t.Errorf("%s(%q) =\n%s\nwant\n%s", tt.direction, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestDeclaredSupertypes(t *testing.T) {
+	tests := []struct {
+		path, kind, name, signature string
+		want                        string
+	}{
+		{"a.kt", "class", "Foo", "class Foo(val x: Int) : Bar(), Baz", "Bar extends, Baz implements"},
+		{"a.cpp", "class", "Circle", "class Circle : public Shape, private ns::Base<int>", "Shape extends, Base extends"},
+		{"a.rb", "class", "Circle", "class Circle < Shapes::Shape", "Shape extends"},
+		{"a.php", "class", "Circle", `class Circle extends \Geo\Shape implements Countable, Foo`, "Shape extends, Countable implements, Foo implements"},
+		{"a.ts", "class", "Map", "export class Map<K extends string, V> extends Base<K>", "Base extends"},
+		{"a.py", "class", "Repo", "class Repo(Generic[T], object):", "Generic extends"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range declaredSupertypes(tt.path, tt.kind, tt.name, tt.signature) {
+			got = append(got, e.name+" "+e.relation)
+		}
+		if strings.Join(got, ", ") != tt.want {
+			t.Errorf("declaredSupertypes(%q) = %s, want %s", tt.signature, strings.Join(got, ", "), tt.want)
+		}
+	}
+}
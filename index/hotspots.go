@@ -44,7 +44,7 @@ func (idx *Index) Hotspots(root string, max int, since string, pathPrefix string
 
 	// Build a set of files that still exist in the index
 	indexed := make(map[string]struct{})
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Kind == "file" {
 			indexed[e.Path] = struct{}{}
 		}
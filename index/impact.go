@@ -2,11 +2,8 @@ package index
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/mj1618/swarm-index/parsers"
 )
 
 // ImpactTarget describes the symbol or file being analyzed.
@@ -23,6 +20,7 @@ type ImpactRef struct {
 	Line            int    `json:"line"`
 	Content         string `json:"content"`
 	EnclosingSymbol string `json:"enclosingSymbol,omitempty"`
+	Kind            string `json:"kind,omitempty"` // see TypedImpact
 }
 
 // ImpactLayer groups references at a given depth.
@@ -47,15 +45,102 @@ type ImpactResult struct {
 }
 
 // Impact performs transitive blast-radius analysis for a symbol or file.
-// If target contains '/' or '.', it is treated as a file path; otherwise as a symbol name.
+// If target contains '/' or '.', it is treated as a file path, unless it
+// names no indexed file but a Go method such as Index.Refs; otherwise as a
+// symbol name. Go functions and methods are traced through the call
+// graph, other symbols through references by name.
 func (idx *Index) Impact(target string, maxDepth, maxResults int) (*ImpactResult, error) {
 	isFile := strings.Contains(target, "/") || strings.Contains(target, ".")
+	if isFile && !strings.Contains(target, "/") && !idx.hasFile(target) && len(idx.goCalls().match(target)) > 0 {
+		isFile = false
+	}
 	if isFile {
 		return idx.impactFile(target, maxDepth, maxResults)
 	}
+	if fns := idx.goCalls().match(target); len(fns) > 0 && idx.onlyGoDefinitions(fns[0].Func) {
+		return idx.impactGoFunc(target, fns, maxDepth, maxResults), nil
+	}
 	return idx.impactSymbol(target, maxDepth, maxResults)
 }
 
+// hasFile reports whether relPath is an indexed file.
+func (idx *Index) hasFile(relPath string) bool {
+	for _, p := range idx.FilePaths() {
+		if p == relPath {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyGoDefinitions reports whether every indexed symbol called name is
+// defined in Go, so the call graph sees all of its uses.
+func (idx *Index) onlyGoDefinitions(name string) bool {
+	for _, e := range idx.entries() {
+		if e.Name == name && e.Kind != "file" && filepath.Ext(e.Path) != ".go" {
+			return false
+		}
+	}
+	return true
+}
+
+// impactGoFunc traces the transitive callers of Go functions through the
+// call graph. Recursive calls are not counted.
+func (idx *Index) impactGoFunc(target string, fns []*GoFunc, maxDepth, maxResults int) *ImpactResult {
+	g := idx.goCalls()
+	t := ImpactTarget{Name: target, File: fns[0].Path, Line: fns[0].Line, Kind: "func"}
+	if fns[0].Receiver != "" {
+		t.Kind = "method"
+	}
+
+	visited := make(map[*GoFunc]bool)
+	for _, fn := range fns {
+		visited[fn] = true
+	}
+	layers := []ImpactLayer{}
+	totalRefs := 0
+	frontier := fns
+	for depth := 1; depth <= maxDepth && totalRefs < maxResults; depth++ {
+		var refs []ImpactRef
+		var next []*GoFunc
+		for _, fn := range frontier {
+			for _, i := range g.callers[fn] {
+				c := g.calls[i]
+				if c.caller == fn {
+					continue
+				}
+				if totalRefs >= maxResults {
+					break
+				}
+				refs = append(refs, ImpactRef{File: c.Path, Line: c.Line, Content: c.Content, EnclosingSymbol: c.Caller})
+				totalRefs++
+				if c.caller != nil && !visited[c.caller] {
+					visited[c.caller] = true
+					next = append(next, c.caller)
+				}
+			}
+		}
+		if len(refs) == 0 && depth > 1 {
+			break
+		}
+
+		label := "direct callers"
+		if depth == 2 {
+			label = "transitive callers"
+		} else if depth > 2 {
+			label = fmt.Sprintf("depth-%d callers", depth)
+		}
+		layers = append(layers, ImpactLayer{Depth: depth, Label: label, Refs: refs})
+		frontier = next
+	}
+
+	return &ImpactResult{
+		Target:  t,
+		Layers:  layers,
+		Summary: computeImpactSummary(layers),
+	}
+}
+
 // impactSymbol traces transitive references for a symbol.
 func (idx *Index) impactSymbol(symbol string, maxDepth, maxResults int) (*ImpactResult, error) {
 	// Find the symbol definition.
@@ -174,7 +259,7 @@ func (idx *Index) impactFile(filePath string, maxDepth, maxResults int) (*Impact
 
 	// Verify the file exists in the index.
 	found := false
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Path == relPath {
 			found = true
 			break
@@ -288,33 +373,20 @@ func computeImpactSummary(layers []ImpactLayer) ImpactSummary {
 	}
 }
 
-// findEnclosingSymbol determines which symbol's line range contains the given line.
+// findEnclosingSymbol determines which indexed symbol's line range contains
+// the given line, using the end lines recorded at scan time.
 func (idx *Index) findEnclosingSymbol(relPath string, line int) string {
-	ext := filepath.Ext(relPath)
-	p := parsers.ForExtension(ext)
-	if p == nil {
-		return ""
-	}
-
-	absPath := filepath.Join(idx.Root, relPath)
-	content, err := os.ReadFile(absPath)
-	if err != nil {
-		return ""
-	}
-
-	symbols, err := p.Parse(absPath, content)
-	if err != nil {
-		return ""
-	}
-
 	// Find the innermost symbol whose range contains the line.
 	var best string
 	bestLine := 0
-	for _, s := range symbols {
-		if s.Line <= line && (s.EndLine == 0 || s.EndLine >= line) {
-			if s.Line > bestLine {
-				best = s.Name
-				bestLine = s.Line
+	for _, e := range idx.entries() {
+		if e.Path != relPath || e.Kind == "file" {
+			continue
+		}
+		if e.Line <= line && (e.EndLine == 0 || e.EndLine >= line) {
+			if e.Line > bestLine {
+				best = e.Name
+				bestLine = e.Line
 			}
 		}
 	}
@@ -344,7 +416,9 @@ func FormatImpact(r *ImpactResult) string {
 		}
 		b.WriteString(fmt.Sprintf("\nDepth %d — %s (%d):\n", layer.Depth, layer.Label, len(layer.Refs)))
 		for _, ref := range layer.Refs {
-			if ref.Content != "" {
+			if ref.Kind != "" {
+				b.WriteString(fmt.Sprintf("  %s:%d  [%s] %s\n", ref.File, ref.Line, ref.Kind, ref.Content))
+			} else if ref.Content != "" {
 				b.WriteString(fmt.Sprintf("  %s:%d  %s\n", ref.File, ref.Line, ref.Content))
 			} else {
 				b.WriteString(fmt.Sprintf("  %s\n", ref.File))
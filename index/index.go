@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mj1618/swarm-index/parsers"
@@ -15,33 +17,132 @@ import (
 
 // Entry represents a single indexed item (file, symbol, package, etc.).
 type Entry struct {
-	Name     string `json:"name"`               // symbol or file name
-	Kind     string `json:"kind"`               // "file", "func", "type", "package", etc.
-	Path     string `json:"path"`               // file path relative to the scanned root
-	Line     int    `json:"line"`               // line number (0 if not applicable)
-	Package  string `json:"package"`            // package or module the entry belongs to
-	Exported bool   `json:"exported,omitempty"` // true if the symbol is publicly exported
+	Name      string `json:"name"`                // symbol or file name
+	Kind      string `json:"kind"`                // "file", "func", "type", "package", etc.
+	Path      string `json:"path"`                // file path relative to the scanned root
+	Line      int    `json:"line"`                // line number (0 if not applicable)
+	EndLine   int    `json:"endLine,omitempty"`   // last line of the symbol's definition
+	Package   string `json:"package"`             // package or module the entry belongs to
+	Exported  bool   `json:"exported,omitempty"`  // true if the symbol is publicly exported
+	Signature string `json:"signature,omitempty"` // declaration, e.g. "func Load(dir string) (*Index, error)"
+	Parent    string `json:"parent,omitempty"`    // enclosing type for methods, empty otherwise
+	Doc       string `json:"doc,omitempty"`       // first sentence of the symbol's doc comment
+	Source    string `json:"source,omitempty"`    // schema a generated stub comes from, e.g. "api/user.proto:12"
+	Abstract  bool   `json:"abstract,omitempty"`  // an interface or abstract member implementers must define
+}
+
+// QualifiedName returns the entry name prefixed with its parent, e.g.
+// "Index.Save" for a method.
+func (e Entry) QualifiedName() string {
+	if e.Parent != "" {
+		return e.Parent + "." + e.Name
+	}
+	return e.Name
 }
 
 func (e Entry) String() string {
+	var s string
 	if e.Line > 0 {
-		return fmt.Sprintf("[%s] %s — %s:%d (%s)", e.Kind, e.Name, e.Path, e.Line, e.Package)
+		s = fmt.Sprintf("[%s] %s — %s:%d (%s)", e.Kind, e.QualifiedName(), e.Path, e.Line, e.Package)
+	} else {
+		s = fmt.Sprintf("[%s] %s — %s (%s)", e.Kind, e.QualifiedName(), e.Path, e.Package)
+	}
+	if e.Signature != "" {
+		s += "\n    " + e.Signature
+	}
+	if e.Source != "" {
+		s += "\n    generated from " + e.Source
 	}
-	return fmt.Sprintf("[%s] %s — %s (%s)", e.Kind, e.Name, e.Path, e.Package)
+	return s
+}
+
+// FileRecord captures a scanned file's size, modification time and content
+// hash so later scans and staleness checks can tell whether it changed.
+type FileRecord struct {
+	Path    string `json:"path"`    // file path relative to the scanned root
+	Size    int64  `json:"size"`    // size in bytes
+	ModTime int64  `json:"modTime"` // modification time in Unix nanoseconds
+	Hash    string `json:"hash"`    // hex SHA-256 of the content, empty if unreadable
 }
 
 // Index holds the scanned codebase data.
 type Index struct {
-	Root      string
-	Entries   []Entry
-	ScannedAt string
+	Root        string
+	Entries     []Entry
+	Files       []FileRecord
+	Diagnostics []Diagnostic // files the scan skipped or could not parse
+	ScannedAt   string
+	Backend     string // storage backend used by Save; defaults to BackendJSON
+	Workers     int    // goroutines for commands that read file contents; 0 means GOMAXPROCS
+
+	store       Store // backing store when loaded from disk
+	loaded      bool  // true once Entries has been read from store
+	filesLoaded bool  // true once Files has been read from store
+
+	dir               string        // swarm/index directory when loaded from disk
+	tri               *trigramIndex // content index, see trigrams
+	trigramsLoaded    bool          // true once tri has been read from dir
+	diagnosticsLoaded bool          // true once Diagnostics has been read from dir
+
+	regexps   map[string]*regexp.Regexp // compiled patterns, see compileRegexp
+	callGraph *goCallGraph              // Go call graph, see goCalls
+	typedGo   *goTypedProgram           // type-checked Go code, see goTypes
+
+	plugins map[string]string // parser plugins the scan used, see pluginStamps
+}
+
+// entries returns every entry in the index. Stores that support queries are
+// read lazily, so the full entry list is only decoded by commands that need it.
+func (idx *Index) entries() []Entry {
+	if idx.store != nil && !idx.loaded {
+		idx.loaded = true
+		if entries, err := idx.store.ReadEntries(); err == nil {
+			idx.Entries = entries
+		}
+	}
+	return idx.Entries
+}
+
+// files returns the per-file records, reading them from the backing store on
+// first use. Indexes saved before file records existed return nil.
+func (idx *Index) files() []FileRecord {
+	if idx.store != nil && !idx.filesLoaded {
+		idx.filesLoaded = true
+		if files, err := idx.store.ReadFiles(); err == nil {
+			idx.Files = files
+		}
+	}
+	return idx.Files
+}
+
+// querier returns the backing store's Querier, or nil if the index is
+// in memory or the store cannot answer queries directly.
+func (idx *Index) querier() Querier {
+	if idx.store == nil || idx.loaded {
+		return nil
+	}
+	q, _ := idx.store.(Querier)
+	return q
+}
+
+// Close releases the backing store, if any.
+func (idx *Index) Close() error {
+	if idx.store == nil {
+		return nil
+	}
+	return idx.store.Close()
 }
 
 // FilePaths returns the unique file paths in the index, preserving first-seen order.
 func (idx *Index) FilePaths() []string {
+	if q := idx.querier(); q != nil {
+		if paths, err := q.FilePaths(); err == nil {
+			return paths
+		}
+	}
 	seen := make(map[string]struct{})
 	var paths []string
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if _, ok := seen[e.Path]; ok {
 			continue
 		}
@@ -59,7 +160,7 @@ func (idx *Index) FileCount() int {
 // PackageCount returns the number of unique packages in the index.
 func (idx *Index) PackageCount() int {
 	seen := make(map[string]struct{})
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Package != "" {
 			seen[e.Package] = struct{}{}
 		}
@@ -72,7 +173,7 @@ func (idx *Index) PackageCount() int {
 func (idx *Index) ExtensionCounts() map[string]int {
 	seen := make(map[string]struct{})
 	counts := make(map[string]int)
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if _, ok := seen[e.Path]; ok {
 			continue
 		}
@@ -88,22 +189,66 @@ func (idx *Index) ExtensionCounts() map[string]int {
 
 // indexMeta holds metadata about a saved index.
 type indexMeta struct {
-	Root         string         `json:"root"`
-	ScannedAt    string         `json:"scannedAt"`
-	Version      string         `json:"version"`
-	FileCount    int            `json:"fileCount"`
-	PackageCount int            `json:"packageCount"`
-	Extensions   map[string]int `json:"extensions"`
+	Root         string            `json:"root"`
+	ScannedAt    string            `json:"scannedAt"`
+	Version      string            `json:"version"`
+	Schema       int               `json:"schemaVersion,omitempty"` // see SchemaVersion; 0 before versioning
+	Backend      string            `json:"backend,omitempty"`
+	FileCount    int               `json:"fileCount"`
+	PackageCount int               `json:"packageCount"`
+	Extensions   map[string]int    `json:"extensions"`
+	Plugins      map[string]string `json:"plugins,omitempty"` // extension to parser plugin, see pluginStamps
 }
 
-// Save writes the index to disk under <dir>/swarm/index/.
+// Save writes the index to disk under <dir>/swarm/index/ using idx.Backend.
 func (idx *Index) Save(dir string) error {
 	indexDir := filepath.Join(dir, "swarm", "index")
 	if err := os.MkdirAll(indexDir, 0o755); err != nil {
 		return fmt.Errorf("creating index directory: %w", err)
 	}
 
-	if err := writeJSON(filepath.Join(indexDir, "index.json"), idx.Entries); err != nil {
+	backend := idx.Backend
+	if backend == "" {
+		backend = BackendJSON
+	}
+	// Read everything before createStore replaces a store we may have been
+	// loaded from.
+	entries := idx.entries()
+	files := idx.files()
+	tri := idx.trigrams()
+	diags := idx.diagnostics()
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+
+	store, err := createStore(indexDir, backend)
+	if err != nil {
+		return err
+	}
+	if err := store.WriteEntries(entries); err != nil {
+		abortStore(store)
+		return err
+	}
+	if err := store.WriteFiles(files); err != nil {
+		abortStore(store)
+		return err
+	}
+	if err := store.Close(); err != nil {
+		return err
+	}
+	removeOtherStores(indexDir, backend)
+
+	// An index without content data must not leave an older trigram file
+	// describing different files.
+	triPath := filepath.Join(indexDir, trigramFile)
+	if tri != nil {
+		if err := writeTrigrams(triPath, tri); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(triPath)
+	}
+	if err := writeJSON(filepath.Join(indexDir, diagnosticsFile), diags); err != nil {
 		return err
 	}
 
@@ -111,39 +256,85 @@ func (idx *Index) Save(dir string) error {
 		Root:         idx.Root,
 		ScannedAt:    time.Now().UTC().Format(time.RFC3339),
 		Version:      "0.1.0",
+		Schema:       SchemaVersion,
+		Backend:      backend,
 		FileCount:    idx.FileCount(),
 		PackageCount: idx.PackageCount(),
 		Extensions:   idx.ExtensionCounts(),
+		Plugins:      idx.plugins,
 	}
 	return writeJSON(filepath.Join(indexDir, "meta.json"), meta)
 }
 
-// writeJSON marshals v as indented JSON and writes it to path.
+// writeJSON marshals v as indented JSON and writes it to path. The file is
+// written under a temporary name and renamed into place, so concurrent
+// readers see either the old or the new content.
 func writeJSON(path string, v any) error {
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling %s: %w", filepath.Base(path), err)
 	}
-	if err := os.WriteFile(path, data, 0o644); err != nil {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
 		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
 	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// readJSON reads path and unmarshals its JSON content into v.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
 	return nil
 }
 
-// Load reads a persisted index from <dir>/swarm/index/.
+// Load reads a persisted index from <dir>/swarm/index/. Stores that support
+// queries (see Querier) are opened lazily; call Close when done with the index.
+// An index saved with an older schema is first migrated (see migrateIndex);
+// one saved by a newer binary is rejected.
 func Load(dir string) (*Index, error) {
 	indexDir := filepath.Join(dir, "swarm", "index")
 
-	data, err := os.ReadFile(filepath.Join(indexDir, "index.json"))
+	meta, err := readMeta(indexDir)
 	if err != nil {
-		return nil, fmt.Errorf("reading index.json: %w", err)
+		return nil, err
 	}
-
-	var entries []Entry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return nil, fmt.Errorf("parsing index.json: %w", err)
+	if meta.Schema > SchemaVersion {
+		return nil, fmt.Errorf("index schema version %d is newer than this binary supports (%d); upgrade swarm-index or re-run scan", meta.Schema, SchemaVersion)
 	}
+	if meta.Schema < SchemaVersion {
+		migrated, err := migrateIndex(dir, meta)
+		if err != nil {
+			return nil, err
+		}
+		if migrated {
+			if meta, err = readMeta(indexDir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	idx, err := openIndex(indexDir, meta)
+	if err != nil {
+		return nil, err
+	}
+	// Queries only parse files on the fly, so a broken config falls back to
+	// the built-in parsers here; scan and watch fail on it, and doctor
+	// reports it.
+	LoadParserPlugins(dir)
+	return idx, nil
+}
 
+// readMeta reads and parses meta.json from indexDir.
+func readMeta(indexDir string) (*indexMeta, error) {
 	metaData, err := os.ReadFile(filepath.Join(indexDir, "meta.json"))
 	if err != nil {
 		return nil, fmt.Errorf("reading meta.json: %w", err)
@@ -153,48 +344,157 @@ func Load(dir string) (*Index, error) {
 	if err := json.Unmarshal(metaData, &meta); err != nil {
 		return nil, fmt.Errorf("parsing meta.json: %w", err)
 	}
+	return &meta, nil
+}
+
+// openIndex opens the store described by meta without migrating it.
+func openIndex(indexDir string, meta *indexMeta) (*Index, error) {
+	backend := meta.Backend
+	if backend == "" {
+		backend = BackendJSON
+	}
+	store, err := openStore(indexDir, backend)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Index{Root: meta.Root, Entries: entries, ScannedAt: meta.ScannedAt}, nil
+	idx := &Index{Root: meta.Root, ScannedAt: meta.ScannedAt, Backend: backend, store: store, dir: indexDir, plugins: meta.Plugins}
+	if _, ok := store.(Querier); !ok {
+		entries, err := store.ReadEntries()
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		idx.Entries = entries
+		idx.loaded = true
+	}
+	return idx, nil
 }
 
 // Scan walks a directory tree and builds an index of files and packages.
 func Scan(root string) (*Index, error) {
+	idx, _, err := scan(root, nil, ScanOptions{})
+	return idx, err
+}
+
+// ScanOptions tunes how a scan reads and parses files.
+type ScanOptions struct {
+	Workers     int   // goroutines reading and parsing files; 0 means GOMAXPROCS
+	MaxFileSize int64 // larger files are indexed by name only, without parsing; 0 means no limit
+}
+
+// Per-file outcomes of a scan relative to the previous index.
+const (
+	fileUnchanged = iota
+	fileModified
+	fileAdded
+	fileNew // no previous index to compare against
+)
+
+// scanJob is one file found by the walk. Workers fill in the record, entries
+// and state; the results are assembled in walk order afterwards.
+type scanJob struct {
+	path    string
+	relPath string
+	pkg     string
+	info    os.FileInfo
+
+	rec         FileRecord
+	entries     []Entry
+	state       int
+	trigrams    []uint32 // distinct trigrams of the lowercased content
+	textIndexed bool     // false if the content was not read, so trigrams is unknown
+	diags       []Diagnostic
+}
+
+// scanPrev is what a scan reuses from the previous index.
+type scanPrev struct {
+	files    map[string]FileRecord
+	entries  map[string][]Entry
+	trigrams map[string][]uint32 // nil if the previous index has no trigram data
+	diags    map[string][]Diagnostic
+	reparse  map[string]bool // extensions whose parser plugin changed
+	ok       bool            // false when there is no previous index
+}
+
+// scan walks root and builds an index. The walk feeds a pool of workers that
+// hash and parse files concurrently; results keep the walk's lexical order so
+// output is deterministic. When prev is non-nil, entries for files whose size
+// and mtime, or failing that content hash, match prev's records are reused
+// instead of re-parsed, unless the parser plugin for their extension changed
+// since prev was scanned, and the differences are reported.
+func scan(root string, prev *Index, opts ScanOptions) (*Index, *ScanChanges, error) {
 	root, err := filepath.Abs(root)
 	if err != nil {
-		return nil, fmt.Errorf("resolving path: %w", err)
+		return nil, nil, fmt.Errorf("resolving path: %w", err)
 	}
 
 	info, err := os.Stat(root)
 	if err != nil {
-		return nil, fmt.Errorf("cannot access %s: %w", root, err)
+		return nil, nil, fmt.Errorf("cannot access %s: %w", root, err)
 	}
 	if !info.IsDir() {
-		return nil, fmt.Errorf("%s is not a directory", root)
+		return nil, nil, fmt.Errorf("%s is not a directory", root)
 	}
 
-	idx := &Index{Root: root}
+	plugins, err := loadParserPlugins(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx := &Index{Root: root, Workers: opts.Workers, plugins: pluginStamps(plugins)}
+	changes := &ScanChanges{Added: []string{}, Modified: []string{}, Removed: []string{}}
 	ignorePatterns := loadIgnorePatterns(root)
 
+	var last scanPrev
+	if prev != nil {
+		last = scanPrev{files: prev.fileRecords(), entries: prev.entriesByPath(), diags: prev.fileDiagnostics(), reparse: changedPlugins(prev.plugins, idx.plugins), ok: true}
+		if t := prev.trigrams(); t != nil {
+			last.trigrams = t.byFile()
+		}
+	}
+	var diags []Diagnostic // ignored and unreadable paths found by the walk
+	ruleSources := ignoreRuleSources(root)
+
+	jobs := make(chan *scanJob, 256)
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount(opts.Workers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.process(&last, opts.MaxFileSize)
+			}
+		}()
+	}
+
+	var found []*scanJob
 	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		relPath, _ := filepath.Rel(root, path)
 		if err != nil {
-			return nil // skip entries we can't read
+			// Skip entries we can't read, but say so.
+			diags = append(diags, unreadableDiagnostic(relPath, err))
+			return nil
 		}
 
 		// Skip hidden directories and common noise
 		name := info.Name()
-		relPath, _ := filepath.Rel(root, path)
 
 		if info.IsDir() {
 			if shouldSkipDir(name) {
 				return filepath.SkipDir
 			}
-			if relPath != "." && shouldIgnore(relPath, true, ignorePatterns) {
-				return filepath.SkipDir
+			if relPath != "." {
+				if rule := matchIgnore(relPath, true, ignorePatterns); rule != "" {
+					diags = append(diags, ignoredDiagnostic(relPath, rule, ruleSources))
+					return filepath.SkipDir
+				}
 			}
 			return nil
 		}
 
-		if shouldIgnore(relPath, false, ignorePatterns) {
+		if rule := matchIgnore(relPath, false, ignorePatterns); rule != "" {
+			diags = append(diags, ignoredDiagnostic(relPath, rule, ruleSources))
 			return nil
 		}
 
@@ -202,42 +502,191 @@ func Scan(root string) (*Index, error) {
 		if pkg == "." {
 			pkg = "(root)"
 		}
+		job := &scanJob{path: path, relPath: relPath, pkg: pkg, info: info}
+		found = append(found, job)
+		jobs <- job
+		return nil
+	})
+	close(jobs)
+	wg.Wait()
+	if err != nil {
+		return nil, nil, fmt.Errorf("walking directory: %w", err)
+	}
 
-		idx.Entries = append(idx.Entries, Entry{
-			Name:    name,
-			Kind:    "file",
-			Path:    relPath,
-			Package: pkg,
-		})
+	visited := make(map[string]bool, len(found))
+	for _, job := range found {
+		visited[job.relPath] = true
+		idx.Files = append(idx.Files, job.rec)
+		idx.Entries = append(idx.Entries, job.entries...)
+		diags = append(diags, job.diags...)
+		switch job.state {
+		case fileUnchanged:
+			changes.Unchanged++
+		case fileModified:
+			changes.Modified = append(changes.Modified, job.relPath)
+		case fileAdded:
+			changes.Added = append(changes.Added, job.relPath)
+		}
+	}
 
-		// Parse symbols from source files using the parser registry.
-		ext := filepath.Ext(name)
-		if p := parsers.ForExtension(ext); p != nil {
-			content, readErr := os.ReadFile(path)
-			if readErr == nil {
-				symbols, parseErr := p.Parse(relPath, content)
-				if parseErr == nil {
-					for _, sym := range symbols {
-						idx.Entries = append(idx.Entries, Entry{
-							Name:     sym.Name,
-							Kind:     sym.Kind,
-							Path:     relPath,
-							Line:     sym.Line,
-							Package:  pkg,
-							Exported: sym.Exported,
-						})
-					}
-				}
+	linkProtoStubs(idx.Entries)
+	idx.tri = buildTrigramIndex(found)
+	sortDiagnostics(diags)
+	idx.Diagnostics = diags
+
+	if prev != nil {
+		for _, p := range prev.FilePaths() {
+			if !visited[p] {
+				changes.Removed = append(changes.Removed, p)
 			}
 		}
+	}
+
+	return idx, changes, nil
+}
+
+// process hashes, parses and trigram-indexes one file, reusing the previous
+// scan's results when the file is unchanged. Files over maxSize are recorded
+// without being read, so they get a file entry but no hash, symbols or
+// trigrams.
+func (job *scanJob) process(prev *scanPrev, maxSize int64) {
+	rec := FileRecord{Path: job.relPath, Size: job.info.Size(), ModTime: job.info.ModTime().UnixNano()}
+	old, known := prev.files[job.relPath]
+	known = known && !prev.reparse[filepath.Ext(job.relPath)]
+	tooLarge := maxSize > 0 && rec.Size > maxSize
+
+	// Unchanged size and mtime: trust the previous scan without reading,
+	// unless it has no trigrams for the file.
+	if known && old.Size == rec.Size && old.ModTime == rec.ModTime && (old.Hash != "" || tooLarge) {
+		rec.Hash = old.Hash
+		job.rec, job.entries, job.state = rec, prev.entries[job.relPath], fileUnchanged
+		job.keepDiagnostics(prev, tooLarge, maxSize)
+		if tris, ok := prev.trigrams[job.relPath]; ok || tooLarge {
+			job.trigrams, job.textIndexed = tris, ok
+		} else if content, err := os.ReadFile(job.path); err == nil {
+			job.trigrams, job.textIndexed = fileTrigrams(content), true
+		}
+		return
+	}
 
-		return nil
-	})
+	var content []byte
+	var readErr error
+	if !tooLarge {
+		content, readErr = os.ReadFile(job.path)
+		if readErr == nil {
+			rec.Hash = hashContent(content)
+			job.trigrams, job.textIndexed = fileTrigrams(content), true
+		}
+	}
+	job.rec = rec
+
+	// Touched but identical content: keep the previous entries.
+	if known && rec.Hash != "" && rec.Hash == old.Hash {
+		job.entries, job.state = prev.entries[job.relPath], fileUnchanged
+		job.keepDiagnostics(prev, tooLarge, maxSize)
+		return
+	}
+	if _, indexed := prev.entries[job.relPath]; indexed {
+		job.state = fileModified
+	} else if prev.ok {
+		job.state = fileAdded
+	} else {
+		job.state = fileNew
+	}
+
+	job.entries = []Entry{{
+		Name:    job.info.Name(),
+		Kind:    "file",
+		Path:    job.relPath,
+		Package: job.pkg,
+	}}
+	switch {
+	case tooLarge:
+		job.diags = []Diagnostic{tooLargeDiagnostic(job.relPath, rec.Size, maxSize)}
+	case readErr != nil:
+		job.diags = []Diagnostic{unreadableDiagnostic(job.relPath, readErr)}
+	case isBinary(content):
+		job.diags = []Diagnostic{{Path: job.relPath, Kind: DiagBinary, Message: "binary content is not parsed"}}
+	default:
+		job.entries[0].Source = protoSource(job.info.Name(), content)
+		entries, err := parseEntries(job.relPath, job.pkg, content)
+		if err != nil {
+			job.diags = []Diagnostic{parseDiagnostic(job.relPath, err)}
+		}
+		job.entries = append(job.entries, entries...)
+	}
+}
+
+// keepDiagnostics carries over the previous scan's diagnostics for a file
+// whose entries are reused. The size cutoff may differ between scans, so a
+// too-large diagnostic is recomputed.
+func (job *scanJob) keepDiagnostics(prev *scanPrev, tooLarge bool, maxSize int64) {
+	job.diags = nil
+	for _, d := range prev.diags[job.relPath] {
+		if d.Kind != DiagTooLarge {
+			job.diags = append(job.diags, d)
+		}
+	}
+	if tooLarge {
+		job.diags = append(job.diags, tooLargeDiagnostic(job.relPath, job.rec.Size, maxSize))
+	}
+}
+
+// parseEntries extracts symbol entries from a file using the parser registry.
+// Files without a parser yield no entries; files that fail to parse yield
+// none and the parser's error. A parser that panics is reported as failing,
+// so one unusual file cannot abort a scan.
+func parseEntries(relPath, pkg string, content []byte) (entries []Entry, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			entries, err = nil, fmt.Errorf("parser panicked: %v", r)
+		}
+	}()
+	ext := filepath.Ext(relPath)
+	p := parsers.ForFile(relPath)
+	if p == nil {
+		return nil, nil
+	}
+	symbols, err := p.Parse(relPath, content)
 	if err != nil {
-		return nil, fmt.Errorf("walking directory: %w", err)
+		return nil, err
+	}
+	lines := strings.Split(string(content), "\n")
+	entries = make([]Entry, 0, len(symbols))
+	for _, sym := range symbols {
+		entries = append(entries, Entry{
+			Name:      sym.Name,
+			Kind:      sym.Kind,
+			Path:      relPath,
+			Line:      sym.Line,
+			EndLine:   sym.EndLine,
+			Package:   pkg,
+			Exported:  sym.Exported,
+			Signature: sym.Signature,
+			Parent:    sym.Parent,
+			Doc:       docSummary(lines, sym.Line, ext),
+			Abstract:  sym.Abstract,
+		})
 	}
+	return entries, nil
+}
 
-	return idx, nil
+// entriesByPath groups the index entries by file path.
+func (idx *Index) entriesByPath() map[string][]Entry {
+	byPath := make(map[string][]Entry)
+	for _, e := range idx.entries() {
+		byPath[e.Path] = append(byPath[e.Path], e)
+	}
+	return byPath
+}
+
+// fileRecords returns the per-file records keyed by path.
+func (idx *Index) fileRecords() map[string]FileRecord {
+	records := make(map[string]FileRecord)
+	for _, f := range idx.files() {
+		records[f.Path] = f
+	}
+	return records
 }
 
 // Match returns entries ranked by relevance using fuzzy matching and scoring.
@@ -259,9 +708,14 @@ func (idx *Index) MatchScored(query string) []ScoredEntry {
 // MatchExact returns all entries whose name or path contains the query
 // (case-insensitive substring match, unranked).
 func (idx *Index) MatchExact(query string) []Entry {
+	if q := idx.querier(); q != nil {
+		if results, err := q.MatchExact(query); err == nil {
+			return results
+		}
+	}
 	q := strings.ToLower(query)
 	var results []Entry
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if strings.Contains(strings.ToLower(e.Name), q) ||
 			strings.Contains(strings.ToLower(e.Path), q) {
 			results = append(results, e)
@@ -298,11 +752,15 @@ func openTextFile(path string) (*os.File, error) {
 	return f, nil
 }
 
+// ignoreFiles are the ignore files read by loadIgnorePatterns, relative to
+// the root.
+var ignoreFiles = []string{".swarmignore", filepath.Join("swarm", ".swarmindexignore")}
+
 // loadIgnorePatterns reads ignore patterns from .swarmignore at root and
 // swarm/.swarmindexignore, merging them. Returns nil if neither file exists.
 func loadIgnorePatterns(root string) []string {
 	var patterns []string
-	for _, name := range []string{".swarmignore", filepath.Join("swarm", ".swarmindexignore")} {
+	for _, name := range ignoreFiles {
 		patterns = append(patterns, readIgnoreFile(filepath.Join(root, name))...)
 	}
 	if len(patterns) == 0 {
@@ -311,6 +769,20 @@ func loadIgnorePatterns(root string) []string {
 	return patterns
 }
 
+// ignoreRuleSources maps each ignore pattern to the first ignore file that
+// declares it.
+func ignoreRuleSources(root string) map[string]string {
+	sources := make(map[string]string)
+	for _, name := range ignoreFiles {
+		for _, pattern := range readIgnoreFile(filepath.Join(root, name)) {
+			if _, ok := sources[pattern]; !ok {
+				sources[pattern] = name
+			}
+		}
+	}
+	return sources
+}
+
 // readIgnoreFile parses a gitignore-style file and returns its patterns.
 // Returns nil if the file doesn't exist.
 func readIgnoreFile(path string) []string {
@@ -336,6 +808,12 @@ func readIgnoreFile(path string) []string {
 // For directories, pass the relative dir path. For files, pass the relative file path.
 // isDir should be true when checking a directory entry.
 func shouldIgnore(relPath string, isDir bool, patterns []string) bool {
+	return matchIgnore(relPath, isDir, patterns) != ""
+}
+
+// matchIgnore returns the first pattern that ignores relPath, or "" if none
+// does. Arguments are as for shouldIgnore.
+func matchIgnore(relPath string, isDir bool, patterns []string) string {
 	basename := filepath.Base(relPath)
 	for _, pattern := range patterns {
 		// Directory-only pattern (trailing /)
@@ -346,11 +824,11 @@ func shouldIgnore(relPath string, isDir bool, patterns []string) bool {
 			dirPattern := strings.TrimSuffix(pattern, "/")
 			// Match against basename
 			if matched, _ := filepath.Match(dirPattern, basename); matched {
-				return true
+				return pattern
 			}
 			// Match against full relative path
 			if matched, _ := filepath.Match(dirPattern, relPath); matched {
-				return true
+				return pattern
 			}
 			continue
 		}
@@ -359,7 +837,7 @@ func shouldIgnore(relPath string, isDir bool, patterns []string) bool {
 		if strings.HasPrefix(pattern, "/") {
 			rooted := strings.TrimPrefix(pattern, "/")
 			if matched, _ := filepath.Match(rooted, relPath); matched {
-				return true
+				return pattern
 			}
 			continue
 		}
@@ -367,17 +845,17 @@ func shouldIgnore(relPath string, isDir bool, patterns []string) bool {
 		// Basename glob pattern (no path separator in pattern)
 		if !strings.Contains(pattern, "/") {
 			if matched, _ := filepath.Match(pattern, basename); matched {
-				return true
+				return pattern
 			}
 			continue
 		}
 
 		// Path pattern with separator — match against full relative path
 		if matched, _ := filepath.Match(pattern, relPath); matched {
-			return true
+			return pattern
 		}
 	}
-	return false
+	return ""
 }
 
 func shouldSkipDir(name string) bool {
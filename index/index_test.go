@@ -2,8 +2,10 @@ package index
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -571,6 +573,49 @@ type Config struct{}
 	}
 }
 
+func TestScanPersistsSymbolMetadata(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "server.go", `package server
+
+type Server struct{}
+
+// Start boots the server. It blocks until ctx is done.
+func (s *Server) Start(addr string) error {
+	return nil
+}
+`)
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	var start *Entry
+	for i := range idx.Entries {
+		if idx.Entries[i].Name == "Start" {
+			start = &idx.Entries[i]
+		}
+	}
+	if start == nil {
+		t.Fatal("Start method not indexed")
+	}
+	if start.Line != 6 || start.EndLine != 8 {
+		t.Errorf("Start lines = %d-%d, want 6-8", start.Line, start.EndLine)
+	}
+	if start.Parent != "Server" {
+		t.Errorf("Parent = %q, want Server", start.Parent)
+	}
+	if !strings.Contains(start.Signature, "Start(addr string) error") {
+		t.Errorf("Signature = %q, want it to contain the parameter list", start.Signature)
+	}
+	if start.Doc != "Start boots the server." {
+		t.Errorf("Doc = %q, want first sentence of the doc comment", start.Doc)
+	}
+	if got := start.QualifiedName(); got != "Server.Start" {
+		t.Errorf("QualifiedName() = %q, want Server.Start", got)
+	}
+}
+
 func TestLookupFindsSymbols(t *testing.T) {
 	tmp := t.TempDir()
 	mkFile(t, tmp, "sample.go", `package sample
@@ -657,3 +702,61 @@ func mkFile(t *testing.T, base, relPath, content string) {
 		t.Fatal(err)
 	}
 }
+
+func TestScanIsDeterministicAcrossWorkerCounts(t *testing.T) {
+	tmp := t.TempDir()
+	for i := 0; i < 40; i++ {
+		mkFile(t, tmp, fmt.Sprintf("pkg%d/file%d.go", i%5, i), fmt.Sprintf("package pkg\n\nfunc F%d() {}\n", i))
+	}
+
+	serial, _, err := ScanWithOptions(tmp, nil, ScanOptions{Workers: 1})
+	if err != nil {
+		t.Fatalf("ScanWithOptions() error: %v", err)
+	}
+	parallel, _, err := ScanWithOptions(tmp, nil, ScanOptions{Workers: 8})
+	if err != nil {
+		t.Fatalf("ScanWithOptions() error: %v", err)
+	}
+	if !reflect.DeepEqual(serial.Entries, parallel.Entries) {
+		t.Error("entries differ between 1 and 8 workers")
+	}
+	if !reflect.DeepEqual(serial.Files, parallel.Files) {
+		t.Error("file records differ between 1 and 8 workers")
+	}
+}
+
+func TestScanMaxFileSize(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "small.go", "package main\n\nfunc Small() {}\n")
+	mkFile(t, tmp, "big.go", "package main\n\nfunc Big() {}\n"+strings.Repeat("// padding\n", 100))
+
+	idx, _, err := ScanWithOptions(tmp, nil, ScanOptions{MaxFileSize: 200})
+	if err != nil {
+		t.Fatalf("ScanWithOptions() error: %v", err)
+	}
+	names := map[string]bool{}
+	for _, e := range idx.Entries {
+		names[e.Name] = true
+	}
+	if !names["big.go"] {
+		t.Error("oversized file should still get a file entry")
+	}
+	if names["Big"] {
+		t.Error("oversized file should not be parsed")
+	}
+	if !names["Small"] {
+		t.Error("small file should be parsed")
+	}
+	if rec := idx.fileRecords()["big.go"]; rec.Hash != "" || rec.Size == 0 {
+		t.Errorf("oversized file record = %+v, want size without hash", rec)
+	}
+
+	// An unchanged oversized file is carried over by an incremental scan.
+	_, changes, err := ScanWithOptions(tmp, idx, ScanOptions{MaxFileSize: 200})
+	if err != nil {
+		t.Fatalf("ScanWithOptions() error: %v", err)
+	}
+	if changes.Unchanged != 2 || len(changes.Modified) != 0 {
+		t.Errorf("changes = %+v, want both files unchanged", changes)
+	}
+}
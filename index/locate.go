@@ -9,13 +9,16 @@ import (
 
 // LocateMatch represents a single match from the unified locate search.
 type LocateMatch struct {
-	Category string `json:"category"`           // "file", "symbol", or "content"
-	Path     string `json:"path"`               // file path relative to root
-	Name     string `json:"name"`               // filename or symbol name
-	Line     int    `json:"line,omitempty"`      // for symbol and content matches
-	Kind     string `json:"kind,omitempty"`      // for symbols: "func", "type", etc.
-	Content  string `json:"content,omitempty"`   // for content matches: the matching line
-	Score    int    `json:"score"`               // relevance score for ranking
+	Category string `json:"category"`          // "file", "symbol", or "content"
+	Path     string `json:"path"`              // file path relative to root
+	Name     string `json:"name"`              // filename or symbol name
+	Line     int    `json:"line,omitempty"`    // for symbol and content matches
+	Kind     string `json:"kind,omitempty"`    // for symbols: "func", "type", etc.
+	Content  string `json:"content,omitempty"` // for content matches: the matching line
+	Score    int    `json:"score"`             // relevance score for ranking
+
+	Signature string `json:"signature,omitempty"` // for symbols: declaration signature
+	Parent    string `json:"parent,omitempty"`    // for symbols: enclosing type, if any
 }
 
 // LocateResult holds the result of a unified locate search.
@@ -73,12 +76,14 @@ func (idx *Index) Locate(query string, max int) (*LocateResult, error) {
 				score = 75 // name starts with query
 			}
 			matches = append(matches, LocateMatch{
-				Category: "symbol",
-				Path:     sym.Path,
-				Name:     sym.Name,
-				Line:     sym.Line,
-				Kind:     sym.Kind,
-				Score:    score,
+				Category:  "symbol",
+				Path:      sym.Path,
+				Name:      sym.Name,
+				Line:      sym.Line,
+				Kind:      sym.Kind,
+				Score:     score,
+				Signature: sym.Signature,
+				Parent:    sym.Parent,
 			})
 		}
 	}
@@ -196,7 +201,11 @@ func FormatLocate(result *LocateResult) string {
 	if len(symbols) > 0 {
 		b.WriteString("Symbols:\n")
 		for _, m := range symbols {
-			b.WriteString(fmt.Sprintf("  %-10s %-40s %s:%d\n", m.Kind, m.Name, m.Path, m.Line))
+			name := m.Name
+			if m.Parent != "" {
+				name = m.Parent + "." + m.Name
+			}
+			b.WriteString(fmt.Sprintf("  %-10s %-40s %s:%d\n", m.Kind, name, m.Path, m.Line))
 		}
 		b.WriteString("\n")
 	}
@@ -0,0 +1,563 @@
+package index
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+// LSP error codes beyond the JSON-RPC set.
+const (
+	lspServerNotInitialized = -32002
+	lspRequestFailed        = -32803
+)
+
+// lspSymbolKinds maps index kinds onto LSP SymbolKind values.
+var lspSymbolKinds = map[string]int{
+	"file":         1,
+	"package":      4,
+	"class":        5,
+	"method":       6,
+	"property":     7,
+	"field":        8,
+	"enum":         10,
+	"interface":    11,
+	"func":         12,
+	"function":     12,
+	"var":          13,
+	"const":        14,
+	"struct":       23,
+	"type":         26,
+	"trait":        11,
+	"module":       2,
+	"macro":        12,
+	"static":       13,
+	"record":       23,
+	"annotation":   11,
+	"object":       19,
+	"namespace":    3,
+	"union":        23,
+	"typedef":      26,
+	"prototype":    12,
+	"overload":     12,
+	"message":      23,
+	"service":      11,
+	"rpc":          6,
+	"input":        23,
+	"scalar":       26,
+	"directive":    12,
+	"query":        6,
+	"mutation":     6,
+	"subscription": 6,
+	"fragment":     19,
+	"path":         20,
+	"operation":    12,
+	"schema":       23,
+	"resource":     19,
+	"data":         19,
+	"provider":     2,
+	"stage":        2,
+	"port":         14,
+	"heading":      15,
+}
+
+func lspSymbolKind(kind string) int {
+	if k, ok := lspSymbolKinds[kind]; ok {
+		return k
+	}
+	return 13 // Variable
+}
+
+// lspPosition is a zero-based line and UTF-16 column.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspSymbolInformation struct {
+	Name          string      `json:"name"`
+	Kind          int         `json:"kind"`
+	Location      lspLocation `json:"location"`
+	ContainerName string      `json:"containerName,omitempty"`
+}
+
+type lspDocumentSymbol struct {
+	Name           string   `json:"name"`
+	Detail         string   `json:"detail,omitempty"`
+	Kind           int      `json:"kind"`
+	Range          lspRange `json:"range"`
+	SelectionRange lspRange `json:"selectionRange"`
+}
+
+type lspHover struct {
+	Contents lspMarkup `json:"contents"`
+}
+
+type lspMarkup struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// lspTextDocumentParams covers the position-based requests.
+type lspTextDocumentParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+	Context  struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+// lspSession tracks the state of one LSP connection.
+type lspSession struct {
+	s           *Server
+	initialized bool
+	shutdown    bool
+}
+
+// ServeLSP speaks the Language Server Protocol over r and w using
+// Content-Length framing. It answers workspace/symbol, documentSymbol,
+// definition, references, and hover from the index, and returns when the
+// client sends exit or closes r.
+func (s *Server) ServeLSP(r io.Reader, w io.Writer) error {
+	sess := &lspSession{s: s}
+	br := bufio.NewReader(r)
+	for {
+		body, err := readLSPMessage(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req RPCRequest
+		var resp *RPCResponse
+		if err := json.Unmarshal(body, &req); err != nil {
+			resp = &RPCResponse{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &RPCError{Code: rpcParseError, Message: err.Error()},
+			}
+		} else {
+			if req.Method == "exit" {
+				return nil
+			}
+			resp = sess.handle(req)
+		}
+		if resp == nil {
+			continue
+		}
+		if err := writeLSPMessage(w, resp); err != nil {
+			return err
+		}
+	}
+}
+
+// readLSPMessage reads one Content-Length framed message body.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+			length = n
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func writeLSPMessage(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
+
+func (sess *lspSession) handle(req RPCRequest) *RPCResponse {
+	resp := &RPCResponse{JSONRPC: "2.0", ID: req.ID}
+	switch {
+	case req.Method == "initialize":
+		sess.initialized = true
+		resp.Result = map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":        0,
+				"workspaceSymbolProvider": true,
+				"documentSymbolProvider":  true,
+				"definitionProvider":      true,
+				"referencesProvider":      true,
+				"hoverProvider":           true,
+			},
+			"serverInfo": map[string]string{"name": "swarm-index"},
+		}
+	case len(req.ID) == 0:
+		return nil // initialized, didOpen, didChange, ... need no reply
+	case !sess.initialized:
+		resp.Error = &RPCError{Code: lspServerNotInitialized, Message: "server not initialized"}
+	case req.Method == "shutdown":
+		sess.shutdown = true
+		resp.Result = json.RawMessage("null")
+	case sess.shutdown:
+		resp.Error = &RPCError{Code: rpcInvalidRequest, Message: "server is shutting down"}
+	default:
+		sess.s.mu.Lock()
+		result, err := sess.s.lspCall(req.Method, req.Params)
+		sess.s.mu.Unlock()
+		if err != nil {
+			return &RPCResponse{JSONRPC: "2.0", ID: req.ID, Error: err}
+		}
+		// Empty results are null in LSP, which omitempty would drop.
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		resp.Result = result
+	}
+	return resp
+}
+
+func (s *Server) lspCall(method string, params json.RawMessage) (result any, rpcErr *RPCError) {
+	defer recoverCall(method, &rpcErr)
+	if method == "workspace/symbol" {
+		var p struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return s.idx.lspWorkspaceSymbols(p.Query), nil
+	}
+
+	var p lspTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	path, err := uriToPath(p.TextDocument.URI)
+	if err != nil {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+
+	switch method {
+	case "textDocument/documentSymbol":
+		result, err = s.idx.lspDocumentSymbols(path)
+	case "textDocument/definition":
+		result, err = s.idx.lspDefinition(path, p.Position)
+	case "textDocument/references":
+		result, err = s.idx.lspReferences(path, p.Position, p.Context.IncludeDeclaration)
+	case "textDocument/hover":
+		result, err = s.idx.lspHover(path, p.Position)
+	default:
+		return nil, &RPCError{Code: rpcMethodNotFound, Message: "method not found: " + method}
+	}
+	if err != nil {
+		return nil, &RPCError{Code: lspRequestFailed, Message: err.Error()}
+	}
+	return result, nil
+}
+
+// lspWorkspaceSymbols answers workspace/symbol with indexed symbols.
+func (idx *Index) lspWorkspaceSymbols(query string) []lspSymbolInformation {
+	out := []lspSymbolInformation{}
+	for _, e := range idx.symbolEntries(query, "") {
+		out = append(out, lspSymbolInformation{
+			Name:          e.Name,
+			Kind:          lspSymbolKind(e.Kind),
+			Location:      idx.lspEntryLocation(e),
+			ContainerName: e.Parent,
+		})
+	}
+	return out
+}
+
+// lspDocumentSymbols answers documentSymbol by outlining the file on disk, so
+// unsaved scans and unindexed files still get symbols.
+func (idx *Index) lspDocumentSymbols(path string) ([]lspDocumentSymbol, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := parsers.ForFile(path)
+	if p == nil {
+		return nil, nil
+	}
+	symbols, err := p.Parse(path, content)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(content), "\n")
+	out := []lspDocumentSymbol{}
+	for _, sym := range symbols {
+		name := sym.Name
+		if sym.Parent != "" {
+			name = sym.Parent + "." + sym.Name
+		}
+		sel := wordRange(lines, sym.Line, sym.Name)
+		end := sym.EndLine
+		if end < sym.Line {
+			end = sym.Line
+		}
+		out = append(out, lspDocumentSymbol{
+			Name:   name,
+			Detail: sym.Signature,
+			Kind:   lspSymbolKind(sym.Kind),
+			Range: lspRange{
+				Start: lspPosition{Line: sym.Line - 1},
+				End:   lspPosition{Line: end - 1, Character: lineLength(lines, end)},
+			},
+			SelectionRange: sel,
+		})
+	}
+	return out, nil
+}
+
+// lspDefinition resolves the identifier under the cursor to its indexed
+// definitions, preferring ones in the same file.
+func (idx *Index) lspDefinition(path string, pos lspPosition) ([]lspLocation, error) {
+	word, err := wordAt(path, pos)
+	if err != nil || word == "" {
+		return nil, err
+	}
+	defs := idx.definitions(word, idx.relPath(path))
+	out := []lspLocation{}
+	for _, e := range defs {
+		out = append(out, idx.lspEntryLocation(e))
+	}
+	return out, nil
+}
+
+// lspReferences answers references with the matches Refs finds.
+func (idx *Index) lspReferences(path string, pos lspPosition, includeDecl bool) ([]lspLocation, error) {
+	word, err := wordAt(path, pos)
+	if err != nil || word == "" {
+		return nil, err
+	}
+	refs, err := idx.Refs(word, 1000)
+	if err != nil {
+		return nil, err
+	}
+	out := []lspLocation{}
+	files := map[string][]string{}
+	add := func(m RefMatch) {
+		lines, ok := files[m.Path]
+		if !ok {
+			lines = readLines(filepath.Join(idx.Root, m.Path))
+			files[m.Path] = lines
+		}
+		out = append(out, lspLocation{
+			URI:   pathToURI(filepath.Join(idx.Root, m.Path)),
+			Range: wordRange(lines, m.Line, word),
+		})
+	}
+	if includeDecl && refs.Definition != nil {
+		add(*refs.Definition)
+	}
+	for _, m := range refs.References {
+		add(m)
+	}
+	return out, nil
+}
+
+// lspHover shows the signature, doc comment, and body of the identifier's
+// definition.
+func (idx *Index) lspHover(path string, pos lspPosition) (*lspHover, error) {
+	word, err := wordAt(path, pos)
+	if err != nil || word == "" {
+		return nil, err
+	}
+	defs := idx.definitions(word, idx.relPath(path))
+	if len(defs) == 0 {
+		return nil, nil
+	}
+	def := defs[0]
+	ctx, err := Context(filepath.Join(idx.Root, def.Path), def.Name)
+	if err != nil {
+		return nil, nil
+	}
+	var b strings.Builder
+	lang := strings.TrimPrefix(filepath.Ext(def.Path), ".")
+	fmt.Fprintf(&b, "```%s\n%s\n```\n", lang, ctx.Signature)
+	if ctx.DocComment != "" {
+		fmt.Fprintf(&b, "\n%s\n", ctx.DocComment)
+	}
+	fmt.Fprintf(&b, "\n%s:%d", def.Path, def.Line)
+	return &lspHover{Contents: lspMarkup{Kind: "markdown", Value: b.String()}}, nil
+}
+
+// definitions returns indexed symbols named exactly name, with those in
+// relPath first.
+func (idx *Index) definitions(name, relPath string) []Entry {
+	var local, other []Entry
+	for _, e := range idx.symbolEntries(name, "") {
+		if e.Name != name {
+			continue
+		}
+		if e.Path == relPath {
+			local = append(local, e)
+		} else {
+			other = append(other, e)
+		}
+	}
+	return append(local, other...)
+}
+
+func (idx *Index) lspEntryLocation(e Entry) lspLocation {
+	full := filepath.Join(idx.Root, e.Path)
+	return lspLocation{URI: pathToURI(full), Range: wordRange(readLines(full), e.Line, e.Name)}
+}
+
+// relPath returns path relative to the index root, as stored in entries.
+func (idx *Index) relPath(path string) string {
+	rel, err := filepath.Rel(idx.Root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q", u.Scheme)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func readLines(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// wordAt returns the identifier under pos in the file at path.
+func wordAt(path string, pos lspPosition) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(data), "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return "", nil
+	}
+	line := []rune(lines[pos.Line])
+	col := runeIndex(line, pos.Character)
+	isIdent := func(r rune) bool { return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	if col >= len(line) || !isIdent(line[col]) {
+		if col > 0 && col-1 < len(line) && isIdent(line[col-1]) {
+			col-- // cursor just past the end of a word
+		} else {
+			return "", nil
+		}
+	}
+	start, end := col, col
+	for start > 0 && isIdent(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdent(line[end]) {
+		end++
+	}
+	return string(line[start:end]), nil
+}
+
+// runeIndex converts a UTF-16 column into an index into line.
+func runeIndex(line []rune, utf16Col int) int {
+	units := 0
+	for i, r := range line {
+		if units >= utf16Col {
+			return i
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return len(line)
+}
+
+// wordRange returns the range of the first whole-word occurrence of word on
+// the 1-based line, or the start of the line if it does not occur.
+func wordRange(lines []string, line int, word string) lspRange {
+	pos := lspPosition{Line: line - 1}
+	if line < 1 || line > len(lines) {
+		return lspRange{Start: pos, End: pos}
+	}
+	text := lines[line-1]
+	for from := 0; ; {
+		i := strings.Index(text[from:], word)
+		if i < 0 {
+			return lspRange{Start: pos, End: pos}
+		}
+		i += from
+		before, after := i == 0 || !isIdentByte(text[i-1]), i+len(word) == len(text) || !isIdentByte(text[i+len(word)])
+		if before && after {
+			start := utf16Len(text[:i])
+			return lspRange{
+				Start: lspPosition{Line: line - 1, Character: start},
+				End:   lspPosition{Line: line - 1, Character: start + utf16Len(word)},
+			}
+		}
+		from = i + len(word)
+	}
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' || b >= 0x80 || (b >= '0' && b <= '9') || (b|0x20 >= 'a' && b|0x20 <= 'z')
+}
+
+func lineLength(lines []string, line int) int {
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	return utf16Len(strings.TrimRight(lines[line-1], "\r"))
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
@@ -0,0 +1,169 @@
+package index
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lspExchange sends framed messages to a fresh LSP session and returns the
+// decoded responses keyed by request ID.
+func lspExchange(t *testing.T, s *Server, msgs ...string) map[int]RPCResponse {
+	t.Helper()
+	var in strings.Builder
+	for _, m := range msgs {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+	var out strings.Builder
+	if err := s.ServeLSP(strings.NewReader(in.String()), &out); err != nil {
+		t.Fatalf("ServeLSP() error: %v", err)
+	}
+
+	resps := map[int]RPCResponse{}
+	r := bufio.NewReader(strings.NewReader(out.String()))
+	for {
+		body, err := readLSPMessage(r)
+		if err != nil {
+			break
+		}
+		var resp RPCResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatalf("decoding %s: %v", body, err)
+		}
+		var id int
+		json.Unmarshal(resp.ID, &id)
+		resps[id] = resp
+	}
+	return resps
+}
+
+func lspRequest(id int, method, params string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":%s}`, id, method, params)
+}
+
+func lspDecode(t *testing.T, resp RPCResponse, v any) {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error.Message)
+	}
+	data, _ := json.Marshal(resp.Result)
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decoding result %s: %v", data, err)
+	}
+}
+
+func TestLSPNavigation(t *testing.T) {
+	s, tmp := newTestServer(t)
+	mainURI := pathToURI(filepath.Join(tmp, "main.go"))
+	handlerURI := pathToURI(filepath.Join(tmp, "api", "handler.go"))
+	doc := func(uri string, line, char int) string {
+		return fmt.Sprintf(`{"textDocument":{"uri":%q},"position":{"line":%d,"character":%d},"context":{"includeDeclaration":true}}`, uri, line, char)
+	}
+
+	resps := lspExchange(t, s,
+		lspRequest(1, "initialize", `{"capabilities":{}}`),
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		lspRequest(2, "workspace/symbol", `{"query":"auth"}`),
+		lspRequest(3, "textDocument/documentSymbol", fmt.Sprintf(`{"textDocument":{"uri":%q}}`, handlerURI)),
+		lspRequest(4, "textDocument/definition", doc(mainURI, 3, 8)),
+		lspRequest(5, "textDocument/references", doc(mainURI, 3, 8)),
+		lspRequest(6, "textDocument/hover", doc(handlerURI, 3, 20)),
+		lspRequest(7, "shutdown", `null`),
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		lspRequest(8, "workspace/symbol", `{"query":"never answered"}`),
+	)
+
+	var init struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	lspDecode(t, resps[1], &init)
+	for _, c := range []string{"workspaceSymbolProvider", "documentSymbolProvider", "definitionProvider", "referencesProvider", "hoverProvider"} {
+		if init.Capabilities[c] != true {
+			t.Errorf("capability %s not advertised", c)
+		}
+	}
+
+	var ws []lspSymbolInformation
+	lspDecode(t, resps[2], &ws)
+	names := map[string]string{}
+	for _, sym := range ws {
+		names[sym.Name] = sym.ContainerName
+	}
+	if _, ok := names["HandleAuth"]; !ok || names["ServeAuth"] != "Handler" {
+		t.Errorf("workspace/symbol = %+v, want HandleAuth and Handler.ServeAuth", ws)
+	}
+
+	var ds []lspDocumentSymbol
+	lspDecode(t, resps[3], &ds)
+	var serve *lspDocumentSymbol
+	for i := range ds {
+		if ds[i].Name == "Handler.ServeAuth" {
+			serve = &ds[i]
+		}
+	}
+	if serve == nil || serve.Kind != lspSymbolKinds["method"] || serve.SelectionRange.Start != (lspPosition{Line: 3, Character: 18}) {
+		t.Errorf("documentSymbol = %+v, want Handler.ServeAuth method selected at 3:18", ds)
+	}
+
+	// main.go line 4 (0-based 3) is "func HandleAuth() {}".
+	var defs []lspLocation
+	lspDecode(t, resps[4], &defs)
+	want := lspLocation{URI: mainURI, Range: lspRange{Start: lspPosition{3, 5}, End: lspPosition{3, 15}}}
+	if len(defs) != 1 || defs[0] != want {
+		t.Errorf("definition = %+v, want %+v", defs, want)
+	}
+
+	var refs []lspLocation
+	lspDecode(t, resps[5], &refs)
+	if len(refs) == 0 || refs[0] != want {
+		t.Errorf("references = %+v, want the declaration first", refs)
+	}
+
+	var hover lspHover
+	lspDecode(t, resps[6], &hover)
+	if hover.Contents.Kind != "markdown" || !strings.Contains(hover.Contents.Value, "ServeAuth()") {
+		t.Errorf("hover = %+v, want the ServeAuth signature", hover)
+	}
+
+	if _, ok := resps[7]; !ok {
+		t.Error("shutdown got no response")
+	}
+	if _, ok := resps[8]; ok {
+		t.Error("requests after exit should not be answered")
+	}
+}
+
+func TestLSPRequiresInitialize(t *testing.T) {
+	s, _ := newTestServer(t)
+	resps := lspExchange(t, s, lspRequest(1, "workspace/symbol", `{"query":"auth"}`))
+	if resps[1].Error == nil || resps[1].Error.Code != lspServerNotInitialized {
+		t.Errorf("response = %+v, want server-not-initialized error", resps[1])
+	}
+}
+
+func TestWordAtAndRange(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "a.go", "x := héllo.World(y)\n")
+	path := filepath.Join(tmp, "a.go")
+
+	for _, tc := range []struct {
+		char int
+		want string
+	}{{0, "x"}, {5, "héllo"}, {11, "World"}, {16, "World"}, {2, ""}} {
+		got, err := wordAt(path, lspPosition{Line: 0, Character: tc.char})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("wordAt(0:%d) = %q, want %q", tc.char, got, tc.want)
+		}
+	}
+
+	r := wordRange([]string{"helloWorld World"}, 1, "World")
+	if r.Start.Character != 11 || r.End.Character != 16 {
+		t.Errorf("wordRange() = %+v, want whole-word match at 11-16", r)
+	}
+}
@@ -0,0 +1,148 @@
+package index
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision ServeMCP speaks.
+const mcpProtocolVersion = "2024-11-05"
+
+// MCPTool describes one tool advertised by the MCP server.
+type MCPTool struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	InputSchema MCPSchema `json:"inputSchema"`
+}
+
+// MCPSchema is the JSON Schema of a tool's arguments.
+type MCPSchema struct {
+	Type       string                 `json:"type"`
+	Properties map[string]MCPProperty `json:"properties"`
+	Required   []string               `json:"required,omitempty"`
+}
+
+// MCPProperty describes a single tool argument.
+type MCPProperty struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// mcpParams documents every RPCParams field by its JSON name.
+var mcpParams = map[string]MCPProperty{
+	"query":     {"string", "Name or text to search for."},
+	"pattern":   {"string", "Regular expression (Go RE2 syntax)."},
+	"symbol":    {"string", "Symbol name."},
+	"target":    {"string", "Symbol name or file path relative to the project root."},
+	"file":      {"string", "File path relative to the project root."},
+	"dir":       {"string", "Directory path relative to the project root."},
+	"path":      {"string", "File or directory path prefix relative to the project root."},
+	"kind":      {"string", "Only include results of this kind (e.g. func, type, method, route)."},
+	"tag":       {"string", "Only include comments with this tag (TODO, FIXME, HACK, XXX)."},
+	"ref":       {"string", "Git ref to diff against (default HEAD~1)."},
+	"since":     {"string", "Only count commits since this time, e.g. \"6 months ago\"."},
+	"focus":     {"string", "File to center the graph on."},
+	"max":       {"integer", "Maximum number of results."},
+	"depth":     {"integer", "Maximum traversal depth."},
+	"min":       {"integer", "Minimum complexity to report."},
+	"startLine": {"integer", "First line of the range (1-based, inclusive)."},
+	"endLine":   {"integer", "Last line of the range (1-based, inclusive)."},
+	"exact":     {"boolean", "Use unranked substring matching instead of fuzzy ranking."},
+	"recursive": {"boolean", "Include subdirectories."},
+	"untested":  {"boolean", "Only show source files without tests."},
+	"tested":    {"boolean", "Only show source files with tests."},
+	"types":     {"boolean", "Resolve Go references with the type checker and classify them (call, type, field, embed, import)."},
+}
+
+// MCPTools returns the tools ServeMCP advertises: one per server method plus
+// reload.
+func MCPTools() []MCPTool {
+	tools := make([]MCPTool, 0, len(serverMethods)+1)
+	for _, m := range serverMethods {
+		schema := MCPSchema{Type: "object", Properties: map[string]MCPProperty{}}
+		for _, name := range strings.Fields(m.params) {
+			if strings.HasSuffix(name, "!") {
+				name = strings.TrimSuffix(name, "!")
+				schema.Required = append(schema.Required, name)
+			}
+			schema.Properties[name] = mcpParams[name]
+		}
+		tools = append(tools, MCPTool{Name: m.name, Description: m.desc, InputSchema: schema})
+	}
+	tools = append(tools, MCPTool{
+		Name:        "reload",
+		Description: "Re-read the index from disk after it has been re-scanned.",
+		InputSchema: MCPSchema{Type: "object", Properties: map[string]MCPProperty{}},
+	})
+	return tools
+}
+
+// mcpContent is a single block of tool output.
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// mcpCallResult is the result of a tools/call request.
+type mcpCallResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+// ServeMCP speaks the Model Context Protocol over newline-delimited JSON-RPC,
+// exposing each server method as a tool. Tool results are the JSON the CLI
+// prints with --json.
+func (s *Server) ServeMCP(r io.Reader, w io.Writer, version string) error {
+	return serveJSONLines(r, w, func(req RPCRequest) *RPCResponse {
+		return s.handleMCP(req, version)
+	})
+}
+
+func (s *Server) handleMCP(req RPCRequest, version string) *RPCResponse {
+	if len(req.ID) == 0 {
+		return nil // notifications/initialized and friends need no reply
+	}
+	resp := &RPCResponse{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "initialize":
+		resp.Result = map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "swarm-index", "version": version},
+		}
+	case "ping":
+		resp.Result = struct{}{}
+	case "tools/list":
+		resp.Result = map[string]any{"tools": MCPTools()}
+	case "tools/call":
+		resp.Result, resp.Error = s.callTool(req.Params)
+	default:
+		resp.Error = &RPCError{Code: rpcMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	return resp
+}
+
+// callTool runs a tools/call request. Unknown tools are protocol errors;
+// failures inside a tool are reported in the result with isError set.
+func (s *Server) callTool(params json.RawMessage) (any, *RPCError) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	result, rpcErr := s.Call(call.Name, call.Arguments)
+	if rpcErr != nil {
+		if rpcErr.Code == rpcMethodNotFound {
+			return nil, &RPCError{Code: rpcInvalidParams, Message: "unknown tool: " + call.Name}
+		}
+		return mcpCallResult{Content: []mcpContent{{"text", rpcErr.Message}}, IsError: true}, nil
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, &RPCError{Code: rpcServerError, Message: err.Error()}
+	}
+	return mcpCallResult{Content: []mcpContent{{"text", string(data)}}}, nil
+}
@@ -0,0 +1,100 @@
+package index
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMCPToolsHaveSchemas(t *testing.T) {
+	tools := MCPTools()
+	if len(tools) != len(Methods())+1 {
+		t.Fatalf("got %d tools, want one per method plus reload", len(tools))
+	}
+	for _, tool := range tools {
+		if tool.Description == "" {
+			t.Errorf("tool %s has no description", tool.Name)
+		}
+		for name, prop := range tool.InputSchema.Properties {
+			if prop.Type == "" || prop.Description == "" {
+				t.Errorf("tool %s: parameter %q is undocumented", tool.Name, name)
+			}
+		}
+		for _, req := range tool.InputSchema.Required {
+			if _, ok := tool.InputSchema.Properties[req]; !ok {
+				t.Errorf("tool %s: required %q is not a property", tool.Name, req)
+			}
+		}
+	}
+}
+
+func TestMCPSession(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	var out strings.Builder
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"symbols","arguments":{"query":"auth"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"refs","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"nope"}}`,
+	}, "\n")
+	if err := s.ServeMCP(strings.NewReader(in), &out, "v9"); err != nil {
+		t.Fatalf("ServeMCP() error: %v", err)
+	}
+
+	type response struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	var resps []response
+	dec := json.NewDecoder(strings.NewReader(out.String()))
+	for dec.More() {
+		var r response
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("decoding: %v", err)
+		}
+		resps = append(resps, r)
+	}
+	if len(resps) != 5 {
+		t.Fatalf("got %d responses, want 5:\n%s", len(resps), out.String())
+	}
+
+	var init struct {
+		ProtocolVersion string            `json:"protocolVersion"`
+		ServerInfo      map[string]string `json:"serverInfo"`
+	}
+	json.Unmarshal(resps[0].Result, &init)
+	if init.ProtocolVersion != mcpProtocolVersion || init.ServerInfo["version"] != "v9" {
+		t.Errorf("initialize result = %s", resps[0].Result)
+	}
+
+	var list struct {
+		Tools []MCPTool `json:"tools"`
+	}
+	json.Unmarshal(resps[1].Result, &list)
+	if len(list.Tools) != len(MCPTools()) {
+		t.Errorf("tools/list returned %d tools, want %d", len(list.Tools), len(MCPTools()))
+	}
+
+	var call mcpCallResult
+	json.Unmarshal(resps[2].Result, &call)
+	if call.IsError || len(call.Content) != 1 {
+		t.Fatalf("symbols call = %s", resps[2].Result)
+	}
+	var symbols SymbolsResult
+	if err := json.Unmarshal([]byte(call.Content[0].Text), &symbols); err != nil || symbols.Total == 0 {
+		t.Errorf("symbols tool text is not a SymbolsResult: %s", call.Content[0].Text)
+	}
+
+	json.Unmarshal(resps[3].Result, &call)
+	if !call.IsError || !strings.Contains(call.Content[0].Text, "missing symbol") {
+		t.Errorf("refs without symbol = %s, want a tool error", resps[3].Result)
+	}
+
+	if resps[4].Error == nil || resps[4].Error.Code != rpcInvalidParams {
+		t.Errorf("unknown tool = %+v, want invalid params error", resps[4].Error)
+	}
+}
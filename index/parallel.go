@@ -0,0 +1,62 @@
+package index
+
+import (
+	"runtime"
+	"sync"
+)
+
+// workerCount returns n, or GOMAXPROCS when n is not positive.
+func workerCount(n int) int {
+	if n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// parallelCollect applies fn to items on a pool of workers goroutines (see
+// workerCount) and passes the results to collect in item order. Items are processed in
+// batches; once collect returns false no further batches are started, so
+// commands with a result limit stop early just as a serial loop would.
+func parallelCollect[In, Out any](items []In, workers int, fn func(In) Out, collect func(Out) bool) {
+	workers = workerCount(workers)
+	batch := workers * 8
+	results := make([]Out, batch)
+	for start := 0; start < len(items); start += batch {
+		end := min(start+batch, len(items))
+		chunk := items[start:end]
+
+		var wg sync.WaitGroup
+		next := make(chan int)
+		for w := 0; w < min(workers, len(chunk)); w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					results[i] = fn(chunk[i])
+				}
+			}()
+		}
+		for i := range chunk {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+
+		for i := range chunk {
+			if !collect(results[i]) {
+				return
+			}
+		}
+	}
+}
+
+// parallelMap applies fn to every item on a pool of workers goroutines and
+// returns the results in item order.
+func parallelMap[In, Out any](items []In, workers int, fn func(In) Out) []Out {
+	out := make([]Out, 0, len(items))
+	parallelCollect(items, workers, fn, func(r Out) bool {
+		out = append(out, r)
+		return true
+	})
+	return out
+}
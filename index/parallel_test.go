@@ -0,0 +1,62 @@
+package index
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMapPreservesOrder(t *testing.T) {
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	got := parallelMap(items, 0, func(n int) int { return n * n })
+	for i, v := range got {
+		if v != i*i {
+			t.Fatalf("result %d = %d, want %d", i, v, i*i)
+		}
+	}
+}
+
+func TestParallelCollectStopsEarly(t *testing.T) {
+	items := make([]int, 100000)
+	var calls atomic.Int64
+	var collected []int
+	parallelCollect(items, 0, func(n int) int {
+		calls.Add(1)
+		return n
+	}, func(n int) bool {
+		collected = append(collected, n)
+		return len(collected) < 3
+	})
+	if len(collected) != 3 {
+		t.Errorf("collected %d results, want 3", len(collected))
+	}
+	if calls.Load() == int64(len(items)) {
+		t.Error("parallelCollect processed every item after collect returned false")
+	}
+}
+
+func TestParallelCollectWorkers(t *testing.T) {
+	items := make([]int, 200)
+	var running, peak atomic.Int64
+	parallelMap(items, 2, func(n int) int {
+		if r := running.Add(1); r > peak.Load() {
+			peak.Store(r)
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return n
+	})
+	if peak.Load() > 2 {
+		t.Errorf("%d goroutines ran at once, want at most 2", peak.Load())
+	}
+}
+
+func TestParallelMapEmpty(t *testing.T) {
+	if got := parallelMap([]string{}, 0, func(s string) string { return s }); !reflect.DeepEqual(got, []string{}) {
+		t.Errorf("parallelMap(empty) = %v, want empty", got)
+	}
+}
@@ -0,0 +1,184 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+// ProjectConfigFile is the per-project configuration file. It is looked up
+// in the scanned directory and then its parents up to the root of the
+// enclosing repository; outside a repository only the scanned directory is
+// searched, so a config in an unrelated parent such as $HOME never runs.
+const ProjectConfigFile = ".swarmindex.json"
+
+// projectConfig is the content of ProjectConfigFile.
+type projectConfig struct {
+	Parsers []parserPlugin `json:"parsers"`
+}
+
+// parserPlugin maps file extensions to an external parser executable, e.g.
+//
+//	{"extensions": [".dsl"], "command": ["./tools/dsl-symbols"], "timeout": "5s"}
+//
+// A relative command path containing a slash is resolved against the
+// directory holding the config file, which is also the working directory.
+type parserPlugin struct {
+	Extensions []string `json:"extensions"`
+	Command    []string `json:"command"`
+	Timeout    string   `json:"timeout,omitempty"` // Go duration; default 10s
+}
+
+// LoadParserPlugins registers the external parsers configured in the
+// nearest ProjectConfigFile for dir (see findProjectConfig), replacing any
+// loaded earlier.
+// Without a config file only the built-in parsers are used.
+func LoadParserPlugins(dir string) error {
+	_, err := loadParserPlugins(dir)
+	return err
+}
+
+// loadParserPlugins is LoadParserPlugins, also returning the plugins loaded.
+func loadParserPlugins(dir string) ([]parsers.Parser, error) {
+	path, err := findProjectConfig(dir)
+	if err != nil || path == "" {
+		parsers.SetExternal(nil)
+		return nil, err
+	}
+	ps, err := readParserPlugins(path)
+	if err != nil {
+		parsers.SetExternal(nil)
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	parsers.SetExternal(ps)
+	return ps, nil
+}
+
+// pluginStamps describes the plugin handling each extension by its command
+// and timeout. Scans save the stamps so an incremental scan can re-parse the
+// files whose plugin was added, changed or removed since.
+func pluginStamps(ps []parsers.Parser) map[string]string {
+	if len(ps) == 0 {
+		return nil
+	}
+	stamps := make(map[string]string)
+	for _, p := range ps {
+		ep, ok := p.(*parsers.ExternalParser)
+		if !ok {
+			continue
+		}
+		stamp := fmt.Sprintf("%q timeout %s", ep.Command, ep.Timeout)
+		for _, ext := range ep.Exts {
+			stamps[ext] = stamp
+		}
+	}
+	return stamps
+}
+
+// changedPlugins returns the extensions whose plugin stamp differs between
+// two scans.
+func changedPlugins(old, cur map[string]string) map[string]bool {
+	changed := make(map[string]bool)
+	for ext, stamp := range old {
+		if cur[ext] != stamp {
+			changed[ext] = true
+		}
+	}
+	for ext, stamp := range cur {
+		if old[ext] != stamp {
+			changed[ext] = true
+		}
+	}
+	return changed
+}
+
+// findProjectConfig returns the path of the nearest ProjectConfigFile in dir
+// or a parent no higher than the repository root (the nearest directory with
+// a .git entry), or "" if there is none. Outside a repository only dir itself
+// is checked.
+func findProjectConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	stop := repoRoot(dir)
+	if stop == "" {
+		stop = dir
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		if dir == stop {
+			return "", nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// repoRoot returns the nearest directory at or above the absolute path dir
+// that holds a .git entry, or "" if there is none.
+func repoRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readParserPlugins reads and validates the parser plugins in a config file.
+func readParserPlugins(path string) ([]parsers.Parser, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg projectConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	base := filepath.Dir(path)
+	var ps []parsers.Parser
+	for i, pl := range cfg.Parsers {
+		if len(pl.Command) == 0 || pl.Command[0] == "" {
+			return nil, fmt.Errorf("parsers[%d]: command is required", i)
+		}
+		if len(pl.Extensions) == 0 {
+			return nil, fmt.Errorf("parsers[%d]: extensions is required", i)
+		}
+		for _, ext := range pl.Extensions {
+			if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+				return nil, fmt.Errorf("parsers[%d]: extension %q must start with a dot", i, ext)
+			}
+		}
+		var timeout time.Duration
+		if pl.Timeout != "" {
+			if timeout, err = time.ParseDuration(pl.Timeout); err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("parsers[%d]: invalid timeout %q", i, pl.Timeout)
+			}
+		}
+
+		command := append([]string(nil), pl.Command...)
+		if !filepath.IsAbs(command[0]) && strings.ContainsRune(command[0], '/') {
+			command[0] = filepath.Join(base, command[0])
+		}
+		ps = append(ps, &parsers.ExternalParser{
+			Exts:    pl.Extensions,
+			Command: command,
+			Dir:     base,
+			Timeout: timeout,
+		})
+	}
+	return ps, nil
+}
@@ -0,0 +1,281 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+// rulesParserScript emits a "rule" symbol for every "rule <name>" line and
+// fails on input containing "syntax error".
+const rulesParserScript = `#!/bin/sh
+input=$(cat)
+case "$input" in *"syntax error"*) echo "line 1: syntax error" >&2; exit 1;; esac
+printf '%s\n' "$input" | awk '
+  BEGIN { printf "[" }
+  $1 == "rule" { if (n++) printf ","; printf "{\"name\":\"%s\",\"kind\":\"rule\",\"line\":%d,\"exported\":true,\"signature\":\"%s\"}", $2, NR, $0 }
+  END { printf "]" }'
+`
+
+// mkRulesPlugin writes the rules parser and a config mapping .rules to it.
+func mkRulesPlugin(t *testing.T, root string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("parser plugin tests use shell scripts")
+	}
+	t.Cleanup(func() { parsers.SetExternal(nil) })
+	mkFile(t, root, "tools/rules-parser", rulesParserScript)
+	if err := os.Chmod(filepath.Join(root, "tools", "rules-parser"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mkFile(t, root, ProjectConfigFile, `{
+  "parsers": [
+    {"extensions": [".rules"], "command": ["./tools/rules-parser"], "timeout": "5s"}
+  ]
+}`)
+}
+
+func TestScanUsesParserPlugins(t *testing.T) {
+	tmp := t.TempDir()
+	mkRulesPlugin(t, tmp)
+	mkFile(t, tmp, "policy/access.rules", "rule allow_admins\nrule deny_all\n")
+	mkFile(t, tmp, "main.go", "package main\n\nfunc main() {}\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	var rules []Entry
+	for _, e := range idx.Entries {
+		if e.Kind == "rule" {
+			rules = append(rules, e)
+		}
+	}
+	if len(rules) != 2 || rules[1].Name != "deny_all" || rules[1].Line != 2 || rules[1].Path != filepath.Join("policy", "access.rules") {
+		t.Errorf("rule entries = %+v, want allow_admins and deny_all from policy/access.rules", rules)
+	}
+	if len(idx.Match("main")) == 0 {
+		t.Error("built-in parsers should still be used alongside plugins")
+	}
+
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	parsers.SetExternal(nil)
+	loaded, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	defer loaded.Close()
+	if parsers.ForExtension(".rules") == nil {
+		t.Fatal("Load() should register the configured parser plugins")
+	}
+	result, err := loaded.Exports("policy")
+	if err != nil {
+		t.Fatalf("Exports() error: %v", err)
+	}
+	if result.Count != 2 || len(result.Errors) != 0 {
+		t.Errorf("exports = %v with errors %v, want the two rules", symbolNames(result), result.Errors)
+	}
+}
+
+func TestExportsReportsPluginErrors(t *testing.T) {
+	tmp := t.TempDir()
+	mkRulesPlugin(t, tmp)
+	mkFile(t, tmp, "broken.rules", "syntax error\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	result, err := idx.Exports("broken.rules")
+	if err != nil {
+		t.Fatalf("Exports() error: %v", err)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "broken.rules: external parser") ||
+		!strings.Contains(result.Errors[0], "line 1: syntax error") {
+		t.Errorf("Errors = %v, want the plugin's error for broken.rules", result.Errors)
+	}
+	if out := FormatExports(result); !strings.Contains(out, "Parse errors (1):") {
+		t.Errorf("FormatExports() missing parse errors:\n%s", out)
+	}
+}
+
+func TestLoadParserPluginsInvalidConfig(t *testing.T) {
+	t.Cleanup(func() { parsers.SetExternal(nil) })
+	tests := []struct {
+		config string
+		want   string
+	}{
+		{`{"parsers": [`, "invalid JSON"},
+		{`{"parsers": [{"extensions": [".x"]}]}`, "parsers[0]: command is required"},
+		{`{"parsers": [{"command": ["x"]}]}`, "parsers[0]: extensions is required"},
+		{`{"parsers": [{"extensions": ["x"], "command": ["x"]}]}`, `extension "x" must start with a dot`},
+		{`{"parsers": [{"extensions": [".x"], "command": ["x"], "timeout": "soon"}]}`, `invalid timeout "soon"`},
+	}
+	for _, tt := range tests {
+		tmp := t.TempDir()
+		mkFile(t, tmp, ProjectConfigFile, tt.config)
+		err := LoadParserPlugins(tmp)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("config %s: error = %v, want %q", tt.config, err, tt.want)
+		}
+		if _, err := Scan(tmp); err == nil {
+			t.Errorf("config %s: Scan() should fail", tt.config)
+		}
+	}
+}
+
+func TestLoadParserPluginsFindsParentConfig(t *testing.T) {
+	tmp := t.TempDir()
+	mkRulesPlugin(t, tmp)
+	if err := os.Mkdir(filepath.Join(tmp, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(tmp, "a", "b")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadParserPlugins(sub); err != nil {
+		t.Fatalf("LoadParserPlugins() error: %v", err)
+	}
+	p, ok := parsers.ForExtension(".rules").(*parsers.ExternalParser)
+	if !ok {
+		t.Fatal("no external parser for .rules")
+	}
+	if p.Command[0] != filepath.Join(tmp, "tools", "rules-parser") || p.Dir != tmp {
+		t.Errorf("command %q in %q, want it resolved against the config directory", p.Command[0], p.Dir)
+	}
+
+	// A directory without any config clears the plugins again.
+	if err := LoadParserPlugins(t.TempDir()); err != nil {
+		t.Fatalf("LoadParserPlugins() error: %v", err)
+	}
+	if parsers.ForExtension(".rules") != nil {
+		t.Error(".rules parser should be cleared")
+	}
+}
+
+func TestLoadParserPluginsStopsAtRepoRoot(t *testing.T) {
+	outer := t.TempDir()
+	mkRulesPlugin(t, outer)
+
+	// Outside a repository only the directory itself is searched.
+	project := filepath.Join(outer, "project")
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadParserPlugins(project); err != nil {
+		t.Fatalf("LoadParserPlugins() error: %v", err)
+	}
+	if parsers.ForExtension(".rules") != nil {
+		t.Error("config above a project outside a repository should not be used")
+	}
+
+	// Inside one the search stops at the repository root.
+	if err := os.Mkdir(filepath.Join(project, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadParserPlugins(filepath.Join(project, "src")); err != nil {
+		t.Fatalf("LoadParserPlugins() error: %v", err)
+	}
+	if parsers.ForExtension(".rules") != nil {
+		t.Error("config above the repository root should not be used")
+	}
+}
+
+func TestLoadIgnoresInvalidPluginConfig(t *testing.T) {
+	t.Cleanup(func() { parsers.SetExternal(nil) })
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main\n\nfunc Save() {}\n")
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	mkFile(t, tmp, ProjectConfigFile, `{"parsers": [{"extensions": ["dsl"], "command": ["x"]}]}`)
+
+	loaded, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() with an invalid config error: %v", err)
+	}
+	defer loaded.Close()
+	if matches, err := loaded.Search("Save", 10); err != nil || len(matches) != 1 {
+		t.Errorf("Search() = %+v, %v, want the match in main.go", matches, err)
+	}
+
+	r, err := Doctor(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := doctorCheck(t, r, "plugins"); c.Status != CheckWarning || !strings.Contains(c.Message, "must start with a dot") {
+		t.Errorf("plugins check = %+v, want a warning about the extension", c)
+	}
+	if _, err := Scan(tmp); err == nil {
+		t.Error("Scan() with an invalid config should fail")
+	}
+}
+
+func TestIncrementalScanReparsesAfterPluginChange(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "policy/access.rules", "rule allow_admins\n")
+	mkFile(t, tmp, "main.go", "package main\n\nfunc main() {}\n")
+	rescan := func() (*Index, *ScanChanges) {
+		t.Helper()
+		prev, err := Load(tmp)
+		if err != nil {
+			t.Fatalf("Load() error: %v", err)
+		}
+		defer prev.Close()
+		idx, changes, err := ScanIncremental(tmp, prev)
+		if err != nil {
+			t.Fatalf("ScanIncremental() error: %v", err)
+		}
+		if err := idx.Save(tmp); err != nil {
+			t.Fatalf("Save() error: %v", err)
+		}
+		return idx, changes
+	}
+	rules := func(idx *Index) int {
+		n := 0
+		for _, e := range idx.entries() {
+			if e.Kind == "rule" {
+				n++
+			}
+		}
+		return n
+	}
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	// Adding a plugin re-parses the files it handles and nothing else.
+	mkRulesPlugin(t, tmp)
+	idx, changes := rescan()
+	if rules(idx) != 1 || len(changes.Modified) != 1 || changes.Modified[0] != filepath.Join("policy", "access.rules") {
+		t.Errorf("after adding the plugin: %d rules, modified %v; want 1 rule from policy/access.rules", rules(idx), changes.Modified)
+	}
+
+	// An unchanged config reuses the plugin's symbols.
+	idx, changes = rescan()
+	if rules(idx) != 1 || len(changes.Modified) != 0 {
+		t.Errorf("unchanged config: %d rules, modified %v; want 1 rule reused", rules(idx), changes.Modified)
+	}
+
+	// Removing the plugin drops them.
+	os.Remove(filepath.Join(tmp, ProjectConfigFile))
+	if idx, _ = rescan(); rules(idx) != 0 {
+		t.Errorf("after removing the plugin: %d rules, want 0", rules(idx))
+	}
+}
@@ -0,0 +1,251 @@
+package index
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+// protoStubSuffixes are the file name endings protoc plugins give generated
+// stubs, used when a stub's header does not name its schema. Longer endings
+// come first so "user_grpc.pb.go" maps to user.proto, not user_grpc.proto.
+var protoStubSuffixes = []string{
+	"_grpc.pb.go", ".pb.gw.go", ".pb.go",
+	"_pb2_grpc.py", "_pb2.pyi", "_pb2.py",
+	"_grpc_pb.d.ts", "_grpc_pb.js", "_pb.d.ts", "_pb.ts", "_pb.js", "_connect.ts",
+}
+
+// protoSourceRe matches the header line naming a stub's schema, as written
+// by protoc-gen-go ("// source: user/v1/user.proto"), the Python generator
+// ("# source: user.proto") and the protobuf-es and protobuf-ts generators
+// ("// @generated from file user/v1/user.proto (package user.v1)").
+var protoSourceRe = regexp.MustCompile(`^(?://|#|\*)\s*(?:source:|@generated from (?:protobuf )?file)\s*"?([^\s"]+\.proto)`)
+
+// protoSource returns the .proto file a generated stub named name was
+// compiled from, as declared in its header or implied by its name, or "" if
+// the file is not a generated stub. The path is relative to the protoc
+// include path, not necessarily to the scanned root.
+func protoSource(name string, content []byte) string {
+	if filepath.Ext(name) == ".proto" {
+		return ""
+	}
+	header := string(content[:min(len(content), 4096)])
+	generated := false
+	for i, line := range strings.Split(header, "\n") {
+		if i >= 30 {
+			break
+		}
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "generated") || strings.Contains(lower, "do not edit") {
+			generated = true
+		}
+		if m := protoSourceRe.FindStringSubmatch(line); m != nil && generated {
+			return m[1]
+		}
+	}
+	for _, suffix := range protoStubSuffixes {
+		if strings.HasSuffix(name, suffix) && len(name) > len(suffix) {
+			return strings.TrimSuffix(name, suffix) + ".proto"
+		}
+	}
+	return ""
+}
+
+// linkProtoStubs points the entries of generated protobuf stubs at the
+// schema definitions they were generated from. A stub's file entry holds
+// the source it declares (see protoSource), which is resolved against the
+// indexed .proto files; each symbol in the stub then gets the location of
+// its definition, or no source if nothing in the schema matches. Entries of
+// a file follow its file entry, as scan produces them.
+func linkProtoStubs(entries []Entry) {
+	var protos []string
+	defs := make(map[string][]parsers.Symbol)
+	for _, e := range entries {
+		if filepath.Ext(e.Path) != ".proto" {
+			continue
+		}
+		if e.Kind == "file" {
+			protos = append(protos, e.Path)
+		} else {
+			defs[e.Path] = append(defs[e.Path], parsers.Symbol{Name: e.Name, Kind: e.Kind, Line: e.Line, Parent: e.Parent})
+		}
+	}
+
+	schema := "" // resolved schema of the current stub
+	for i := range entries {
+		e := &entries[i]
+		if e.Kind == "file" {
+			schema = ""
+			if e.Source != "" {
+				if p := resolveProtoPath(e.Source, e.Path, protos); p != "" {
+					e.Source, schema = p, p
+				}
+			}
+			continue
+		}
+		e.Source = ""
+		if schema == "" {
+			continue
+		}
+		if d := matchProtoSymbol(e.Name, e.Parent, defs[schema]); d != nil {
+			e.Source = fmt.Sprintf("%s:%d", schema, d.Line)
+		}
+	}
+}
+
+// resolveProtoPath returns the indexed .proto file that a stub at stubPath
+// names as src, or "" if there is none. src is relative to the protoc
+// include path, so the indexed path may have extra leading directories; of
+// several candidates the one sharing the most directories with the stub
+// wins.
+func resolveProtoPath(src, stubPath string, protos []string) string {
+	src = filepath.FromSlash(src)
+	best, bestShared := "", -1
+	for _, p := range protos {
+		if p != src && !strings.HasSuffix(p, string(filepath.Separator)+src) {
+			continue
+		}
+		if shared := sharedDirs(filepath.Dir(p), filepath.Dir(stubPath)); shared > bestShared {
+			best, bestShared = p, shared
+		}
+	}
+	return best
+}
+
+// sharedDirs counts the leading directories two relative paths have in
+// common.
+func sharedDirs(a, b string) int {
+	as := strings.Split(a, string(filepath.Separator))
+	bs := strings.Split(b, string(filepath.Separator))
+	n := 0
+	for n < len(as) && n < len(bs) && as[n] == bs[n] && as[n] != "." {
+		n++
+	}
+	return n
+}
+
+// matchProtoSymbol finds the schema definition a generated symbol comes
+// from, following protoc plugin naming. In order of preference:
+//
+//   - messages and enums keep their name, nested ones joined with "_" or
+//     with the enclosing message as parent;
+//   - rpc methods keep their name (lowercased first letter in JS/TS) on a
+//     stub type named after the service, or are embedded after the service
+//     name, as in _UserService_GetUser_Handler;
+//   - message methods such as getters have the message as receiver, and
+//     enum constants are prefixed with the enum name;
+//   - clients, servers and registration functions embed the service name.
+func matchProtoSymbol(name, parent string, defs []parsers.Symbol) *parsers.Symbol {
+	qualified := name
+	if parent != "" {
+		qualified = parent + "." + name
+	}
+	var rpc, member, service *parsers.Symbol
+	memberLen, serviceLen := 0, 0
+	for i := range defs {
+		d := &defs[i]
+		full := d.Name
+		if d.Parent != "" {
+			full = d.Parent + "." + d.Name
+		}
+		switch d.Kind {
+		case "message", "enum":
+			flat := strings.ReplaceAll(full, ".", "_")
+			if qualified == full || (parent == "" && name == flat) {
+				return d
+			}
+			if (parent == full || parent == flat || strings.HasPrefix(name, flat+"_")) && len(flat) > memberLen {
+				member, memberLen = d, len(flat)
+			}
+		case "rpc":
+			if rpc != nil {
+				continue
+			}
+			if strings.EqualFold(name, d.Name) && strings.Contains(strings.ToLower(parent), strings.ToLower(d.Parent)) {
+				rpc = d
+			} else if k := strings.Index(name, d.Parent); k >= 0 && strings.Contains(name[k+len(d.Parent):], "_"+d.Name) {
+				rpc = d
+			}
+		case "service":
+			svc := strings.ToLower(d.Name)
+			if (strings.Contains(strings.ToLower(name), svc) || strings.Contains(strings.ToLower(parent), svc)) && len(svc) > serviceLen {
+				service, serviceLen = d, len(svc)
+			}
+		}
+	}
+	switch {
+	case rpc != nil:
+		return rpc
+	case member != nil:
+		return member
+	}
+	return service
+}
+
+// findSchemaDefinition returns the .proto definition that sym, declared in
+// the generated stub at filePath, was generated from, or nil if the file is
+// not a stub or its schema cannot be found.
+func findSchemaDefinition(filePath string, content []byte, sym parsers.Symbol) *Implementation {
+	src := protoSource(filepath.Base(filePath), content)
+	if src == "" {
+		return nil
+	}
+	path := findProtoFile(filePath, src)
+	if path == "" {
+		return nil
+	}
+	schema, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	defs, err := (&parsers.ProtoParser{}).Parse(path, schema)
+	if err != nil {
+		return nil
+	}
+	if d := matchProtoSymbol(sym.Name, sym.Parent, defs); d != nil {
+		return newImplementation(path, schema, *d)
+	}
+	return nil
+}
+
+// findProtoFile looks for the schema src named by the stub at stubPath
+// without an index: in each directory from the stub's upwards, and in their
+// immediate subdirectories such as proto/ or api/, which protoc is often
+// run from. The search stops at the repository root.
+func findProtoFile(stubPath, src string) string {
+	src = filepath.FromSlash(src)
+	dir, err := filepath.Abs(filepath.Dir(stubPath))
+	if err != nil {
+		return ""
+	}
+	isFile := func(p string) bool {
+		info, err := os.Stat(p)
+		return err == nil && !info.IsDir()
+	}
+	for {
+		if p := filepath.Join(dir, src); isFile(p) {
+			return p
+		}
+		children, _ := os.ReadDir(dir)
+		for _, c := range children {
+			if c.IsDir() && !shouldSkipDir(c.Name()) {
+				if p := filepath.Join(dir, c.Name(), src); isFile(p) {
+					return p
+				}
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,303 @@
+package index
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+const userProto = `syntax = "proto3";
+
+package acme.user.v1;
+
+message User {
+  string id = 1;
+  message Address {
+    string street = 1;
+  }
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+}
+
+service UserService {
+  rpc GetUser(GetUserRequest) returns (User);
+}
+
+message GetUserRequest {
+  string id = 1;
+}
+`
+
+const userPbGo = `// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// source: user/v1/user.proto
+
+package userv1
+
+type User struct {
+	Id string
+}
+
+func (x *User) GetId() string { return x.Id }
+
+type User_Address struct {
+	Street string
+}
+
+type Status int32
+
+const Status_STATUS_UNSPECIFIED Status = 0
+
+type GetUserRequest struct {
+	Id string
+}
+
+func helper() {}
+`
+
+const userGrpcPbGo = `// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// source: user/v1/user.proto
+
+package userv1
+
+const UserService_GetUser_FullMethodName = "/acme.user.v1.UserService/GetUser"
+
+type UserServiceClient interface {
+	GetUser() (*User, error)
+}
+
+type userServiceClient struct{}
+
+func NewUserServiceClient() UserServiceClient { return &userServiceClient{} }
+
+func (c *userServiceClient) GetUser() (*User, error) { return nil, nil }
+
+func _UserService_GetUser_Handler() {}
+`
+
+const userPb2Grpc = `# Generated by the gRPC Python protocol compiler plugin. DO NOT EDIT!
+"""Client and server classes corresponding to protobuf-defined services."""
+
+
+class UserServiceStub(object):
+    def __init__(self, channel):
+        self.GetUser = channel
+
+
+class UserServiceServicer(object):
+    def GetUser(self, request, context):
+        raise NotImplementedError()
+
+
+def add_UserServiceServicer_to_server(servicer, server):
+    pass
+`
+
+func TestProtoSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"user.pb.go", userPbGo, "user/v1/user.proto"},
+		{"user_pb2.py", "# Generated by the protocol buffer compiler.  DO NOT EDIT!\n# source: user.proto\n", "user.proto"},
+		{"user_pb.ts", "// @generated by protoc-gen-es v1.10.0\n// @generated from file user/v1/user.proto (package user.v1, syntax proto3)\n", "user/v1/user.proto"},
+		{"user.ts", "// Code generated by protoc-gen-ts_proto. DO NOT EDIT.\n// source: api/user.proto\n", "api/user.proto"},
+		{"user_pb2_grpc.py", userPb2Grpc, "user.proto"},
+		{"client.go", "// source: user.proto\npackage main\n", ""},
+		{"main.go", "package main\n", ""},
+		{"user.proto", userProto, ""},
+	}
+	for _, tt := range tests {
+		if got := protoSource(tt.name, []byte(tt.content)); got != tt.want {
+			t.Errorf("protoSource(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveProtoPath(t *testing.T) {
+	protos := []string{
+		filepath.FromSlash("proto/user/v1/user.proto"),
+		filepath.FromSlash("legacy/user.proto"),
+		filepath.FromSlash("services/billing/user.proto"),
+	}
+	tests := []struct {
+		src, stub, want string
+	}{
+		{"user/v1/user.proto", "gen/go/user/v1/user.pb.go", "proto/user/v1/user.proto"},
+		{"user.proto", "services/billing/gen/user_pb2.py", "services/billing/user.proto"},
+		{"missing.proto", "gen/missing.pb.go", ""},
+	}
+	for _, tt := range tests {
+		if got := resolveProtoPath(tt.src, filepath.FromSlash(tt.stub), protos); got != filepath.FromSlash(tt.want) {
+			t.Errorf("resolveProtoPath(%q, %q) = %q, want %q", tt.src, tt.stub, got, tt.want)
+		}
+	}
+}
+
+func TestMatchProtoSymbol(t *testing.T) {
+	defs, err := (&parsers.ProtoParser{}).Parse("user.proto", []byte(userProto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, parent string
+		want         string // qualified name of the matched definition
+	}{
+		{"User", "", "User"},
+		{"User_Address", "", "User.Address"},
+		{"Address", "User", "User.Address"},
+		{"GetId", "User", "User"},
+		{"Status_STATUS_UNSPECIFIED", "", "Status"},
+		{"GetUser", "userServiceClient", "UserService.GetUser"},
+		{"getUser", "UserServiceClient", "UserService.GetUser"},
+		{"_UserService_GetUser_Handler", "", "UserService.GetUser"},
+		{"UserService_GetUser_FullMethodName", "", "UserService.GetUser"},
+		{"NewUserServiceClient", "", "UserService"},
+		{"__init__", "UserServiceStub", "UserService"},
+		{"GetUserRequest", "", "GetUserRequest"},
+		{"helper", "", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if d := matchProtoSymbol(tt.name, tt.parent, defs); d != nil {
+			got = d.Name
+			if d.Parent != "" {
+				got = d.Parent + "." + d.Name
+			}
+		}
+		if got != tt.want {
+			t.Errorf("matchProtoSymbol(%q, %q) = %q, want %q", tt.name, tt.parent, got, tt.want)
+		}
+	}
+}
+
+// entrySource returns the source of the entry with the qualified name in
+// path, or of the file entry when qualified is empty.
+func entrySource(idx *Index, path, qualified string) (string, bool) {
+	for _, e := range idx.entries() {
+		if e.Path == filepath.FromSlash(path) && (e.QualifiedName() == qualified || e.Kind == "file" && qualified == "") {
+			return e.Source, true
+		}
+	}
+	return "", false
+}
+
+func TestScanLinksProtoStubs(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "proto/user/v1/user.proto", userProto)
+	mkFile(t, tmp, "gen/go/user/v1/user.pb.go", userPbGo)
+	mkFile(t, tmp, "gen/go/user/v1/user_grpc.pb.go", userGrpcPbGo)
+	mkFile(t, tmp, "gen/py/user/v1/user_pb2_grpc.py", userPb2Grpc)
+	mkFile(t, tmp, "cmd/main.go", "package main\n\nfunc main() {}\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	schema := filepath.FromSlash("proto/user/v1/user.proto")
+	tests := []struct {
+		path, symbol string
+		want         string
+	}{
+		{"gen/go/user/v1/user.pb.go", "", schema},
+		{"gen/go/user/v1/user.pb.go", "User", schema + ":5"},
+		{"gen/go/user/v1/user.pb.go", "User.GetId", schema + ":5"},
+		{"gen/go/user/v1/user.pb.go", "User_Address", schema + ":7"},
+		{"gen/go/user/v1/user.pb.go", "Status", schema + ":12"},
+		{"gen/go/user/v1/user.pb.go", "helper", ""},
+		{"gen/go/user/v1/user_grpc.pb.go", "userServiceClient.GetUser", schema + ":17"},
+		{"gen/go/user/v1/user_grpc.pb.go", "UserServiceClient", schema + ":16"},
+		{"gen/go/user/v1/user_grpc.pb.go", "_UserService_GetUser_Handler", schema + ":17"},
+		{"gen/py/user/v1/user_pb2_grpc.py", "UserServiceServicer.GetUser", schema + ":17"},
+		{"gen/py/user/v1/user_pb2_grpc.py", "add_UserServiceServicer_to_server", schema + ":16"},
+		{"cmd/main.go", "", ""},
+		{"cmd/main.go", "main", ""},
+	}
+	for _, tt := range tests {
+		got, ok := entrySource(idx, tt.path, tt.symbol)
+		if !ok {
+			t.Errorf("no entry %q in %s", tt.symbol, tt.path)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %q: source = %q, want %q", tt.path, tt.symbol, got, tt.want)
+		}
+	}
+
+	var found bool
+	for _, e := range idx.MatchExact("GetUser") {
+		if e.Path == filepath.FromSlash("gen/go/user/v1/user_grpc.pb.go") {
+			found = true
+			if !strings.Contains(e.String(), "generated from "+schema+":17") {
+				t.Errorf("lookup result should show the schema, got %q", e.String())
+			}
+		}
+	}
+	if !found {
+		t.Error("MatchExact(GetUser) missing the generated client method")
+	}
+
+	// Links survive an incremental scan that reuses the stub's entries and
+	// follow the schema when it changes.
+	mkFile(t, tmp, "proto/user/v1/user.proto", "// Users.\n"+userProto)
+	next, _, err := ScanIncremental(tmp, idx)
+	if err != nil {
+		t.Fatalf("ScanIncremental() error: %v", err)
+	}
+	if got, _ := entrySource(next, "gen/go/user/v1/user_grpc.pb.go", "userServiceClient.GetUser"); got != schema+":18" {
+		t.Errorf("after the schema changed, source = %q, want %s:18", got, schema)
+	}
+}
+
+func TestContextGeneratedStubSchema(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, ".git/HEAD", "ref: refs/heads/main\n")
+	mkFile(t, tmp, "proto/user/v1/user.proto", userProto)
+	mkFile(t, tmp, "gen/go/user/v1/user_grpc.pb.go", userGrpcPbGo)
+
+	result, err := Context(filepath.Join(tmp, "gen/go/user/v1/user_grpc.pb.go"), "GetUser")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Schema == nil {
+		t.Fatal("Schema = nil, want the rpc definition")
+	}
+	if result.Schema.File != filepath.Join(tmp, "proto/user/v1/user.proto") || result.Schema.Line != 17 {
+		t.Errorf("Schema = %s:%d, want proto/user/v1/user.proto:17", result.Schema.File, result.Schema.Line)
+	}
+	if !strings.Contains(result.Schema.Body, "rpc GetUser(GetUserRequest) returns (User);") {
+		t.Errorf("Schema.Body = %q", result.Schema.Body)
+	}
+	if out := FormatContext(result); !strings.Contains(out, "Generated from ") {
+		t.Errorf("FormatContext() should point at the schema:\n%s", out)
+	}
+
+	// Hand-written files have no schema.
+	mkFile(t, tmp, "cmd/main.go", "package main\n\nfunc main() {}\n")
+	result, err = Context(filepath.Join(tmp, "cmd/main.go"), "main")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if result.Schema != nil {
+		t.Errorf("Schema = %+v, want nil", result.Schema)
+	}
+}
+
+func TestSQLiteKeepsProtoSource(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "proto/user/v1/user.proto", userProto)
+	mkFile(t, tmp, "gen/go/user/v1/user.pb.go", userPbGo)
+
+	_, loaded := scanAndSave(t, tmp, BackendSQLite)
+	got, ok := entrySource(loaded, "gen/go/user/v1/user.pb.go", "User")
+	if want := filepath.FromSlash("proto/user/v1/user.proto") + ":5"; !ok || got != want {
+		t.Errorf("loaded source = %q, want %q", got, want)
+	}
+}
@@ -13,6 +13,10 @@ type RefMatch struct {
 	Line         int    `json:"line"`
 	Content      string `json:"content"`
 	IsDefinition bool   `json:"isDefinition"`
+	// Kind classifies a type-checked Go reference (see GoRefCall), and
+	// Target is the qualified name of the declaration it resolves to.
+	Kind   string `json:"kind,omitempty"`
+	Target string `json:"target,omitempty"`
 }
 
 // RefsResult holds the definition and all references to a symbol.
@@ -21,6 +25,7 @@ type RefsResult struct {
 	Definition *RefMatch  `json:"definition"`
 	References []RefMatch `json:"references"`
 	TotalRefs  int        `json:"totalReferences"`
+	Typed      bool       `json:"typed,omitempty"` // resolved by the Go type checker, see TypedRefs
 }
 
 // definitionPatterns are regex patterns that indicate a line is a symbol definition.
@@ -41,7 +46,7 @@ var definitionPatterns = []string{
 
 // Refs finds the definition and all references of a symbol across indexed files.
 func (idx *Index) Refs(symbol string, maxResults int) (*RefsResult, error) {
-	wordRe, err := regexp.Compile(`\b` + regexp.QuoteMeta(symbol) + `\b`)
+	wordRe, err := idx.compileRegexp(`\b` + regexp.QuoteMeta(symbol) + `\b`)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +54,7 @@ func (idx *Index) Refs(symbol string, maxResults int) (*RefsResult, error) {
 	// Build definition-detecting regexes.
 	var defRegexes []*regexp.Regexp
 	for _, pat := range definitionPatterns {
-		re, err := regexp.Compile(strings.Replace(pat, "%s", regexp.QuoteMeta(symbol), 1))
+		re, err := idx.compileRegexp(strings.Replace(pat, "%s", regexp.QuoteMeta(symbol), 1))
 		if err != nil {
 			continue
 		}
@@ -59,7 +64,7 @@ func (idx *Index) Refs(symbol string, maxResults int) (*RefsResult, error) {
 	// Check if any index entry is the authoritative definition.
 	var indexDefPath string
 	var indexDefLine int
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Name == symbol && e.Line > 0 {
 			indexDefPath = e.Path
 			indexDefLine = e.Line
@@ -67,22 +72,18 @@ func (idx *Index) Refs(symbol string, maxResults int) (*RefsResult, error) {
 		}
 	}
 
-	paths := idx.FilePaths()
+	paths := idx.contentPaths(wordRe)
 
 	result := &RefsResult{
 		Symbol:     symbol,
 		References: []RefMatch{},
 	}
 
-	for _, p := range paths {
-		if result.TotalRefs >= maxResults {
-			break
-		}
+	parallelCollect(paths, idx.Workers, func(p string) []RefMatch {
 		full := filepath.Join(idx.Root, p)
-		matches, err := refsInFile(full, p, wordRe, defRegexes, indexDefPath, indexDefLine, maxResults-result.TotalRefs)
-		if err != nil {
-			continue
-		}
+		matches, _ := refsInFile(full, p, wordRe, defRegexes, indexDefPath, indexDefLine, maxResults)
+		return matches
+	}, func(matches []RefMatch) bool {
 		for _, m := range matches {
 			if m.IsDefinition && result.Definition == nil {
 				def := m
@@ -94,7 +95,8 @@ func (idx *Index) Refs(symbol string, maxResults int) (*RefsResult, error) {
 				}
 			}
 		}
-	}
+		return result.TotalRefs < maxResults
+	})
 
 	return result, nil
 }
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/mj1618/swarm-index/parsers"
 )
 
 // RelatedResult holds the dependency neighborhood of a file.
@@ -14,16 +17,26 @@ type RelatedResult struct {
 	Imports   []string `json:"imports"`   // files this file imports/requires
 	Importers []string `json:"importers"` // files that import/require this file
 	TestFiles []string `json:"testFiles"` // associated test files
+	// Counterparts pairs a C/C++ header with its implementation files, or
+	// an implementation file with its headers.
+	Counterparts []string `json:"counterparts"`
 }
 
 // importable file extensions with known import syntax.
 var importableExts = map[string]bool{
-	".go":  true,
-	".js":  true,
-	".jsx": true,
-	".ts":  true,
-	".tsx": true,
-	".py":  true,
+	".go":   true,
+	".js":   true,
+	".jsx":  true,
+	".ts":   true,
+	".tsx":  true,
+	".py":   true,
+	".java": true,
+	".kt":   true,
+	".c":    true,
+	".h":    true,
+	".cpp":  true,
+	".hpp":  true,
+	".cc":   true,
 }
 
 // Import extraction regexes.
@@ -41,6 +54,15 @@ var (
 	// Python: from X import ... or import X
 	pyFromImport = regexp.MustCompile(`^\s*from\s+(\S+)\s+import`)
 	pyImport     = regexp.MustCompile(`^\s*import\s+(\S+)`)
+
+	// Rust: [pub] use path;
+	rustUse = regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?use\s+(.*)`)
+
+	// Java/Kotlin: import [static] a.b.C[.*] [as D]
+	jvmImport = regexp.MustCompile(`^\s*import\s+(?:static\s+)?((?:\w+\.)*\w+(?:\.\*)?)`)
+
+	// C/C++: #include "path" or #include <path>
+	cInclude = regexp.MustCompile(`^\s*#\s*include\s*["<]([^">]+)[">]`)
 )
 
 // Related finds files connected to the given file path: imports, importers, and test files.
@@ -57,7 +79,7 @@ func (idx *Index) Related(filePath string) (*RelatedResult, error) {
 
 	// Verify the file exists in the index.
 	found := false
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Path == relPath {
 			found = true
 			break
@@ -82,11 +104,18 @@ func (idx *Index) Related(filePath string) (*RelatedResult, error) {
 	// 3. Find associated test files.
 	testFiles := idx.findTestFiles(relPath, indexedPaths)
 
+	// 4. Pair C/C++ headers with their implementation files.
+	counterparts := findCounterparts(relPath, func(p string) bool { return indexedPaths[p] })
+	if counterparts == nil {
+		counterparts = []string{}
+	}
+
 	return &RelatedResult{
-		File:      relPath,
-		Imports:   imports,
-		Importers: importers,
-		TestFiles: testFiles,
+		File:         relPath,
+		Imports:      imports,
+		Importers:    importers,
+		TestFiles:    testFiles,
+		Counterparts: counterparts,
 	}, nil
 }
 
@@ -115,6 +144,10 @@ func (idx *Index) extractImports(relPath string, indexedPaths map[string]bool) [
 		rawImports = extractJSImports(scanner)
 	case ".py":
 		rawImports = extractPyImports(scanner)
+	case ".java", ".kt":
+		rawImports = extractJVMImports(scanner)
+	case ".c", ".h", ".cpp", ".hpp", ".cc":
+		rawImports = extractCIncludes(scanner)
 	}
 
 	// Resolve raw imports to indexed file paths.
@@ -197,6 +230,54 @@ func extractPyImports(scanner *bufio.Scanner) []string {
 	return imports
 }
 
+// extractRustImports returns the paths of use declarations. Grouped imports
+// that span several lines are joined into one.
+func extractRustImports(scanner *bufio.Scanner) []string {
+	var imports []string
+	var pending []string
+	for scanner.Scan() {
+		trimmed := strings.TrimSpace(scanner.Text())
+		if pending == nil {
+			m := rustUse.FindStringSubmatch(trimmed)
+			if m == nil {
+				continue
+			}
+			trimmed = m[1]
+		}
+		if i := strings.IndexByte(trimmed, ';'); i >= 0 {
+			pending = append(pending, trimmed[:i])
+			imports = append(imports, strings.Join(strings.Fields(strings.Join(pending, " ")), " "))
+			pending = nil
+			continue
+		}
+		pending = append(pending, trimmed)
+	}
+	return imports
+}
+
+// extractJVMImports returns the names imported by Java or Kotlin import
+// statements, without any alias.
+func extractJVMImports(scanner *bufio.Scanner) []string {
+	var imports []string
+	for scanner.Scan() {
+		if m := jvmImport.FindStringSubmatch(scanner.Text()); m != nil {
+			imports = append(imports, m[1])
+		}
+	}
+	return imports
+}
+
+// extractCIncludes returns the paths named by #include directives.
+func extractCIncludes(scanner *bufio.Scanner) []string {
+	var includes []string
+	for scanner.Scan() {
+		if m := cInclude.FindStringSubmatch(scanner.Text()); m != nil {
+			includes = append(includes, m[1])
+		}
+	}
+	return includes
+}
+
 // resolveImport tries to resolve a raw import string to indexed file paths.
 func resolveImport(imp string, ext string, fileDir string, indexedPaths map[string]bool) []string {
 	switch ext {
@@ -206,6 +287,10 @@ func resolveImport(imp string, ext string, fileDir string, indexedPaths map[stri
 		return resolveJSImport(imp, fileDir, indexedPaths)
 	case ".py":
 		return resolvePyImport(imp, fileDir, indexedPaths)
+	case ".java", ".kt":
+		return resolveJVMImport(imp, indexedPaths)
+	case ".c", ".h", ".cpp", ".hpp", ".cc":
+		return resolveCInclude(imp, fileDir, indexedPaths)
 	}
 	return nil
 }
@@ -296,6 +381,107 @@ func resolvePyImport(imp string, fileDir string, indexedPaths map[string]bool) [
 	return nil
 }
 
+// resolveJVMImport resolves a Java or Kotlin import to indexed source files.
+// Packages map onto directories by suffix, so com.example.User matches
+// src/main/java/com/example/User.java. A wildcard import matches every
+// source file in the package directory, and an import of a static member or
+// nested class falls back to the file of its enclosing class.
+func resolveJVMImport(imp string, indexedPaths map[string]bool) []string {
+	isSource := func(p string) bool {
+		ext := filepath.Ext(p)
+		return ext == ".java" || ext == ".kt"
+	}
+	hasPathSuffix := func(p, suffix string) bool {
+		return p == suffix || strings.HasSuffix(p, "/"+suffix)
+	}
+
+	var matches []string
+	if pkg, ok := strings.CutSuffix(imp, ".*"); ok {
+		dir := strings.ReplaceAll(pkg, ".", "/")
+		for p := range indexedPaths {
+			if isSource(p) && hasPathSuffix(filepath.Dir(p), dir) {
+				matches = append(matches, p)
+			}
+		}
+		sort.Strings(matches)
+		return matches
+	}
+
+	// Try the full name, then drop trailing segments (member or nested
+	// class names), keeping at least a package and a class.
+	parts := strings.Split(imp, ".")
+	for n := len(parts); n >= 2; n-- {
+		suffix := strings.Join(parts[:n], "/")
+		for p := range indexedPaths {
+			if isSource(p) && hasPathSuffix(strings.TrimSuffix(p, filepath.Ext(p)), suffix) {
+				matches = append(matches, p)
+			}
+		}
+		if len(matches) > 0 {
+			sort.Strings(matches)
+			return matches
+		}
+	}
+	return nil
+}
+
+// resolveCInclude resolves an #include path relative to the including
+// file's directory, then the project root, then any include directory
+// holding a file with that path suffix.
+func resolveCInclude(imp string, fileDir string, indexedPaths map[string]bool) []string {
+	for _, candidate := range []string{filepath.Join(fileDir, imp), filepath.Clean(imp)} {
+		if indexedPaths[candidate] {
+			return []string{candidate}
+		}
+	}
+	var matches []string
+	for p := range indexedPaths {
+		if strings.HasSuffix(p, "/"+imp) {
+			matches = append(matches, p)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// findCounterparts pairs a C/C++ header with the implementation files of
+// the same name, or an implementation file with its headers. Files are
+// looked for in the same directory and, for the common layout that keeps
+// headers under include/ and sources under src/, in the mirrored directory.
+// exists reports whether a candidate path is present.
+func findCounterparts(path string, exists func(string) bool) []string {
+	ext := filepath.Ext(path)
+	var exts []string
+	from, to := "src/", "include/"
+	switch {
+	case parsers.IsCHeader(ext):
+		exts = []string{".c", ".cpp", ".cc"}
+		from, to = to, from
+	case ext == ".c" || ext == ".cpp" || ext == ".cc":
+		exts = []string{".h", ".hpp"}
+	default:
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	base := strings.TrimSuffix(filepath.Base(path), ext)
+	dirs := []string{dir}
+	if d := filepath.ToSlash(dir) + "/"; strings.Contains("/"+d, "/"+from) {
+		i := strings.LastIndex("/"+d, "/"+from)
+		dirs = append(dirs, filepath.FromSlash(d[:i]+to+d[i+len(from):]))
+	}
+
+	var counterparts []string
+	for _, d := range dirs {
+		for _, e := range exts {
+			if candidate := filepath.Join(d, base+e); exists(candidate) {
+				counterparts = append(counterparts, candidate)
+			}
+		}
+	}
+	return counterparts
+}
+
 // findImporters scans all importable files to find ones that import the target.
 func (idx *Index) findImporters(targetPath string, indexedPaths map[string]bool) []string {
 	var importers []string
@@ -364,6 +550,18 @@ func (idx *Index) findTestFiles(relPath string, indexedPaths map[string]bool) []
 		// Also check tests/ directory.
 		addIfExists(filepath.Join(dir, "tests", "test_"+base+".py"))
 		addIfExists(filepath.Join(dir, "tests", base+"_test.py"))
+
+	case ".java", ".kt":
+		// Java/Kotlin: <Name>Test and <Name>Tests in the same directory or
+		// the mirrored src/test tree of a Maven/Gradle layout.
+		dirs := []string{dir}
+		if strings.Contains(dir+"/", "src/main/") {
+			dirs = append(dirs, strings.Replace(dir+"/", "src/main/", "src/test/", 1))
+		}
+		for _, d := range dirs {
+			addIfExists(filepath.Join(d, base+"Test"+ext))
+			addIfExists(filepath.Join(d, base+"Tests"+ext))
+		}
 	}
 
 	if testFiles == nil {
@@ -378,7 +576,7 @@ func FormatRelated(r *RelatedResult) string {
 
 	b.WriteString(fmt.Sprintf("Related files for %s:\n", r.File))
 
-	if len(r.Imports) == 0 && len(r.Importers) == 0 && len(r.TestFiles) == 0 {
+	if len(r.Imports) == 0 && len(r.Importers) == 0 && len(r.TestFiles) == 0 && len(r.Counterparts) == 0 {
 		b.WriteString("\n  No related files found\n")
 		return b.String()
 	}
@@ -404,5 +602,12 @@ func FormatRelated(r *RelatedResult) string {
 		}
 	}
 
+	if len(r.Counterparts) > 0 {
+		b.WriteString(fmt.Sprintf("\nHeader/source pair (%d):\n", len(r.Counterparts)))
+		for _, p := range r.Counterparts {
+			b.WriteString(fmt.Sprintf("  %s\n", p))
+		}
+	}
+
 	return b.String()
 }
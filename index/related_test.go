@@ -471,3 +471,94 @@ func TestRelatedJSTestsDir(t *testing.T) {
 		t.Errorf("expected __tests__/widget.js in test files, got %v", result.TestFiles)
 	}
 }
+
+func TestRelatedJVMImports(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "src/main/java/com/example/App.java", `package com.example;
+
+import com.example.model.User;
+import static com.example.util.Strings.isBlank;
+import com.example.repo.*;
+import java.util.List;
+`)
+	mkFile(t, tmp, "src/main/java/com/example/model/User.java", `package com.example.model;`)
+	mkFile(t, tmp, "src/main/java/com/example/util/Strings.java", `package com.example.util;`)
+	mkFile(t, tmp, "src/main/kotlin/com/example/repo/UserRepo.kt", `package com.example.repo`)
+	mkFile(t, tmp, "src/main/kotlin/com/example/repo/OrderRepo.kt", `package com.example.repo`)
+	mkFile(t, tmp, "src/test/java/com/example/AppTest.java", `package com.example;`)
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	result, err := idx.Related("src/main/java/com/example/App.java")
+	if err != nil {
+		t.Fatalf("Related() error: %v", err)
+	}
+
+	want := []string{
+		"src/main/java/com/example/model/User.java",
+		"src/main/java/com/example/util/Strings.java",
+		"src/main/kotlin/com/example/repo/OrderRepo.kt",
+		"src/main/kotlin/com/example/repo/UserRepo.kt",
+	}
+	if strings.Join(result.Imports, "|") != strings.Join(want, "|") {
+		t.Errorf("Imports = %v, want %v", result.Imports, want)
+	}
+	if len(result.TestFiles) != 1 || result.TestFiles[0] != "src/test/java/com/example/AppTest.java" {
+		t.Errorf("TestFiles = %v, want the mirrored AppTest.java", result.TestFiles)
+	}
+
+	importers, err := idx.Related("src/main/java/com/example/model/User.java")
+	if err != nil {
+		t.Fatalf("Related() error: %v", err)
+	}
+	if len(importers.Importers) != 1 || importers.Importers[0] != "src/main/java/com/example/App.java" {
+		t.Errorf("Importers = %v, want App.java", importers.Importers)
+	}
+}
+
+func TestRelatedCIncludesAndCounterparts(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "include/geo/shape.h", `#ifndef SHAPE_H
+#define SHAPE_H
+#include "point.h"
+#include <stdio.h>
+#endif
+`)
+	mkFile(t, tmp, "include/geo/point.h", `struct point { int x, y; };`)
+	mkFile(t, tmp, "src/geo/shape.c", `#include "geo/shape.h"`)
+	mkFile(t, tmp, "lib/util.c", `#include "util.h"`)
+	mkFile(t, tmp, "lib/util.h", `int util(void);`)
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	result, err := idx.Related("include/geo/shape.h")
+	if err != nil {
+		t.Fatalf("Related() error: %v", err)
+	}
+	if len(result.Imports) != 1 || result.Imports[0] != "include/geo/point.h" {
+		t.Errorf("Imports = %v, want the sibling point.h", result.Imports)
+	}
+	if len(result.Importers) != 1 || result.Importers[0] != "src/geo/shape.c" {
+		t.Errorf("Importers = %v, want src/geo/shape.c", result.Importers)
+	}
+	if len(result.Counterparts) != 1 || result.Counterparts[0] != "src/geo/shape.c" {
+		t.Errorf("Counterparts = %v, want the mirrored src/geo/shape.c", result.Counterparts)
+	}
+	if out := FormatRelated(result); !strings.Contains(out, "Header/source pair (1):") {
+		t.Errorf("FormatRelated() missing counterparts:\n%s", out)
+	}
+
+	result, err = idx.Related("lib/util.c")
+	if err != nil {
+		t.Fatalf("Related() error: %v", err)
+	}
+	if len(result.Counterparts) != 1 || result.Counterparts[0] != "lib/util.h" {
+		t.Errorf("Counterparts = %v, want lib/util.h", result.Counterparts)
+	}
+}
@@ -0,0 +1,64 @@
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ScanChanges reports how an incremental scan differed from the previous index.
+type ScanChanges struct {
+	Added     []string `json:"added"`
+	Modified  []string `json:"modified"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// ScanIncremental rescans root, re-parsing only files that are new or whose
+// content changed since prev was scanned. Entries for unchanged files are
+// carried over from prev and entries for deleted files are dropped. If prev
+// is nil or was scanned from a different root, a full scan is performed.
+func ScanIncremental(root string, prev *Index) (*Index, *ScanChanges, error) {
+	return ScanWithOptions(root, prev, ScanOptions{})
+}
+
+// ScanWithOptions is ScanIncremental with control over worker count and the
+// file-size cutoff. A nil prev performs a full scan.
+func ScanWithOptions(root string, prev *Index, opts ScanOptions) (*Index, *ScanChanges, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolving path: %w", err)
+	}
+	if prev != nil && prev.Root != absRoot {
+		prev = nil
+	}
+	idx, changes, err := scan(absRoot, prev, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if prev == nil {
+		// Everything is new relative to an empty index.
+		changes.Added = idx.FilePaths()
+		if changes.Added == nil {
+			changes.Added = []string{}
+		}
+	}
+	return idx, changes, nil
+}
+
+// hashContent returns the hex SHA-256 digest of content.
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFile returns the hex SHA-256 digest of the file at path.
+func hashFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return hashContent(content), nil
+}
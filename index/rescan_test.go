@@ -0,0 +1,194 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// saveAndReload saves idx under dir and loads it back.
+func saveAndReload(t *testing.T, idx *Index, dir string) *Index {
+	t.Helper()
+	if err := idx.Save(dir); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	t.Cleanup(func() { loaded.Close() })
+	return loaded
+}
+
+func TestScanRecordsFileHashes(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main\n")
+	mkFile(t, tmp, "README.md", "# hi\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if len(idx.Files) != 2 {
+		t.Fatalf("Files has %d records, want 2", len(idx.Files))
+	}
+	for _, f := range idx.Files {
+		if f.Hash == "" || f.Size == 0 || f.ModTime == 0 {
+			t.Errorf("incomplete file record: %+v", f)
+		}
+	}
+	if got, want := idx.fileRecords()["main.go"].Hash, hashContent([]byte("package main\n")); got != want {
+		t.Errorf("main.go hash = %q, want %q", got, want)
+	}
+}
+
+func TestFileRecordsRoundTrip(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			tmp := t.TempDir()
+			mkFile(t, tmp, "main.go", "package main\n")
+			mkFile(t, tmp, "lib/util.go", "package lib\n")
+
+			idx, err := Scan(tmp)
+			if err != nil {
+				t.Fatalf("Scan() error: %v", err)
+			}
+			idx.Backend = backend
+			loaded := saveAndReload(t, idx, tmp)
+			if !reflect.DeepEqual(loaded.files(), idx.Files) {
+				t.Errorf("files mismatch:\n got %+v\nwant %+v", loaded.Files, idx.Files)
+			}
+		})
+	}
+}
+
+func TestScanIncrementalReparsesOnlyChangedFiles(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "keep.go", "package main\n\nfunc Keep() {}\n")
+	mkFile(t, tmp, "touch.go", "package main\n\nfunc Touch() {}\n")
+	mkFile(t, tmp, "edit.go", "package main\n\nfunc Before() {}\n")
+	mkFile(t, tmp, "gone.go", "package main\n\nfunc Gone() {}\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	prev := saveAndReload(t, idx, tmp)
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(tmp, "touch.go"), future, future); err != nil {
+		t.Fatalf("Chtimes() error: %v", err)
+	}
+	mkFile(t, tmp, "edit.go", "package main\n\nfunc After() {}\n")
+	mkFile(t, tmp, "new.go", "package main\n\nfunc Fresh() {}\n")
+	if err := os.Remove(filepath.Join(tmp, "gone.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	next, changes, err := ScanIncremental(tmp, prev)
+	if err != nil {
+		t.Fatalf("ScanIncremental() error: %v", err)
+	}
+
+	if !reflect.DeepEqual(changes.Added, []string{"new.go"}) {
+		t.Errorf("Added = %v, want [new.go]", changes.Added)
+	}
+	if !reflect.DeepEqual(changes.Modified, []string{"edit.go"}) {
+		t.Errorf("Modified = %v, want [edit.go]", changes.Modified)
+	}
+	if !reflect.DeepEqual(changes.Removed, []string{"gone.go"}) {
+		t.Errorf("Removed = %v, want [gone.go]", changes.Removed)
+	}
+	if changes.Unchanged != 2 {
+		t.Errorf("Unchanged = %d, want 2 (keep.go, touch.go)", changes.Unchanged)
+	}
+
+	names := map[string]bool{}
+	for _, e := range next.Entries {
+		names[e.Name] = true
+	}
+	for _, want := range []string{"Keep", "Touch", "After", "Fresh"} {
+		if !names[want] {
+			t.Errorf("missing entry %q after incremental scan", want)
+		}
+	}
+	for _, gone := range []string{"Before", "Gone", "gone.go"} {
+		if names[gone] {
+			t.Errorf("entry %q should have been dropped", gone)
+		}
+	}
+
+	// The touched file's record picks up its new mtime so the next scan
+	// takes the fast path.
+	if got := next.fileRecords()["touch.go"].ModTime; got != future.UnixNano() {
+		t.Errorf("touch.go ModTime = %d, want %d", got, future.UnixNano())
+	}
+}
+
+func TestScanIncrementalMatchesFullScan(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "a.go", "package main\n\nfunc A() {}\n")
+	mkFile(t, tmp, "lib/b.go", "package lib\n\nfunc B() {}\n")
+	mkFile(t, tmp, "lib/c.py", "def c():\n    pass\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	prev := saveAndReload(t, idx, tmp)
+
+	mkFile(t, tmp, "lib/b.go", "package lib\n\nfunc B2() {}\n")
+
+	next, _, err := ScanIncremental(tmp, prev)
+	if err != nil {
+		t.Fatalf("ScanIncremental() error: %v", err)
+	}
+	full, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if !reflect.DeepEqual(next.Entries, full.Entries) {
+		t.Errorf("incremental entries differ from full scan:\n got %+v\nwant %+v", next.Entries, full.Entries)
+	}
+}
+
+func TestScanIncrementalWithoutPrevious(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main\n")
+
+	idx, changes, err := ScanIncremental(tmp, nil)
+	if err != nil {
+		t.Fatalf("ScanIncremental() error: %v", err)
+	}
+	if idx.FileCount() != 1 {
+		t.Errorf("FileCount() = %d, want 1", idx.FileCount())
+	}
+	if !reflect.DeepEqual(changes.Added, []string{"main.go"}) {
+		t.Errorf("Added = %v, want [main.go]", changes.Added)
+	}
+}
+
+func TestScanIncrementalDifferentRootIsFullScan(t *testing.T) {
+	other := t.TempDir()
+	mkFile(t, other, "other.go", "package other\n")
+	prev, err := Scan(other)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main\n")
+
+	_, changes, err := ScanIncremental(tmp, prev)
+	if err != nil {
+		t.Fatalf("ScanIncremental() error: %v", err)
+	}
+	if len(changes.Removed) != 0 {
+		t.Errorf("Removed = %v, want none when roots differ", changes.Removed)
+	}
+	if !reflect.DeepEqual(changes.Added, []string{"main.go"}) {
+		t.Errorf("Added = %v, want [main.go]", changes.Added)
+	}
+}
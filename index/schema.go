@@ -0,0 +1,49 @@
+package index
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// SchemaVersion is the layout of the index written by Save. Bump it whenever
+// an index written by an older binary would lack data that commands rely on,
+// including the symbols of files a newly added parser handles: incremental
+// scans reuse the entries of unchanged files, so only a migration re-parses
+// them.
+//
+//	0  unversioned: entries, possibly without file records, symbol metadata
+//	   or trigrams.bin
+//	1  entries with symbol metadata, file records, trigrams.bin
+//	2  diagnostics.json with the scan's parse errors and skipped files
+//	3  entries of generated protobuf stubs linked to their schema (source)
+//	4  symbols from GraphQL, OpenAPI, Terraform, Dockerfile, Compose,
+//	   Kubernetes, Markdown and reStructuredText files
+//	5  abstract flag on interface and abstract members
+const SchemaVersion = 5
+
+// migrationNotice is where migrateIndex announces a rescan, since it can
+// make a simple query take as long as a scan.
+var migrationNotice io.Writer = os.Stderr
+
+// migrateIndex brings an index saved with an older schema up to date. None of
+// the older layouts can be upgraded in place, since the missing data comes
+// from the source files, so the root is rescanned, with a one-line notice on
+// stderr, and the result saved over the old index with the same store. It
+// reports false without error when the root no longer exists, leaving the
+// old index for the caller to load as-is; doctor reports both problems.
+func migrateIndex(dir string, meta *indexMeta) (bool, error) {
+	if info, err := os.Stat(meta.Root); err != nil || !info.IsDir() {
+		return false, nil
+	}
+	fmt.Fprintf(migrationNotice, "swarm-index: the index has schema version %d, older than %d; rescanning %s first (run 'swarm-index scan' after upgrading to avoid this)\n", meta.Schema, SchemaVersion, meta.Root)
+	idx, _, err := ScanWithOptions(meta.Root, nil, ScanOptions{})
+	if err != nil {
+		return false, fmt.Errorf("migrating index from schema %d: %w", meta.Schema, err)
+	}
+	idx.Backend = meta.Backend
+	if err := idx.Save(dir); err != nil {
+		return false, fmt.Errorf("migrating index from schema %d: %w", meta.Schema, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,150 @@
+package index
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLegacyIndex saves an unversioned, entries-only index of root under dir,
+// as binaries before schema versioning did.
+func writeLegacyIndex(t *testing.T, dir, root string) {
+	t.Helper()
+	captureMigrationNotice(t)
+	mkFile(t, dir, "swarm/index/index.json", `[{"name":"a.go","kind":"file","path":"a.go","line":0,"package":"(root)"}]`)
+	mkFile(t, dir, "swarm/index/meta.json", `{"root":"`+root+`","scannedAt":"2024-01-01T00:00:00Z","version":"0.1.0"}`)
+}
+
+// captureMigrationNotice collects the notices migrations print for the
+// rest of the test.
+func captureMigrationNotice(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := migrationNotice
+	migrationNotice = &buf
+	t.Cleanup(func() { migrationNotice = old })
+	return &buf
+}
+
+func TestSaveWritesSchemaVersion(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "a.go", "package a\n")
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := readMeta(filepath.Join(tmp, "swarm", "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Schema != SchemaVersion {
+		t.Errorf("schemaVersion = %d, want %d", meta.Schema, SchemaVersion)
+	}
+}
+
+func TestLoadMigratesOlderSchema(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "a.go", "package a\n\nfunc Alpha() {}\n")
+	writeLegacyIndex(t, tmp, tmp)
+	notice := captureMigrationNotice(t)
+
+	idx, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	defer idx.Close()
+	if got := notice.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "schema version 0") || !strings.Contains(got, "rescanning "+tmp) {
+		t.Errorf("migration notice = %q, want one line naming the old schema and the root", got)
+	}
+
+	meta, err := readMeta(filepath.Join(tmp, "swarm", "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Schema != SchemaVersion {
+		t.Errorf("migrated schemaVersion = %d, want %d", meta.Schema, SchemaVersion)
+	}
+	var found bool
+	for _, e := range idx.entries() {
+		if e.Name == "Alpha" && e.Signature == "func Alpha()" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("migration did not rescan symbols with metadata")
+	}
+	if len(idx.files()) != 1 || idx.trigrams() == nil {
+		t.Error("migration did not write file records and trigrams")
+	}
+}
+
+func TestLoadMigratesSchemaBeforeNewParsers(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "api/schema.graphql", "type Query {\n  user: String\n}\n")
+	mkFile(t, tmp, "infra/main.tf", "resource \"aws_s3_bucket\" \"logs\" {\n}\n")
+	mkFile(t, tmp, "docs/guide.md", "# Guide\n")
+	// Schema 3 binaries indexed these files by name only.
+	mkFile(t, tmp, "swarm/index/index.json", `[
+{"name":"schema.graphql","kind":"file","path":"api/schema.graphql","line":0,"package":"api"},
+{"name":"main.tf","kind":"file","path":"infra/main.tf","line":0,"package":"infra"},
+{"name":"guide.md","kind":"file","path":"docs/guide.md","line":0,"package":"docs"}]`)
+	mkFile(t, tmp, "swarm/index/meta.json", `{"root":"`+tmp+`","scannedAt":"2024-01-01T00:00:00Z","version":"0.1.0","schemaVersion":3}`)
+	captureMigrationNotice(t)
+
+	idx, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	defer idx.Close()
+	symbols := make(map[string]bool)
+	for _, e := range idx.entries() {
+		if e.Kind != "file" {
+			symbols[filepath.ToSlash(e.Path)] = true
+		}
+	}
+	for _, p := range []string{"api/schema.graphql", "infra/main.tf", "docs/guide.md"} {
+		if !symbols[p] {
+			t.Errorf("migration did not parse %s", p)
+		}
+	}
+}
+
+func TestLoadKeepsOlderSchemaWhenRootIsGone(t *testing.T) {
+	tmp := t.TempDir()
+	writeLegacyIndex(t, tmp, filepath.Join(tmp, "moved-away"))
+	notice := captureMigrationNotice(t)
+
+	idx, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if notice.Len() != 0 {
+		t.Errorf("migration notice = %q, want none when nothing is rescanned", notice.String())
+	}
+	if len(idx.Entries) != 1 {
+		t.Errorf("loaded %d entries, want the legacy index's 1", len(idx.Entries))
+	}
+	meta, _ := readMeta(filepath.Join(tmp, "swarm", "index"))
+	if meta.Schema != 0 {
+		t.Errorf("schemaVersion = %d, want the index left untouched", meta.Schema)
+	}
+}
+
+func TestLoadRejectsNewerSchema(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "swarm/index/index.json", `[]`)
+	mkFile(t, tmp, "swarm/index/meta.json", `{"root":"/x","scannedAt":"2024-01-01T00:00:00Z","version":"9.0.0","schemaVersion":99}`)
+
+	_, err := Load(tmp)
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("Load() error = %v, want newer-schema error", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(tmp, "swarm", "index", "index.json")); statErr != nil {
+		t.Error("rejected index was modified")
+	}
+}
@@ -39,7 +39,7 @@ func (idx *Index) Scope(dir string, recursive bool) (*ScopeResult, error) {
 
 	// Collect file paths within the target directory.
 	var filePaths []string
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Kind != "file" {
 			continue
 		}
@@ -86,8 +86,7 @@ func (idx *Index) Scope(dir string, recursive bool) (*ScopeResult, error) {
 		}
 
 		// Parse symbols.
-		ext := filepath.Ext(relPath)
-		p := parsers.ForExtension(ext)
+		p := parsers.ForFile(relPath)
 		if p == nil {
 			continue
 		}
@@ -17,29 +17,45 @@ type SearchMatch struct {
 // Search finds lines matching a regex pattern across all indexed files.
 // It returns up to maxResults matches. Binary files are skipped.
 func (idx *Index) Search(pattern string, maxResults int) ([]SearchMatch, error) {
-	re, err := regexp.Compile(pattern)
+	re, err := idx.compileRegexp(pattern)
 	if err != nil {
 		return nil, err
 	}
 
-	paths := idx.FilePaths()
+	paths := idx.contentPaths(re)
 
 	var matches []SearchMatch
-	for _, p := range paths {
-		if len(matches) >= maxResults {
-			break
-		}
-		full := filepath.Join(idx.Root, p)
-		m, err := searchFile(full, p, re, maxResults-len(matches))
-		if err != nil {
-			continue // skip files we can't read
-		}
-		matches = append(matches, m...)
-	}
+	parallelCollect(paths, idx.Workers, func(p string) []SearchMatch {
+		m, _ := searchFile(filepath.Join(idx.Root, p), p, re, maxResults) // skip files we can't read
+		return m
+	}, func(m []SearchMatch) bool {
+		matches = append(matches, m[:min(len(m), maxResults-len(matches))]...)
+		return len(matches) < maxResults
+	})
 
 	return matches, nil
 }
 
+// maxCachedRegexps bounds the compiled-pattern cache of a long-lived index.
+const maxCachedRegexps = 256
+
+// compileRegexp compiles pattern, reusing earlier compilations so that an
+// index kept in memory by a Server does not recompile repeated queries.
+func (idx *Index) compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := idx.regexps[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if idx.regexps == nil || len(idx.regexps) >= maxCachedRegexps {
+		idx.regexps = make(map[string]*regexp.Regexp)
+	}
+	idx.regexps[pattern] = re
+	return re, nil
+}
+
 // searchFile scans a single file for regex matches, returning up to limit results.
 // Binary files (containing null bytes in the first 512 bytes) are skipped.
 func searchFile(fullPath, relPath string, re *regexp.Regexp, limit int) ([]SearchMatch, error) {
@@ -1,6 +1,7 @@
 package index
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -149,3 +150,28 @@ func TestSearchInvalidRegex(t *testing.T) {
 		t.Fatal("Search() should return error for invalid regex")
 	}
 }
+
+func TestSearchMaxResultsKeepsFileOrder(t *testing.T) {
+	tmp := t.TempDir()
+	for i := 0; i < 50; i++ {
+		mkFile(t, tmp, fmt.Sprintf("f%02d.txt", i), "needle\nneedle\n")
+	}
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	matches, err := idx.Search("needle", 5)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	want := []string{"f00.txt", "f00.txt", "f01.txt", "f01.txt", "f02.txt"}
+	if len(matches) != len(want) {
+		t.Fatalf("got %d matches, want %d", len(matches), len(want))
+	}
+	for i, m := range matches {
+		if m.Path != want[i] {
+			t.Errorf("match %d in %s, want %s", i, m.Path, want[i])
+		}
+	}
+}
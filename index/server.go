@@ -0,0 +1,508 @@
+package index
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// RPCRequest is a JSON-RPC 2.0 request. Requests without an ID are
+// notifications and receive no response.
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCResponse is a JSON-RPC 2.0 response carrying either a result or an error.
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError describes a failed JSON-RPC request.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// RPCParams holds the parameters accepted by server methods. Each method reads
+// the fields it needs; zero values fall back to the CLI defaults. Relative
+// file and directory paths are resolved against the index root.
+type RPCParams struct {
+	Query     string `json:"query,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	Symbol    string `json:"symbol,omitempty"`
+	Target    string `json:"target,omitempty"`
+	File      string `json:"file,omitempty"`
+	Dir       string `json:"dir,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Ref       string `json:"ref,omitempty"`
+	Since     string `json:"since,omitempty"`
+	Focus     string `json:"focus,omitempty"`
+	Max       int    `json:"max,omitempty"`
+	Depth     int    `json:"depth,omitempty"`
+	Min       int    `json:"min,omitempty"`
+	StartLine int    `json:"startLine,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	Exact     bool   `json:"exact,omitempty"`
+	Recursive bool   `json:"recursive,omitempty"`
+	Untested  bool   `json:"untested,omitempty"`
+	Tested    bool   `json:"tested,omitempty"`
+	Types     bool   `json:"types,omitempty"`
+}
+
+// Server keeps an index in memory and answers queries against it. Calls are
+// serialized, so a single Server can be shared by many connections.
+type Server struct {
+	mu      sync.Mutex
+	dir     string // directory the index was loaded from
+	idx     *Index
+	workers int // see SetWorkers
+}
+
+// NewServer loads the index saved under dir and returns a server answering
+// queries against it.
+func NewServer(dir string) (*Server, error) {
+	idx, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{dir: dir, idx: idx}, nil
+}
+
+// SetWorkers sets the number of goroutines commands that read file contents
+// use, for this index and any reloaded one; 0 means GOMAXPROCS.
+func (s *Server) SetWorkers(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workers = n
+	s.idx.Workers = n
+}
+
+// Close releases the server's index.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.idx.Close()
+}
+
+// Methods returns the names of all methods the server answers, in the order
+// they are documented.
+func Methods() []string {
+	names := make([]string, len(serverMethods))
+	for i, m := range serverMethods {
+		names[i] = m.name
+	}
+	return names
+}
+
+type serverMethod struct {
+	name   string
+	desc   string
+	params string // space-separated RPCParams names; a trailing ! marks required
+	call   func(idx *Index, p RPCParams) (any, error)
+}
+
+// serverMethods maps method names to index queries. Results are the same
+// structs the CLI prints with --json.
+var serverMethods = []serverMethod{
+	{"lookup", "Look up files and symbols by name, fuzzy-ranked by relevance.", "query! max exact", func(idx *Index, p RPCParams) (any, error) {
+		if p.Query == "" {
+			return nil, errMissing("query")
+		}
+		max := orDefault(p.Max, 20)
+		if p.Exact {
+			entries := idx.MatchExact(p.Query)
+			if len(entries) > max {
+				entries = entries[:max]
+			}
+			if entries == nil {
+				entries = []Entry{}
+			}
+			return entries, nil
+		}
+		scored := idx.MatchScored(p.Query)
+		if len(scored) > max {
+			scored = scored[:max]
+		}
+		if scored == nil {
+			scored = []ScoredEntry{}
+		}
+		return scored, nil
+	}},
+	{"search", "Regex search across indexed file contents.", "pattern! max", func(idx *Index, p RPCParams) (any, error) {
+		if p.Pattern == "" {
+			return nil, errMissing("pattern")
+		}
+		matches, err := idx.Search(p.Pattern, orDefault(p.Max, 50))
+		if matches == nil && err == nil {
+			matches = []SearchMatch{}
+		}
+		return matches, err
+	}},
+	{"locate", "Unified search across filenames, symbols, and file contents.", "query! max", func(idx *Index, p RPCParams) (any, error) {
+		if p.Query == "" {
+			return nil, errMissing("query")
+		}
+		return idx.Locate(p.Query, orDefault(p.Max, 20))
+	}},
+	{"symbols", "Search indexed symbols by name, optionally filtered by kind.", "query! kind max", func(idx *Index, p RPCParams) (any, error) {
+		if p.Query == "" {
+			return nil, errMissing("query")
+		}
+		return idx.Symbols(p.Query, p.Kind, orDefault(p.Max, 50))
+	}},
+	{"refs", "Find the definition and all references of a symbol.", "symbol! max types", func(idx *Index, p RPCParams) (any, error) {
+		if p.Symbol == "" {
+			return nil, errMissing("symbol")
+		}
+		if p.Types {
+			return idx.TypedRefs(p.Symbol, orDefault(p.Max, 50))
+		}
+		return idx.Refs(p.Symbol, orDefault(p.Max, 50))
+	}},
+	{"impact", "Blast radius of a symbol or file: transitive references or importers.", "target! depth max types", func(idx *Index, p RPCParams) (any, error) {
+		if p.Target == "" {
+			return nil, errMissing("target")
+		}
+		if p.Types {
+			return idx.TypedImpact(p.Target, orDefault(p.Depth, 3), orDefault(p.Max, 100))
+		}
+		return idx.Impact(p.Target, orDefault(p.Depth, 3), orDefault(p.Max, 100))
+	}},
+	{"callers", "Go call sites that call a function or method, from the static call graph.", "symbol! max", func(idx *Index, p RPCParams) (any, error) {
+		if p.Symbol == "" {
+			return nil, errMissing("symbol")
+		}
+		return idx.Callers(p.Symbol, orDefault(p.Max, 100))
+	}},
+	{"callees", "Go functions and methods a function or method calls.", "symbol! max", func(idx *Index, p RPCParams) (any, error) {
+		if p.Symbol == "" {
+			return nil, errMissing("symbol")
+		}
+		return idx.Callees(p.Symbol, orDefault(p.Max, 100))
+	}},
+	{"implementations", "Types that implement an interface (Go method sets) or extend a class or interface.", "symbol! max", func(idx *Index, p RPCParams) (any, error) {
+		if p.Symbol == "" {
+			return nil, errMissing("symbol")
+		}
+		return idx.Implementations(p.Symbol, orDefault(p.Max, 100))
+	}},
+	{"supertypes", "Interfaces a type implements and the base types it extends or embeds.", "symbol! max", func(idx *Index, p RPCParams) (any, error) {
+		if p.Symbol == "" {
+			return nil, errMissing("symbol")
+		}
+		return idx.Supertypes(p.Symbol, orDefault(p.Max, 100))
+	}},
+	{"summary", "Project overview: languages, file count, LOC, entry points, manifests.", "", func(idx *Index, p RPCParams) (any, error) {
+		return idx.Summary(), nil
+	}},
+	{"tree", "Directory structure of the project.", "dir depth", func(idx *Index, p RPCParams) (any, error) {
+		return BuildTree(idx.resolvePath(orDefaultString(p.Dir, ".")), p.Depth)
+	}},
+	{"show", "Read a file with line numbers, optionally a line range.", "file! startLine endLine", func(idx *Index, p RPCParams) (any, error) {
+		if p.File == "" {
+			return nil, errMissing("file")
+		}
+		return ShowFile(idx.resolvePath(p.File), p.StartLine, p.EndLine)
+	}},
+	{"outline", "Top-level symbols of a file with signatures.", "file!", func(idx *Index, p RPCParams) (any, error) {
+		if p.File == "" {
+			return nil, errMissing("file")
+		}
+		content, err := os.ReadFile(idx.resolvePath(p.File))
+		if err != nil {
+			return nil, err
+		}
+		ext := filepath.Ext(p.File)
+		parser := parsers.ForFile(p.File)
+		if parser == nil {
+			return nil, fmt.Errorf("no parser available for %s files", ext)
+		}
+		symbols, err := parser.Parse(p.File, content)
+		if symbols == nil && err == nil {
+			symbols = []parsers.Symbol{}
+		}
+		return symbols, err
+	}},
+	{"context", "A symbol's full definition with file imports and doc comments.", "symbol! file! types", func(idx *Index, p RPCParams) (any, error) {
+		if p.Symbol == "" || p.File == "" {
+			return nil, errMissing("symbol and file")
+		}
+		if p.Types {
+			return idx.TypedContext(idx.resolvePath(p.File), p.Symbol)
+		}
+		return Context(idx.resolvePath(p.File), p.Symbol)
+	}},
+	{"exports", "Exported/public symbols of a file or directory.", "path!", func(idx *Index, p RPCParams) (any, error) {
+		if p.Path == "" {
+			return nil, errMissing("path")
+		}
+		return idx.Exports(p.Path)
+	}},
+	{"related", "Imports, importers, and test files of a file.", "file!", func(idx *Index, p RPCParams) (any, error) {
+		if p.File == "" {
+			return nil, errMissing("file")
+		}
+		return idx.Related(p.File)
+	}},
+	{"graph", "Import dependency graph, optionally focused on one file.", "focus depth", func(idx *Index, p RPCParams) (any, error) {
+		if p.Focus != "" {
+			return idx.GraphFocused(p.Focus, p.Depth)
+		}
+		return idx.Graph(), nil
+	}},
+	{"todos", "TODO/FIXME/HACK/XXX comments.", "tag max", func(idx *Index, p RPCParams) (any, error) {
+		return idx.Todos(p.Tag, orDefault(p.Max, 100))
+	}},
+	{"deps", "Dependencies declared in manifest files.", "", func(idx *Index, p RPCParams) (any, error) {
+		return idx.Deps()
+	}},
+	{"entry-points", "Main functions, route handlers, CLI commands, init code, and API operations.", "kind max", func(idx *Index, p RPCParams) (any, error) {
+		return idx.EntryPoints(p.Kind, orDefault(p.Max, 100))
+	}},
+	{"config", "Detected toolchain: language, framework, build/test/lint tools.", "", func(idx *Index, p RPCParams) (any, error) {
+		return idx.Config()
+	}},
+	{"services", "Runtime topology from Compose files, Kubernetes manifests, and Dockerfiles: service, image, command, ports, env.", "", func(idx *Index, p RPCParams) (any, error) {
+		return idx.Services()
+	}},
+	{"complexity", "Cyclomatic complexity per function, for one file or the project.", "file max min", func(idx *Index, p RPCParams) (any, error) {
+		max := orDefault(p.Max, 20)
+		if p.File != "" {
+			return ComplexityFile(idx.resolvePath(p.File), max, p.Min)
+		}
+		return idx.Complexity("", max, p.Min)
+	}},
+	{"scope", "Summary of a directory: files, symbols, LOC, dependencies.", "dir! recursive", func(idx *Index, p RPCParams) (any, error) {
+		if p.Dir == "" {
+			return nil, errMissing("dir")
+		}
+		return idx.Scope(p.Dir, p.Recursive)
+	}},
+	{"dead-code", "Exported symbols with no references outside their definition.", "kind path max", func(idx *Index, p RPCParams) (any, error) {
+		return idx.DeadCode(p.Kind, p.Path, orDefault(p.Max, 50))
+	}},
+	{"test-map", "Source-to-test-file mapping.", "path untested tested max", func(idx *Index, p RPCParams) (any, error) {
+		return idx.TestMap(p.Path, p.Untested, p.Tested, orDefault(p.Max, 100))
+	}},
+	{"stale", "New, deleted, and modified files since the last scan.", "", func(idx *Index, p RPCParams) (any, error) {
+		return idx.Stale()
+	}},
+	{"doctor", "Integrity checks on the saved index: schema, root, missing files, symbol lines, and counts.", "", func(idx *Index, p RPCParams) (any, error) {
+		return Doctor(filepath.Dir(filepath.Dir(idx.dir)))
+	}},
+	{"scan-report", "Parse errors and files the last scan skipped or could not read.", "kind path max", func(idx *Index, p RPCParams) (any, error) {
+		return idx.ScanReport(p.Kind, p.Path, orDefault(p.Max, 100))
+	}},
+	{"doc-refs", "Backticked identifiers, relative links, and heading anchors in Markdown and reStructuredText docs that no longer resolve.", "kind path max", func(idx *Index, p RPCParams) (any, error) {
+		return idx.DocRefs(p.Kind, p.Path, orDefault(p.Max, 100))
+	}},
+	{"diff-summary", "Files changed since a git ref, with affected symbols.", "ref", func(idx *Index, p RPCParams) (any, error) {
+		return idx.DiffSummary(idx.Root, orDefaultString(p.Ref, "HEAD~1"))
+	}},
+	{"blame", "Git blame for a file or line range.", "file! startLine endLine", func(idx *Index, p RPCParams) (any, error) {
+		if p.File == "" {
+			return nil, errMissing("file")
+		}
+		return Blame(idx.Root, p.File, p.StartLine, p.EndLine)
+	}},
+	{"history", "Recent git commits that touched a file.", "file! max", func(idx *Index, p RPCParams) (any, error) {
+		if p.File == "" {
+			return nil, errMissing("file")
+		}
+		return History(idx.Root, p.File, orDefault(p.Max, 10))
+	}},
+	{"hotspots", "Files ranked by git commit frequency.", "since path max", func(idx *Index, p RPCParams) (any, error) {
+		return idx.Hotspots(idx.Root, orDefault(p.Max, 20), p.Since, p.Path)
+	}},
+}
+
+// errInvalidParams marks errors that should be reported as invalid params.
+var errInvalidParams = errors.New("invalid params")
+
+func errMissing(name string) error {
+	return fmt.Errorf("%w: missing %s", errInvalidParams, name)
+}
+
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+func orDefaultString(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// resolvePath joins relative paths onto the index root.
+func (idx *Index) resolvePath(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(idx.Root, p)
+}
+
+// Call runs a single method with JSON-encoded params and returns its result.
+// The special method "reload" re-reads the index from disk. A method that
+// panics, such as on a parser bug, fails with a server error and leaves the
+// session running.
+func (s *Server) Call(method string, params json.RawMessage) (result any, rpcErr *RPCError) {
+	var p RPCParams
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer recoverCall(method, &rpcErr)
+
+	if method == "reload" {
+		return s.reload()
+	}
+	for _, m := range serverMethods {
+		if m.name != method {
+			continue
+		}
+		result, err := m.call(s.idx, p)
+		if err != nil {
+			code := rpcServerError
+			if errors.Is(err, errInvalidParams) {
+				code = rpcInvalidParams
+			}
+			return nil, &RPCError{Code: code, Message: err.Error()}
+		}
+		return result, nil
+	}
+	return nil, &RPCError{Code: rpcMethodNotFound, Message: "method not found: " + method}
+}
+
+// recoverCall, deferred, turns a panic in method into a server error.
+func recoverCall(method string, rpcErr **RPCError) {
+	if r := recover(); r != nil {
+		*rpcErr = &RPCError{Code: rpcServerError, Message: fmt.Sprintf("%s: internal error: %v", method, r)}
+	}
+}
+
+// reload replaces the in-memory index with a fresh copy from disk.
+func (s *Server) reload() (any, *RPCError) {
+	idx, err := Load(s.dir)
+	if err != nil {
+		return nil, &RPCError{Code: rpcServerError, Message: err.Error()}
+	}
+	s.idx.Close()
+	idx.Workers = s.workers
+	s.idx = idx
+	return map[string]any{"files": idx.FileCount(), "scannedAt": idx.ScannedAt}, nil
+}
+
+// Handle answers one request. It returns nil for notifications.
+func (s *Server) Handle(req RPCRequest) *RPCResponse {
+	resp := &RPCResponse{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &RPCError{Code: rpcInvalidRequest, Message: "invalid request"}
+	} else {
+		resp.Result, resp.Error = s.Call(req.Method, req.Params)
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+	return resp
+}
+
+// Serve reads newline-delimited JSON-RPC requests from r and writes one
+// response line per request to w until r is exhausted.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	return serveJSONLines(r, w, s.Handle)
+}
+
+// serveJSONLines runs handle on each newline-delimited JSON-RPC request read
+// from r, writing any responses to w.
+func serveJSONLines(r io.Reader, w io.Writer, handle func(RPCRequest) *RPCResponse) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req RPCRequest
+		var resp *RPCResponse
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp = &RPCResponse{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &RPCError{Code: rpcParseError, Message: err.Error()},
+			}
+		} else {
+			resp = handle(req)
+		}
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ServeUnix listens on a Unix domain socket at path and serves each
+// connection until the listener fails. A stale socket file is replaced.
+func (s *Server) ServeUnix(path string) error {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	return s.serveListener(ln)
+}
+
+func (s *Server) serveListener(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			s.Serve(conn, conn)
+		}()
+	}
+}
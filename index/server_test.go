@@ -0,0 +1,265 @@
+package index
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer scans a small project, saves it, and serves it.
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	tmp := makeStoreFixture(t)
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	s, err := NewServer(tmp)
+	if err != nil {
+		t.Fatalf("NewServer() error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, tmp
+}
+
+// serveLines feeds newline-delimited requests to s and returns the decoded responses.
+func serveLines(t *testing.T, s *Server, lines ...string) []RPCResponse {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+	var resps []RPCResponse
+	dec := json.NewDecoder(strings.NewReader(out.String()))
+	for dec.More() {
+		var r RPCResponse
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("decoding response: %v\n%s", err, out.String())
+		}
+		resps = append(resps, r)
+	}
+	return resps
+}
+
+func TestServerCallMatchesDirectQuery(t *testing.T) {
+	s, tmp := newTestServer(t)
+
+	got, rpcErr := s.Call("symbols", json.RawMessage(`{"query":"auth"}`))
+	if rpcErr != nil {
+		t.Fatalf("Call(symbols) error: %v", rpcErr.Message)
+	}
+	idx, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want, err := idx.Symbols("auth", "", 50)
+	if err != nil {
+		t.Fatalf("Symbols() error: %v", err)
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("symbols result = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestServerAnswersEveryMethod(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	params := map[string]string{
+		"lookup":  `{"query":"auth"}`,
+		"search":  `{"pattern":"func"}`,
+		"locate":  `{"query":"handler"}`,
+		"symbols": `{"query":"auth"}`,
+		"refs":    `{"symbol":"HandleAuth"}`,
+		"impact":  `{"target":"HandleAuth"}`,
+		"callers": `{"symbol":"HandleAuth"}`,
+		"callees": `{"symbol":"HandleAuth"}`,
+		"show":    `{"file":"main.go","startLine":1,"endLine":2}`,
+		"outline": `{"file":"main.go"}`,
+		"context": `{"symbol":"HandleAuth","file":"main.go"}`,
+		"exports": `{"path":"api"}`,
+		"related": `{"file":"main.go"}`,
+		"scope":   `{"dir":"api"}`,
+
+		"implementations": `{"symbol":"Handler"}`,
+		"supertypes":      `{"symbol":"Handler"}`,
+	}
+	// Git-backed methods need a repository and are covered by their own tests.
+	skip := map[string]bool{"diff-summary": true, "blame": true, "history": true, "hotspots": true}
+
+	for _, method := range Methods() {
+		if skip[method] {
+			continue
+		}
+		result, rpcErr := s.Call(method, json.RawMessage(params[method]))
+		if rpcErr != nil {
+			t.Errorf("Call(%s) error: %s", method, rpcErr.Message)
+			continue
+		}
+		if result == nil {
+			t.Errorf("Call(%s) returned nil result", method)
+		}
+	}
+}
+
+func TestServerDoctor(t *testing.T) {
+	s, tmp := newTestServer(t)
+
+	got, rpcErr := s.Call("doctor", nil)
+	if rpcErr != nil {
+		t.Fatalf("Call(doctor) error: %v", rpcErr.Message)
+	}
+	result := got.(*DoctorResult)
+	if !result.Healthy || result.IndexDir != filepath.Join(tmp, "swarm", "index") {
+		t.Errorf("doctor result = %+v, want a healthy check of %s", result, tmp)
+	}
+}
+
+func TestServerServeProtocol(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	resps := serveLines(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"lookup","params":{"query":"HandleAuth","max":1}}`,
+		`{"jsonrpc":"2.0","method":"summary"}`, // notification: no response
+		`{"jsonrpc":"2.0","id":2,"method":"nope"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"refs","params":{}}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":"r","method":"reload"}`,
+	)
+	if len(resps) != 5 {
+		t.Fatalf("got %d responses, want 5", len(resps))
+	}
+
+	if string(resps[0].ID) != "1" || resps[0].Error != nil {
+		t.Errorf("lookup response = %+v", resps[0])
+	}
+	data, _ := json.Marshal(resps[0].Result)
+	var scored []ScoredEntry
+	if err := json.Unmarshal(data, &scored); err != nil || len(scored) != 1 || scored[0].Name != "HandleAuth" {
+		t.Errorf("lookup result = %s, want one HandleAuth entry", data)
+	}
+
+	if resps[1].Error == nil || resps[1].Error.Code != rpcMethodNotFound {
+		t.Errorf("unknown method error = %+v, want code %d", resps[1].Error, rpcMethodNotFound)
+	}
+	if resps[2].Error == nil || resps[2].Error.Code != rpcInvalidParams {
+		t.Errorf("missing param error = %+v, want code %d", resps[2].Error, rpcInvalidParams)
+	}
+	if resps[3].Error == nil || resps[3].Error.Code != rpcParseError {
+		t.Errorf("parse error = %+v, want code %d", resps[3].Error, rpcParseError)
+	}
+	if string(resps[4].ID) != `"r"` || resps[4].Error != nil {
+		t.Errorf("reload response = %+v", resps[4])
+	}
+}
+
+func TestServerReloadPicksUpRescan(t *testing.T) {
+	s, tmp := newTestServer(t)
+
+	mkFile(t, tmp, "extra.go", "package main\n\nfunc FreshlyAdded() {}\n")
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	if _, rpcErr := s.Call("reload", nil); rpcErr != nil {
+		t.Fatalf("reload error: %s", rpcErr.Message)
+	}
+	result, rpcErr := s.Call("symbols", json.RawMessage(`{"query":"freshly"}`))
+	if rpcErr != nil {
+		t.Fatalf("symbols error: %s", rpcErr.Message)
+	}
+	if r := result.(*SymbolsResult); r.Total != 1 {
+		t.Errorf("symbols after reload: Total = %d, want 1", r.Total)
+	}
+}
+
+func TestServerServeUnix(t *testing.T) {
+	s, _ := newTestServer(t)
+	sock := filepath.Join(t.TempDir(), "swarm.sock")
+
+	go s.ServeUnix(sock)
+
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("unix", sock); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("dial %s: %v", sock, err)
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for id := 1; id <= 2; id++ {
+		if _, err := conn.Write([]byte(`{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"summary"}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("reading response %d: %v", id, err)
+		}
+		var resp RPCResponse
+		if err := json.Unmarshal(line, &resp); err != nil || resp.Error != nil {
+			t.Fatalf("response %d = %s (err %v)", id, line, err)
+		}
+	}
+}
+
+func TestCompileRegexpCaches(t *testing.T) {
+	idx := &Index{}
+	a, err := idx.compileRegexp(`foo\d+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := idx.compileRegexp(`foo\d+`)
+	if a != b {
+		t.Error("compileRegexp() recompiled a cached pattern")
+	}
+	if _, err := idx.compileRegexp(`(`); err == nil {
+		t.Error("compileRegexp() accepted an invalid pattern")
+	}
+}
+
+func TestServerSurvivesPanic(t *testing.T) {
+	s, tmp := newTestServer(t)
+	mkFile(t, tmp, "a.boom", "anything\n")
+
+	// panicParser handles .boom files; see diagnostics_test.go.
+	_, rpcErr := s.Call("outline", json.RawMessage(`{"file":"a.boom"}`))
+	if rpcErr == nil || rpcErr.Code != rpcServerError || !strings.Contains(rpcErr.Message, "index out of range") {
+		t.Fatalf("Call(outline) error = %+v, want a server error with the panic", rpcErr)
+	}
+	if _, rpcErr := s.Call("symbols", json.RawMessage(`{"query":"auth"}`)); rpcErr != nil {
+		t.Errorf("Call(symbols) after a panic error: %v", rpcErr.Message)
+	}
+
+	uri := pathToURI(filepath.Join(tmp, "a.boom"))
+	resps := lspExchange(t, s,
+		lspRequest(1, "initialize", `{"capabilities":{}}`),
+		lspRequest(2, "textDocument/documentSymbol", fmt.Sprintf(`{"textDocument":{"uri":%q}}`, uri)),
+		lspRequest(3, "workspace/symbol", `{"query":"auth"}`),
+	)
+	if resps[2].Error == nil || resps[2].Error.Code != rpcServerError {
+		t.Errorf("documentSymbol response = %+v, want a server error", resps[2])
+	}
+	if resps[3].Error != nil {
+		t.Errorf("workspace/symbol after a panic error: %v", resps[3].Error.Message)
+	}
+}
@@ -0,0 +1,354 @@
+package index
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mj1618/swarm-index/parsers"
+)
+
+// Service is a process the project deploys: a Docker Compose service, a
+// container of a Kubernetes workload, or the image a Dockerfile that no
+// Compose service builds produces.
+type Service struct {
+	Name    string   `json:"name"`
+	Source  string   `json:"source"` // "compose", "kubernetes" or "dockerfile"
+	Path    string   `json:"path"`
+	Line    int      `json:"line"`
+	Image   string   `json:"image,omitempty"`
+	Build   string   `json:"build,omitempty"`   // Dockerfile the image is built from
+	Command string   `json:"command,omitempty"` // what the container runs
+	Ports   []string `json:"ports"`
+	Env     []string `json:"env"` // env files, or the ConfigMaps and Secrets of envFrom
+}
+
+// ServicesResult holds the services found in the project.
+type ServicesResult struct {
+	Services []Service `json:"services"`
+	Total    int       `json:"total"`
+}
+
+// kubernetesWorkloads are the object kinds whose pod template declares the
+// containers that run.
+var kubernetesWorkloads = map[string]bool{
+	"Deployment": true, "StatefulSet": true, "DaemonSet": true, "ReplicaSet": true,
+	"Job": true, "CronJob": true, "Pod": true,
+}
+
+// Services summarises the runtime topology declared in the project's
+// Compose files, Kubernetes manifests and Dockerfiles: each service with
+// the image it runs or the Dockerfile it is built from, its command, ports
+// and environment sources. A Compose service built from a Dockerfile takes
+// its command and, if it publishes none, its ports from the Dockerfile's
+// target stage.
+func (idx *Index) Services() (*ServicesResult, error) {
+	var candidates []string
+	for _, p := range idx.FilePaths() {
+		ext := strings.ToLower(filepath.Ext(p))
+		if ext == ".yaml" || ext == ".yml" || parsers.IsDockerfile(filepath.Base(p)) {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.Strings(candidates)
+
+	perFile := parallelMap(candidates, idx.Workers, func(relPath string) []Service {
+		content, err := os.ReadFile(filepath.Join(idx.Root, relPath))
+		if err != nil {
+			return nil
+		}
+		if parsers.IsDockerfile(filepath.Base(relPath)) {
+			return dockerfileServices(idx.Root, relPath, content)
+		}
+		nodes := parsers.YAMLNodes(content)
+		if parsers.IsComposeFile(relPath, nodes) {
+			return idx.composeServices(relPath, nodes)
+		}
+		return kubernetesServices(relPath, nodes)
+	})
+
+	// Dockerfiles a Compose service builds are reported with that service.
+	built := make(map[string]bool)
+	for _, found := range perFile {
+		for _, s := range found {
+			if s.Source == "compose" && s.Build != "" {
+				built[s.Build] = true
+			}
+		}
+	}
+	sourceOrder := map[string]int{"compose": 0, "kubernetes": 1, "dockerfile": 2}
+	services := []Service{}
+	for _, found := range perFile {
+		for _, s := range found {
+			if s.Source != "dockerfile" || !built[s.Path] {
+				services = append(services, s)
+			}
+		}
+	}
+	sort.SliceStable(services, func(i, j int) bool {
+		return sourceOrder[services[i].Source] < sourceOrder[services[j].Source]
+	})
+	return &ServicesResult{Services: services, Total: len(services)}, nil
+}
+
+// composeServices returns the services of the Compose file at relPath.
+func (idx *Index) composeServices(relPath string, nodes []parsers.DataNode) []Service {
+	values := parsers.DataValues(nodes)
+	dir := filepath.Dir(relPath)
+	var services []Service
+	for _, n := range nodes {
+		if n.Doc != 0 || len(n.Path) != 2 || n.Path[0] != "services" {
+			continue
+		}
+		name := n.Path[1]
+		s := Service{
+			Name:   name,
+			Source: "compose",
+			Path:   relPath,
+			Line:   n.Line,
+			Image:  values[parsers.DataKey("services", name, "image")],
+			Ports:  []string{},
+			Env:    []string{},
+		}
+		entrypoint, hasEntrypoint := dataCommand(nodes, values, "services", name, "entrypoint")
+		command, hasCommand := dataCommand(nodes, values, "services", name, "command")
+
+		var stage *parsers.DockerStage
+		if context := parsers.ComposeBuildContext(values, name); context != "" {
+			dockerfile := orDefaultString(values[parsers.DataKey("services", name, "build", "dockerfile")], "Dockerfile")
+			s.Build = filepath.Clean(filepath.Join(dir, context, dockerfile))
+			if content, err := os.ReadFile(filepath.Join(idx.Root, s.Build)); err == nil {
+				stage = targetStage(parsers.DockerfileStages(content), values[parsers.DataKey("services", name, "build", "target")])
+			}
+		}
+
+		// Compose overrides the image's entrypoint and command separately;
+		// a new entrypoint drops the image's command.
+		if stage != nil && !hasEntrypoint {
+			entrypoint = stage.Entrypoint
+			if !hasCommand {
+				command = stage.Cmd
+			}
+		}
+		s.Command = strings.TrimSpace(entrypoint + " " + command)
+
+		s.Ports = append(s.Ports, dataList(nodes, values, "services", name, "ports")...)
+		for _, item := range dataItems(nodes, "services", name, "ports") {
+			target := values[childKey(item, "target")]
+			if published := values[childKey(item, "published")]; published != "" {
+				target = published + ":" + target
+			}
+			if target != "" {
+				s.Ports = append(s.Ports, target)
+			}
+		}
+		s.Ports = append(s.Ports, dataList(nodes, values, "services", name, "expose")...)
+		if len(s.Ports) == 0 && stage != nil {
+			for _, p := range stage.Ports {
+				s.Ports = append(s.Ports, p.Port)
+			}
+		}
+
+		envFiles := dataList(nodes, values, "services", name, "env_file")
+		for _, item := range dataItems(nodes, "services", name, "env_file") {
+			envFiles = append(envFiles, values[childKey(item, "path")])
+		}
+		for _, f := range envFiles {
+			if f != "" {
+				s.Env = append(s.Env, filepath.Clean(filepath.Join(dir, f)))
+			}
+		}
+		services = append(services, s)
+	}
+	return services
+}
+
+// dataCommand returns a command given as a string or a list at path, and
+// whether it is set.
+func dataCommand(nodes []parsers.DataNode, values map[string]string, path ...string) (string, bool) {
+	args := dataList(nodes, values, path...)
+	return strings.Join(args, " "), len(args) > 0
+}
+
+// dataList returns the scalar items of the value at path: the value
+// itself if it is a scalar, the items of a flow sequence ["a", "b"], or the
+// scalar items of a block sequence.
+func dataList(nodes []parsers.DataNode, values map[string]string, path ...string) []string {
+	if v := values[parsers.DataKey(path...)]; v != "" {
+		if !strings.HasPrefix(v, "[") {
+			return []string{v}
+		}
+		var items []string
+		for _, item := range strings.Split(strings.Trim(v, "[]"), ",") {
+			if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	var items []string
+	for _, n := range nodes {
+		if len(n.Path) == len(path)+1 && n.Value != "" && n.Path[len(path)] == "[]" && hasPathPrefix(n.Path, path) {
+			items = append(items, n.Value)
+		}
+	}
+	return items
+}
+
+// dataItems returns the paths of the mapping items of the block
+// sequence at path, such as the long syntax of ports.
+func dataItems(nodes []parsers.DataNode, path ...string) [][]string {
+	var items [][]string
+	for _, n := range nodes {
+		if len(n.Path) == len(path)+1 && n.Value == "" && n.Path[len(path)] == "[]" && hasPathPrefix(n.Path, path) {
+			items = append(items, n.Path)
+		}
+	}
+	return items
+}
+
+// childPath returns the path of a node under parent, without sharing
+// parent's storage.
+func childPath(parent []string, path ...string) []string {
+	return append(append([]string(nil), parent...), path...)
+}
+
+// childKey returns the DataValues key of the node at path under parent.
+func childKey(parent []string, path ...string) string {
+	return parsers.DataKey(childPath(parent, path...)...)
+}
+
+func hasPathPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// targetStage returns the stage named target, or the last stage, which a
+// build produces by default.
+func targetStage(stages []parsers.DockerStage, target string) *parsers.DockerStage {
+	for i := range stages {
+		if target != "" && stages[i].Name == target {
+			return &stages[i]
+		}
+	}
+	if len(stages) == 0 {
+		return nil
+	}
+	return &stages[len(stages)-1]
+}
+
+// kubernetesServices returns a service for each container of the workloads
+// in the Kubernetes manifest at relPath. A workload with several
+// containers names them workload/container.
+func kubernetesServices(relPath string, nodes []parsers.DataNode) []Service {
+	var services []Service
+	for _, doc := range parsers.SplitDocuments(nodes) {
+		values := parsers.DataValues(doc)
+		name := values[parsers.DataKey("metadata", "name")]
+		if !kubernetesWorkloads[values[parsers.DataKey("kind")]] || name == "" {
+			continue
+		}
+		var containers []parsers.DataNode
+		for _, n := range doc {
+			if k := len(n.Path); k >= 2 && n.Path[k-2] == "containers" && n.Path[k-1] == "[]" {
+				containers = append(containers, n)
+			}
+		}
+		for _, c := range containers {
+			s := Service{
+				Name:   name,
+				Source: "kubernetes",
+				Path:   relPath,
+				Line:   c.Line,
+				Image:  values[childKey(c.Path, "image")],
+				Ports:  []string{},
+				Env:    []string{},
+			}
+			if len(containers) > 1 {
+				s.Name += "/" + values[childKey(c.Path, "name")]
+			}
+			command, _ := dataCommand(doc, values, childPath(c.Path, "command")...)
+			args, _ := dataCommand(doc, values, childPath(c.Path, "args")...)
+			s.Command = strings.TrimSpace(command + " " + args)
+
+			for _, n := range doc {
+				if !hasPathPrefix(n.Path, c.Path) || n.Value == "" {
+					continue
+				}
+				rest := strings.Join(n.Path[len(c.Path):], ".")
+				switch rest {
+				case "ports.[].containerPort":
+					s.Ports = append(s.Ports, n.Value)
+				case "envFrom.[].configMapRef.name":
+					s.Env = append(s.Env, "configmap/"+n.Value)
+				case "envFrom.[].secretRef.name":
+					s.Env = append(s.Env, "secret/"+n.Value)
+				}
+			}
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+// dockerfileServices returns the image the Dockerfile at relPath builds,
+// named after its directory.
+func dockerfileServices(root, relPath string, content []byte) []Service {
+	stage := targetStage(parsers.DockerfileStages(content), "")
+	if stage == nil {
+		return nil
+	}
+	name := filepath.Base(filepath.Dir(relPath))
+	if name == "." {
+		name = filepath.Base(root)
+	}
+	s := Service{
+		Name:    name,
+		Source:  "dockerfile",
+		Path:    relPath,
+		Line:    stage.Line,
+		Build:   relPath,
+		Command: stage.Command(),
+		Ports:   []string{},
+		Env:     []string{},
+	}
+	for _, p := range stage.Ports {
+		s.Ports = append(s.Ports, p.Port)
+	}
+	return []Service{s}
+}
+
+// FormatServices returns a human-readable rendering of the services.
+func FormatServices(r *ServicesResult) string {
+	if len(r.Services) == 0 {
+		return "No services found\n"
+	}
+	var b strings.Builder
+	for i, s := range r.Services {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("%s (%s, %s:%d)\n", s.Name, s.Source, s.Path, s.Line))
+		field := func(label, value string) {
+			if value != "" {
+				b.WriteString(fmt.Sprintf("  %-9s %s\n", label+":", value))
+			}
+		}
+		field("image", s.Image)
+		field("build", s.Build)
+		field("command", s.Command)
+		field("ports", strings.Join(s.Ports, ", "))
+		field("env", strings.Join(s.Env, ", "))
+	}
+	b.WriteString(fmt.Sprintf("\n%d services found\n", r.Total))
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package index
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServices(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "docker-compose.yml", `services:
+  api:
+    build:
+      context: ./api
+      target: runtime
+    env_file:
+      - .env
+      - api/.env
+  web:
+    image: nginx:1.25
+    ports: ["80:80", "443:443"]
+    command: nginx -g 'daemon off;'
+  db:
+    image: postgres:16
+    ports:
+      - target: 5432
+        published: 5433
+`)
+	mkFile(t, tmp, "api/Dockerfile", `FROM golang:1.22 AS build
+RUN go build -o /out/api ./cmd/api
+
+FROM gcr.io/distroless/base AS runtime
+COPY --from=build /out/api /api
+EXPOSE 8080
+ENTRYPOINT ["/api"]
+CMD ["--listen", ":8080"]
+
+FROM runtime AS debug
+CMD ["--debug"]
+`)
+	mkFile(t, tmp, "worker/Dockerfile", "FROM python:3.12\nCMD python -m worker\n")
+	mkFile(t, tmp, "deploy/k8s.yaml", `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: ghcr.io/acme/migrate:1
+      containers:
+        - name: api
+          image: ghcr.io/acme/api:1.2.0
+          args: ["--listen", ":8080"]
+          ports:
+            - containerPort: 8080
+          envFrom:
+            - configMapRef:
+                name: api-config
+            - secretRef:
+                name: api-secrets
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+`)
+	mkFile(t, tmp, "config.yaml", "log_level: debug\n")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	// Dockerfiles are parsed by name during the scan.
+	var stage bool
+	for _, e := range idx.MatchExact("runtime") {
+		stage = stage || e.Kind == "stage" && e.Path == filepath.FromSlash("api/Dockerfile")
+	}
+	if !stage {
+		t.Error("MatchExact(runtime) missing the Dockerfile stage")
+	}
+
+	result, err := idx.Services()
+	if err != nil {
+		t.Fatalf("Services() error: %v", err)
+	}
+
+	want := []Service{
+		{Name: "api", Source: "compose", Path: "docker-compose.yml", Line: 2, Build: filepath.FromSlash("api/Dockerfile"),
+			Command: "/api --listen :8080", Ports: []string{"8080"}, Env: []string{".env", filepath.FromSlash("api/.env")}},
+		{Name: "web", Source: "compose", Path: "docker-compose.yml", Line: 9, Image: "nginx:1.25",
+			Command: "nginx -g 'daemon off;'", Ports: []string{"80:80", "443:443"}, Env: []string{}},
+		{Name: "db", Source: "compose", Path: "docker-compose.yml", Line: 13, Image: "postgres:16",
+			Ports: []string{"5433:5432"}, Env: []string{}},
+		{Name: "api", Source: "kubernetes", Path: filepath.FromSlash("deploy/k8s.yaml"), Line: 12, Image: "ghcr.io/acme/api:1.2.0",
+			Command: "--listen :8080", Ports: []string{"8080"}, Env: []string{"configmap/api-config", "secret/api-secrets"}},
+		{Name: "worker", Source: "dockerfile", Path: filepath.FromSlash("worker/Dockerfile"), Line: 1, Build: filepath.FromSlash("worker/Dockerfile"),
+			Command: "python -m worker", Ports: []string{}, Env: []string{}},
+	}
+	if result.Total != len(want) {
+		t.Errorf("Total = %d, want %d", result.Total, len(want))
+	}
+	if !reflect.DeepEqual(result.Services, want) {
+		t.Errorf("Services() =\n%+v\nwant\n%+v", result.Services, want)
+	}
+
+	out := FormatServices(result)
+	for _, s := range []string{"api (compose, docker-compose.yml:2)", "command:  /api --listen :8080", "5 services found"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("FormatServices() missing %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestServicesEmpty(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main\n\nfunc main() {}\n")
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	result, err := idx.Services()
+	if err != nil {
+		t.Fatalf("Services() error: %v", err)
+	}
+	if result.Total != 0 || len(result.Services) != 0 {
+		t.Errorf("Services() = %+v, want none", result)
+	}
+	if out := FormatServices(result); out != "No services found\n" {
+		t.Errorf("FormatServices() = %q", out)
+	}
+}
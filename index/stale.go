@@ -27,7 +27,10 @@ type StaleSummary struct {
 }
 
 // Stale compares the persisted index against the current filesystem and reports
-// new, deleted, and modified files.
+// new, deleted, and modified files. A file counts as modified only if its
+// content hash differs from the one recorded at scan time, so touching a file
+// or switching branches back and forth does not make the index stale. Indexes
+// without file records fall back to comparing mtimes against scannedAt.
 func (idx *Index) Stale() (*StaleResult, error) {
 	scannedAt, err := time.Parse(time.RFC3339, idx.ScannedAt)
 	if err != nil {
@@ -40,11 +43,12 @@ func (idx *Index) Stale() (*StaleResult, error) {
 
 	// Build set of indexed file paths
 	indexed := make(map[string]struct{})
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if e.Kind == "file" {
 			indexed[e.Path] = struct{}{}
 		}
 	}
+	records := idx.fileRecords()
 
 	newFiles := []string{}
 	modifiedFiles := []string{}
@@ -75,7 +79,7 @@ func (idx *Index) Stale() (*StaleResult, error) {
 
 		if _, ok := indexed[relPath]; ok {
 			// File exists in index — check if modified since scan
-			if info.ModTime().After(scannedAt) {
+			if fileChanged(path, info, records[relPath], scannedAt) {
 				modifiedFiles = append(modifiedFiles, relPath)
 			}
 			delete(indexed, relPath)
@@ -115,6 +119,19 @@ func (idx *Index) Stale() (*StaleResult, error) {
 	return result, nil
 }
 
+// fileChanged reports whether the file at path differs from its scan-time
+// record. Size and mtime are checked first so unchanged files are not read.
+func fileChanged(path string, info os.FileInfo, rec FileRecord, scannedAt time.Time) bool {
+	if rec.Hash == "" {
+		return info.ModTime().After(scannedAt)
+	}
+	if rec.Size == info.Size() && rec.ModTime == info.ModTime().UnixNano() {
+		return false
+	}
+	hash, err := hashFile(path)
+	return err != nil || hash != rec.Hash
+}
+
 // FormatStale returns a human-readable text rendering of the stale result.
 func FormatStale(r *StaleResult) string {
 	var b strings.Builder
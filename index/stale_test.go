@@ -139,11 +139,8 @@ func TestStaleModifiedFile(t *testing.T) {
 		t.Fatalf("Save() error: %v", err)
 	}
 
-	// Touch the file with a future mtime
-	future := time.Now().Add(time.Hour)
-	if err := os.Chtimes(filepath.Join(tmp, "main.go"), future, future); err != nil {
-		t.Fatalf("Chtimes() error: %v", err)
-	}
+	// Change the file's content
+	mkFile(t, tmp, "main.go", "package main\n\nfunc main() {}\n")
 
 	loaded, err := Load(tmp)
 	if err != nil {
@@ -169,6 +166,73 @@ func TestStaleModifiedFile(t *testing.T) {
 	}
 }
 
+func TestStaleTouchedFileNotModified(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	// Touch the file with a future mtime but keep its content.
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(tmp, "main.go"), future, future); err != nil {
+		t.Fatalf("Chtimes() error: %v", err)
+	}
+
+	loaded, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	result, err := loaded.Stale()
+	if err != nil {
+		t.Fatalf("Stale() error: %v", err)
+	}
+	if result.IsStale {
+		t.Errorf("IsStale = true, want false for a touched but unchanged file: %+v", result)
+	}
+}
+
+func TestStaleLegacyIndexUsesMtime(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main")
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	idx.Files = nil // simulate an index saved before file records existed
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if err := os.Remove(filepath.Join(tmp, "swarm", "index", "files.json")); err != nil {
+		t.Fatalf("removing files.json: %v", err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(tmp, "main.go"), future, future); err != nil {
+		t.Fatalf("Chtimes() error: %v", err)
+	}
+
+	loaded, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	result, err := loaded.Stale()
+	if err != nil {
+		t.Fatalf("Stale() error: %v", err)
+	}
+	if len(result.ModifiedFiles) != 1 || result.ModifiedFiles[0] != "main.go" {
+		t.Errorf("ModifiedFiles = %v, want [main.go]", result.ModifiedFiles)
+	}
+}
+
 func TestStaleCombined(t *testing.T) {
 	tmp := t.TempDir()
 	mkFile(t, tmp, "keep.go", "package main")
@@ -187,11 +251,8 @@ func TestStaleCombined(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 	mkFile(t, tmp, "new.go", "package main")
 
-	// Modify a file (touch with future time)
-	future := time.Now().Add(time.Hour)
-	if err := os.Chtimes(filepath.Join(tmp, "modify.go"), future, future); err != nil {
-		t.Fatalf("Chtimes() error: %v", err)
-	}
+	// Modify a file's content
+	mkFile(t, tmp, "modify.go", "package main\n\nvar x = 1\n")
 
 	// Delete a file
 	if err := os.Remove(filepath.Join(tmp, "delete.go")); err != nil {
@@ -0,0 +1,415 @@
+package index
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver
+)
+
+// Storage backends understood by Save and Load.
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// backendFiles maps each storage backend to the files it owns under
+// swarm/index/. The first file is the one that must exist for Load.
+var backendFiles = map[string][]string{
+	BackendJSON:   {"index.json", "files.json"},
+	BackendSQLite: {"index.db"},
+}
+
+// ValidBackend reports whether name is a known storage backend.
+func ValidBackend(name string) bool {
+	_, ok := backendFiles[name]
+	return ok
+}
+
+// Store persists index entries and per-file records. Metadata always lives in
+// meta.json next to the store so that the index root can be found without
+// opening the store.
+type Store interface {
+	WriteEntries(entries []Entry) error
+	ReadEntries() ([]Entry, error)
+	WriteFiles(files []FileRecord) error
+	// ReadFiles returns nil without error for indexes written before file
+	// records existed.
+	ReadFiles() ([]FileRecord, error)
+	Close() error
+}
+
+// Querier is implemented by stores that can answer the hot-path queries
+// without decoding every entry. Results must be in index order.
+type Querier interface {
+	// FilePaths returns the unique file paths, in first-seen order.
+	FilePaths() ([]string, error)
+	// MatchExact returns entries whose name or path contains query
+	// (case-insensitive).
+	MatchExact(query string) ([]Entry, error)
+	// MatchCandidates returns a superset of the entries scoreName can match.
+	MatchCandidates(query string) ([]Entry, error)
+	// SymbolEntries returns the symbol entries whose name contains query
+	// (case-insensitive), optionally restricted to kind (case-insensitive).
+	SymbolEntries(query, kind string) ([]Entry, error)
+}
+
+// createStore removes any existing store for backend in indexDir and opens an
+// empty one for writing.
+func createStore(indexDir, backend string) (Store, error) {
+	files, ok := backendFiles[backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown store backend %q", backend)
+	}
+	path := filepath.Join(indexDir, files[0])
+	switch backend {
+	case BackendSQLite:
+		// Build the new database beside the old one and swap it in on Close,
+		// so readers never see a half-written index.
+		tmp := path + ".tmp"
+		if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("removing %s: %w", filepath.Base(tmp), err)
+		}
+		store, err := openSQLiteStore(tmp, false)
+		if err != nil {
+			return nil, err
+		}
+		store.renameTo = path
+		return store, nil
+	default:
+		return newJSONStore(indexDir), nil
+	}
+}
+
+// openStore opens the existing store for backend in indexDir for reading.
+func openStore(indexDir, backend string) (Store, error) {
+	files, ok := backendFiles[backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown store backend %q", backend)
+	}
+	path := filepath.Join(indexDir, files[0])
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", files[0], err)
+	}
+	switch backend {
+	case BackendSQLite:
+		return openSQLiteStore(path, true)
+	default:
+		return newJSONStore(indexDir), nil
+	}
+}
+
+// removeOtherStores deletes store files left behind by backends other than
+// the one in use, so a stale index.json never shadows a newer index.db.
+func removeOtherStores(indexDir, backend string) {
+	for b, files := range backendFiles {
+		if b == backend {
+			continue
+		}
+		for _, file := range files {
+			os.Remove(filepath.Join(indexDir, file))
+		}
+	}
+}
+
+// abortStore closes a store whose write failed without replacing the
+// previous index.
+func abortStore(store Store) {
+	if s, ok := store.(*sqliteStore); ok && s.renameTo != "" {
+		s.db.Close()
+		os.Remove(s.renameTo + ".tmp")
+		return
+	}
+	store.Close()
+}
+
+// jsonStore keeps all entries in a single indented JSON array (index.json)
+// and file records in a second array (files.json).
+type jsonStore struct {
+	path      string
+	filesPath string
+}
+
+func newJSONStore(indexDir string) *jsonStore {
+	return &jsonStore{
+		path:      filepath.Join(indexDir, "index.json"),
+		filesPath: filepath.Join(indexDir, "files.json"),
+	}
+}
+
+func (s *jsonStore) WriteEntries(entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return writeJSON(s.path, entries)
+}
+
+func (s *jsonStore) ReadEntries() ([]Entry, error) {
+	var entries []Entry
+	if err := readJSON(s.path, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *jsonStore) WriteFiles(files []FileRecord) error {
+	if files == nil {
+		files = []FileRecord{}
+	}
+	return writeJSON(s.filesPath, files)
+}
+
+func (s *jsonStore) ReadFiles() ([]FileRecord, error) {
+	if _, err := os.Stat(s.filesPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var files []FileRecord
+	if err := readJSON(s.filesPath, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (s *jsonStore) Close() error { return nil }
+
+// sqliteSchema creates the entries and files tables. The *_lower and stem_len columns
+// are precomputed in Go so that queries match the in-memory scoring exactly.
+const sqliteSchema = `
+CREATE TABLE entries (
+	id         INTEGER PRIMARY KEY,
+	name       TEXT NOT NULL,
+	kind       TEXT NOT NULL,
+	path       TEXT NOT NULL,
+	line       INTEGER NOT NULL DEFAULT 0,
+	package    TEXT NOT NULL DEFAULT '',
+	exported   INTEGER NOT NULL DEFAULT 0,
+	end_line   INTEGER NOT NULL DEFAULT 0,
+	signature  TEXT NOT NULL DEFAULT '',
+	parent     TEXT NOT NULL DEFAULT '',
+	doc        TEXT NOT NULL DEFAULT '',
+	source     TEXT NOT NULL DEFAULT '',
+	abstract   INTEGER NOT NULL DEFAULT 0,
+	name_lower TEXT NOT NULL,
+	path_lower TEXT NOT NULL,
+	stem_len   INTEGER NOT NULL
+);
+CREATE INDEX idx_entries_name ON entries(name);
+CREATE INDEX idx_entries_kind ON entries(kind);
+CREATE INDEX idx_entries_path ON entries(path);
+CREATE TABLE files (
+	path     TEXT PRIMARY KEY,
+	size     INTEGER NOT NULL,
+	mod_time INTEGER NOT NULL,
+	hash     TEXT NOT NULL DEFAULT ''
+);
+`
+
+// entryColumns lists the columns scanned by queryEntries, in order.
+const entryColumns = `name, kind, path, line, package, exported, end_line, signature, parent, doc, source, abstract`
+
+// sqliteStore keeps entries in an indexed SQLite database (index.db).
+type sqliteStore struct {
+	db       *sql.DB
+	renameTo string // final path of a store being written, moved into place on Close
+}
+
+// openSQLiteStore opens the database at path. A writable store gets a fresh
+// schema; a read-only store must already exist.
+func openSQLiteStore(path string, readOnly bool) (*sqliteStore, error) {
+	dsn := "file:" + path
+	if readOnly {
+		dsn += "?mode=ro"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", filepath.Base(path), err)
+	}
+	if !readOnly {
+		if _, err := db.Exec(sqliteSchema); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("creating %s schema: %w", filepath.Base(path), err)
+		}
+	} else if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening %s: %w", filepath.Base(path), err)
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) WriteEntries(entries []Entry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("writing index.db: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM entries`); err != nil {
+		return fmt.Errorf("writing index.db: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT INTO entries (` + entryColumns + `, name_lower, path_lower, stem_len)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("writing index.db: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, e := range entries {
+		nameLower := strings.ToLower(e.Name)
+		stem := strings.TrimSuffix(nameLower, strings.ToLower(filepath.Ext(e.Name)))
+		if _, err := stmt.Exec(e.Name, e.Kind, e.Path, e.Line, e.Package, e.Exported,
+			e.EndLine, e.Signature, e.Parent, e.Doc, e.Source, e.Abstract,
+			nameLower, strings.ToLower(e.Path), len(stem)); err != nil {
+			return fmt.Errorf("writing index.db: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("writing index.db: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) ReadEntries() ([]Entry, error) {
+	return s.queryEntries(`SELECT ` + entryColumns + ` FROM entries ORDER BY id`)
+}
+
+func (s *sqliteStore) WriteFiles(files []FileRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("writing index.db: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM files`); err != nil {
+		return fmt.Errorf("writing index.db: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT INTO files (path, size, mod_time, hash) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("writing index.db: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, f := range files {
+		if _, err := stmt.Exec(f.Path, f.Size, f.ModTime, f.Hash); err != nil {
+			return fmt.Errorf("writing index.db: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("writing index.db: %w", err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) ReadFiles() ([]FileRecord, error) {
+	rows, err := s.db.Query(`SELECT path, size, mod_time, hash FROM files ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("querying index.db: %w", err)
+	}
+	defer rows.Close()
+
+	var files []FileRecord
+	for rows.Next() {
+		var f FileRecord
+		if err := rows.Scan(&f.Path, &f.Size, &f.ModTime, &f.Hash); err != nil {
+			return nil, fmt.Errorf("querying index.db: %w", err)
+		}
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying index.db: %w", err)
+	}
+	return files, nil
+}
+
+func (s *sqliteStore) Close() error {
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	if s.renameTo != "" {
+		if err := os.Rename(s.renameTo+".tmp", s.renameTo); err != nil {
+			return fmt.Errorf("replacing %s: %w", filepath.Base(s.renameTo), err)
+		}
+	}
+	return nil
+}
+
+func (s *sqliteStore) FilePaths() ([]string, error) {
+	return s.queryStrings(`SELECT path FROM entries GROUP BY path ORDER BY MIN(id)`)
+}
+
+func (s *sqliteStore) MatchExact(query string) ([]Entry, error) {
+	q := strings.ToLower(query)
+	return s.queryEntries(`SELECT `+entryColumns+` FROM entries
+		WHERE instr(name_lower, ?1) > 0 OR instr(path_lower, ?1) > 0
+		ORDER BY id`, q)
+}
+
+func (s *sqliteStore) MatchCandidates(query string) ([]Entry, error) {
+	// Substring matches cover every scoreName tier except the fuzzy one,
+	// which only considers names within maxDist bytes of the query length.
+	q := strings.ToLower(query)
+	const maxDist = 2
+	return s.queryEntries(`SELECT `+entryColumns+` FROM entries
+		WHERE instr(name_lower, ?1) > 0 OR instr(path_lower, ?1) > 0
+			OR stem_len BETWEEN ?2 AND ?3
+		ORDER BY id`, q, len(q)-maxDist, len(q)+maxDist)
+}
+
+func (s *sqliteStore) SymbolEntries(query, kind string) ([]Entry, error) {
+	q := strings.ToLower(query)
+	if kind == "" {
+		return s.queryEntries(`SELECT `+entryColumns+` FROM entries
+			WHERE kind != 'file' AND instr(name_lower, ?1) > 0
+			ORDER BY id`, q)
+	}
+	return s.queryEntries(`SELECT `+entryColumns+` FROM entries
+		WHERE kind != 'file' AND kind = ?2 COLLATE NOCASE AND instr(name_lower, ?1) > 0
+		ORDER BY id`, q, kind)
+}
+
+// queryEntries runs a SELECT over entryColumns and scans the rows.
+func (s *sqliteStore) queryEntries(query string, args ...any) ([]Entry, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying index.db: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.Name, &e.Kind, &e.Path, &e.Line, &e.Package, &e.Exported,
+			&e.EndLine, &e.Signature, &e.Parent, &e.Doc, &e.Source, &e.Abstract); err != nil {
+			return nil, fmt.Errorf("querying index.db: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying index.db: %w", err)
+	}
+	return entries, nil
+}
+
+// queryStrings runs a single-column SELECT and returns the values.
+func (s *sqliteStore) queryStrings(query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying index.db: %w", err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("querying index.db: %w", err)
+		}
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying index.db: %w", err)
+	}
+	return values, nil
+}
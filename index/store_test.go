@@ -0,0 +1,201 @@
+package index
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// scanAndSave scans root and saves it with the given backend, then loads it back.
+func scanAndSave(t *testing.T, root, backend string) (*Index, *Index) {
+	t.Helper()
+	idx, err := Scan(root)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	idx.Backend = backend
+	if err := idx.Save(root); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	t.Cleanup(func() { loaded.Close() })
+	return idx, loaded
+}
+
+func makeStoreFixture(t *testing.T) string {
+	t.Helper()
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", `package main
+
+func main() {}
+func HandleAuth() {}
+`)
+	mkFile(t, tmp, "api/handler.go", `package api
+
+type Handler struct{}
+func (h *Handler) ServeAuth() {}
+const MaxRetries = 3
+`)
+	mkFile(t, tmp, "api/auth.go", "package api\n")
+	mkFile(t, tmp, "README.md", "# readme\n")
+	return tmp
+}
+
+func TestSQLiteSaveLoadRoundTrip(t *testing.T) {
+	tmp := makeStoreFixture(t)
+	idx, loaded := scanAndSave(t, tmp, BackendSQLite)
+
+	if _, err := os.Stat(filepath.Join(tmp, "swarm", "index", "index.db")); err != nil {
+		t.Fatalf("index.db not created: %v", err)
+	}
+	if loaded.Backend != BackendSQLite {
+		t.Errorf("Backend = %q, want %q", loaded.Backend, BackendSQLite)
+	}
+	if loaded.Root != idx.Root {
+		t.Errorf("Root = %q, want %q", loaded.Root, idx.Root)
+	}
+	if !reflect.DeepEqual(loaded.entries(), idx.Entries) {
+		t.Errorf("entries mismatch:\n got %+v\nwant %+v", loaded.Entries, idx.Entries)
+	}
+}
+
+func TestSQLiteLoadIsLazy(t *testing.T) {
+	tmp := makeStoreFixture(t)
+	_, loaded := scanAndSave(t, tmp, BackendSQLite)
+
+	if loaded.Entries != nil {
+		t.Fatalf("Load() decoded %d entries up front, want lazy loading", len(loaded.Entries))
+	}
+	loaded.MatchExact("auth")
+	loaded.Match("handler")
+	loaded.FilePaths()
+	if loaded.Entries != nil {
+		t.Error("queries decoded the full index, want them answered by the store")
+	}
+}
+
+func TestSQLiteQueriesMatchInMemory(t *testing.T) {
+	tmp := makeStoreFixture(t)
+	idx, loaded := scanAndSave(t, tmp, BackendSQLite)
+
+	if got, want := loaded.FilePaths(), idx.FilePaths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilePaths() = %v, want %v", got, want)
+	}
+
+	for _, q := range []string{"auth", "AUTH", "api/", "handlr", "main", ".go", "nothing-here"} {
+		if got, want := loaded.MatchExact(q), idx.MatchExact(q); !reflect.DeepEqual(got, want) {
+			t.Errorf("MatchExact(%q) = %+v, want %+v", q, got, want)
+		}
+		if got, want := loaded.MatchScored(q), idx.MatchScored(q); !reflect.DeepEqual(got, want) {
+			t.Errorf("MatchScored(%q) = %+v, want %+v", q, got, want)
+		}
+	}
+
+	for _, tc := range []struct{ query, kind string }{
+		{"auth", ""},
+		{"auth", "method"},
+		{"max", "CONST"},
+		{"handler", "func"},
+	} {
+		got, err := loaded.Symbols(tc.query, tc.kind, 50)
+		if err != nil {
+			t.Fatalf("Symbols(%q, %q) error: %v", tc.query, tc.kind, err)
+		}
+		want, err := idx.Symbols(tc.query, tc.kind, 50)
+		if err != nil {
+			t.Fatalf("Symbols(%q, %q) error: %v", tc.query, tc.kind, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Symbols(%q, %q) = %+v, want %+v", tc.query, tc.kind, got, want)
+		}
+	}
+}
+
+func TestSaveSwitchingBackendRemovesOldStore(t *testing.T) {
+	tmp := makeStoreFixture(t)
+	indexDir := filepath.Join(tmp, "swarm", "index")
+
+	scanAndSave(t, tmp, BackendJSON)
+	if _, err := os.Stat(filepath.Join(indexDir, "index.json")); err != nil {
+		t.Fatalf("index.json not created: %v", err)
+	}
+
+	scanAndSave(t, tmp, BackendSQLite)
+	if _, err := os.Stat(filepath.Join(indexDir, "index.json")); !os.IsNotExist(err) {
+		t.Errorf("index.json still present after saving with sqlite backend")
+	}
+
+	data, err := os.ReadFile(filepath.Join(indexDir, "meta.json"))
+	if err != nil {
+		t.Fatalf("reading meta.json: %v", err)
+	}
+	var meta indexMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("parsing meta.json: %v", err)
+	}
+	if meta.Backend != BackendSQLite {
+		t.Errorf("meta.Backend = %q, want %q", meta.Backend, BackendSQLite)
+	}
+
+	_, loaded := scanAndSave(t, tmp, BackendJSON)
+	if _, err := os.Stat(filepath.Join(indexDir, "index.db")); !os.IsNotExist(err) {
+		t.Errorf("index.db still present after saving with json backend")
+	}
+	if loaded.Entries == nil {
+		t.Error("json backend should load entries eagerly")
+	}
+}
+
+func TestSaveReloadedSQLiteIndex(t *testing.T) {
+	tmp := makeStoreFixture(t)
+	idx, loaded := scanAndSave(t, tmp, BackendSQLite)
+
+	// Re-saving an index loaded from the same store must not lose entries.
+	if err := loaded.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	again, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	defer again.Close()
+	if got := len(again.entries()); got != len(idx.Entries) {
+		t.Errorf("reloaded %d entries, want %d", got, len(idx.Entries))
+	}
+}
+
+func TestLoadLegacyMetaDefaultsToJSON(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "swarm/index/index.json", `[{"name":"a.go","kind":"file","path":"a.go","line":0,"package":"(root)"}]`)
+	mkFile(t, tmp, "swarm/index/meta.json", `{"root":"/x","scannedAt":"2024-01-01T00:00:00Z","version":"0.1.0"}`)
+
+	idx, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if idx.Backend != BackendJSON {
+		t.Errorf("Backend = %q, want %q", idx.Backend, BackendJSON)
+	}
+	if len(idx.Entries) != 1 {
+		t.Errorf("loaded %d entries, want 1", len(idx.Entries))
+	}
+}
+
+func TestSaveUnknownBackend(t *testing.T) {
+	tmp := t.TempDir()
+	idx := &Index{Root: tmp, Backend: "yaml"}
+	if err := idx.Save(tmp); err == nil {
+		t.Fatal("Save() should fail for an unknown backend")
+	}
+	if ValidBackend("yaml") {
+		t.Error(`ValidBackend("yaml") = true, want false`)
+	}
+	if !ValidBackend(BackendSQLite) || !ValidBackend(BackendJSON) {
+		t.Error("ValidBackend() rejected a built-in backend")
+	}
+}
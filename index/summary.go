@@ -21,10 +21,12 @@ var languageMap = map[string]string{
 	".rs":    "Rust",
 	".java":  "Java",
 	".rb":    "Ruby",
+	".php":   "PHP",
 	".c":     "C",
 	".h":     "C",
 	".cpp":   "C++",
 	".hpp":   "C++",
+	".cc":    "C++",
 	".cs":    "C#",
 	".swift": "Swift",
 	".kt":    "Kotlin",
@@ -119,7 +121,7 @@ func (idx *Index) Summary() SummaryResult {
 	var manifests []string
 	topDirs := make(map[string]struct{})
 
-	for _, e := range idx.Entries {
+	for _, e := range idx.entries() {
 		if _, ok := seen[e.Path]; ok {
 			continue
 		}
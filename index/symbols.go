@@ -2,12 +2,8 @@ package index
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
-
-	"github.com/mj1618/swarm-index/parsers"
 )
 
 // SymbolMatch represents a single symbol found across the project.
@@ -16,7 +12,10 @@ type SymbolMatch struct {
 	Kind      string `json:"kind"`
 	Path      string `json:"path"`
 	Line      int    `json:"line"`
+	EndLine   int    `json:"endLine,omitempty"`
 	Signature string `json:"signature"`
+	Parent    string `json:"parent,omitempty"`
+	Doc       string `json:"doc,omitempty"`
 	Exported  bool   `json:"exported"`
 }
 
@@ -27,52 +26,26 @@ type SymbolsResult struct {
 	Total   int           `json:"total"`
 }
 
-// Symbols searches all parseable files in the index for symbols matching
-// the query by name (case-insensitive substring). If kind is non-empty,
-// only symbols of that kind are returned. Results are sorted: exact name
-// matches first, then prefix matches, then substring matches. At most max
-// results are returned.
+// Symbols searches the indexed symbols for names matching the query
+// (case-insensitive substring). If kind is non-empty, only symbols of that
+// kind are returned. Results are sorted: exact name matches first, then
+// prefix matches, then substring matches. At most max results are returned.
 func (idx *Index) Symbols(query string, kind string, max int) (*SymbolsResult, error) {
 	queryLower := strings.ToLower(query)
-	kindLower := strings.ToLower(kind)
 
 	var matches []SymbolMatch
-
-	for _, relPath := range idx.FilePaths() {
-		ext := filepath.Ext(relPath)
-		p := parsers.ForExtension(ext)
-		if p == nil {
-			continue
-		}
-
-		absPath := filepath.Join(idx.Root, relPath)
-		content, err := os.ReadFile(absPath)
-		if err != nil {
-			continue
-		}
-
-		symbols, err := p.Parse(absPath, content)
-		if err != nil {
-			continue
-		}
-
-		for _, sym := range symbols {
-			nameLower := strings.ToLower(sym.Name)
-			if !strings.Contains(nameLower, queryLower) {
-				continue
-			}
-			if kindLower != "" && strings.ToLower(sym.Kind) != kindLower {
-				continue
-			}
-			matches = append(matches, SymbolMatch{
-				Name:      sym.Name,
-				Kind:      sym.Kind,
-				Path:      relPath,
-				Line:      sym.Line,
-				Signature: sym.Signature,
-				Exported:  sym.Exported,
-			})
-		}
+	for _, e := range idx.symbolEntries(query, kind) {
+		matches = append(matches, SymbolMatch{
+			Name:      e.Name,
+			Kind:      e.Kind,
+			Path:      e.Path,
+			Line:      e.Line,
+			EndLine:   e.EndLine,
+			Signature: e.Signature,
+			Parent:    e.Parent,
+			Doc:       e.Doc,
+			Exported:  e.Exported,
+		})
 	}
 
 	// Sort: exact > prefix > substring, then alphabetically by name.
@@ -97,6 +70,31 @@ func (idx *Index) Symbols(query string, kind string, max int) (*SymbolsResult, e
 	}, nil
 }
 
+// symbolEntries returns the symbol entries whose name contains query,
+// optionally restricted to kind. Both comparisons are case-insensitive.
+func (idx *Index) symbolEntries(query, kind string) []Entry {
+	if q := idx.querier(); q != nil {
+		if entries, err := q.SymbolEntries(query, kind); err == nil {
+			return entries
+		}
+	}
+	queryLower := strings.ToLower(query)
+	var entries []Entry
+	for _, e := range idx.entries() {
+		if e.Kind == "file" {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(e.Name), queryLower) {
+			continue
+		}
+		if kind != "" && !strings.EqualFold(e.Kind, kind) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
 // matchRank returns 0 for exact match, 1 for prefix match, 2 for substring.
 func matchRank(name string, queryLower string) int {
 	nameLower := strings.ToLower(name)
@@ -121,7 +119,14 @@ func FormatSymbols(r *SymbolsResult) string {
 	b.WriteString(fmt.Sprintf("Symbols matching %q (%d found):\n\n", r.Query, r.Total))
 
 	for _, m := range r.Matches {
-		b.WriteString(fmt.Sprintf("  %-10s %-40s %s:%d\n", m.Kind, m.Name, m.Path, m.Line))
+		name := m.Name
+		if m.Parent != "" {
+			name = m.Parent + "." + m.Name
+		}
+		b.WriteString(fmt.Sprintf("  %-10s %-40s %s:%d\n", m.Kind, name, m.Path, m.Line))
+		if m.Signature != "" {
+			b.WriteString(fmt.Sprintf("  %-10s %s\n", "", m.Signature))
+		}
 	}
 
 	if r.Total > len(r.Matches) {
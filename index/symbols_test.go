@@ -223,6 +223,44 @@ def authenticate():
 	}
 }
 
+func TestSymbolsIncludeMetadata(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "handler.go", `package api
+
+type Handler struct{}
+
+// ServeAuth checks credentials.
+func (h *Handler) ServeAuth(token string) bool {
+	return true
+}
+`)
+
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	result, err := idx.Symbols("serveauth", "", 50)
+	if err != nil {
+		t.Fatalf("Symbols() error: %v", err)
+	}
+	if len(result.Matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(result.Matches))
+	}
+	m := result.Matches[0]
+	if m.Parent != "Handler" || m.EndLine != 8 || m.Doc != "ServeAuth checks credentials." {
+		t.Errorf("metadata = parent %q, endLine %d, doc %q", m.Parent, m.EndLine, m.Doc)
+	}
+	if !strings.Contains(m.Signature, "token string") {
+		t.Errorf("Signature = %q, want parameters", m.Signature)
+	}
+
+	out := FormatSymbols(result)
+	if !strings.Contains(out, "Handler.ServeAuth") {
+		t.Errorf("FormatSymbols() should qualify the method name, got:\n%s", out)
+	}
+}
+
 func TestFormatSymbolsEmpty(t *testing.T) {
 	result := &SymbolsResult{
 		Query:   "xyz",
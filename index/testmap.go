@@ -30,12 +30,14 @@ type TestMapResult struct {
 
 // sourceExts are file extensions considered source files for test mapping.
 var sourceExts = map[string]bool{
-	".go":  true,
-	".js":  true,
-	".jsx": true,
-	".ts":  true,
-	".tsx": true,
-	".py":  true,
+	".go":   true,
+	".js":   true,
+	".jsx":  true,
+	".ts":   true,
+	".tsx":  true,
+	".py":   true,
+	".java": true,
+	".kt":   true,
 }
 
 // isTestFilePath returns true if the file path looks like a test file.
@@ -51,6 +53,8 @@ func isTestFilePath(path string) bool {
 		return strings.HasSuffix(name, ".test") || strings.HasSuffix(name, ".spec")
 	case ".py":
 		return strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test")
+	case ".java", ".kt":
+		return strings.HasSuffix(name, "Test") || strings.HasSuffix(name, "Tests")
 	}
 	return false
 }
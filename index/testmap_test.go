@@ -319,6 +319,9 @@ func TestIsTestFilePath(t *testing.T) {
 		{"test_foo.py", true},
 		{"foo_test.py", true},
 		{"foo.py", false},
+		{"UserServiceTest.java", true},
+		{"UserRepoTests.kt", true},
+		{"Latest.java", false},
 		{"README.md", false},
 	}
 	for _, tt := range tests {
@@ -40,12 +40,13 @@ func (idx *Index) Todos(tag string, maxResults int) (*TodosResult, error) {
 	byTag := map[string]int{"TODO": 0, "FIXME": 0, "HACK": 0, "XXX": 0}
 	total := 0
 
-	paths := idx.FilePaths()
+	paths := idx.contentPaths(todoPattern)
 	sort.Strings(paths)
 
-	for _, relPath := range paths {
-		absPath := filepath.Join(idx.Root, relPath)
-		found := todosInFile(absPath, relPath)
+	perFile := parallelMap(paths, idx.Workers, func(relPath string) []TodoComment {
+		return todosInFile(filepath.Join(idx.Root, relPath), relPath)
+	})
+	for _, found := range perFile {
 		for _, tc := range found {
 			byTag[tc.Tag]++
 			total++
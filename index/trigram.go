@@ -0,0 +1,479 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+)
+
+// trigramFile holds the content index written next to the store by Save. It
+// is shared by every storage backend.
+const trigramFile = "trigrams.bin"
+
+// trigramMagic identifies the trigram file format.
+const trigramMagic = "swarm-trigrams-1\n"
+
+// trigramIndex maps each three-byte sequence of lowercased file content to
+// the files containing it. Content commands use it to skip files that cannot
+// match before reading anything from disk.
+type trigramIndex struct {
+	paths     []string            // file id -> path
+	postings  map[uint32][]uint32 // trigram -> ascending file ids
+	unindexed []uint32            // files whose content was not read (too large or unreadable)
+}
+
+// fileTrigrams returns the sorted, distinct trigrams of content, lowercased
+// so case-insensitive patterns can use them too. Binary files, which content
+// commands skip, have none.
+func fileTrigrams(content []byte) []uint32 {
+	if bytes.IndexByte(content[:min(len(content), 512)], 0) >= 0 {
+		return nil
+	}
+	lower := bytes.ToLower(content)
+	if len(lower) < 3 {
+		return nil
+	}
+	tris := make([]uint32, 0, len(lower)-2)
+	for i := 0; i+3 <= len(lower); i++ {
+		tris = append(tris, trigramAt(lower, i))
+	}
+	slices.Sort(tris)
+	return slices.Compact(tris)
+}
+
+func trigramAt(b []byte, i int) uint32 {
+	return uint32(b[i])<<16 | uint32(b[i+1])<<8 | uint32(b[i+2])
+}
+
+// buildTrigramIndex assembles the index from a scan's jobs, in walk order.
+func buildTrigramIndex(jobs []*scanJob) *trigramIndex {
+	t := &trigramIndex{paths: make([]string, len(jobs)), postings: make(map[uint32][]uint32)}
+	for i, job := range jobs {
+		t.paths[i] = job.relPath
+		if !job.textIndexed {
+			t.unindexed = append(t.unindexed, uint32(i))
+			continue
+		}
+		for _, tri := range job.trigrams {
+			t.postings[tri] = append(t.postings[tri], uint32(i))
+		}
+	}
+	return t
+}
+
+// byFile inverts the index so an incremental scan can reuse the trigrams of
+// unchanged files. Files whose content was indexed are present, possibly with
+// no trigrams; unindexed files are absent.
+func (t *trigramIndex) byFile() map[string][]uint32 {
+	skip := make(map[uint32]bool, len(t.unindexed))
+	for _, id := range t.unindexed {
+		skip[id] = true
+	}
+	perID := make([][]uint32, len(t.paths))
+	for tri, ids := range t.postings {
+		for _, id := range ids {
+			perID[id] = append(perID[id], tri)
+		}
+	}
+	byPath := make(map[string][]uint32, len(t.paths))
+	for id, p := range t.paths {
+		if skip[uint32(id)] {
+			continue
+		}
+		slices.Sort(perID[id])
+		byPath[p] = perID[id]
+	}
+	return byPath
+}
+
+// trigrams returns the content index, reading it from disk on first use.
+// Indexes saved before it existed, or whose file is unreadable, return nil.
+func (idx *Index) trigrams() *trigramIndex {
+	if idx.dir != "" && !idx.trigramsLoaded {
+		idx.trigramsLoaded = true
+		if t, err := readTrigrams(filepath.Join(idx.dir, trigramFile)); err == nil {
+			idx.tri = t
+		}
+	}
+	return idx.tri
+}
+
+// contentPaths returns the indexed files that may contain a match for re, in
+// index order. Without a trigram index, or when re has no literal the index
+// can use, that is every file.
+func (idx *Index) contentPaths(re *regexp.Regexp) []string {
+	return idx.contentFilter().candidates(re)
+}
+
+// contentFilter narrows content searches to candidate files. Build one per
+// command and reuse it across patterns.
+type contentFilter struct {
+	paths []string      // every indexed file, in index order
+	tri   *trigramIndex // nil when the index has no content data
+	ids   []int         // trigram file id of each path, -1 if unknown
+	must  []bool        // paths that are always read: unindexed, or changed since the scan
+}
+
+// contentFilter prepares a filter over the index's files. Files whose size or
+// mtime no longer match their scan record are always candidates, so edits
+// made since the last scan are still found.
+func (idx *Index) contentFilter() *contentFilter {
+	f := &contentFilter{paths: idx.FilePaths(), tri: idx.trigrams()}
+	if f.tri == nil {
+		return f
+	}
+
+	ids := make(map[string]int, len(f.tri.paths))
+	for id, p := range f.tri.paths {
+		ids[p] = id
+	}
+	unindexed := make(map[int]bool, len(f.tri.unindexed))
+	for _, id := range f.tri.unindexed {
+		unindexed[int(id)] = true
+	}
+	records := idx.fileRecords()
+	root := idx.Root
+
+	f.ids = make([]int, len(f.paths))
+	f.must = parallelMap(f.paths, idx.Workers, func(p string) bool {
+		id, ok := ids[p]
+		rec, hasRec := records[p]
+		if !ok || !hasRec || unindexed[id] {
+			return true
+		}
+		info, err := os.Stat(filepath.Join(root, p))
+		return err != nil || info.Size() != rec.Size || info.ModTime().UnixNano() != rec.ModTime
+	})
+	for i, p := range f.paths {
+		if id, ok := ids[p]; ok {
+			f.ids[i] = id
+		} else {
+			f.ids[i] = -1
+		}
+	}
+	return f
+}
+
+// candidates returns the paths that may contain a match for re.
+func (f *contentFilter) candidates(re *regexp.Regexp) []string {
+	if f.tri == nil {
+		return f.paths
+	}
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return f.paths
+	}
+	ids, all := f.tri.eval(regexpQuery(parsed))
+	if all {
+		return f.paths
+	}
+	match := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		match[int(id)] = true
+	}
+	var out []string
+	for i, p := range f.paths {
+		if f.must[i] || match[f.ids[i]] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Query operators over the trigram index.
+const (
+	queryAll = iota // matches every file
+	queryAnd        // every trigram and every sub-query must match
+	queryOr         // at least one sub-query must match
+)
+
+// trigramQuery is a boolean condition a file's trigrams must satisfy for the
+// file to possibly contain a match.
+type trigramQuery struct {
+	op   int
+	tris []uint32
+	subs []*trigramQuery
+}
+
+var matchAll = &trigramQuery{op: queryAll}
+
+// regexpQuery derives the trigram condition implied by a parsed pattern: the
+// literals every match must contain. Constructs it cannot reason about, such
+// as character classes and optional parts, impose no condition.
+func regexpQuery(re *syntax.Regexp) *trigramQuery {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return literalQuery(string(re.Rune))
+	case syntax.OpCapture, syntax.OpPlus:
+		return regexpQuery(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return regexpQuery(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		q := &trigramQuery{op: queryAnd}
+		var run []rune
+		flush := func() {
+			if len(run) > 0 {
+				q.subs = append(q.subs, literalQuery(string(run)))
+				run = nil
+			}
+		}
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				run = append(run, sub.Rune...)
+				continue
+			}
+			flush()
+			q.subs = append(q.subs, regexpQuery(sub))
+		}
+		flush()
+		return q
+	case syntax.OpAlternate:
+		q := &trigramQuery{op: queryOr}
+		for _, sub := range re.Sub {
+			sq := regexpQuery(sub)
+			if sq.op == queryAll {
+				return matchAll
+			}
+			q.subs = append(q.subs, sq)
+		}
+		return q
+	}
+	return matchAll
+}
+
+// literalQuery requires every trigram of the lowercased literal s.
+func literalQuery(s string) *trigramQuery {
+	lower := []byte(strings.ToLower(s))
+	if len(lower) < 3 {
+		return matchAll
+	}
+	q := &trigramQuery{op: queryAnd}
+	for i := 0; i+3 <= len(lower); i++ {
+		q.tris = append(q.tris, trigramAt(lower, i))
+	}
+	return q
+}
+
+// eval returns the ascending ids of the files satisfying q, or all=true when
+// q places no restriction on them.
+func (t *trigramIndex) eval(q *trigramQuery) (ids []uint32, all bool) {
+	switch q.op {
+	case queryAnd:
+		all = true
+		for _, tri := range q.tris {
+			ids, all = intersectIDs(ids, all, t.postings[tri])
+			if len(ids) == 0 && !all {
+				return nil, false
+			}
+		}
+		for _, sub := range q.subs {
+			subIDs, subAll := t.eval(sub)
+			if subAll {
+				continue
+			}
+			ids, all = intersectIDs(ids, all, subIDs)
+			if len(ids) == 0 && !all {
+				return nil, false
+			}
+		}
+		return ids, all
+	case queryOr:
+		for _, sub := range q.subs {
+			subIDs, subAll := t.eval(sub)
+			if subAll {
+				return nil, true
+			}
+			ids = unionIDs(ids, subIDs)
+		}
+		return ids, false
+	}
+	return nil, true
+}
+
+// intersectIDs intersects two ascending id lists; a is every file when all is set.
+func intersectIDs(a []uint32, all bool, b []uint32) ([]uint32, bool) {
+	if all {
+		return b, false
+	}
+	var out []uint32
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out, false
+}
+
+// unionIDs merges two ascending id lists.
+func unionIDs(a, b []uint32) []uint32 {
+	out := make([]uint32, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			out = append(out, a[i])
+			i++
+		case a[i] > b[j]:
+			out = append(out, b[j])
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	out = append(out, a[i:]...)
+	return append(out, b[j:]...)
+}
+
+// writeTrigrams stores t at path, replacing any previous file atomically.
+// The format is the magic line followed by uvarints: the path table, the
+// unindexed ids, then each trigram with its file ids, delta-encoded.
+func writeTrigrams(path string, t *trigramIndex) error {
+	var buf []byte
+	buf = append(buf, trigramMagic...)
+	buf = binary.AppendUvarint(buf, uint64(len(t.paths)))
+	for _, p := range t.paths {
+		buf = binary.AppendUvarint(buf, uint64(len(p)))
+		buf = append(buf, p...)
+	}
+	buf = appendDeltas(buf, t.unindexed)
+
+	keys := make([]uint32, 0, len(t.postings))
+	for tri := range t.postings {
+		keys = append(keys, tri)
+	}
+	slices.Sort(keys)
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	prev := uint32(0)
+	for _, tri := range keys {
+		buf = binary.AppendUvarint(buf, uint64(tri-prev))
+		prev = tri
+		buf = appendDeltas(buf, t.postings[tri])
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// appendDeltas appends the count of ascending ids followed by their gaps.
+func appendDeltas(buf []byte, ids []uint32) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(ids)))
+	prev := uint32(0)
+	for _, id := range ids {
+		buf = binary.AppendUvarint(buf, uint64(id-prev))
+		prev = id
+	}
+	return buf
+}
+
+var errBadTrigrams = errors.New("malformed trigram index")
+
+// readTrigrams reads an index written by writeTrigrams.
+func readTrigrams(path string) (*trigramIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	t, err := decodeTrigrams(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	return t, nil
+}
+
+func decodeTrigrams(data []byte) (*trigramIndex, error) {
+	if !bytes.HasPrefix(data, []byte(trigramMagic)) {
+		return nil, errBadTrigrams
+	}
+	d := &uvarintDecoder{data: data[len(trigramMagic):]}
+	t := &trigramIndex{postings: make(map[uint32][]uint32)}
+	n := d.next()
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		size := d.next()
+		if size > uint64(len(d.data)) {
+			return nil, errBadTrigrams
+		}
+		t.paths = append(t.paths, string(d.data[:size]))
+		d.data = d.data[size:]
+	}
+	t.unindexed = d.deltas(len(t.paths))
+	keys := d.next()
+	tri := uint64(0)
+	for i := uint64(0); i < keys && d.err == nil; i++ {
+		tri += d.next()
+		t.postings[uint32(tri)] = d.deltas(len(t.paths))
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return t, nil
+}
+
+// uvarintDecoder reads the uvarints of a trigram file, remembering the first
+// error so callers can check once at the end.
+type uvarintDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *uvarintDecoder) next() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errBadTrigrams
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// deltas reads a list written by appendDeltas, checking that every id refers
+// to one of the n files.
+func (d *uvarintDecoder) deltas(n int) []uint32 {
+	count := d.next()
+	if count > uint64(n) {
+		d.err = errBadTrigrams
+	}
+	if d.err != nil || count == 0 {
+		return nil
+	}
+	ids := make([]uint32, 0, count)
+	id := uint64(0)
+	for i := uint64(0); i < count; i++ {
+		id += d.next()
+		if d.err == nil && id >= uint64(n) {
+			d.err = errBadTrigrams
+		}
+		if d.err != nil {
+			return nil
+		}
+		ids = append(ids, uint32(id))
+	}
+	return ids
+}
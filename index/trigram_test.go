@@ -0,0 +1,211 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestFileTrigrams(t *testing.T) {
+	got := fileTrigrams([]byte("AbcAB"))
+	want := []uint32{
+		trigramAt([]byte("abc"), 0),
+		trigramAt([]byte("bca"), 0),
+		trigramAt([]byte("cab"), 0),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fileTrigrams = %v, want %v", got, want)
+	}
+	if got := fileTrigrams([]byte("ab")); got != nil {
+		t.Errorf("short content: got %v, want nil", got)
+	}
+	if got := fileTrigrams([]byte("abc\x00def")); got != nil {
+		t.Errorf("binary content: got %v, want nil", got)
+	}
+}
+
+// trigramFixture scans a small tree whose files share few literals.
+func trigramFixture(t *testing.T) (string, *Index) {
+	t.Helper()
+	dir := t.TempDir()
+	mkFile(t, dir, "a.go", "package a\n\nfunc Alpha() {}\n")
+	mkFile(t, dir, "b.go", "package b\n\nfunc Beta() { Alpha() }\n")
+	mkFile(t, dir, "c.txt", "TODO: write docs\n")
+	mkFile(t, dir, "d.txt", "nothing here\n")
+	idx, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	return dir, idx
+}
+
+func TestContentPaths(t *testing.T) {
+	_, idx := trigramFixture(t)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`\bAlpha\b`, []string{"a.go", "b.go"}},
+		{`func Beta`, []string{"b.go"}},
+		{`(?i)alpha`, []string{"a.go", "b.go"}},
+		{`(?i)\b(TODO|FIXME|HACK|XXX)\b`, []string{"c.txt"}},
+		{`Beta|nothing`, []string{"b.go", "d.txt"}},
+		{`(Alpha)+\(`, []string{"a.go", "b.go"}},
+		{`missing`, nil},
+		{`[A-Z]\w+`, []string{"a.go", "b.go", "c.txt", "d.txt"}}, // no literal
+		{`Al|zzz`, []string{"a.go", "b.go", "c.txt", "d.txt"}},   // short alternative
+		{`package (a|b)?`, []string{"a.go", "b.go"}},             // optional part ignored
+	}
+	for _, tt := range tests {
+		got := idx.contentPaths(regexp.MustCompile(tt.pattern))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("contentPaths(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestTrigramsRoundTrip(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			dir, idx := trigramFixture(t)
+			idx.Backend = backend
+			loaded := saveAndReload(t, idx, dir)
+			defer loaded.Close()
+
+			if _, err := os.Stat(filepath.Join(dir, "swarm", "index", trigramFile)); err != nil {
+				t.Fatalf("trigram file not written: %v", err)
+			}
+			if loaded.trigrams() == nil {
+				t.Fatal("loaded index has no trigrams")
+			}
+			if !reflect.DeepEqual(loaded.trigrams(), idx.trigrams()) {
+				t.Error("trigram index changed across save and load")
+			}
+			got := loaded.contentPaths(regexp.MustCompile(`Beta`))
+			if want := []string{"b.go"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("contentPaths = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReadTrigramsRejectsCorruptFile(t *testing.T) {
+	dir, idx := trigramFixture(t)
+	path := filepath.Join(dir, trigramFile)
+	if err := writeTrigrams(path, idx.trigrams()); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, data[:len(data)-3], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readTrigrams(path); err == nil {
+		t.Error("expected error for truncated trigram file")
+	}
+	if err := os.WriteFile(path, []byte("not a trigram index"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readTrigrams(path); err == nil {
+		t.Error("expected error for wrong magic")
+	}
+}
+
+func TestLoadWithoutTrigramsSearchesEveryFile(t *testing.T) {
+	dir, idx := trigramFixture(t)
+	loaded := saveAndReload(t, idx, dir)
+	defer loaded.Close()
+	os.Remove(filepath.Join(dir, "swarm", "index", trigramFile))
+
+	if loaded.trigrams() != nil {
+		t.Fatal("expected no trigram index")
+	}
+	got := loaded.contentPaths(regexp.MustCompile(`Beta`))
+	if len(got) != 4 {
+		t.Errorf("contentPaths = %v, want all 4 files", got)
+	}
+	matches, err := loaded.Search("Beta", 10)
+	if err != nil || len(matches) != 1 {
+		t.Errorf("Search = %v, %v; want one match", matches, err)
+	}
+}
+
+func TestContentPathsIncludesFilesChangedSinceScan(t *testing.T) {
+	dir, idx := trigramFixture(t)
+	loaded := saveAndReload(t, idx, dir)
+	defer loaded.Close()
+
+	// d.txt gains the literal after the scan; the stale trigrams would
+	// exclude it, but its size no longer matches the scan record.
+	mkFile(t, dir, "d.txt", "now mentions Gamma\n")
+	matches, err := loaded.Search("Gamma", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Path != "d.txt" {
+		t.Errorf("Search = %v, want the edited d.txt", matches)
+	}
+}
+
+func TestContentPathsIncludesUnindexedFiles(t *testing.T) {
+	dir := t.TempDir()
+	mkFile(t, dir, "small.go", "package small\n")
+	mkFile(t, dir, "big.txt", "Needle in a large file, padded out past the limit.\n")
+	idx, _, err := ScanWithOptions(dir, nil, ScanOptions{MaxFileSize: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := idx.contentPaths(regexp.MustCompile(`Needle`))
+	if want := []string{"big.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contentPaths = %v, want %v", got, want)
+	}
+}
+
+func TestScanIncrementalReusesTrigrams(t *testing.T) {
+	dir, idx := trigramFixture(t)
+	prev := saveAndReload(t, idx, dir)
+	defer prev.Close()
+
+	// Change b.go so it no longer mentions Alpha; the rest are reused.
+	mkFile(t, dir, "b.go", "package b\n\nfunc Beta() {}\n")
+	future := time.Now().Add(time.Second)
+	os.Chtimes(filepath.Join(dir, "b.go"), future, future)
+
+	next, _, err := ScanIncremental(dir, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(next.trigrams(), full.trigrams()) {
+		t.Error("incremental trigrams differ from a full scan")
+	}
+	got := next.contentPaths(regexp.MustCompile(`Alpha`))
+	if want := []string{"a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("contentPaths = %v, want %v", got, want)
+	}
+}
+
+func TestScanIncrementalRebuildsMissingTrigrams(t *testing.T) {
+	dir, idx := trigramFixture(t)
+	prev := saveAndReload(t, idx, dir)
+	defer prev.Close()
+	os.Remove(filepath.Join(dir, "swarm", "index", trigramFile))
+
+	next, changes, err := ScanIncremental(dir, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Unchanged != 4 {
+		t.Errorf("Unchanged = %d, want 4", changes.Unchanged)
+	}
+	full, _ := Scan(dir)
+	if !reflect.DeepEqual(next.trigrams(), full.trigrams()) {
+		t.Error("trigrams not rebuilt for unchanged files")
+	}
+}
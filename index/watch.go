@@ -0,0 +1,78 @@
+package index
+
+import (
+	"time"
+)
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	Interval time.Duration // time between polls; defaults to one second
+	Backend  string        // store used for saves; defaults to the existing index's store
+	Scan     ScanOptions   // worker count and file-size cutoff for each poll
+
+	// OnSave, if set, is called after each save with the new index and what
+	// changed since the previous one.
+	OnSave func(idx *Index, changes *ScanChanges)
+}
+
+// Watch keeps the index saved under dir in sync with root until stop is
+// closed. Each poll walks root with the same skip and .swarmignore rules as
+// Scan, re-parses only files that were added or whose content changed, and
+// rewrites the stored index when anything changed. The first poll starts from
+// the index already saved under dir, if it was scanned from the same root.
+func Watch(root, dir string, opts WatchOptions, stop <-chan struct{}) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	// A missing or unreadable index just means the first poll is a full scan.
+	prev, _ := Load(dir)
+	backend := opts.Backend
+	if backend == "" && prev != nil {
+		backend = prev.Backend
+	}
+	if backend == "" {
+		backend = BackendJSON
+	}
+
+	// The first poll also saves when there is no index yet or its store is
+	// being switched.
+	dirty := prev == nil || prev.Backend != backend
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		next, changes, err := ScanWithOptions(root, prev, opts.Scan)
+		if prev != nil {
+			prev.Close()
+		}
+		if err != nil {
+			return err
+		}
+		if dirty || changes.any() {
+			next.Backend = backend
+			if err := next.Save(dir); err != nil {
+				return err
+			}
+			if opts.OnSave != nil {
+				opts.OnSave(next, changes)
+			}
+		}
+		// Keep the in-memory index, whose file records carry the latest
+		// mtimes, so touched-but-unchanged files take the fast path next time.
+		prev = next
+		dirty = false
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// any reports whether an incremental scan found anything to rewrite.
+func (c *ScanChanges) any() bool {
+	return len(c.Added) > 0 || len(c.Modified) > 0 || len(c.Removed) > 0
+}
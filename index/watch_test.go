@@ -0,0 +1,130 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// startWatch runs Watch in the background and returns a channel of saves and
+// a function that stops the watcher and returns its error.
+func startWatch(t *testing.T, root string, opts WatchOptions) (<-chan *ScanChanges, func() error) {
+	t.Helper()
+	saves := make(chan *ScanChanges, 16)
+	opts.Interval = 10 * time.Millisecond
+	opts.OnSave = func(idx *Index, changes *ScanChanges) { saves <- changes }
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- Watch(root, root, opts, stop) }()
+	return saves, func() error {
+		close(stop)
+		return <-done
+	}
+}
+
+func nextSave(t *testing.T, saves <-chan *ScanChanges) *ScanChanges {
+	t.Helper()
+	select {
+	case c := <-saves:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watcher to save")
+		return nil
+	}
+}
+
+func TestWatchKeepsIndexFresh(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "keep.go", "package main\n\nfunc Keep() {}\n")
+	mkFile(t, tmp, "edit.go", "package main\n\nfunc Before() {}\n")
+	mkFile(t, tmp, ".swarmignore", "ignored/\n")
+
+	saves, stop := startWatch(t, tmp, WatchOptions{})
+
+	first := nextSave(t, saves)
+	if len(first.Added) != 3 {
+		t.Errorf("first save Added = %v, want all 3 files", first.Added)
+	}
+
+	mkFile(t, tmp, "edit.go", "package main\n\nfunc After() {}\n")
+	mkFile(t, tmp, "ignored/skip.go", "package ignored\n\nfunc Skipped() {}\n")
+	changes := nextSave(t, saves)
+	if !reflect.DeepEqual(changes.Modified, []string{"edit.go"}) || len(changes.Added) != 0 {
+		t.Errorf("changes = %+v, want only edit.go modified", changes)
+	}
+
+	if err := stop(); err != nil {
+		t.Fatalf("Watch() error: %v", err)
+	}
+
+	idx, err := Load(tmp)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	names := map[string]bool{}
+	for _, e := range idx.Entries {
+		names[e.Name] = true
+	}
+	if !names["After"] || names["Before"] || names["Skipped"] {
+		t.Errorf("saved index names = %v, want After but not Before or Skipped", names)
+	}
+}
+
+func TestWatchSkipsSaveWhenNothingChanged(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main\n")
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	saves, stop := startWatch(t, tmp, WatchOptions{})
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(tmp, "main.go"), future, future) // touch only
+	time.Sleep(100 * time.Millisecond)
+	if err := stop(); err != nil {
+		t.Fatalf("Watch() error: %v", err)
+	}
+	if len(saves) != 0 {
+		t.Errorf("watcher saved %d times, want 0 when no content changed", len(saves))
+	}
+}
+
+func TestWatchSwitchesStore(t *testing.T) {
+	tmp := t.TempDir()
+	mkFile(t, tmp, "main.go", "package main\n")
+	idx, err := Scan(tmp)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if err := idx.Save(tmp); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	saves, stop := startWatch(t, tmp, WatchOptions{Backend: BackendSQLite})
+	nextSave(t, saves)
+	if err := stop(); err != nil {
+		t.Fatalf("Watch() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "swarm", "index", "index.db")); err != nil {
+		t.Errorf("index.db not written: %v", err)
+	}
+}
+
+func TestSaveLeavesNoTempFiles(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			tmp := makeStoreFixture(t)
+			scanAndSave(t, tmp, backend)
+			matches, _ := filepath.Glob(filepath.Join(tmp, "swarm", "index", "*.tmp"))
+			if len(matches) != 0 {
+				t.Errorf("temporary files left behind: %v", matches)
+			}
+		})
+	}
+}
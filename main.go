@@ -4,15 +4,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/mj1618/swarm-index/index"
 	"github.com/mj1618/swarm-index/parsers"
 )
 
+// version is reported by the version command and to MCP clients.
+const version = "v0.1.0"
+
 // extractJSONFlag strips --json from args and returns whether it was present.
 func extractJSONFlag(args []string) ([]string, bool) {
 	var filtered []string
@@ -42,6 +48,7 @@ func fatal(useJSON bool, msg string) {
 
 func main() {
 	args, jsonOutput := extractJSONFlag(os.Args)
+	workers := parseIntFlag(args, "--workers", 0)
 
 	if len(args) < 2 {
 		if !jsonOutput {
@@ -53,13 +60,41 @@ func main() {
 	switch args[1] {
 	case "scan":
 		if len(args) < 3 {
-			fatal(jsonOutput, "usage: swarm-index scan <directory>")
+			fatal(jsonOutput, "usage: swarm-index scan <directory> [--store json|sqlite] [--incremental] [--workers N] [--max-file-size SIZE]")
 		}
 		dir := args[2]
-		idx, err := index.Scan(dir)
+		extraArgs := args[3:]
+		backend := parseStringFlag(extraArgs, "--store", "")
+		if backend != "" && !index.ValidBackend(backend) {
+			fatal(jsonOutput, fmt.Sprintf("error: unknown store %q (want json or sqlite)", backend))
+		}
+		incremental := hasBoolFlag(extraArgs, "--incremental")
+		opts, err := parseScanOptions(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		var idx *index.Index
+		var changes *index.ScanChanges
+		if incremental {
+			// A missing or unreadable index just means a full scan.
+			prev, _ := index.Load(".")
+			idx, changes, err = index.ScanWithOptions(dir, prev, opts)
+			if prev != nil {
+				if backend == "" {
+					backend = prev.Backend
+				}
+				prev.Close()
+			}
+		} else {
+			idx, _, err = index.ScanWithOptions(dir, nil, opts)
+		}
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
+		if backend == "" {
+			backend = index.BackendJSON
+		}
+		idx.Backend = backend
 		if err := idx.Save("."); err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error saving index: %v", err))
 		}
@@ -68,7 +103,12 @@ func main() {
 				"filesIndexed": idx.FileCount(),
 				"packages":     idx.PackageCount(),
 				"indexPath":    "./swarm/index/",
+				"store":        backend,
 				"extensions":   idx.ExtensionCounts(),
+				"diagnostics":  idx.DiagnosticCounts(),
+			}
+			if changes != nil {
+				result["changes"] = changes
 			}
 			data, _ := json.Marshal(result)
 			fmt.Println(string(data))
@@ -77,6 +117,11 @@ func main() {
 			if summary := extensionSummary(idx.ExtensionCounts()); summary != "" {
 				fmt.Printf("  %s\n", summary)
 			}
+			if changes != nil {
+				fmt.Printf("  %d added, %d modified, %d removed, %d unchanged\n",
+					len(changes.Added), len(changes.Modified), len(changes.Removed), changes.Unchanged)
+			}
+			printScanDiagnostics(idx)
 		}
 
 	case "lookup":
@@ -94,7 +139,7 @@ func main() {
 		}
 		max := parseIntFlag(extraArgs, "--max", 20)
 		exact := hasBoolFlag(extraArgs, "--exact")
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -152,7 +197,7 @@ func main() {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
 		max := parseIntFlag(extraArgs, "--max", 50)
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -183,7 +228,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -236,7 +281,7 @@ func main() {
 
 	case "refs":
 		if len(args) < 3 {
-			fatal(jsonOutput, "usage: swarm-index refs <symbol> [--root <dir>] [--max N]")
+			fatal(jsonOutput, "usage: swarm-index refs <symbol> [--root <dir>] [--max N] [--types]")
 		}
 		symbol := args[2]
 		extraArgs := args[3:]
@@ -245,11 +290,15 @@ func main() {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
 		max := parseIntFlag(extraArgs, "--max", 50)
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		refsResult, err := idx.Refs(symbol, max)
+		refs := idx.Refs
+		if hasBoolFlag(extraArgs, "--types") {
+			refs = idx.TypedRefs
+		}
+		refsResult, err := refs(symbol, max)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -259,7 +308,7 @@ func main() {
 		} else {
 			if refsResult.Definition != nil {
 				fmt.Println("Definition:")
-				fmt.Printf("  %s:%d  %s\n", refsResult.Definition.Path, refsResult.Definition.Line, refsResult.Definition.Content)
+				fmt.Printf("  %s:%d  %s%s\n", refsResult.Definition.Path, refsResult.Definition.Line, refTag(*refsResult.Definition), refsResult.Definition.Content)
 				fmt.Println()
 			}
 			if len(refsResult.References) == 0 {
@@ -271,7 +320,7 @@ func main() {
 			} else {
 				fmt.Printf("References (%d matches):\n", refsResult.TotalRefs)
 				for _, r := range refsResult.References {
-					fmt.Printf("  %s:%d  %s\n", r.Path, r.Line, r.Content)
+					fmt.Printf("  %s:%d  %s%s\n", r.Path, r.Line, refTag(r), r.Content)
 				}
 			}
 		}
@@ -285,8 +334,11 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
+		if err := index.LoadParserPlugins(pluginDir(filePath)); err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
 		ext := filepath.Ext(filePath)
-		p := parsers.ForExtension(ext)
+		p := parsers.ForFile(filePath)
 		if p == nil {
 			fatal(jsonOutput, fmt.Sprintf("no parser available for %s files", ext))
 		}
@@ -317,7 +369,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -340,7 +392,7 @@ func main() {
 		}
 		max := parseIntFlag(extraArgs, "--max", 100)
 		tag := parseStringFlag(extraArgs, "--tag", "")
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -365,7 +417,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -392,7 +444,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -436,7 +488,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -460,7 +512,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -481,7 +533,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -504,7 +556,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -535,7 +587,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -550,9 +602,30 @@ func main() {
 			fmt.Print(index.FormatConfig(configResult))
 		}
 
+	case "services":
+		extraArgs := args[2:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		idx, err := loadIndex(root, workers)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		servicesResult, err := idx.Services()
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		if jsonOutput {
+			data, _ := json.MarshalIndent(servicesResult, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(index.FormatServices(servicesResult))
+		}
+
 	case "context":
 		if len(args) < 4 {
-			fatal(jsonOutput, "usage: swarm-index context <symbol> <file> [--root <dir>]")
+			fatal(jsonOutput, "usage: swarm-index context <symbol> <file> [--root <dir>] [--types]")
 		}
 		symbol := args[2]
 		filePath := args[3]
@@ -561,7 +634,25 @@ func main() {
 		if root != "" {
 			filePath = filepath.Join(root, filePath)
 		}
-		contextResult, err := index.Context(filePath, symbol)
+		if err := index.LoadParserPlugins(pluginDir(filePath)); err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		var contextResult *index.ContextResult
+		var err error
+		if hasBoolFlag(extraArgs, "--types") {
+			root, rootErr := resolveRoot(extraArgs)
+			if rootErr != nil {
+				fatal(jsonOutput, fmt.Sprintf("error: %v", rootErr))
+			}
+			idx, loadErr := loadIndex(root, workers)
+			if loadErr != nil {
+				fatal(jsonOutput, fmt.Sprintf("error: %v", loadErr))
+			}
+			absPath, _ := filepath.Abs(filePath)
+			contextResult, err = idx.TypedContext(absPath, symbol)
+		} else {
+			contextResult, err = index.Context(filePath, symbol)
+		}
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -584,7 +675,7 @@ func main() {
 		}
 		max := parseIntFlag(extraArgs, "--max", 50)
 		kind := parseStringFlag(extraArgs, "--kind", "")
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -619,7 +710,7 @@ func main() {
 			if rootErr != nil {
 				fatal(jsonOutput, fmt.Sprintf("error: %v", rootErr))
 			}
-			idx, loadErr := index.Load(root)
+			idx, loadErr := loadIndex(root, workers)
 			if loadErr != nil {
 				fatal(jsonOutput, fmt.Sprintf("error: %v", loadErr))
 			}
@@ -641,7 +732,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -656,6 +747,68 @@ func main() {
 			fmt.Print(index.FormatStale(staleResult))
 		}
 
+	case "scan-report":
+		extraArgs := args[2:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		idx, err := loadIndex(root, workers)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		report, err := idx.ScanReport(parseStringFlag(extraArgs, "--kind", ""), parseStringFlag(extraArgs, "--path", ""), parseIntFlag(extraArgs, "--max", 100))
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		if jsonOutput {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(index.FormatScanReport(report))
+		}
+
+	case "doc-refs":
+		extraArgs := args[2:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		idx, err := loadIndex(root, workers)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		docRefs, err := idx.DocRefs(parseStringFlag(extraArgs, "--kind", ""), parseStringFlag(extraArgs, "--path", ""), parseIntFlag(extraArgs, "--max", 100))
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		if jsonOutput {
+			data, _ := json.MarshalIndent(docRefs, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(index.FormatDocRefs(docRefs))
+		}
+
+	case "doctor":
+		extraArgs := args[2:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		doctorResult, err := index.Doctor(root)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		if jsonOutput {
+			data, _ := json.MarshalIndent(doctorResult, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(index.FormatDoctor(doctorResult))
+		}
+		if !doctorResult.Healthy {
+			os.Exit(1)
+		}
+
 	case "blame":
 		if len(args) < 3 {
 			fatal(jsonOutput, "usage: swarm-index blame <file> [--lines M:N] [--root <dir>]")
@@ -696,7 +849,7 @@ func main() {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
 		max := parseIntFlag(extraArgs, "--max", 20)
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -722,7 +875,7 @@ func main() {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
 		recursive := hasBoolFlag(extraArgs, "--recursive")
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -743,7 +896,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -767,7 +920,7 @@ func main() {
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -788,7 +941,7 @@ func main() {
 
 	case "impact":
 		if len(args) < 3 {
-			fatal(jsonOutput, "usage: swarm-index impact <symbol-or-file> [--root <dir>] [--depth N] [--max N]")
+			fatal(jsonOutput, "usage: swarm-index impact <symbol-or-file> [--root <dir>] [--depth N] [--max N] [--types]")
 		}
 		target := args[2]
 		extraArgs := args[3:]
@@ -798,11 +951,15 @@ func main() {
 		}
 		depth := parseIntFlag(extraArgs, "--depth", 3)
 		max := parseIntFlag(extraArgs, "--max", 100)
-		idx, err := index.Load(root)
+		idx, err := loadIndex(root, workers)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
-		impactResult, err := idx.Impact(target, depth, max)
+		impact := idx.Impact
+		if hasBoolFlag(extraArgs, "--types") {
+			impact = idx.TypedImpact
+		}
+		impactResult, err := impact(target, depth, max)
 		if err != nil {
 			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
 		}
@@ -813,12 +970,217 @@ func main() {
 			fmt.Print(index.FormatImpact(impactResult))
 		}
 
+	case "callers":
+		if len(args) < 3 {
+			fatal(jsonOutput, "usage: swarm-index callers <func> [--root <dir>] [--max N]")
+		}
+		query := args[2]
+		extraArgs := args[3:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		max := parseIntFlag(extraArgs, "--max", 100)
+		idx, err := loadIndex(root, workers)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		graphResult, err := idx.Callers(query, max)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		if jsonOutput {
+			data, _ := json.MarshalIndent(graphResult, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(index.FormatCallGraph(graphResult))
+		}
+
+	case "callees":
+		if len(args) < 3 {
+			fatal(jsonOutput, "usage: swarm-index callees <func> [--root <dir>] [--max N]")
+		}
+		query := args[2]
+		extraArgs := args[3:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		max := parseIntFlag(extraArgs, "--max", 100)
+		idx, err := loadIndex(root, workers)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		graphResult, err := idx.Callees(query, max)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		if jsonOutput {
+			data, _ := json.MarshalIndent(graphResult, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(index.FormatCallGraph(graphResult))
+		}
+
+	case "implementations":
+		if len(args) < 3 {
+			fatal(jsonOutput, "usage: swarm-index implementations <interface> [--root <dir>] [--max N]")
+		}
+		query := args[2]
+		extraArgs := args[3:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		max := parseIntFlag(extraArgs, "--max", 100)
+		idx, err := loadIndex(root, workers)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		hierarchyResult, err := idx.Implementations(query, max)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		if jsonOutput {
+			data, _ := json.MarshalIndent(hierarchyResult, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(index.FormatHierarchy(hierarchyResult))
+		}
+
+	case "supertypes":
+		if len(args) < 3 {
+			fatal(jsonOutput, "usage: swarm-index supertypes <type> [--root <dir>] [--max N]")
+		}
+		query := args[2]
+		extraArgs := args[3:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		max := parseIntFlag(extraArgs, "--max", 100)
+		idx, err := loadIndex(root, workers)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		hierarchyResult, err := idx.Supertypes(query, max)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		if jsonOutput {
+			data, _ := json.MarshalIndent(hierarchyResult, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(index.FormatHierarchy(hierarchyResult))
+		}
+
+	case "serve":
+		extraArgs := args[2:]
+		root, err := resolveRoot(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		socket := parseStringFlag(extraArgs, "--socket", "")
+		server, err := index.NewServer(root)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		server.SetWorkers(workers)
+		defer server.Close()
+		if socket != "" {
+			fmt.Fprintf(os.Stderr, "serving %s on %s\n", root, socket)
+			err = server.ServeUnix(socket)
+		} else {
+			err = server.Serve(os.Stdin, os.Stdout)
+		}
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+
+	case "mcp":
+		root, err := resolveRoot(args[2:])
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		server, err := index.NewServer(root)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		server.SetWorkers(workers)
+		defer server.Close()
+		if err := server.ServeMCP(os.Stdin, os.Stdout, version); err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+
+	case "lsp":
+		root, err := resolveRoot(args[2:])
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		server, err := index.NewServer(root)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		server.SetWorkers(workers)
+		defer server.Close()
+		if err := server.ServeLSP(os.Stdin, os.Stdout); err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+
+	case "watch":
+		extraArgs := args[2:]
+		dir := "."
+		if len(extraArgs) > 0 && !strings.HasPrefix(extraArgs[0], "--") {
+			dir = extraArgs[0]
+			extraArgs = extraArgs[1:]
+		}
+		backend := parseStringFlag(extraArgs, "--store", "")
+		if backend != "" && !index.ValidBackend(backend) {
+			fatal(jsonOutput, fmt.Sprintf("error: unknown store %q (want json or sqlite)", backend))
+		}
+		interval, err := time.ParseDuration(parseStringFlag(extraArgs, "--interval", "1s"))
+		if err != nil || interval <= 0 {
+			fatal(jsonOutput, "error: --interval must be a positive duration such as 500ms or 2s")
+		}
+		scanOpts, err := parseScanOptions(extraArgs)
+		if err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+		stop := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			close(stop)
+		}()
+		opts := index.WatchOptions{
+			Interval: interval,
+			Backend:  backend,
+			Scan:     scanOpts,
+			OnSave: func(idx *index.Index, changes *index.ScanChanges) {
+				if jsonOutput {
+					data, _ := json.Marshal(map[string]interface{}{
+						"filesIndexed": idx.FileCount(),
+						"changes":      changes,
+					})
+					fmt.Println(string(data))
+				} else {
+					fmt.Printf("%s  index saved (%d files): %d added, %d modified, %d removed\n",
+						time.Now().Format("15:04:05"), idx.FileCount(),
+						len(changes.Added), len(changes.Modified), len(changes.Removed))
+				}
+			},
+		}
+		if err := index.Watch(dir, ".", opts, stop); err != nil {
+			fatal(jsonOutput, fmt.Sprintf("error: %v", err))
+		}
+
 	case "version":
 		if jsonOutput {
-			data, _ := json.Marshal(map[string]string{"version": "v0.1.0"})
+			data, _ := json.Marshal(map[string]string{"version": version})
 			fmt.Println(string(data))
 		} else {
-			fmt.Println("swarm-index v0.1.0")
+			fmt.Println("swarm-index " + version)
 		}
 
 	default:
@@ -843,6 +1205,18 @@ func parseIntFlag(args []string, flag string, defaultVal int) int {
 	return defaultVal
 }
 
+// refTag labels a type-checked reference with its kind and the
+// declaration it resolves to, e.g. "[call index.Index.Save] ".
+func refTag(r index.RefMatch) string {
+	switch {
+	case r.Kind != "":
+		return fmt.Sprintf("[%s %s] ", r.Kind, r.Target)
+	case r.Target != "":
+		return fmt.Sprintf("[%s] ", r.Target)
+	}
+	return ""
+}
+
 // hasBoolFlag returns true if the given flag is present in args.
 func hasBoolFlag(args []string, flag string) bool {
 	for _, arg := range args {
@@ -864,6 +1238,64 @@ func parseStringFlag(args []string, flag string, defaultVal string) string {
 	return defaultVal
 }
 
+// parseScanOptions reads --workers N and --max-file-size SIZE from args.
+func parseScanOptions(args []string) (index.ScanOptions, error) {
+	opts := index.ScanOptions{Workers: parseIntFlag(args, "--workers", 0)}
+	if size := parseStringFlag(args, "--max-file-size", ""); size != "" {
+		n, err := parseSize(size)
+		if err != nil {
+			return opts, err
+		}
+		opts.MaxFileSize = n
+	}
+	return opts, nil
+}
+
+// parseSize parses a byte count with an optional K, M, or G suffix (an
+// optional trailing B is allowed), e.g. "500000", "512KB", "2M".
+func parseSize(s string) (int64, error) {
+	num := strings.ToUpper(strings.TrimSpace(s))
+	num = strings.TrimSuffix(num, "B")
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(num, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(num, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(num, "G"):
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		num = num[:len(num)-1]
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 500000, 512KB, or 2MB)", s)
+	}
+	return n * mult, nil
+}
+
+// pluginDir returns the directory whose parser plugin config applies to
+// filePath: the root of the index containing it, or else its directory.
+func pluginDir(filePath string) string {
+	dir := filepath.Dir(filePath)
+	if root, err := findIndexRoot(dir); err == nil {
+		return root
+	}
+	return dir
+}
+
+// loadIndex loads the index saved under root, with commands that read file
+// contents using the given number of goroutines.
+func loadIndex(root string, workers int) (*index.Index, error) {
+	idx, err := index.Load(root)
+	if err != nil {
+		return nil, err
+	}
+	idx.Workers = workers
+	return idx, nil
+}
+
 // resolveRoot checks args for --root <dir>. If not found, walks up from CWD.
 func resolveRoot(args []string) (string, error) {
 	for i, arg := range args {
@@ -893,6 +1325,27 @@ func findIndexRoot(dir string) (string, error) {
 	}
 }
 
+// printScanDiagnostics prints a one-line count of the scan's diagnostics
+// and lists the parse errors and unreadable files, which usually need
+// fixing, up to a limit.
+func printScanDiagnostics(idx *index.Index) {
+	summary := idx.DiagnosticSummary()
+	if summary == "" {
+		return
+	}
+	fmt.Printf("  Diagnostics: %s\n", summary)
+	const limit = 10
+	shown := 0
+	for _, kind := range []string{index.DiagParseError, index.DiagUnreadable} {
+		report, _ := idx.ScanReport(kind, "", limit-shown)
+		for _, d := range report.Diagnostics {
+			fmt.Printf("    %s\n", d)
+			shown++
+		}
+	}
+	fmt.Println("  Run 'swarm-index scan-report' for details.")
+}
+
 // extensionSummary returns a one-line summary of extension counts, sorted by
 // count descending. Example: ".go: 28, .md: 8, .json: 4"
 func extensionSummary(counts map[string]int) string {
@@ -966,22 +1419,23 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, `swarm-index — a helpful index lookup for coding agents
 
 Usage:
-  swarm-index scan <directory>    Scan and index a codebase
+  swarm-index scan <directory> [--store json|sqlite] [--incremental] [--workers N] [--max-file-size SIZE]   Scan and index a codebase
   swarm-index lookup <query> [--root <dir>] [--max N] [--exact]   Look up symbols, files, or concepts (fuzzy-ranked by default)
   swarm-index search <pattern> [--root <dir>] [--max N]   Regex search across file contents
   swarm-index summary [--root <dir>]   Show project overview (languages, LOC, entry points)
   swarm-index tree <directory> [--depth N]   Print directory structure
   swarm-index show <path> [--lines M:N]   Read a file with line numbers
-  swarm-index refs <symbol> [--root <dir>] [--max N]   Find all references to a symbol
+  swarm-index refs <symbol> [--root <dir>] [--max N] [--types]   Find all references to a symbol
   swarm-index outline <file>      Show top-level symbols (functions, types, etc.)
   swarm-index exports <file|directory> [--root <dir>]   List exported/public symbols
-  swarm-index context <symbol> <file> [--root <dir>]   Show symbol definition with imports and doc comments
+  swarm-index context <symbol> <file> [--root <dir>] [--types]   Show symbol definition with imports and doc comments
   swarm-index todos [--root <dir>] [--max N] [--tag TAG]   Find TODO/FIXME/HACK/XXX comments
   swarm-index related <file> [--root <dir>]   Show imports, importers, and test files for a file
   swarm-index graph [--root <dir>] [--format dot|list] [--focus <file>] [--depth N]   Show project-wide import dependency graph
   swarm-index deps [--root <dir>]   List dependencies from manifest files (go.mod, package.json, etc.)
-  swarm-index entry-points [--root <dir>] [--max N] [--kind KIND]   Find main functions, route handlers, CLI commands, init functions
+  swarm-index entry-points [--root <dir>] [--max N] [--kind KIND]   Find main functions, route handlers, CLI commands, init functions, API operations
   swarm-index config [--root <dir>]   Detect project toolchain (framework, build, test, lint, format)
+  swarm-index services [--root <dir>]   Summarize runtime services from Compose, Kubernetes, and Dockerfiles (image, command, ports, env)
   swarm-index diff-summary [git-ref] [--root <dir>]   Show changed files and affected symbols since a git ref
   swarm-index history <file> [--root <dir>] [--max N]   Show recent git commits for a file
   swarm-index hotspots [--root <dir>] [--max N] [--since <time>] [--path <prefix>]   Show most frequently changed files
@@ -992,10 +1446,25 @@ Usage:
   swarm-index scope <directory> [--root <dir>] [--recursive]   Summarize a directory: files, symbols, LOC, dependencies
   swarm-index dead-code [--root <dir>] [--max N] [--kind KIND] [--path PREFIX]   Detect potentially unused exports
   swarm-index stale [--root <dir>]   Check if index is out of date
+  swarm-index doctor [--root <dir>]   Check index integrity (schema, root, missing files, line numbers, counts)
+  swarm-index scan-report [--root <dir>] [--kind KIND] [--path PREFIX] [--max N]   Show parse errors and files the last scan skipped
+  swarm-index doc-refs [--root <dir>] [--kind symbol|file|anchor] [--path PREFIX] [--max N]   Find doc references to symbols, files, or headings that no longer exist
   swarm-index test-map [--root <dir>] [--path PREFIX] [--untested] [--tested] [--max N]   Show source-to-test-file mapping
-  swarm-index impact <symbol-or-file> [--root <dir>] [--depth N] [--max N]   Analyze blast radius of a symbol or file
+  swarm-index impact <symbol-or-file> [--root <dir>] [--depth N] [--max N] [--types]   Analyze blast radius of a symbol or file
+  swarm-index callers <func> [--root <dir>] [--max N]   Go call sites that call a function or method
+  swarm-index callees <func> [--root <dir>] [--max N]   Go functions and methods a function calls
+  swarm-index implementations <interface> [--root <dir>] [--max N]   Types that implement an interface or extend a type
+  swarm-index supertypes <type> [--root <dir>] [--max N]   Interfaces and base types of a type
+  swarm-index serve [--root <dir>] [--socket <path>]   Answer JSON-RPC queries over stdio or a Unix socket
+  swarm-index mcp [--root <dir>]   Serve the index as Model Context Protocol tools over stdio
+  swarm-index lsp [--root <dir>]   Serve symbols, definitions, references, and hover over the Language Server Protocol
+  swarm-index watch [directory] [--interval DURATION] [--store json|sqlite] [--workers N] [--max-file-size SIZE]   Keep the index up to date as files change
   swarm-index version             Print version info
 
+Global flags:
+  --json        Output structured JSON
+  --workers N   Goroutines for scans and commands that read file contents (default: one per CPU)
+
 Examples:
   swarm-index scan .
   swarm-index lookup "handleAuth"
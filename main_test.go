@@ -195,3 +195,27 @@ func TestExtractJSONFlagEmpty(t *testing.T) {
 		t.Errorf("args = %v, want empty", args)
 	}
 }
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"500000", 500000, false},
+		{"512KB", 512 << 10, false},
+		{"512k", 512 << 10, false},
+		{"2MB", 2 << 20, false},
+		{"1G", 1 << 30, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"-5", 0, true},
+		{"lots", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
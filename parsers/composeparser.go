@@ -0,0 +1,81 @@
+package parsers
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+)
+
+func init() {
+	Register(&ComposeParser{})
+}
+
+// ComposeParser extracts the services of Docker Compose files. A service's
+// signature names the image it runs or the directory it is built from.
+// Files are recognised by their conventional names (compose.yaml,
+// docker-compose.prod.yml) or by top-level services with an image or
+// build; other YAML files yield no symbols.
+type ComposeParser struct{}
+
+func (p *ComposeParser) Extensions() []string {
+	return []string{".yaml", ".yml"}
+}
+
+var composeFileRe = regexp.MustCompile(`^(?:docker-)?compose(?:\.[\w.-]+)?\.ya?ml$`)
+
+func (p *ComposeParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	if !bytes.Contains(content, []byte("services:")) {
+		return nil, nil
+	}
+	nodes := YAMLNodes(content)
+	if !IsComposeFile(filePath, nodes) {
+		return nil, nil
+	}
+	values := DataValues(nodes)
+	var symbols []Symbol
+	for _, n := range nodes {
+		if n.Doc != 0 || len(n.Path) != 2 || n.Path[0] != "services" {
+			continue
+		}
+		name := n.Path[1]
+		sig := "service " + name
+		if image := values[DataKey("services", name, "image")]; image != "" {
+			sig += " (image " + image + ")"
+		} else if build := ComposeBuildContext(values, name); build != "" {
+			sig += " (build " + build + ")"
+		}
+		symbols = append(symbols, Symbol{
+			Name:      name,
+			Kind:      "service",
+			Line:      n.Line,
+			EndLine:   n.EndLine,
+			Exported:  true,
+			Signature: sig,
+		})
+	}
+	return symbols, nil
+}
+
+// IsComposeFile reports whether the YAML file at path, read into nodes, is
+// a Compose file.
+func IsComposeFile(path string, nodes []DataNode) bool {
+	if composeFileRe.MatchString(filepath.Base(path)) {
+		return true
+	}
+	for _, n := range nodes {
+		if n.Doc == 0 && len(n.Path) == 3 && n.Path[0] == "services" && (n.Path[2] == "image" || n.Path[2] == "build") {
+			return true
+		}
+	}
+	return false
+}
+
+// ComposeBuildContext returns the build context of the named service in a
+// Compose file's DataValues, or "" if it is not built. The short form
+// "build: ./api" and the context key of the long form are understood.
+func ComposeBuildContext(values map[string]string, service string) string {
+	if build := values[DataKey("services", service, "build")]; build != "" {
+		return build
+	}
+	return values[DataKey("services", service, "build", "context")]
+}
@@ -0,0 +1,77 @@
+package parsers
+
+import "testing"
+
+const sampleComposeFile = `services:
+  api:
+    build:
+      context: ./api
+      target: runtime
+    ports:
+      - "8080:8080"
+    env_file: .env
+  web:
+    image: nginx:1.25 # reverse proxy
+    depends_on: [api]
+  db:
+    image: postgres:16
+
+volumes:
+  data: {}
+`
+
+func TestComposeParser(t *testing.T) {
+	symbols, err := (&ComposeParser{}).Parse("docker-compose.yml", []byte(sampleComposeFile))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"api", 2, 8, "service api (build ./api)"},
+		{"web", 9, 11, "service web (image nginx:1.25)"},
+		{"db", 12, 13, "service db (image postgres:16)"},
+	}
+	if len(symbols) != len(tests) {
+		t.Errorf("got %d symbols, want %d: %+v", len(symbols), len(tests), symbols)
+	}
+	byName := symbolsByName(symbols)
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Kind != "service" || !sym.Exported {
+			t.Errorf("%s: kind %q exported %v, want exported service", tt.name, sym.Kind, sym.Exported)
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+func TestIsComposeFile(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    bool
+	}{
+		{"compose.yaml", "services: {}\n", true},
+		{"deploy/docker-compose.prod.yml", "services:\n  api:\n    restart: always\n", true},
+		{"stack.yml", "services:\n  api:\n    image: app\n", true},
+		{"ci.yml", "services:\n  - postgres\n", false},
+		{"values.yaml", "image: app\n", false},
+	}
+	for _, tt := range tests {
+		if got := IsComposeFile(tt.path, YAMLNodes([]byte(tt.content))); got != tt.want {
+			t.Errorf("IsComposeFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
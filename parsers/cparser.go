@@ -0,0 +1,544 @@
+package parsers
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&CParser{})
+}
+
+// CParser extracts functions, types, typedefs, macros and namespaces from C
+// and C++ source and header files. Function declarations without a body
+// have kind "prototype" so declarations in headers can be told apart from
+// their definitions. Comments, literals and preprocessor lines are masked
+// out before brace-depth tracking.
+type CParser struct{}
+
+func (p *CParser) Extensions() []string {
+	return []string{".c", ".h", ".cpp", ".hpp", ".cc"}
+}
+
+// IsCHeader reports whether ext is a C or C++ header extension.
+func IsCHeader(ext string) bool {
+	return ext == ".h" || ext == ".hpp"
+}
+
+var (
+	cDefineRe      = regexp.MustCompile(`^#\s*define\s+(\w+)(\([^)]*\))?(.*)`)
+	cIfndefRe      = regexp.MustCompile(`^#\s*ifndef\s+(\w+)`)
+	cNamespaceRe   = regexp.MustCompile(`^(?:inline\s+)?namespace\b\s*([\w:]*)`)
+	cExternCRe     = regexp.MustCompile(`^extern\s+"\s*"\s*\{`)
+	cAccessRe      = regexp.MustCompile(`^(public|private|protected)\s*:([^:]|$)`)
+	cUsingRe       = regexp.MustCompile(`^using\s+(\w+)\s*=`)
+	cTypedefRe     = regexp.MustCompile(`^typedef\b`)
+	cTagRe         = regexp.MustCompile(`^(typedef\s+)?(?:(?:const|volatile)\s+)?(class|struct|union|enum)\b(?:\s+(?:class|struct)\b)?\s*(?:\[\[[^\]]*\]\]\s*|alignas\s*\([^)]*\)\s*|__attribute__\s*\(\([^)]*\)\)\s*|[A-Z][A-Z0-9_]*_(?:API|EXPORT)\s+)*(\w*)`)
+	cFuncNameRe    = regexp.MustCompile(`((?:~?\w+::)*(?:operator\s*\S+|~?\w+))\s*$`)
+	cArraySuffixRe = regexp.MustCompile(`(\[[^\]]*\]\s*)+$`)
+	cWordRe        = regexp.MustCompile(`\w+`)
+	cPureVirtualRe = regexp.MustCompile(`\)[^()]*=\s*0\s*;?\s*$`)
+)
+
+// cSpecifiers are words that may precede a function name without being
+// part of its return type.
+var cSpecifiers = map[string]bool{
+	"static": true, "inline": true, "extern": true, "virtual": true, "explicit": true,
+	"constexpr": true, "consteval": true, "friend": true, "__inline": true,
+	"__inline__": true, "__forceinline": true,
+}
+
+// cKeywords are statement keywords that can look like a call.
+var cKeywords = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "do": true, "switch": true,
+	"case": true, "return": true, "sizeof": true, "alignof": true, "decltype": true,
+	"static_assert": true, "typeof": true, "__typeof__": true, "defined": true,
+}
+
+// cScope is a namespace, linkage block or class body whose members are
+// being parsed.
+type cScope struct {
+	kind    string // "namespace", "extern" or "class"
+	name    string // class name; empty for namespaces and linkage blocks
+	visible bool   // the scope itself is visible outside the file
+	public  bool   // the current access section is public
+	depth   int    // brace depth of the members inside the body
+	open    int    // line of the '{' opening the body
+}
+
+// cDeclEnd describes where a declaration ends: the end of its header at
+// (line, col), used for the signature, and its last line.
+type cDeclEnd struct {
+	line, col, last int
+}
+
+func (p *CParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	header := IsCHeader(filepath.Ext(filePath))
+	lines := strings.Split(string(content), "\n")
+	masked := strings.Split(string(maskCLike(content, false)), "\n")
+	symbols := cMacros(lines, masked, header)
+
+	var scopes []cScope
+	depth := 0
+	consumed := -1 // last line of the declaration being consumed
+
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		var scope *cScope
+		itemDepth := 0
+		if len(scopes) > 0 {
+			scope = &scopes[len(scopes)-1]
+			itemDepth = scope.depth
+		}
+
+		if i > consumed && depth == itemDepth && trimmed != "" && trimmed[0] != '}' && !strings.HasPrefix(trimmed, "//") {
+			col := len(masked[i]) - len(strings.TrimLeft(masked[i], " \t"))
+			if scope != nil && scope.kind == "class" {
+				if m := cAccessRe.FindStringSubmatch(trimmed); m != nil {
+					scope.public = m[1] == "public"
+					col += strings.IndexByte(trimmed, ':') + 1
+				}
+			}
+			declLine, declCol := skipCPrefixes(masked, i, col)
+			if declLine >= len(masked) {
+				break
+			}
+			consumed = declLine - 1
+			if decl := strings.TrimSpace(masked[declLine][declCol:]); decl != "" {
+				syms, enter, end := p.matchDecl(lines, masked, declLine, declCol, decl, scope, header)
+				consumed = end.last
+				for _, sym := range syms {
+					sym.Line = declLine + 1
+					sym.EndLine = end.last + 1
+					sym.Signature = joinSignature(lines, masked, i, col, end.line, end.col)
+					symbols = append(symbols, sym)
+				}
+				if enter != nil {
+					enter.depth, enter.open = depth+1, end.line
+					scopes = append(scopes, *enter)
+					consumed = end.line
+				}
+			}
+		}
+
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+		for len(scopes) > 0 && depth < scopes[len(scopes)-1].depth && i >= scopes[len(scopes)-1].open {
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+
+	sortSymbolsByLine(symbols)
+	return symbols, nil
+}
+
+// matchDecl recognises a declaration starting at (line, col), whose masked
+// text from there is decl. It returns the symbols declared, a scope to enter
+// if the declaration opens a namespace, linkage block or class body, and
+// where the declaration ends.
+func (p *CParser) matchDecl(lines, masked []string, line, col int, decl string, scope *cScope, header bool) ([]Symbol, *cScope, cDeclEnd) {
+	inClass := scope != nil && scope.kind == "class"
+	visible := scope == nil || scope.visible && scope.public
+	parent := ""
+	if inClass {
+		parent = scope.name
+	}
+	// Types declared in a source file are private to it.
+	typeExported := visible && (header || inClass)
+	endLine, endCol, body := cHeaderEnd(masked, line, col)
+	end := cDeclEnd{endLine, endCol, findDeclEnd(masked, endLine, endCol, body)}
+
+	if !inClass {
+		if cExternCRe.MatchString(decl) {
+			return nil, &cScope{kind: "extern", visible: visible, public: true}, end
+		}
+		if m := cNamespaceRe.FindStringSubmatch(decl); m != nil {
+			if !body {
+				return nil, nil, end // namespace alias
+			}
+			ns := &cScope{kind: "namespace", visible: visible && m[1] != "", public: true}
+			if m[1] == "" {
+				return nil, ns, end
+			}
+			return []Symbol{{Name: m[1], Kind: "namespace", Exported: visible}}, ns, end
+		}
+	}
+	if m := cUsingRe.FindStringSubmatch(decl); m != nil {
+		return []Symbol{{Name: m[1], Kind: "typedef", Exported: typeExported, Parent: parent}}, nil, end
+	}
+	if strings.HasPrefix(decl, "using ") || strings.HasPrefix(decl, "friend ") || strings.HasPrefix(decl, "static_assert") {
+		return nil, nil, end
+	}
+
+	if m := cTagRe.FindStringSubmatch(decl); m != nil {
+		typedef, kind, tag := m[1] != "", m[2], m[3]
+		switch {
+		case body && !strings.Contains(cSpan(masked, line, col, endLine, endCol), "("):
+			var syms []Symbol
+			if tag != "" {
+				syms = append(syms, Symbol{Name: tag, Kind: kind, Exported: typeExported, Parent: parent})
+			}
+			if typedef {
+				// typedef struct [tag] { ... } Alias, *AliasPtr;
+				for _, alias := range cTrailingNames(masked[end.last]) {
+					switch {
+					case alias == tag:
+					case tag == "" && len(syms) == 0:
+						syms = append(syms, Symbol{Name: alias, Kind: kind, Exported: typeExported, Parent: parent})
+					default:
+						syms = append(syms, Symbol{Name: alias, Kind: "typedef", Exported: typeExported, Parent: parent})
+					}
+				}
+			}
+			if len(syms) == 0 || kind == "enum" {
+				return syms, nil, end
+			}
+			// Members of a class are private by default; of a struct or union, public.
+			return syms, &cScope{kind: "class", name: syms[0].Name, visible: typeExported, public: kind != "class"}, end
+		case typedef:
+			return p.matchTypedef(masked, line, col, end, parent, typeExported)
+		}
+		// A forward declaration, or a function or variable of that type.
+	}
+	if cTypedefRe.MatchString(decl) {
+		return p.matchTypedef(masked, line, col, end, parent, typeExported)
+	}
+	return p.matchFunc(masked, line, col, end, body, scope), nil, end
+}
+
+// matchTypedef handles a typedef without a body, such as
+// typedef unsigned long size_t; or typedef int (*handler)(int);
+func (p *CParser) matchTypedef(masked []string, line, col int, end cDeclEnd, parent string, exported bool) ([]Symbol, *cScope, cDeclEnd) {
+	text := strings.TrimSpace(cSpan(masked, line, col, end.line, end.col))
+	name := ""
+	if i := strings.Index(text, "(*"); i >= 0 {
+		// Function pointer: the name is inside the first parentheses.
+		name = cWordRe.FindString(text[i:])
+	} else {
+		text = cArraySuffixRe.ReplaceAllString(text, "")
+		if words := cWordRe.FindAllString(text, -1); len(words) > 1 {
+			name = words[len(words)-1]
+		}
+	}
+	if name == "" {
+		return nil, nil, end
+	}
+	return []Symbol{{Name: name, Kind: "typedef", Exported: exported, Parent: parent}}, nil, end
+}
+
+// matchFunc recognises a function or method declaration or definition. A
+// name qualified with its class, as in Foo::bar, defines a method of that
+// class. It returns nil for variables and macro invocations.
+func (p *CParser) matchFunc(masked []string, line, col int, end cDeclEnd, body bool, scope *cScope) []Symbol {
+	text := cSpan(masked, line, col, end.line, end.col)
+	paren := cTopLevelParen(text)
+	if paren < 0 {
+		return nil
+	}
+	before := strings.TrimSpace(text[:paren])
+	if strings.HasSuffix(before, "operator") && strings.HasPrefix(text[paren:], "()") {
+		before += "()" // operator()(...)
+	}
+	if strings.Contains(before, "=") && !strings.Contains(before, "operator") {
+		return nil // a variable initialised with a call
+	}
+	m := cFuncNameRe.FindStringSubmatch(stripTemplateArgs(before))
+	if m == nil {
+		return nil
+	}
+	qualified := m[1]
+	returnType := strings.TrimSpace(strings.TrimSuffix(stripTemplateArgs(before), qualified))
+
+	hasType, static := false, false
+	for _, w := range cWordRe.FindAllString(returnType, -1) {
+		switch {
+		case w == "static":
+			static = true
+		case !cSpecifiers[w]:
+			hasType = true
+		}
+	}
+
+	name, parent := qualified, ""
+	if i := strings.LastIndex(qualified, "::"); i >= 0 {
+		name = qualified[i+2:]
+		parent = qualified[:i]
+		if j := strings.LastIndex(parent, "::"); j >= 0 {
+			parent = parent[j+2:]
+		}
+	}
+	inClass := scope != nil && scope.kind == "class"
+	if inClass {
+		parent = scope.name
+	}
+	if cKeywords[name] {
+		return nil
+	}
+	// Without a return type only constructors and destructors declare a
+	// function; anything else is a macro invocation.
+	if !hasType && !(parent != "" && (name == parent || name == "~"+parent)) {
+		return nil
+	}
+
+	kind := "func"
+	switch {
+	case !body:
+		kind = "prototype"
+	case parent != "":
+		kind = "method"
+	}
+	exported := !static
+	switch {
+	case inClass:
+		exported = scope.visible && scope.public // static members are still members
+	case scope != nil:
+		exported = exported && scope.visible
+	}
+	// A pure virtual member function (= 0) must be overridden.
+	abstract := inClass && !body && cPureVirtualRe.MatchString(text)
+	return []Symbol{{Name: name, Kind: kind, Exported: exported, Parent: parent, Abstract: abstract}}
+}
+
+// cMacros returns a macro symbol for each #define other than include
+// guards, and blanks every preprocessor line in masked (with its
+// continuation lines) so directives do not affect declaration matching or
+// brace depth.
+func cMacros(lines, masked []string, header bool) []Symbol {
+	var symbols []Symbol
+	lastIfndef := ""
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		if !strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		start := i
+		for i < len(masked)-1 && strings.HasSuffix(strings.TrimRight(masked[i], " \t\r"), "\\") {
+			i++
+		}
+		if m := cIfndefRe.FindStringSubmatch(trimmed); m != nil {
+			lastIfndef = m[1]
+		} else if m := cDefineRe.FindStringSubmatch(trimmed); m != nil {
+			guard := m[1] == lastIfndef && m[2] == "" && strings.TrimSpace(m[3]) == ""
+			if !guard {
+				symbols = append(symbols, Symbol{
+					Name:      m[1],
+					Kind:      "macro",
+					Line:      start + 1,
+					EndLine:   i + 1,
+					Exported:  header,
+					Signature: "#define " + m[1] + m[2],
+				})
+			}
+			lastIfndef = ""
+		} else {
+			lastIfndef = ""
+		}
+		for k := start; k <= i; k++ {
+			masked[k] = strings.Repeat(" ", len(masked[k]))
+		}
+	}
+	return symbols
+}
+
+// skipCPrefixes returns the position of the first character after any
+// template parameter lists, [[attributes]] and line comments starting at
+// (line, col).
+func skipCPrefixes(masked []string, line, col int) (int, int) {
+	for line < len(masked) {
+		s := masked[line]
+		for col < len(s) && (s[col] == ' ' || s[col] == '\t' || s[col] == '\r') {
+			col++
+		}
+		if col >= len(s) || strings.HasPrefix(s[col:], "//") {
+			line, col = line+1, 0
+			continue
+		}
+		rest := s[col:]
+		switch {
+		case strings.HasPrefix(rest, "template") && strings.HasPrefix(strings.TrimLeft(rest[len("template"):], " \t"), "<"):
+			line, col = cClosing(masked, line, col+strings.IndexByte(rest, '<'), '<', '>')
+			col++
+		case strings.HasPrefix(rest, "[["):
+			line, col = cClosing(masked, line, col, '[', ']')
+			col++
+		default:
+			return line, col
+		}
+	}
+	return line, col
+}
+
+// cClosing returns the position of the close character matching the open
+// character at (line, col).
+func cClosing(masked []string, line, col int, open, close byte) (int, int) {
+	depth := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch masked[i][j] {
+			case open:
+				depth++
+			case close:
+				depth--
+				if depth == 0 {
+					return i, j
+				}
+			}
+		}
+	}
+	return len(masked), 0
+}
+
+// cHeaderEnd is findHeaderEnd for C and C++, without stopping at '='. After
+// a constructor's parameter list, a member initializer list such as
+// ": a(x), b{y}" may hold braces that do not open the body.
+func cHeaderEnd(masked []string, line, col int) (endLine, endCol int, body bool) {
+	nesting := 0
+	sawParams, initList := false, false
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch c := masked[i][j]; c {
+			case '(', '[':
+				nesting++
+			case ')', ']':
+				nesting--
+				sawParams = sawParams || nesting == 0 && c == ')'
+			case ':':
+				colons := j > 0 && masked[i][j-1] == ':' || j+1 < len(masked[i]) && masked[i][j+1] == ':'
+				if nesting == 0 && sawParams && !colons {
+					initList = true
+				}
+			case '{', ';':
+				if nesting != 0 {
+					continue
+				}
+				if c == '{' && initList && cFollowsName(masked, i, j) {
+					// A brace-initialized member: skip to its closing brace.
+					i, j = cClosing(masked, i, j, '{', '}')
+					if i >= len(masked) {
+						return len(masked) - 1, len(masked[len(masked)-1]), false
+					}
+					continue
+				}
+				return i, j, c == '{'
+			}
+		}
+	}
+	return len(masked) - 1, len(masked[len(masked)-1]), false
+}
+
+// cFollowsName reports whether the '{' at (line, col) directly follows an
+// identifier or template argument list, ignoring whitespace.
+func cFollowsName(masked []string, line, col int) bool {
+	for i := line; i >= 0; i-- {
+		to := len(masked[i]) - 1
+		if i == line {
+			to = col - 1
+		}
+		for j := to; j >= 0; j-- {
+			switch c := masked[i][j]; c {
+			case ' ', '\t', '\r':
+			default:
+				return isWordByte(c) || c == '>'
+			}
+		}
+	}
+	return false
+}
+
+// cSpan returns the masked text from (line, col) up to (endLine, endCol),
+// joining lines with spaces.
+func cSpan(masked []string, line, col, endLine, endCol int) string {
+	if line == endLine {
+		return masked[line][min(col, len(masked[line])):min(endCol, len(masked[line]))]
+	}
+	parts := []string{masked[line][min(col, len(masked[line])):]}
+	parts = append(parts, masked[line+1:endLine]...)
+	parts = append(parts, masked[endLine][:min(endCol, len(masked[endLine]))])
+	return strings.Join(parts, " ")
+}
+
+// cTopLevelParen returns the index of the first '(' in s outside template
+// argument lists, or -1.
+func cTopLevelParen(s string) int {
+	angle := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			if strings.HasSuffix(strings.TrimRight(s[:i], " "), "operator") || i+1 < len(s) && s[i+1] == '<' {
+				i++ // operator< or <<
+				continue
+			}
+			angle++
+		case '>':
+			if angle > 0 {
+				angle--
+			}
+		case '(':
+			if angle == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// stripTemplateArgs removes template argument lists such as <T, U> from s,
+// leaving operator names like operator< untouched.
+func stripTemplateArgs(s string) string {
+	var b strings.Builder
+	angle := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '<' && strings.HasSuffix(strings.TrimRight(s[:i], " "), "operator"):
+		case c == '<':
+			angle++
+			continue
+		case c == '>' && angle > 0:
+			angle--
+			continue
+		}
+		if angle == 0 {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// cTrailingNames returns the declarator names after the closing brace of a
+// struct, union or enum body, as in "} Point, *PointPtr;".
+func cTrailingNames(maskedLine string) []string {
+	k := strings.LastIndex(maskedLine, "}")
+	if k < 0 {
+		return nil
+	}
+	rest := maskedLine[k+1:]
+	if j := strings.IndexByte(rest, ';'); j >= 0 {
+		rest = rest[:j]
+	}
+	var names []string
+	for _, part := range strings.Split(rest, ",") {
+		if words := cWordRe.FindAllString(part, -1); len(words) > 0 {
+			names = append(names, words[len(words)-1])
+		}
+	}
+	return names
+}
+
+// sortSymbolsByLine orders symbols by starting line, keeping the original
+// order of symbols that start on the same line.
+func sortSymbolsByLine(symbols []Symbol) {
+	for i := 1; i < len(symbols); i++ {
+		for j := i; j > 0 && symbols[j].Line < symbols[j-1].Line; j-- {
+			symbols[j], symbols[j-1] = symbols[j-1], symbols[j]
+		}
+	}
+}
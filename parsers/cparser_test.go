@@ -0,0 +1,256 @@
+package parsers
+
+import "testing"
+
+const sampleCHeader = `#ifndef GEOMETRY_H
+#define GEOMETRY_H
+
+#include <stddef.h>
+
+#define MAX_POINTS 64
+#define SQUARE(x) \
+    ((x) * (x))
+
+/* A point in the plane. */
+typedef struct {
+    int x;
+    int y;
+} Point;
+
+struct shape {
+    const char *name; /* "{" */
+    size_t count;
+};
+
+typedef struct node {
+    struct node *next;
+} Node, *NodePtr;
+
+typedef unsigned long ulong;
+typedef int (*compare_fn)(const void *, const void *);
+
+enum color { RED, GREEN, BLUE };
+
+union value {
+    int i;
+    double d;
+};
+
+// Returns the distance between two points.
+double distance(Point a,
+                Point b);
+static inline int sign(int v) { return (v > 0) - (v < 0); }
+extern int verbose;
+
+#endif
+`
+
+const sampleCppSource = `#include "geometry.hpp"
+#include <vector>
+
+namespace geo {
+
+class Shape : public Base {
+public:
+    Shape();
+    virtual ~Shape();
+    virtual double area() const = 0;
+    static int count();
+
+protected:
+    int id_;
+
+private:
+    void reset();
+    struct Cache {
+        int hits;
+    };
+};
+
+template <typename T>
+T clamp(T v, T lo, T hi) {
+    if (v < lo) {
+        return lo;
+    }
+    return v > hi ? hi : v;
+}
+
+using Points = std::vector<Point>;
+
+Shape::Shape()
+    : id_(0), cache_{1, 2} {
+    reset();
+}
+
+double Circle::perimeter() const {
+    return 3.14 * r_ * r_;
+}
+
+bool Shape::operator==(const Shape &other) const {
+    return id_ == other.id_;
+}
+
+} // namespace geo
+
+namespace {
+int helper(int x) {
+    return x;
+}
+}
+
+extern "C" {
+void c_entry(void);
+}
+
+static void internal_only(void) {
+    MACRO_CALL(x);
+}
+
+int main(int argc, char **argv) {
+    return 0;
+}
+`
+
+func TestCParserHeader(t *testing.T) {
+	p := &CParser{}
+	symbols, err := p.Parse("geometry.h", []byte(sampleCHeader))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	assertSymbol(t, byName, "MAX_POINTS", "macro", true, "")
+	assertSymbol(t, byName, "SQUARE", "macro", true, "")
+	assertSymbol(t, byName, "Point", "struct", true, "")
+	assertSymbol(t, byName, "shape", "struct", true, "")
+	assertSymbol(t, byName, "node", "struct", true, "")
+	assertSymbol(t, byName, "Node", "typedef", true, "")
+	assertSymbol(t, byName, "NodePtr", "typedef", true, "")
+	assertSymbol(t, byName, "ulong", "typedef", true, "")
+	assertSymbol(t, byName, "compare_fn", "typedef", true, "")
+	assertSymbol(t, byName, "color", "enum", true, "")
+	assertSymbol(t, byName, "value", "union", true, "")
+	assertSymbol(t, byName, "distance", "prototype", true, "")
+	assertSymbol(t, byName, "sign", "func", false, "")
+
+	// Include guards, fields, enumerators and variables are not symbols.
+	for _, name := range []string{"GEOMETRY_H", "x", "name", "next", "RED", "i", "verbose"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not appear as a symbol", name)
+		}
+	}
+}
+
+func TestCParserCpp(t *testing.T) {
+	p := &CParser{}
+	symbols, err := p.Parse("shape.cpp", []byte(sampleCppSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	members, ctors := splitCConstructors(symbols)
+	if len(ctors) != 2 || ctors[0].Kind != "prototype" || ctors[1].Kind != "method" {
+		t.Errorf("constructors = %+v, want a prototype and a definition", ctors)
+	}
+	byName := symbolsByName(members)
+
+	assertSymbol(t, byName, "geo", "namespace", true, "")
+	assertSymbol(t, byName, "Shape", "class", false, "")
+	assertSymbol(t, byName, "~Shape", "prototype", false, "Shape")
+	assertSymbol(t, byName, "count", "prototype", false, "Shape")
+	assertSymbol(t, byName, "reset", "prototype", false, "Shape")
+	assertSymbol(t, byName, "Cache", "struct", false, "Shape")
+	assertSymbol(t, byName, "clamp", "func", true, "")
+	assertSymbol(t, byName, "Points", "typedef", false, "")
+	assertSymbol(t, byName, "area", "prototype", false, "Shape")
+	assertSymbol(t, byName, "perimeter", "method", true, "Circle")
+	assertSymbol(t, byName, "operator==", "method", true, "Shape")
+	assertSymbol(t, byName, "helper", "func", false, "")
+	assertSymbol(t, byName, "c_entry", "prototype", true, "")
+	assertSymbol(t, byName, "internal_only", "func", false, "")
+	assertSymbol(t, byName, "main", "func", true, "")
+
+	for _, name := range []string{"id_", "hits", "MACRO_CALL", "if", "return"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not appear as a symbol", name)
+		}
+	}
+}
+
+func TestCParserSignaturesAndLines(t *testing.T) {
+	p := &CParser{}
+	header, err := p.Parse("geometry.h", []byte(sampleCHeader))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	source, err := p.Parse("shape.cpp", []byte(sampleCppSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	members, ctors := splitCConstructors(source)
+	byName := symbolsByName(append(header, members...))
+	byName["Shape()"] = ctors[1]
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"SQUARE", 7, 8, "#define SQUARE(x)"},
+		{"Point", 11, 14, "typedef struct"},
+		{"shape", 16, 19, "struct shape"},
+		{"ulong", 25, 25, "typedef unsigned long ulong;"},
+		{"distance", 36, 37, "double distance(Point a, Point b);"},
+		{"sign", 38, 38, "static inline int sign(int v)"},
+		{"Shape", 6, 21, "class Shape : public Base"},
+		{"count", 11, 11, "static int count();"},
+		{"clamp", 24, 29, "template <typename T> T clamp(T v, T lo, T hi)"},
+		{"Shape()", 33, 36, "Shape::Shape() : id_(0), cache_{1, 2}"},
+		{"area", 10, 10, "virtual double area() const = 0;"},
+		{"perimeter", 38, 40, "double Circle::perimeter() const"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+// splitCConstructors is splitConstructors for C++, where a constructor is
+// also declared as a prototype inside its class.
+func splitCConstructors(symbols []Symbol) (members, ctors []Symbol) {
+	for _, s := range symbols {
+		if s.Name == s.Parent {
+			ctors = append(ctors, s)
+		} else {
+			members = append(members, s)
+		}
+	}
+	return members, ctors
+}
+
+func TestCParserAbstractMembers(t *testing.T) {
+	src := `class Shape {
+public:
+    virtual double area() const = 0;
+    virtual void draw();
+    virtual ~Shape() {}
+    std::string describe() const { return "shape"; }
+};
+`
+	p := &CParser{}
+	symbols, err := p.Parse("shape.hpp", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	assertAbstract(t, symbols, map[string]bool{"area": true, "draw": false, "describe": false})
+}
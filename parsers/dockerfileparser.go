@@ -0,0 +1,171 @@
+package parsers
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&DockerfileParser{})
+}
+
+// DockerfileParser extracts the build stages of a Dockerfile and the ports
+// each stage exposes. A stage is named by its AS alias, or by its base image
+// when it has none; ports have their stage as parent. Dockerfiles are
+// recognised by name (Dockerfile, Containerfile, Dockerfile.prod,
+// api.Dockerfile) rather than extension.
+type DockerfileParser struct{}
+
+func (p *DockerfileParser) Extensions() []string {
+	return nil
+}
+
+// MatchFile reports whether base names a Dockerfile.
+func (p *DockerfileParser) MatchFile(base string) bool {
+	return IsDockerfile(base)
+}
+
+// IsDockerfile reports whether a file name is one Docker or Podman builds
+// from by convention.
+func IsDockerfile(base string) bool {
+	lower := strings.ToLower(base)
+	for _, name := range []string{"dockerfile", "containerfile"} {
+		if lower == name || strings.HasPrefix(lower, name+".") || strings.HasSuffix(lower, "."+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// DockerStage is a build stage of a Dockerfile.
+type DockerStage struct {
+	Name       string // the AS alias, or "" if the stage has none
+	Image      string // the image or stage the stage starts from
+	Line       int
+	EndLine    int
+	Signature  string // the FROM instruction
+	Ports      []DockerPort
+	Entrypoint string // ENTRYPOINT, with an exec-form array joined by spaces
+	Cmd        string // CMD, likewise
+}
+
+// DockerPort is a port declared by an EXPOSE instruction, such as
+// "8080/tcp".
+type DockerPort struct {
+	Port string
+	Line int
+}
+
+// Command returns what a container of the stage runs: the entrypoint
+// followed by the default arguments.
+func (s DockerStage) Command() string {
+	return strings.TrimSpace(s.Entrypoint + " " + s.Cmd)
+}
+
+var (
+	dockerFromRe    = regexp.MustCompile(`(?i)^FROM\s+(?:--\S+\s+)*(\S+)(?:\s+AS\s+(\S+))?`)
+	dockerHeredocRe = regexp.MustCompile(`<<-?["']?([A-Za-z_]\w*)["']?`)
+)
+
+func (p *DockerfileParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	var symbols []Symbol
+	for _, stage := range DockerfileStages(content) {
+		name := stage.Name
+		if name == "" {
+			name = stage.Image
+		}
+		symbols = append(symbols, Symbol{
+			Name:      name,
+			Kind:      "stage",
+			Line:      stage.Line,
+			EndLine:   stage.EndLine,
+			Exported:  true,
+			Signature: stage.Signature,
+		})
+		for _, port := range stage.Ports {
+			symbols = append(symbols, Symbol{
+				Name:      port.Port,
+				Kind:      "port",
+				Line:      port.Line,
+				EndLine:   port.Line,
+				Exported:  true,
+				Signature: "EXPOSE " + port.Port,
+				Parent:    name,
+			})
+		}
+	}
+	return symbols, nil
+}
+
+// DockerfileStages returns the build stages of a Dockerfile in order; the
+// last is the image the build produces unless a target stage is chosen.
+// Instructions continued with a trailing backslash are joined, and comment
+// lines and heredoc bodies are skipped.
+func DockerfileStages(content []byte) []DockerStage {
+	lines := strings.Split(string(content), "\n")
+	var stages []DockerStage
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Join continuation lines into one instruction.
+		start := i
+		var parts []string
+		for {
+			part := strings.TrimSpace(lines[i])
+			if !strings.HasPrefix(part, "#") {
+				parts = append(parts, strings.TrimSuffix(part, "\\"))
+			}
+			if !strings.HasSuffix(part, "\\") || i+1 >= len(lines) {
+				break
+			}
+			i++
+		}
+		instr := strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+		keyword, args, _ := strings.Cut(instr, " ")
+		keyword = strings.ToUpper(keyword)
+		if keyword == "RUN" || keyword == "COPY" || keyword == "ADD" {
+			if m := dockerHeredocRe.FindStringSubmatch(args); m != nil {
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != m[1] {
+					i++
+				}
+				i = min(i+1, len(lines)-1)
+			}
+		}
+		if keyword == "FROM" {
+			if m := dockerFromRe.FindStringSubmatch(instr); m != nil {
+				stages = append(stages, DockerStage{Name: m[2], Image: m[1], Line: start + 1, Signature: instr})
+			}
+		}
+		if len(stages) == 0 {
+			continue // ARG before the first FROM
+		}
+		stage := &stages[len(stages)-1]
+		stage.EndLine = i + 1
+		switch keyword {
+		case "EXPOSE":
+			for _, port := range strings.Fields(args) {
+				stage.Ports = append(stage.Ports, DockerPort{Port: port, Line: start + 1})
+			}
+		case "ENTRYPOINT":
+			stage.Entrypoint = dockerCommand(args)
+			stage.Cmd = "" // a new entrypoint resets the inherited CMD
+		case "CMD":
+			stage.Cmd = dockerCommand(args)
+		}
+	}
+	return stages
+}
+
+// dockerCommand returns the command of an ENTRYPOINT or CMD instruction,
+// joining the exec form's JSON array with spaces.
+func dockerCommand(args string) string {
+	var argv []string
+	if strings.HasPrefix(args, "[") && json.Unmarshal([]byte(args), &argv) == nil {
+		return strings.Join(argv, " ")
+	}
+	return args
+}
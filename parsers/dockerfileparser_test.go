@@ -0,0 +1,95 @@
+package parsers
+
+import "testing"
+
+const sampleDockerfile = `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION} AS build
+WORKDIR /src
+RUN <<EOF2
+go build -o /out/server ./cmd/server
+EOF2
+RUN go build \
+    -o /out/worker ./cmd/worker
+
+FROM gcr.io/distroless/base
+COPY --from=build /out/server /app/server
+EXPOSE 8080/tcp 9090
+ENTRYPOINT ["/app/server"]
+CMD ["--port", "8080"]
+`
+
+func TestDockerfileParser(t *testing.T) {
+	symbols, err := (&DockerfileParser{}).Parse("Dockerfile", []byte(sampleDockerfile))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	tests := []struct {
+		name      string
+		kind      string
+		line      int
+		endLine   int
+		signature string
+		parent    string
+	}{
+		{"build", "stage", 3, 9, "FROM --platform=$BUILDPLATFORM golang:${GO_VERSION} AS build", ""},
+		{"gcr.io/distroless/base", "stage", 11, 15, "FROM gcr.io/distroless/base", ""},
+		{"8080/tcp", "port", 13, 13, "EXPOSE 8080/tcp", "gcr.io/distroless/base"},
+		{"9090", "port", 13, 13, "EXPOSE 9090", "gcr.io/distroless/base"},
+	}
+	if len(symbols) != len(tests) {
+		t.Errorf("got %d symbols, want %d: %+v", len(symbols), len(tests), symbols)
+	}
+	byName := symbolsByName(symbols)
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Kind != tt.kind || !sym.Exported || sym.Parent != tt.parent {
+			t.Errorf("%s: kind %q exported %v parent %q, want %q exported parent %q", tt.name, sym.Kind, sym.Exported, sym.Parent, tt.kind, tt.parent)
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+func TestDockerfileStagesCommand(t *testing.T) {
+	stages := DockerfileStages([]byte(sampleDockerfile))
+	if len(stages) != 2 {
+		t.Fatalf("got %d stages, want 2", len(stages))
+	}
+	if got := stages[1].Command(); got != "/app/server --port 8080" {
+		t.Errorf("Command() = %q, want %q", got, "/app/server --port 8080")
+	}
+	if got := stages[0].Command(); got != "" {
+		t.Errorf("build stage Command() = %q, want none", got)
+	}
+
+	// A shell-form CMD is kept as written, and ENTRYPOINT drops an earlier CMD.
+	stages = DockerfileStages([]byte("FROM alpine\nCMD [\"sh\"]\nENTRYPOINT ./run.sh --verbose\n"))
+	if got := stages[0].Command(); got != "./run.sh --verbose" {
+		t.Errorf("Command() = %q, want %q", got, "./run.sh --verbose")
+	}
+}
+
+func TestForFileMatchesDockerfileNames(t *testing.T) {
+	for _, name := range []string{"Dockerfile", "deploy/Dockerfile.prod", "api.Dockerfile", "Containerfile"} {
+		if _, ok := ForFile(name).(*DockerfileParser); !ok {
+			t.Errorf("ForFile(%q) = %T, want *DockerfileParser", name, ForFile(name))
+		}
+	}
+	if p := ForFile("dockerfile.go"); p == nil {
+		t.Error("ForFile(dockerfile.go) = nil, want the Go parser")
+	} else if _, ok := p.(*DockerfileParser); ok {
+		t.Error("ForFile(dockerfile.go) should prefer the extension's parser")
+	}
+	if p := ForFile("README"); p != nil {
+		t.Errorf("ForFile(README) = %T, want nil", p)
+	}
+}
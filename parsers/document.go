@@ -0,0 +1,223 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DataNode is a mapping key or sequence item of a YAML or JSON document,
+// with the lines its value spans.
+type DataNode struct {
+	Doc     int      // index of the document in a multi-document YAML stream
+	Path    []string // keys from the document root, "[]" for sequence items
+	Line    int
+	EndLine int
+	Value   string // the value if it is a scalar or flow collection
+}
+
+var yamlKeyRe = regexp.MustCompile(`^(?:"([^"]*)"|'([^']*)'|([^"'#\s][^#]*?))\s*:(?:\s+(.*)|$)`)
+
+// YAMLNodes returns the mapping keys and sequence items of a YAML stream in
+// order. YAML is read by indentation rather than fully parsed: block
+// mappings and sequences, quoted keys, "---" document separators and block
+// scalars, whose contents are skipped, are understood, which covers the
+// layout of specs, Compose files and manifests in practice. Flow
+// collections are kept as the value of their key.
+func YAMLNodes(content []byte) []DataNode {
+	lines := strings.Split(string(content), "\n")
+	type frame struct{ indent, node int }
+	var stack []frame
+	var nodes []DataNode
+	doc := 0
+	last := 0         // last non-blank line seen, 0-based
+	blockIndent := -1 // indent of the key whose block scalar is being skipped
+
+	closeTo := func(indent int) {
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			nodes[stack[len(stack)-1].node].EndLine = last + 1
+			stack = stack[:len(stack)-1]
+		}
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := lineIndent(line)
+		if blockIndent >= 0 {
+			if indent > blockIndent {
+				last = i
+				continue
+			}
+			blockIndent = -1
+		}
+		if indent == 0 && (trimmed == "---" || strings.HasPrefix(trimmed, "--- ")) {
+			closeTo(0)
+			if len(nodes) > 0 && nodes[len(nodes)-1].Doc == doc {
+				doc++
+			}
+			continue
+		}
+		closeTo(indent)
+		last = i
+
+		// push records a node under the innermost open one.
+		push := func(key string, indent int, value string) {
+			var path []string
+			if len(stack) > 0 {
+				path = append(path, nodes[stack[len(stack)-1].node].Path...)
+			}
+			path = append(path, key)
+			nodes = append(nodes, DataNode{Doc: doc, Path: path, Line: i + 1, EndLine: i + 1, Value: value})
+			stack = append(stack, frame{indent: indent, node: len(nodes) - 1})
+		}
+		// A sequence item may start with the first key of a mapping, which
+		// is indented as if the dash were a space.
+		for strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			rest := strings.TrimLeft(trimmed[1:], " ")
+			value := ""
+			if yamlKeyRe.FindString(rest) == "" && !strings.HasPrefix(rest, "- ") {
+				value = yamlScalar(rest)
+			}
+			push("[]", indent, value)
+			indent += len(trimmed) - len(rest)
+			trimmed = rest
+		}
+
+		m := yamlKeyRe.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		value := yamlScalar(m[4])
+		push(m[1]+m[2]+m[3], indent, value)
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+	}
+	closeTo(0)
+	return nodes
+}
+
+// yamlScalar returns a plain or quoted scalar value without its quotes or
+// a trailing comment.
+func yamlScalar(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return ""
+	}
+	if q := v[0]; q == '"' || q == '\'' {
+		if end := strings.IndexByte(v[1:], q); end >= 0 {
+			return v[1 : end+1]
+		}
+		return v[1:]
+	}
+	if k := strings.Index(v, " #"); k >= 0 {
+		v = strings.TrimSpace(v[:k])
+	}
+	return v
+}
+
+// jsonNodes returns the object keys of a JSON document in order. On a
+// syntax error the keys read so far are returned with the error, which
+// names the line.
+func jsonNodes(content []byte) ([]DataNode, error) {
+	var newlines []int
+	for i, c := range content {
+		if c == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	// lineBefore returns the line of the byte just before offset.
+	lineBefore := func(offset int64) int {
+		return sort.SearchInts(newlines, int(offset)-1) + 1
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var nodes []DataNode
+	var walk func(path []string) (string, error)
+	walk = func(path []string) (string, error) {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			child := func(key string) []string {
+				return append(append([]string(nil), path...), key)
+			}
+			if t == '{' {
+				for dec.More() {
+					keyTok, err := dec.Token()
+					if err != nil {
+						return "", err
+					}
+					key, _ := keyTok.(string)
+					n := len(nodes)
+					nodes = append(nodes, DataNode{Path: child(key), Line: lineBefore(dec.InputOffset())})
+					value, err := walk(nodes[n].Path)
+					if err != nil {
+						return "", err
+					}
+					nodes[n].Value, nodes[n].EndLine = value, lineBefore(dec.InputOffset())
+				}
+			} else {
+				for dec.More() {
+					if _, err := walk(child("[]")); err != nil {
+						return "", err
+					}
+				}
+			}
+			_, err = dec.Token() // the closing delimiter
+			return "", err
+		case string:
+			return t, nil
+		case json.Number:
+			return t.String(), nil
+		}
+		return "", nil
+	}
+	if _, err := walk(nil); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			err = fmt.Errorf("line %d: %w", lineBefore(syntaxErr.Offset), err)
+		}
+		return nodes, err
+	}
+	return nodes, nil
+}
+
+// DataValues maps the path of each node in nodes with a value, joined by
+// DataKey, to that value.
+func DataValues(nodes []DataNode) map[string]string {
+	values := make(map[string]string)
+	for _, n := range nodes {
+		if n.Value != "" {
+			values[DataKey(n.Path...)] = n.Value
+		}
+	}
+	return values
+}
+
+// DataKey joins a node path into a DataValues key.
+func DataKey(path ...string) string {
+	return strings.Join(path, "\x00")
+}
+
+// SplitDocuments groups the nodes of a multi-document YAML stream by
+// document.
+func SplitDocuments(nodes []DataNode) [][]DataNode {
+	var docs [][]DataNode
+	for i, n := range nodes {
+		if i == 0 || n.Doc != nodes[i-1].Doc {
+			docs = append(docs, nil)
+		}
+		docs[len(docs)-1] = append(docs[len(docs)-1], n)
+	}
+	return docs
+}
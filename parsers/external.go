@@ -0,0 +1,92 @@
+package parsers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultExternalTimeout bounds a single run of an external parser whose
+// configuration sets no timeout.
+const DefaultExternalTimeout = 10 * time.Second
+
+// ExternalParser runs an executable to parse files. The executable receives
+// the file content on stdin, with the file path in the SWARM_INDEX_FILE
+// environment variable, and writes the file's symbols to stdout as a JSON
+// array of Symbol. A non-zero exit status, a timeout or malformed output is
+// reported as an error naming the command.
+type ExternalParser struct {
+	Exts    []string      // file extensions handled, e.g. ".dsl"
+	Command []string      // program and arguments
+	Dir     string        // working directory; empty means the current one
+	Timeout time.Duration // 0 means DefaultExternalTimeout
+}
+
+func (p *ExternalParser) Extensions() []string {
+	return p.Exts
+}
+
+func (p *ExternalParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	if len(p.Command) == 0 {
+		return nil, errors.New("external parser: empty command")
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultExternalTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Env = append(os.Environ(), "SWARM_INDEX_FILE="+filePath)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Do not wait forever on output pipes held open by a killed command's
+	// children.
+	cmd.WaitDelay = time.Second
+
+	name := p.Command[0]
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("external parser %s: timed out after %s", name, timeout)
+	}
+	if err != nil {
+		if msg := firstLine(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("external parser %s: %v: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("external parser %s: %w", name, err)
+	}
+
+	var symbols []Symbol
+	if err := json.Unmarshal(stdout.Bytes(), &symbols); err != nil {
+		return nil, fmt.Errorf("external parser %s: invalid output: %w", name, err)
+	}
+	for i, s := range symbols {
+		if s.Name == "" || s.Kind == "" {
+			return nil, fmt.Errorf("external parser %s: symbol %d has no name or kind", name, i)
+		}
+		if symbols[i].EndLine < s.Line {
+			symbols[i].EndLine = s.Line
+		}
+	}
+	return symbols, nil
+}
+
+// firstLine returns the first non-blank line of s, trimmed.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package parsers
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScript writes an executable shell script and returns its path.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("external parser tests use shell scripts")
+	}
+	path := filepath.Join(t.TempDir(), "parser.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExternalParserParse(t *testing.T) {
+	// Emits one symbol per "rule <name>" line, named after the input file.
+	script := writeScript(t, `n=0
+printf '['
+while read -r kw name; do
+  n=$((n+1))
+  [ "$kw" = rule ] || continue
+  [ -n "$sep" ] && printf ','
+  printf '{"name":"%s","kind":"rule","line":%d,"exported":true,"signature":"rule %s","parent":"%s"}' "$name" "$n" "$name" "$SWARM_INDEX_FILE"
+  sep=1
+done
+printf ']'
+`)
+	p := &ExternalParser{Exts: []string{".rules"}, Command: []string{script}}
+	symbols, err := p.Parse("policy.rules", []byte("rule allow\n# comment\nrule deny\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(symbols) != 2 {
+		t.Fatalf("got %d symbols, want 2: %+v", len(symbols), symbols)
+	}
+	deny := symbols[1]
+	if deny.Name != "deny" || deny.Kind != "rule" || deny.Line != 3 || deny.EndLine != 3 || !deny.Exported {
+		t.Errorf("symbol = %+v, want rule deny at line 3", deny)
+	}
+	if deny.Parent != "policy.rules" {
+		t.Errorf("SWARM_INDEX_FILE = %q, want policy.rules", deny.Parent)
+	}
+}
+
+func TestExternalParserErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"exit status", "echo 'line 2: unexpected token' >&2\nexit 3\n", "exit status 3: line 2: unexpected token"},
+		{"invalid JSON", "echo 'not json'\n", "invalid output"},
+		{"missing name", `echo '[{"kind":"rule","line":1}]'` + "\n", "no name or kind"},
+		{"timeout", "sleep 5\n", "timed out after 200ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := writeScript(t, tt.script)
+			p := &ExternalParser{Exts: []string{".x"}, Command: []string{script}, Timeout: 200 * time.Millisecond}
+			start := time.Now()
+			_, err := p.Parse("a.x", []byte("input\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want it to contain %q", err, tt.want)
+			}
+			if time.Since(start) > 3*time.Second {
+				t.Errorf("Parse() took %s, want the timeout to stop it", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestSetExternalOverridesBuiltin(t *testing.T) {
+	t.Cleanup(func() { SetExternal(nil) })
+	ext := &ExternalParser{Exts: []string{".go", ".dsl"}, Command: []string{"true"}}
+	SetExternal([]Parser{ext})
+	if ForExtension(".go") != Parser(ext) || ForExtension(".dsl") != Parser(ext) {
+		t.Error("external parser should handle .go and .dsl")
+	}
+	SetExternal(nil)
+	if _, ok := ForExtension(".go").(*GoParser); !ok {
+		t.Error("built-in Go parser should be restored")
+	}
+	if ForExtension(".dsl") != nil {
+		t.Error(".dsl should have no parser once external parsers are cleared")
+	}
+}
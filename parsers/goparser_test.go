@@ -189,6 +189,22 @@ func symbolsByName(symbols []Symbol) map[string]Symbol {
 	return m
 }
 
+// assertAbstract checks the Abstract flag of each named symbol.
+func assertAbstract(t *testing.T, symbols []Symbol, want map[string]bool) {
+	t.Helper()
+	byName := symbolsByName(symbols)
+	for name, abstract := range want {
+		sym, ok := byName[name]
+		if !ok {
+			t.Errorf("symbol %q not found", name)
+			continue
+		}
+		if sym.Abstract != abstract {
+			t.Errorf("symbol %q abstract = %v, want %v", name, sym.Abstract, abstract)
+		}
+	}
+}
+
 func assertSymbol(t *testing.T, byName map[string]Symbol, name, kind string, exported bool, parent string) {
 	t.Helper()
 	sym, ok := byName[name]
@@ -0,0 +1,245 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&GraphQLParser{})
+}
+
+// GraphQLParser extracts type system definitions and named operations from
+// GraphQL documents. Fields of object, interface and input types are
+// recorded with their type as parent; fields of the root operation types
+// (Query, Mutation and Subscription, or those a schema definition names)
+// get the kinds query, mutation and subscription instead of field, which
+// is also how fields added by "extend type Query" are recorded. Comments
+// and strings, including block-string descriptions, use Python's syntax
+// and are masked with maskPython.
+type GraphQLParser struct{}
+
+func (p *GraphQLParser) Extensions() []string {
+	return []string{".graphql", ".gql", ".graphqls"}
+}
+
+var (
+	gqlTypeRe      = regexp.MustCompile(`^(extend\s+)?(type|interface|input|enum|union|scalar)\s+(\w+)`)
+	gqlDirectiveRe = regexp.MustCompile(`^directive\s+@(\w+)`)
+	gqlOperationRe = regexp.MustCompile(`^(query|mutation|subscription|fragment)\s+(\w+)`)
+	gqlSkipRe      = regexp.MustCompile(`^(?:(?:extend\s+)?schema\b|(?:query|mutation|subscription)\b|\{)`)
+	gqlFieldRe     = regexp.MustCompile(`^(\w+)\s*[(:]`)
+
+	// gqlDefinitionRe matches the start of any top-level definition, which
+	// ends a preceding definition without a body.
+	gqlDefinitionRe = regexp.MustCompile(`^(?:extend|type|interface|input|enum|union|scalar|directive|schema|query|mutation|subscription|fragment)\b`)
+
+	gqlSchemaRe = regexp.MustCompile(`(?:^|\n)\s*schema\b[^{]*\{([^}]*)\}`)
+	gqlRootRe   = regexp.MustCompile(`\b(query|mutation|subscription)\s*:\s*(\w+)`)
+)
+
+func (p *GraphQLParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	masked, _ := maskPython(lines)
+	roots := gqlRootTypes(strings.Join(masked, "\n"))
+	var symbols []Symbol
+
+	depth := 0
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		if depth == 0 && trimmed != "" {
+			if sym, typeName, fieldKind, ok := p.matchDefinition(trimmed, roots); ok {
+				col := strings.Index(masked[i], trimmed)
+				endLine, endCol, body := gqlHeaderEnd(masked, i, col)
+				declEnd := endLine
+				if body {
+					declEnd = findBlockEnd(masked, endLine, endCol)
+				}
+				if sym.Kind != "" {
+					sym.Line, sym.EndLine = i+1, declEnd+1
+					sym.Signature = gqlText(lines, masked, i, col, endLine, endCol)
+					symbols = append(symbols, sym)
+				}
+				if body && fieldKind != "" {
+					symbols = append(symbols, gqlFields(lines, masked, endLine, endCol, declEnd, typeName, fieldKind)...)
+				}
+				i = declEnd
+				continue
+			}
+		}
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+	}
+	return symbols, nil
+}
+
+// matchDefinition recognises a top-level definition at the start of a
+// masked line. It returns the symbol to record, the type whose fields the
+// body holds and the kind to give those fields; an empty kind means the
+// body's contents are not recorded. Definitions that are skipped, such as
+// schema definitions, anonymous operations and type extensions, return a
+// symbol without a kind.
+func (p *GraphQLParser) matchDefinition(trimmed string, roots map[string]string) (Symbol, string, string, bool) {
+	if m := gqlTypeRe.FindStringSubmatch(trimmed); m != nil {
+		kind, name := m[2], m[3]
+		fieldKind := ""
+		switch kind {
+		case "type":
+			fieldKind = "field"
+			if root, ok := roots[name]; ok {
+				fieldKind = root
+			}
+		case "interface", "input":
+			fieldKind = "field"
+		}
+		if m[1] != "" {
+			return Symbol{}, name, fieldKind, true // an extension adds fields to a type defined elsewhere
+		}
+		return Symbol{Name: name, Kind: kind, Exported: true}, name, fieldKind, true
+	}
+	if m := gqlDirectiveRe.FindStringSubmatch(trimmed); m != nil {
+		return Symbol{Name: "@" + m[1], Kind: "directive", Exported: true}, "", "", true
+	}
+	if m := gqlOperationRe.FindStringSubmatch(trimmed); m != nil {
+		return Symbol{Name: m[2], Kind: m[1], Exported: true}, "", "", true
+	}
+	if gqlSkipRe.MatchString(trimmed) {
+		return Symbol{}, "", "", true
+	}
+	return Symbol{}, "", "", false
+}
+
+// gqlFields returns the fields declared in the body opening at (open,
+// openCol) and closing on line end, with parent and kind. Fields of
+// single-line bodies are not recorded.
+func gqlFields(lines, masked []string, open, openCol, end int, parent, kind string) []Symbol {
+	var fields []Symbol
+	nesting := 0 // brackets opened inside the body, e.g. argument lists
+	for i := open + 1; i < end; i++ {
+		if nesting == 0 {
+			trimmed := strings.TrimSpace(masked[i])
+			if m := gqlFieldRe.FindStringSubmatch(trimmed); m != nil {
+				col := strings.Index(masked[i], trimmed)
+				fieldEnd := gqlFieldEnd(masked, i, col, end)
+				endCol := len(masked[fieldEnd])
+				if fieldEnd == end {
+					// A truncated body, as in a file being edited, has no '}'.
+					if k := strings.LastIndex(masked[end], "}"); k >= 0 {
+						endCol = k
+					}
+				}
+				fields = append(fields, Symbol{
+					Name:      m[1],
+					Kind:      kind,
+					Line:      i + 1,
+					EndLine:   fieldEnd + 1,
+					Exported:  true,
+					Signature: gqlText(lines, masked, i, col, fieldEnd, endCol),
+					Parent:    parent,
+				})
+			}
+		}
+		for _, c := range masked[i] {
+			switch c {
+			case '(', '[', '{':
+				nesting++
+			case ')', ']', '}':
+				nesting--
+			}
+		}
+	}
+	return fields
+}
+
+// gqlFieldEnd returns the last line of a field starting at (line, col): the
+// line on which its argument list, if any, has closed.
+func gqlFieldEnd(masked []string, line, col, limit int) int {
+	nesting := 0
+	for i := line; i <= limit && i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for _, c := range masked[i][from:] {
+			switch c {
+			case '(':
+				nesting++
+			case ')':
+				nesting--
+			}
+		}
+		if nesting <= 0 {
+			return i
+		}
+	}
+	return limit
+}
+
+// gqlHeaderEnd finds where the header of a top-level definition starting at
+// (line, col) ends: at the '{' opening its body, outside parentheses, or,
+// for definitions without a body, on the last non-blank line before the
+// next definition. It returns the line and column and whether a body opens
+// there.
+func gqlHeaderEnd(masked []string, line, col int) (endLine, endCol int, body bool) {
+	nesting := 0
+	last := line // last non-blank line of the header so far
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		} else if trimmed := strings.TrimSpace(masked[i]); nesting == 0 && gqlDefinitionRe.MatchString(trimmed) {
+			break
+		} else if trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, `"`) {
+			last = i // comments and descriptions belong to the next definition
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch masked[i][j] {
+			case '(', '[':
+				nesting++
+			case ')', ']':
+				nesting--
+			case '{':
+				if nesting == 0 {
+					return i, j, true
+				}
+			}
+		}
+	}
+	return last, len(masked[last]), false
+}
+
+// gqlText joins the original text from (line, col) up to (endLine, endCol),
+// dropping comments and collapsing whitespace.
+func gqlText(lines, masked []string, line, col, endLine, endCol int) string {
+	var parts []string
+	for i := line; i <= endLine && i < len(lines); i++ {
+		text := lines[i]
+		cut := len(text)
+		if i == endLine {
+			cut = min(endCol, cut)
+		}
+		if k := strings.IndexByte(masked[i], '#'); k >= 0 && k < cut {
+			cut = k
+		}
+		from := 0
+		if i == line {
+			from = min(col, cut)
+		}
+		parts = append(parts, strings.TrimSpace(text[from:cut]))
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// gqlRootTypes maps the names of the root operation types to the kind
+// their fields get. Without a schema definition the default names apply.
+func gqlRootTypes(masked string) map[string]string {
+	roots := make(map[string]string)
+	for _, m := range gqlSchemaRe.FindAllStringSubmatch(masked, -1) {
+		for _, r := range gqlRootRe.FindAllStringSubmatch(m[1], -1) {
+			roots[r[2]] = r[1]
+		}
+	}
+	if len(roots) == 0 {
+		roots = map[string]string{"Query": "query", "Mutation": "mutation", "Subscription": "subscription"}
+	}
+	return roots
+}
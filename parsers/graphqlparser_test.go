@@ -0,0 +1,182 @@
+package parsers
+
+import "testing"
+
+const sampleGraphQLSource = `# Schema for the user service.
+scalar DateTime
+
+"""
+A registered account. { not a block
+"""
+type User implements Node @key(fields: "id") {
+  id: ID!
+  "The display name."
+  name: String # trailing comment
+  posts(first: Int = 10, after: String): [Post!]!
+}
+
+interface Node {
+  id: ID!
+}
+
+input CreateUserInput {
+  name: String!
+  email: String!
+}
+
+enum Role {
+  ADMIN
+  MEMBER
+}
+
+union SearchResult =
+  | User
+  | Post
+
+directive @auth(requires: Role = ADMIN) on OBJECT | FIELD_DEFINITION
+
+type Query {
+  user(id: ID!): User
+  search(
+    term: String!
+    limit: Int
+  ): [SearchResult!]!
+}
+
+type Mutation {
+  createUser(input: CreateUserInput!): User @auth
+}
+
+extend type Query {
+  me: User
+}
+
+query GetUser($id: ID!) {
+  user(id: $id) {
+    ...UserFields
+  }
+}
+
+fragment UserFields on User {
+  id
+  name
+}
+
+{
+  anonymous: me { id }
+}
+`
+
+func TestGraphQLParser(t *testing.T) {
+	p := &GraphQLParser{}
+	symbols, err := p.Parse("schema.graphql", []byte(sampleGraphQLSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := map[string]Symbol{}
+	for _, s := range symbols {
+		key := s.Name
+		if s.Parent != "" {
+			key = s.Parent + "." + s.Name
+		}
+		byName[key] = s
+	}
+
+	tests := []struct {
+		key       string
+		kind      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"DateTime", "scalar", 2, 2, "scalar DateTime"},
+		{"User", "type", 7, 12, `type User implements Node @key(fields: "id")`},
+		{"User.id", "field", 8, 8, "id: ID!"},
+		{"User.name", "field", 10, 10, "name: String"},
+		{"User.posts", "field", 11, 11, "posts(first: Int = 10, after: String): [Post!]!"},
+		{"Node", "interface", 14, 16, "interface Node"},
+		{"Node.id", "field", 15, 15, "id: ID!"},
+		{"CreateUserInput", "input", 18, 21, "input CreateUserInput"},
+		{"CreateUserInput.email", "field", 20, 20, "email: String!"},
+		{"Role", "enum", 23, 26, "enum Role"},
+		{"SearchResult", "union", 28, 30, "union SearchResult = | User | Post"},
+		{"@auth", "directive", 32, 32, "directive @auth(requires: Role = ADMIN) on OBJECT | FIELD_DEFINITION"},
+		{"Query", "type", 34, 40, "type Query"},
+		{"Query.user", "query", 35, 35, "user(id: ID!): User"},
+		{"Query.search", "query", 36, 39, "search( term: String! limit: Int ): [SearchResult!]!"},
+		{"Mutation.createUser", "mutation", 43, 43, "createUser(input: CreateUserInput!): User @auth"},
+		{"Query.me", "query", 47, 47, "me: User"},
+		{"GetUser", "query", 50, 54, "query GetUser($id: ID!)"},
+		{"UserFields", "fragment", 56, 59, "fragment UserFields on User"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.key]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.key)
+			continue
+		}
+		if sym.Kind != tt.kind || !sym.Exported {
+			t.Errorf("%s: kind %q exported %v, want %q exported", tt.key, sym.Kind, sym.Exported, tt.kind)
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.key, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.key, sym.Signature, tt.signature)
+		}
+	}
+
+	// Enum values, selections and the extension itself are not symbols.
+	for _, key := range []string{"ADMIN", "Role.ADMIN", "GetUser.user", "UserFields.id", "anonymous", "Query.first"} {
+		if _, ok := byName[key]; ok {
+			t.Errorf("%q should not appear as a symbol", key)
+		}
+	}
+	// The table omits the Mutation type and CreateUserInput.name.
+	if n := len(symbols); n != len(tests)+2 {
+		t.Errorf("got %d symbols, want %d", n, len(tests)+2)
+	}
+}
+
+func TestGraphQLParserSchemaRoots(t *testing.T) {
+	src := `schema {
+  query: RootQuery
+  mutation: RootMutation
+}
+
+type RootQuery {
+  ping: String
+}
+
+type Query {
+  notRoot: String
+}
+`
+	symbols, err := (&GraphQLParser{}).Parse("schema.gql", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	kinds := map[string]string{}
+	for _, s := range symbols {
+		kinds[s.Parent+"."+s.Name] = s.Kind
+	}
+	if kinds["RootQuery.ping"] != "query" {
+		t.Errorf("RootQuery.ping kind = %q, want query", kinds["RootQuery.ping"])
+	}
+	if kinds["Query.notRoot"] != "field" {
+		t.Errorf("Query.notRoot kind = %q, want field when the schema names other roots", kinds["Query.notRoot"])
+	}
+}
+
+func TestGraphQLParserTruncatedBody(t *testing.T) {
+	p := &GraphQLParser{}
+	symbols, err := p.Parse("schema.graphql", []byte("type Q {\n  a(x: Int\n"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+	assertSymbol(t, byName, "Q", "type", true, "")
+	if a, ok := byName["a"]; ok && a.Signature != "a(x: Int" {
+		t.Errorf("a signature = %q, want %q", a.Signature, "a(x: Int")
+	}
+}
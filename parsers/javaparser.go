@@ -0,0 +1,222 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&JavaParser{})
+}
+
+// JavaParser extracts packages, types, methods and fields from Java source
+// files. Comments and literals are masked out before brace-depth tracking,
+// members are attributed to their innermost enclosing type, and leading
+// annotations are kept in signatures.
+type JavaParser struct{}
+
+func (p *JavaParser) Extensions() []string {
+	return []string{".java"}
+}
+
+var (
+	// Modifiers that may precede any declaration.
+	javaModifiers = `^((?:(?:public|protected|private|static|final|abstract|sealed|non-sealed|strictfp|synchronized|native|transient|volatile|default)\s+)*)`
+
+	// A type such as int, String[], java.util.Map<K, List<V>>.
+	javaType = `[\w.$]+(?:\s*<[^(){};=]*>)?(?:\s*\[\s*\])*`
+
+	javaPackageRe = regexp.MustCompile(`^package\s+([\w.]+)`)
+	javaTypeRe    = regexp.MustCompile(javaModifiers + `(class|interface|enum|record|@\s*interface)\s+(\w+)`)
+	javaMethodRe  = regexp.MustCompile(javaModifiers + `(?:<[^(){};=]*>\s*)?(?:` + javaType + `\s+)?(\w+)\s*\(`)
+	javaFieldRe   = regexp.MustCompile(javaModifiers + `(` + javaType + `)\s+(\w+)\s*(?:[=;,\[]|$)`)
+	javaPublicRe  = regexp.MustCompile(`\bpublic\b`)
+	javaPrivateRe = regexp.MustCompile(`\bprivate\b`)
+	javaNativeRe  = regexp.MustCompile(`\bnative\b`)
+)
+
+// javaKeywords are words that can look like a method name or field type at
+// the start of a statement but never declare one.
+var javaKeywords = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "do": true, "switch": true,
+	"case": true, "try": true, "catch": true, "finally": true, "return": true,
+	"throw": true, "new": true, "this": true, "super": true, "assert": true,
+	"yield": true, "synchronized": true, "import": true, "package": true,
+}
+
+// javaScope is a type body whose members are being parsed.
+type javaScope struct {
+	name     string
+	kind     string // "class", "interface", "enum", "record" or "annotation"
+	depth    int    // brace depth of the members inside the body
+	open     int    // line of the '{' opening the body
+	exported bool
+}
+
+func (p *JavaParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	masked := strings.Split(string(maskCLike(content, false)), "\n")
+	var symbols []Symbol
+
+	var scopes []javaScope
+	depth := 0
+	consumed := -1 // last line of the declaration being consumed
+
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		var scope *javaScope
+		itemDepth := 0
+		if len(scopes) > 0 {
+			scope = &scopes[len(scopes)-1]
+			itemDepth = scope.depth
+		}
+
+		if i > consumed && depth == itemDepth && trimmed != "" {
+			col := len(masked[i]) - len(strings.TrimLeft(masked[i], " \t"))
+			declLine, declCol := skipJVMAnnotations(masked, i, col)
+			if declLine >= len(masked) {
+				break
+			}
+			sym, ok := p.matchDecl(strings.TrimSpace(masked[declLine][declCol:]), scope)
+			if !ok {
+				consumed = declLine - 1
+			} else {
+				endLine, endCol, body := findHeaderEnd(masked, declLine, declCol, sym.Kind == "field")
+				declEnd := findDeclEnd(masked, endLine, endCol, body)
+				consumed = declEnd
+				sym.Line = declLine + 1
+				sym.Signature = joinSignature(lines, masked, i, col, endLine, endCol)
+				if sym.Kind == "field" {
+					// A field's signature ends before its initializer, if
+					// any, so the ';' of one without is dropped too.
+					sym.Signature = strings.TrimSpace(strings.TrimSuffix(sym.Signature, ";"))
+				}
+				sym.EndLine = declEnd + 1
+				// Methods of interfaces and abstract classes without a
+				// body are abstract; annotation elements and native
+				// methods are not.
+				sym.Abstract = sym.Kind == "method" && !body && scope.kind != "annotation" && !javaNativeRe.MatchString(sym.Signature)
+				symbols = append(symbols, sym)
+
+				if body && sym.Kind != "method" {
+					consumed = endLine
+					scopes = append(scopes, javaScope{name: sym.Name, kind: sym.Kind, depth: depth + 1, open: endLine, exported: sym.Exported})
+					if sym.Kind == "enum" {
+						// Skip the constant list that opens an enum body.
+						consumed = findStatementEnd(masked, endLine, endCol+1)
+					}
+				}
+			}
+		}
+
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+		for len(scopes) > 0 && depth < scopes[len(scopes)-1].depth && i >= scopes[len(scopes)-1].open {
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+
+	return symbols, nil
+}
+
+// matchDecl recognises a declaration at the start of a masked line with any
+// annotations already skipped. Members of interfaces and annotation types
+// are public unless declared private.
+func (p *JavaParser) matchDecl(decl string, scope *javaScope) (Symbol, bool) {
+	if scope == nil {
+		if m := javaPackageRe.FindStringSubmatch(decl); m != nil {
+			return Symbol{Name: m[1], Kind: "package"}, true
+		}
+	}
+	parent := ""
+	implicitPublic := false
+	if scope != nil {
+		parent = scope.name
+		implicitPublic = scope.kind == "interface" || scope.kind == "annotation"
+	}
+	exported := func(mods string) bool {
+		if implicitPublic {
+			return !javaPrivateRe.MatchString(mods)
+		}
+		return javaPublicRe.MatchString(mods)
+	}
+
+	if m := javaTypeRe.FindStringSubmatch(decl); m != nil {
+		kind := m[2]
+		if strings.HasPrefix(kind, "@") {
+			kind = "annotation"
+		}
+		return Symbol{Name: m[3], Kind: kind, Exported: exported(m[1]), Parent: parent}, true
+	}
+	if scope == nil {
+		return Symbol{}, false
+	}
+	if m := javaMethodRe.FindStringSubmatch(decl); m != nil && !javaKeywords[m[2]] {
+		return Symbol{Name: m[2], Kind: "method", Exported: exported(m[1]), Parent: parent}, true
+	}
+	if m := javaFieldRe.FindStringSubmatch(decl); m != nil && !javaKeywords[m[2]] && !javaKeywords[m[3]] {
+		return Symbol{Name: m[3], Kind: "field", Exported: exported(m[1]), Parent: parent}, true
+	}
+	return Symbol{}, false
+}
+
+// skipJVMAnnotations returns the position of the first character after any
+// annotations starting at (line, col), such as @Override or
+// @RequestMapping(value = "/x") spread over several lines. Kotlin use-site
+// targets like @file:JvmName are included. An @interface declaration is not
+// an annotation.
+func skipJVMAnnotations(masked []string, line, col int) (int, int) {
+	for line < len(masked) {
+		s := masked[line]
+		for col < len(s) && (s[col] == ' ' || s[col] == '\t' || s[col] == '\r') {
+			col++
+		}
+		if col >= len(s) {
+			line, col = line+1, 0
+			continue
+		}
+		if s[col] != '@' || strings.HasPrefix(strings.TrimLeft(s[col+1:], " "), "interface") {
+			return line, col
+		}
+		col++
+		for col < len(s) && (isWordByte(s[col]) || s[col] == '.' || s[col] == ':') {
+			col++
+		}
+		k := col
+		for k < len(s) && s[k] == ' ' {
+			k++
+		}
+		if k < len(s) && s[k] == '(' {
+			line, col = closingParen(masked, line, k)
+			col++
+		}
+	}
+	return line, col
+}
+
+// closingParen returns the position of the ')' matching the '(' at
+// (line, col).
+func closingParen(masked []string, line, col int) (int, int) {
+	depth := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch masked[i][j] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i, j
+				}
+			}
+		}
+	}
+	return len(masked), 0
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
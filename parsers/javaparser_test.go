@@ -0,0 +1,239 @@
+package parsers
+
+import "testing"
+
+const sampleJavaSource = `package com.example.users;
+
+import java.util.List;
+import java.util.Map;
+
+/**
+ * Stores users.
+ */
+@Service
+@RequestMapping(
+    value = "/users",
+    produces = "application/json")
+public class UserService extends BaseService implements Closeable {
+    public static final int MAX_USERS = 100;
+    private final Map<String, List<User>> cache = new HashMap<>();
+    protected String name;
+    int count;
+    private Runnable task = () -> {
+        System.out.println("{ not a brace");
+    };
+
+    static {
+        init();
+    }
+
+    public UserService(String name) {
+        this.name = name;
+    }
+
+    @Override
+    public String toString() {
+        return "UserService{" + name + "}";
+    }
+
+    @GetMapping("/{id}") public User find(@PathVariable long id) throws NotFoundException {
+        if (id < 0) {
+            throw new NotFoundException();
+        }
+        return null;
+    }
+
+    private <T extends Comparable<T>> List<T> sort(List<T> items) {
+        // a comment with a } brace
+        return items;
+    }
+
+    void packagePrivate() {}
+
+    public static class Builder {
+        private String title;
+
+        public Builder withTitle(String title) {
+            this.title = title;
+            return this;
+        }
+    }
+
+    private enum State {
+        ACTIVE("a") {
+            @Override
+            String label() { return "A"; }
+        },
+        INACTIVE("i");
+
+        private final String code;
+
+        State(String code) {
+            this.code = code;
+        }
+
+        String label() {
+            return code;
+        }
+    }
+}
+
+interface Repository<T> {
+    String TABLE = "users";
+
+    T findById(long id);
+
+    default int size() {
+        return 0;
+    }
+
+    private void helper() {}
+}
+
+public record Point(int x, int y) implements Shape {
+    public double area() {
+        return 0;
+    }
+}
+
+@Retention(RetentionPolicy.RUNTIME)
+public @interface Audited {
+    String value() default "";
+}
+`
+
+func TestJavaParserBasic(t *testing.T) {
+	p := &JavaParser{}
+	symbols, err := p.Parse("UserService.java", []byte(sampleJavaSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	members, ctors := splitConstructors(symbols)
+	if len(ctors) != 2 || ctors[0].Parent != "UserService" || ctors[1].Parent != "State" {
+		t.Errorf("constructors = %+v, want UserService and State", ctors)
+	}
+	byName := symbolsByName(members)
+
+	assertSymbol(t, byName, "com.example.users", "package", false, "")
+
+	// Types
+	assertSymbol(t, byName, "UserService", "class", true, "")
+	assertSymbol(t, byName, "Builder", "class", true, "UserService")
+	assertSymbol(t, byName, "State", "enum", false, "UserService")
+	assertSymbol(t, byName, "Repository", "interface", false, "")
+	assertSymbol(t, byName, "Point", "record", true, "")
+	assertSymbol(t, byName, "Audited", "annotation", true, "")
+
+	// Fields
+	assertSymbol(t, byName, "MAX_USERS", "field", true, "UserService")
+	assertSymbol(t, byName, "cache", "field", false, "UserService")
+	assertSymbol(t, byName, "name", "field", false, "UserService")
+	assertSymbol(t, byName, "count", "field", false, "UserService")
+	assertSymbol(t, byName, "task", "field", false, "UserService")
+	assertSymbol(t, byName, "title", "field", false, "Builder")
+	assertSymbol(t, byName, "code", "field", false, "State")
+	assertSymbol(t, byName, "TABLE", "field", true, "Repository")
+
+	// Methods and constructors
+	assertSymbol(t, byName, "toString", "method", true, "UserService")
+	assertSymbol(t, byName, "find", "method", true, "UserService")
+	assertSymbol(t, byName, "sort", "method", false, "UserService")
+	assertSymbol(t, byName, "packagePrivate", "method", false, "UserService")
+	assertSymbol(t, byName, "withTitle", "method", true, "Builder")
+	assertSymbol(t, byName, "label", "method", false, "State")
+	assertSymbol(t, byName, "findById", "method", true, "Repository")
+	assertSymbol(t, byName, "helper", "method", false, "Repository")
+	assertSymbol(t, byName, "area", "method", true, "Point")
+	assertSymbol(t, byName, "value", "method", true, "Audited")
+
+	// Enum constants, statements and locals are not members.
+	for _, name := range []string{"ACTIVE", "INACTIVE", "init", "println", "id", "items"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not appear as a symbol", name)
+		}
+	}
+}
+
+func TestJavaParserSignaturesAndLines(t *testing.T) {
+	p := &JavaParser{}
+	symbols, err := p.Parse("UserService.java", []byte(sampleJavaSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	members, _ := splitConstructors(symbols)
+	byName := symbolsByName(members)
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"com.example.users", 1, 1, "package com.example.users;"},
+		{"UserService", 13, 75, `@Service @RequestMapping( value = "/users", produces = "application/json") public class UserService extends BaseService implements Closeable`},
+		{"MAX_USERS", 14, 14, "public static final int MAX_USERS"},
+		{"count", 17, 17, "int count"},
+		{"task", 18, 20, "private Runnable task"},
+		{"toString", 31, 33, "@Override public String toString()"},
+		{"find", 35, 40, `@GetMapping("/{id}") public User find(@PathVariable long id) throws NotFoundException`},
+		{"sort", 42, 45, "private <T extends Comparable<T>> List<T> sort(List<T> items)"},
+		{"packagePrivate", 47, 47, "void packagePrivate()"},
+		{"Builder", 49, 56, "public static class Builder"},
+		{"findById", 80, 80, "T findById(long id);"},
+		{"Point", 89, 93, "public record Point(int x, int y) implements Shape"},
+		{"Audited", 96, 98, "@Retention(RetentionPolicy.RUNTIME) public @interface Audited"},
+		{"value", 97, 97, `String value() default "";`},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+// splitConstructors separates constructors, which share their class's name,
+// from the other symbols so lookups by name stay unambiguous.
+func splitConstructors(symbols []Symbol) (members, ctors []Symbol) {
+	for _, s := range symbols {
+		if s.Kind == "method" && s.Name == s.Parent {
+			ctors = append(ctors, s)
+		} else {
+			members = append(members, s)
+		}
+	}
+	return members, ctors
+}
+
+func TestJavaParserAbstractMembers(t *testing.T) {
+	src := `public interface Shape {
+    double area();
+    default String label() { return "shape"; }
+    static Shape unit() { return null; }
+}
+
+abstract class Base implements Shape {
+    abstract void draw();
+    native long handle();
+    public double area() { return 0; }
+}
+
+@interface Marker {
+    String value();
+}
+`
+	p := &JavaParser{}
+	symbols, err := p.Parse("Shape.java", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	assertAbstract(t, symbols, map[string]bool{"area": false, "label": false, "unit": false, "draw": true, "handle": false, "value": false})
+}
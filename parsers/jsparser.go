@@ -9,8 +9,11 @@ func init() {
 	Register(&JSParser{})
 }
 
-// JSParser extracts symbols from JavaScript and TypeScript source files
-// using regex heuristics and brace-depth tracking.
+// JSParser extracts symbols from JavaScript and TypeScript source files.
+// Comments, strings, template literals and regex literals are masked out
+// before brace-depth tracking, so braces inside them cannot end a block
+// early. Declarations are recognised at the top level, inside namespaces,
+// class bodies, interface bodies and object literals assigned to variables.
 type JSParser struct{}
 
 func (p *JSParser) Extensions() []string {
@@ -18,314 +21,575 @@ func (p *JSParser) Extensions() []string {
 }
 
 var (
-	// Function declarations: [export] [default] [async] function name(
-	jsFuncRe = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s+(\w+)`)
+	// Function declarations: [export] [default] [declare] [async] function [*] name(
+	jsFuncRe = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\b\s*\*?\s*(\w*)`)
 
-	// Class declarations: [export] [default] [abstract] class Name
-	jsClassRe = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`)
+	// Class declarations: [export] [default] [declare] [abstract] class Name
+	jsClassRe = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?class\b\s*(\w*)`)
 
-	// Interface declarations: [export] interface Name
-	jsInterfaceRe = regexp.MustCompile(`^(?:export\s+)?interface\s+(\w+)`)
+	// Interface declarations: [export] [default] [declare] interface Name
+	jsInterfaceRe = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?interface\s+(\w+)`)
 
-	// Type alias declarations: [export] type Name =
-	jsTypeRe = regexp.MustCompile(`^(?:export\s+)?type\s+(\w+)\b`)
+	// Type alias declarations: [export] [declare] type Name =
+	jsTypeRe = regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?type\s+(\w+)\b`)
 
-	// Enum declarations: [export] [const] enum Name
-	jsEnumRe = regexp.MustCompile(`^(?:export\s+)?(?:const\s+)?enum\s+(\w+)`)
+	// Enum declarations: [export] [declare] [const] enum Name
+	jsEnumRe = regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+(\w+)`)
 
-	// Variable declarations: [export] const/let/var name
-	jsVarRe = regexp.MustCompile(`^(?:export\s+)?(?:const|let|var)\s+(\w+)`)
+	// Namespaces and ambient modules: [export] [declare] namespace A.B,
+	// declare module "name", declare global
+	jsNamespaceRe = regexp.MustCompile(`^(?:export\s+)?(?:(?:declare\s+)?(namespace|module)\s+([\w.]+|["'])|declare\s+(global)\b)`)
+
+	// Variable declarations: [export] [declare] const/let/var name
+	jsVarRe = regexp.MustCompile(`^(?:export\s+)?(?:declare\s+)?(?:const|let|var)\s+(\w+)`)
+
+	// Export lists: export [type] { a, b as c } [from "mod"]
+	jsExportListRe = regexp.MustCompile(`^export\s+(?:type\s+)?\{`)
+	jsExportItemRe = regexp.MustCompile(`^(?:type\s+)?(\w+)(?:\s+as\s+(\w+))?$`)
+
+	// Namespace re-exports: export * as name from "mod"
+	jsExportStarRe = regexp.MustCompile(`^export\s+\*\s*as\s+(\w+)`)
+
+	// Default exports of an expression, and export = name
+	jsExportDefaultRe = regexp.MustCompile(`^export\s+default\b\s*(.*)`)
+	jsExportAssignRe  = regexp.MustCompile(`^export\s*=\s*(\w+)\s*;?$`)
 
 	// Class method/property: name(...) { or async name(...) { or get/set name(
-	jsMethodRe = regexp.MustCompile(`^(?:(?:public|private|protected|static|readonly|abstract|override|async|get|set)\s+)*(\w+)\s*[<(]`)
+	jsMethodRe = regexp.MustCompile(`^(?:(?:public|private|protected|static|readonly|abstract|override|declare|async|get|set)\s+)*\*?\s*(#?\w+)\s*[?!]?\s*[<(]`)
+
+	// The abstract modifier on a class member.
+	jsAbstractRe = regexp.MustCompile(`^(?:(?:public|private|protected|static|readonly|override|declare)\s+)*abstract\s`)
+
+	// Interface method signature: name[?](...) or name[?]<T>(...), or
+	// property signature: name[?]: type
+	jsMemberMethodRe = regexp.MustCompile(`^(\w+)\s*(\??)\s*[<(]`)
+	jsMemberPropRe   = regexp.MustCompile(`^(?:readonly\s+)?(\w+)\s*(\??)\s*:(.*)`)
+
+	// Class property with an initializer: name = value
+	jsPropRe = regexp.MustCompile(`^(?:(?:public|private|protected|static|readonly|override)\s+)*(#?\w+)\s*[?!]?\s*(?::[^=]*)?=([^=>].*)`)
+
+	// Object literal method: name(...) {, or property: name: value
+	jsObjMethodRe = regexp.MustCompile(`^(?:async\s+)?(?:(?:get|set)\s+)?\*?\s*(\w+)\s*\(`)
+	jsObjPropRe   = regexp.MustCompile(`^(\w+)\s*:(.*)`)
+
+	// The start of a function value: function, or arrow parameters.
+	jsFuncValueRe = regexp.MustCompile(`^(?:async\s+)?(?:(function)\b|(\w+)\s*=>|(?:<[^>]*>\s*)?(\())`)
+	jsIdentRe     = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
 )
 
+// jsScope is a namespace, class body or object literal whose members are
+// being parsed.
+type jsScope struct {
+	name     string
+	kind     string // "namespace", "ambient", "class", "interface" or "object"
+	depth    int    // brace depth of the members inside the body
+	open     int    // line of the '{' opening the body
+	exported bool
+}
+
+// jsDecl is a declaration recognised at the start of a line.
+type jsDecl struct {
+	symbols []Symbol
+	enter   string   // scope kind the declaration's body or initializer opens, if any
+	exports []string // local names exported by an export list or default export
+}
+
 func (p *JSParser) Parse(filePath string, content []byte) ([]Symbol, error) {
 	lines := strings.Split(string(content), "\n")
+	masked := strings.Split(string(maskJS(content)), "\n")
 	var symbols []Symbol
+	var bodiless []bool // per symbol: a function or method signature without a body
 
-	braceDepth := 0
-	inBlockComment := false
-	var currentClass string
-	var classStartDepth int
-
-	for i := 0; i < len(lines); i++ {
-		line := lines[i]
-		trimmed := strings.TrimSpace(line)
-
-		// Handle block comments.
-		if inBlockComment {
-			if strings.Contains(trimmed, "*/") {
-				inBlockComment = false
-				// Process the rest of the line after the comment end.
-				idx := strings.Index(trimmed, "*/")
-				trimmed = strings.TrimSpace(trimmed[idx+2:])
-				if trimmed == "" {
-					continue
-				}
-			} else {
-				continue
-			}
-		}
+	// Local names listed in export statements, keyed by enclosing scope.
+	exports := map[string]map[string]bool{}
 
-		// Skip empty lines and single-line comments.
-		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
-			continue
+	var scopes []jsScope
+	depth := 0
+	consumed := -1 // last line of the statement being consumed
+
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		var scope *jsScope
+		itemDepth := 0
+		if len(scopes) > 0 {
+			scope = &scopes[len(scopes)-1]
+			itemDepth = scope.depth
 		}
 
-		// Check for block comment start on this line.
-		if strings.Contains(trimmed, "/*") {
-			if strings.Contains(trimmed, "*/") {
-				// Single-line block comment — remove it and continue processing.
-				trimmed = removeInlineBlockComments(trimmed)
-				if strings.TrimSpace(trimmed) == "" {
-					continue
+		if i > consumed && depth == itemDepth && trimmed != "" && trimmed[0] != '}' && !strings.HasPrefix(trimmed, "//") {
+			col := len(masked[i]) - len(strings.TrimLeft(masked[i], " \t"))
+			// Decorators are skipped like annotations, and kept in signatures.
+			declLine, declCol := skipJVMAnnotations(masked, i, col)
+			if declLine >= len(masked) {
+				break
+			}
+			decl := strings.TrimSpace(masked[declLine][declCol:])
+			text := strings.TrimSpace(lines[declLine][declCol:])
+			list := scope != nil && scope.kind == "object"
+			last := jsStatementEnd(masked, declLine, declCol, list)
+			stmt := strings.TrimSpace(cSpan(masked, declLine, declCol, last, len(masked[last])))
+			d := p.matchDecl(decl, text, stmt, scope)
+
+			parent := ""
+			if scope != nil {
+				parent = scope.name
+			}
+			for _, name := range d.exports {
+				if exports[parent] == nil {
+					exports[parent] = map[string]bool{}
+				}
+				exports[parent][name] = true
+			}
+
+			var open *jsScope
+			switch d.enter {
+			case "namespace", "ambient", "class", "interface":
+				endLine, endCol, body := jsHeaderEnd(masked, declLine, declCol)
+				last = endLine
+				if body {
+					last = findBlockEnd(masked, endLine, endCol)
+					open = &jsScope{kind: d.enter, open: endLine}
 				}
-			} else {
-				// Multi-line block comment starts here.
-				// Process the part before the comment.
-				idx := strings.Index(trimmed, "/*")
-				before := strings.TrimSpace(trimmed[:idx])
-				inBlockComment = true
-				if before == "" {
-					continue
+			case "object":
+				if line, ok := jsObjectInitializer(masked, declLine, declCol); ok {
+					open = &jsScope{kind: d.enter, open: line}
 				}
-				trimmed = before
 			}
-		}
+			consumed = last
 
-		// Skip decorators / annotations (lines starting with @).
-		if strings.HasPrefix(trimmed, "@") {
-			continue
+			decorators := ""
+			if declLine != i || declCol != col {
+				decorators = joinSignature(lines, masked, i, col, declLine, declCol) + " "
+			}
+			_, _, body := jsHeaderEnd(masked, declLine, declCol)
+			for _, sym := range d.symbols {
+				sym.Line = declLine + 1
+				sym.EndLine = last + 1
+				sym.Signature = decorators + sym.Signature
+				symbols = append(symbols, sym)
+				bodiless = append(bodiless, !body)
+			}
+			if open != nil && len(d.symbols) > 0 {
+				open.name = d.symbols[0].Name
+				open.exported = d.symbols[0].Exported || open.kind == "ambient"
+				open.depth = depth + 1
+				scopes = append(scopes, *open)
+				consumed = open.open
+			}
 		}
 
-		// Skip import/require lines.
-		if strings.HasPrefix(trimmed, "import ") || strings.HasPrefix(trimmed, "import{") ||
-			strings.HasPrefix(trimmed, "require(") {
-			braceDepth += countBraces(trimmed)
-			continue
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+		for len(scopes) > 0 && depth < scopes[len(scopes)-1].depth && i >= scopes[len(scopes)-1].open {
+			scopes = scopes[:len(scopes)-1]
 		}
+	}
 
-		exported := strings.HasPrefix(trimmed, "export ")
-
-		if braceDepth == 0 {
-			// Top-level: look for declarations.
-			if sym, ok := p.matchTopLevel(trimmed, i+1, exported); ok {
-				if sym.Kind == "class" {
-					currentClass = sym.Name
-					classStartDepth = braceDepth
+	for k := range symbols {
+		s := &symbols[k]
+		if exports[s.Parent][s.Name] && s.Kind != "method" {
+			s.Exported = true
+		}
+		// A signature without a body followed by another declaration of the
+		// same function is an overload.
+		if bodiless[k] && (s.Kind == "func" || s.Kind == "method") {
+			for _, later := range symbols[k+1:] {
+				if later.Name == s.Name && later.Parent == s.Parent && later.Kind == s.Kind {
+					s.Kind = "overload"
+					break
 				}
-				// Find end line by scanning forward.
-				sym.EndLine = findJSBlockEnd(lines, i, braceDepth)
-				symbols = append(symbols, sym)
-				braceDepth += countBraces(trimmed)
-				continue
-			}
-		} else if braceDepth == classStartDepth+1 && currentClass != "" {
-			// Inside a class body: look for methods.
-			if sym, ok := p.matchMethod(trimmed, i+1, currentClass); ok {
-				sym.EndLine = findJSBlockEnd(lines, i, braceDepth)
-				symbols = append(symbols, sym)
-				braceDepth += countBraces(trimmed)
-				continue
 			}
 		}
+	}
+	return symbols, nil
+}
 
-		braceDepth += countBraces(trimmed)
-
-		// If brace depth returns to class start depth, we've left the class.
-		if currentClass != "" && braceDepth <= classStartDepth {
-			currentClass = ""
+// matchDecl recognises a declaration on a masked line, with decorators
+// already skipped. text is the same part of the original line, and stmt
+// the masked text of the whole statement.
+func (p *JSParser) matchDecl(decl, text, stmt string, scope *jsScope) jsDecl {
+	if scope != nil && scope.kind == "class" {
+		if sym, ok := p.matchMethod(decl, text, scope.name); ok {
+			return jsDecl{symbols: []Symbol{sym}}
+		}
+		return jsDecl{}
+	}
+	if scope != nil && scope.kind == "interface" {
+		if sym, ok := p.matchInterfaceMember(decl, text, scope); ok {
+			return jsDecl{symbols: []Symbol{sym}}
+		}
+		return jsDecl{}
+	}
+	if scope != nil && scope.kind == "object" {
+		if sym, ok := p.matchObjectMember(decl, text, scope); ok {
+			return jsDecl{symbols: []Symbol{sym}}
 		}
+		return jsDecl{}
 	}
 
-	return symbols, nil
+	parent := ""
+	exported := strings.HasPrefix(decl, "export ") || strings.HasPrefix(decl, "export{")
+	if scope != nil {
+		parent = scope.name
+		exported = exported || scope.kind == "ambient"
+	}
+
+	if jsExportListRe.MatchString(decl) || jsExportStarRe.MatchString(decl) {
+		return p.matchExportList(stmt, text, parent)
+	}
+	if m := jsExportAssignRe.FindStringSubmatch(decl); m != nil {
+		return jsDecl{exports: []string{m[1]}}
+	}
+
+	sym, enter, ok := p.matchTopLevel(decl, text, exported)
+	if !ok {
+		m := jsExportDefaultRe.FindStringSubmatch(decl)
+		if m == nil {
+			return jsDecl{}
+		}
+		switch rest := strings.TrimSuffix(strings.TrimSpace(m[1]), ";"); {
+		case jsIdentRe.MatchString(rest):
+			// export default name: the declaration of name is exported.
+			return jsDecl{exports: []string{rest}}
+		case strings.HasPrefix(rest, "{"):
+			sym, enter = Symbol{Name: "default", Kind: "const", Exported: true, Signature: text}, "object"
+		case jsIsFunctionValue(rest):
+			sym = Symbol{Name: "default", Kind: "func", Exported: true, Signature: text}
+		default:
+			sym = Symbol{Name: "default", Kind: "const", Exported: true, Signature: text}
+		}
+	}
+	sym.Parent = parent
+	return jsDecl{symbols: []Symbol{sym}, enter: enter}
 }
 
-// matchTopLevel tries to match a top-level declaration on the given line.
-func (p *JSParser) matchTopLevel(trimmed string, lineNum int, exported bool) (Symbol, bool) {
+// matchTopLevel tries to match a declaration at the top level or inside a
+// namespace. It returns the symbol and the kind of scope its body or
+// initializer opens, if any.
+func (p *JSParser) matchTopLevel(decl, text string, exported bool) (Symbol, string, bool) {
 	// Order matters: check more specific patterns first.
 
 	// Function declarations.
-	if m := jsFuncRe.FindStringSubmatch(trimmed); m != nil {
-		return Symbol{
-			Name:      m[1],
-			Kind:      "func",
-			Line:      lineNum,
-			Exported:  exported,
-			Signature: trimmed,
-		}, true
+	if m := jsFuncRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: jsDefaultName(m[1]), Kind: "func", Exported: exported, Signature: text}, "", true
 	}
 
 	// Class declarations.
-	if m := jsClassRe.FindStringSubmatch(trimmed); m != nil {
-		return Symbol{
-			Name:      m[1],
-			Kind:      "class",
-			Line:      lineNum,
-			Exported:  exported,
-			Signature: trimFirstBrace(trimmed),
-		}, true
+	if m := jsClassRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: jsDefaultName(m[1]), Kind: "class", Exported: exported, Signature: trimFirstBrace(text)}, "class", true
 	}
 
 	// Interface declarations.
-	if m := jsInterfaceRe.FindStringSubmatch(trimmed); m != nil {
-		return Symbol{
-			Name:      m[1],
-			Kind:      "interface",
-			Line:      lineNum,
-			Exported:  exported,
-			Signature: trimFirstBrace(trimmed),
-		}, true
+	if m := jsInterfaceRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: m[1], Kind: "interface", Exported: exported, Signature: trimFirstBrace(text)}, "interface", true
 	}
 
 	// Enum declarations (check before type to avoid conflict with "const enum").
-	if m := jsEnumRe.FindStringSubmatch(trimmed); m != nil {
-		return Symbol{
-			Name:      m[1],
-			Kind:      "enum",
-			Line:      lineNum,
-			Exported:  exported,
-			Signature: trimFirstBrace(trimmed),
-		}, true
+	if m := jsEnumRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: m[1], Kind: "enum", Exported: exported, Signature: trimFirstBrace(text)}, "", true
+	}
+
+	// Namespaces and ambient module declarations.
+	if m := jsNamespaceRe.FindStringSubmatch(decl); m != nil {
+		name, enter := m[2], "namespace"
+		switch {
+		case m[3] != "":
+			name, enter = "global", "ambient"
+		case name == `"` || name == `'`:
+			// declare module "name": the name is masked, so read it from text.
+			// An unterminated name, as in a file being edited, is skipped.
+			start := strings.IndexAny(text, `"'`)
+			if start < 0 {
+				return Symbol{}, "", false
+			}
+			end := strings.IndexByte(text[start+1:], text[start])
+			if end < 0 {
+				return Symbol{}, "", false
+			}
+			name, enter = text[start+1:start+1+end], "ambient"
+		}
+		return Symbol{Name: name, Kind: "namespace", Exported: exported, Signature: trimFirstBrace(text)}, enter, true
 	}
 
 	// Type alias declarations.
-	if m := jsTypeRe.FindStringSubmatch(trimmed); m != nil {
-		return Symbol{
-			Name:      m[1],
-			Kind:      "type",
-			Line:      lineNum,
-			Exported:  exported,
-			Signature: trimmed,
-		}, true
-	}
-
-	// Variable declarations (const/let/var).
-	if m := jsVarRe.FindStringSubmatch(trimmed); m != nil {
-		return Symbol{
-			Name:      m[1],
-			Kind:      "const",
-			Line:      lineNum,
-			Exported:  exported,
-			Signature: trimmed,
-		}, true
-	}
-
-	return Symbol{}, false
-}
+	if m := jsTypeRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: m[1], Kind: "type", Exported: exported, Signature: text}, "", true
+	}
 
-// matchMethod tries to match a class method or property declaration.
-func (p *JSParser) matchMethod(trimmed string, lineNum int, className string) (Symbol, bool) {
-	// Skip lines that are just a closing brace or similar.
-	if trimmed == "}" || trimmed == "};" {
-		return Symbol{}, false
+	// Variable declarations (const/let/var). An object literal initializer
+	// is entered so that its methods are recorded.
+	if m := jsVarRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: m[1], Kind: "const", Exported: exported, Signature: text}, "object", true
 	}
 
-	// Skip lines that are property declarations without parens (e.g., "name: string;").
-	// We only want methods (things with parentheses).
-	if m := jsMethodRe.FindStringSubmatch(trimmed); m != nil {
-		name := m[1]
-		// Skip keywords that are not method names.
-		if isJSKeyword(name) {
-			return Symbol{}, false
-		}
-		exported := !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#")
-		// Private methods are not exported.
-		if strings.HasPrefix(trimmed, "private ") {
-			exported = false
-		}
-		return Symbol{
-			Name:      name,
-			Kind:      "method",
-			Line:      lineNum,
-			Exported:  exported,
-			Parent:    className,
-			Signature: trimmed,
-		}, true
-	}
-
-	// Constructor.
-	if strings.HasPrefix(trimmed, "constructor(") || strings.HasPrefix(trimmed, "constructor (") {
-		return Symbol{
-			Name:      "constructor",
-			Kind:      "method",
-			Line:      lineNum,
-			Exported:  true,
-			Parent:    className,
-			Signature: trimmed,
-		}, true
-	}
-
-	return Symbol{}, false
+	return Symbol{}, "", false
 }
 
-// countBraces returns the net brace count ({  minus }) in a line,
-// ignoring braces in string literals and comments.
-func countBraces(line string) int {
-	depth := 0
-	inSingle := false
-	inDouble := false
-	inTemplate := false
-	escaped := false
-
-	for _, ch := range line {
-		if escaped {
-			escaped = false
+// matchExportList handles export { a, b as c } [from "mod"] and
+// export * as ns from "mod", given the masked statement. Re-exported names
+// become "reexport" symbols; names exported from the file itself are
+// returned as exports.
+func (p *JSParser) matchExportList(stmt, text, parent string) jsDecl {
+	if m := jsExportStarRe.FindStringSubmatch(stmt); m != nil {
+		return jsDecl{symbols: []Symbol{{Name: m[1], Kind: "reexport", Exported: true, Signature: text, Parent: parent}}}
+	}
+	open := strings.IndexByte(stmt, '{')
+	close := strings.IndexByte(stmt, '}')
+	if close < open {
+		close = len(stmt)
+	}
+	reexport := strings.Contains(stmt[close:], "from")
+	var d jsDecl
+	for _, item := range strings.Split(stmt[open+1:close], ",") {
+		m := jsExportItemRe.FindStringSubmatch(strings.TrimSpace(item))
+		if m == nil {
 			continue
 		}
-		if ch == '\\' {
-			escaped = true
+		if !reexport {
+			d.exports = append(d.exports, m[1])
 			continue
 		}
+		name := m[1]
+		if m[2] != "" {
+			name = m[2]
+		}
+		d.symbols = append(d.symbols, Symbol{Name: name, Kind: "reexport", Exported: true, Signature: text, Parent: parent})
+	}
+	return d
+}
 
-		switch {
-		case inSingle:
-			if ch == '\'' {
-				inSingle = false
-			}
-		case inDouble:
-			if ch == '"' {
-				inDouble = false
-			}
-		case inTemplate:
-			if ch == '`' {
-				inTemplate = false
+// matchMethod tries to match a class method or a property initialised with
+// a function.
+func (p *JSParser) matchMethod(decl, text, className string) (Symbol, bool) {
+	name := ""
+	if m := jsMethodRe.FindStringSubmatch(decl); m != nil {
+		name = m[1]
+	} else if m := jsPropRe.FindStringSubmatch(decl); m != nil && jsIsFunctionValue(m[2]) {
+		name = m[1]
+	}
+	// Skip keywords that are not method names.
+	if name == "" || isJSKeyword(name) {
+		return Symbol{}, false
+	}
+	exported := !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#")
+	// Private methods are not exported.
+	if strings.HasPrefix(decl, "private ") {
+		exported = false
+	}
+	return Symbol{
+		Name:      name,
+		Kind:      "method",
+		Exported:  exported,
+		Parent:    className,
+		Signature: text,
+		Abstract:  jsAbstractRe.MatchString(decl),
+	}, true
+}
+
+// matchInterfaceMember tries to match a method or property signature in an
+// interface body. Members that are not optional must be implemented.
+func (p *JSParser) matchInterfaceMember(decl, text string, scope *jsScope) (Symbol, bool) {
+	sym := Symbol{Exported: scope.exported, Parent: scope.name, Signature: text}
+	if m := jsMemberMethodRe.FindStringSubmatch(decl); m != nil {
+		sym.Name, sym.Kind, sym.Abstract = m[1], "method", m[2] == ""
+	} else if m := jsMemberPropRe.FindStringSubmatch(decl); m != nil {
+		// A property with a function type is a method to implementers.
+		sym.Name, sym.Kind, sym.Abstract = m[1], "property", m[2] == ""
+		if jsIsFunctionValue(strings.TrimSpace(m[3])) {
+			sym.Kind = "method"
+		}
+	}
+	if sym.Name == "" || isJSKeyword(sym.Name) {
+		return Symbol{}, false
+	}
+	return sym, true
+}
+
+// matchObjectMember tries to match a method or function-valued property of
+// an object literal.
+func (p *JSParser) matchObjectMember(decl, text string, scope *jsScope) (Symbol, bool) {
+	name := ""
+	if m := jsObjMethodRe.FindStringSubmatch(decl); m != nil {
+		name = m[1]
+	} else if m := jsObjPropRe.FindStringSubmatch(decl); m != nil && jsIsFunctionValue(m[2]) {
+		name = m[1]
+	}
+	if name == "" || isJSKeyword(name) {
+		return Symbol{}, false
+	}
+	return Symbol{
+		Name:      name,
+		Kind:      "method",
+		Exported:  scope.exported && !strings.HasPrefix(name, "_"),
+		Parent:    scope.name,
+		Signature: text,
+	}, true
+}
+
+// jsDefaultName names an anonymous default-exported function or class.
+func jsDefaultName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// jsIsFunctionValue reports whether the masked expression v starts with a
+// function or arrow function. A parenthesised expression such as (a + b)
+// is an arrow function only if => or a return type follows it.
+func jsIsFunctionValue(v string) bool {
+	v = strings.TrimSpace(v)
+	m := jsFuncValueRe.FindStringSubmatchIndex(v)
+	switch {
+	case m == nil:
+		return false
+	case m[2] >= 0 || m[4] >= 0:
+		return true
+	}
+	close := jsClosingParen(v, m[6])
+	// Parameters spanning several lines are taken to be a function's.
+	return close < 0 || jsArrowAfter(v[close+1:])
+}
+
+// jsClosingParen returns the index of the ')' matching the '(' at s[open],
+// or -1 if it is not on this line.
+func jsClosingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
 			}
-		default:
-			switch ch {
-			case '\'':
-				inSingle = true
-			case '"':
-				inDouble = true
-			case '`':
-				inTemplate = true
-			case '{':
-				depth++
-			case '}':
-				depth--
+		}
+	}
+	return -1
+}
+
+// jsArrowAfter reports whether s, the text after an arrow function's
+// parameter list, continues with => or a return type annotation.
+func jsArrowAfter(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "=>") || strings.HasPrefix(s, ":")
+}
+
+// jsHeaderEnd is findHeaderEnd for JavaScript, where a declaration without
+// a body may end at a line break instead of a ';'.
+func jsHeaderEnd(masked []string, line, col int) (endLine, endCol int, body bool) {
+	nesting := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch c := masked[i][j]; c {
+			case '(', '[':
+				nesting++
+			case ')', ']':
+				nesting--
+			case '{', ';':
+				if nesting == 0 {
+					return i, j, c == '{'
+				}
 			}
 		}
+		if nesting == 0 && !jsContinues(masked, i) {
+			return i, len(masked[i]), false
+		}
 	}
+	return len(masked) - 1, len(masked[len(masked)-1]), false
+}
 
-	return depth
+// jsStatementEnd returns the last line of the statement starting at
+// (line, col): the line of its ';', or of the line break that ends it under
+// automatic semicolon insertion. With list set, a ',' also ends it, as for
+// a member of an object literal. An unmatched closing bracket ends the
+// statement too.
+func jsStatementEnd(masked []string, line, col int, list bool) int {
+	nesting := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch masked[i][j] {
+			case '(', '[', '{':
+				nesting++
+			case ')', ']', '}':
+				nesting--
+				if nesting < 0 {
+					return i
+				}
+			case ';':
+				if nesting == 0 {
+					return i
+				}
+			case ',':
+				if list && nesting == 0 {
+					return i
+				}
+			}
+		}
+		if nesting == 0 && !jsContinues(masked, i) {
+			return i
+		}
+	}
+	return len(masked) - 1
 }
 
-// findJSBlockEnd finds the last line of a block (delimited by braces) starting at startLine.
-func findJSBlockEnd(lines []string, startLine int, currentDepth int) int {
-	depth := currentDepth
-	for i := startLine; i < len(lines); i++ {
-		trimmed := strings.TrimSpace(lines[i])
-		// Skip comment-only lines.
-		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+// jsContinues reports whether the statement on masked line i carries on to
+// the next line: the line ends with an operator, or the next line starts
+// with one or with a '{' opening the body.
+func jsContinues(masked []string, i int) bool {
+	cur := masked[i]
+	if k := strings.Index(cur, "//"); k >= 0 {
+		cur = cur[:k]
+	}
+	cur = strings.TrimSpace(cur)
+	if cur != "" && (strings.ContainsRune("=,([{+-*/%&|^!?:<.~", rune(cur[len(cur)-1])) || strings.HasSuffix(cur, "=>")) {
+		return true
+	}
+	for k := i + 1; k < len(masked); k++ {
+		next := strings.TrimSpace(masked[k])
+		if next == "" || strings.HasPrefix(next, "//") {
 			continue
 		}
-		depth += countBraces(trimmed)
-		if depth <= currentDepth && i > startLine {
-			return i + 1 // 1-indexed
+		if strings.ContainsRune(".?:|&+-*/%=,)]>{", rune(next[0])) {
+			return true
+		}
+		for _, kw := range []string{"extends ", "implements ", "as ", "satisfies "} {
+			if strings.HasPrefix(next, kw) {
+				return true
+			}
 		}
+		return false
 	}
-	return len(lines) // end of file
+	return false
+}
+
+// jsObjectInitializer reports whether the variable declaration starting at
+// (line, col) is initialised with an object literal, and returns the line
+// of its '{'.
+func jsObjectInitializer(masked []string, line, col int) (int, bool) {
+	endLine, endCol, body := findHeaderEnd(masked, line, col, true)
+	if body {
+		return endLine, true // export default { ... }
+	}
+	if endLine >= len(masked) || endCol >= len(masked[endLine]) || masked[endLine][endCol] != '=' {
+		return 0, false
+	}
+	for i, from := endLine, endCol+1; i < len(masked); i, from = i+1, 0 {
+		rest := strings.TrimSpace(masked[i][from:])
+		if rest == "" {
+			continue
+		}
+		return i, rest[0] == '{'
+	}
+	return 0, false
 }
 
 // trimFirstBrace trims everything from the first '{' onward for a cleaner signature.
@@ -336,22 +600,6 @@ func trimFirstBrace(s string) string {
 	return s
 }
 
-// removeInlineBlockComments removes /* ... */ comments from a single line.
-func removeInlineBlockComments(s string) string {
-	for {
-		start := strings.Index(s, "/*")
-		if start < 0 {
-			break
-		}
-		end := strings.Index(s[start+2:], "*/")
-		if end < 0 {
-			break
-		}
-		s = s[:start] + s[start+2+end+2:]
-	}
-	return s
-}
-
 // isJSKeyword returns true if the name is a JavaScript/TypeScript keyword
 // that shouldn't be treated as a method name.
 func isJSKeyword(name string) bool {
@@ -365,3 +613,151 @@ func isJSKeyword(name string) bool {
 	}
 	return false
 }
+
+// maskJS returns a copy of src with the contents of comments, string
+// literals, template literals (including their ${} expressions) and regex
+// literals replaced by spaces. Like maskCLike, it keeps the "//" of line
+// comments, and newlines and byte offsets are preserved.
+func maskJS(src []byte) []byte {
+	out := make([]byte, len(src))
+	copy(out, src)
+	blank := func(from, to int) {
+		for k := from; k < to && k < len(out); k++ {
+			if out[k] != '\n' {
+				out[k] = ' '
+			}
+		}
+	}
+
+	n := len(src)
+	for i := 0; i < n; {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < n && src[i+1] == '/':
+			end := i
+			for end < n && src[end] != '\n' {
+				end++
+			}
+			blank(i+2, end)
+			i = end
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			end := strings.Index(string(src[i+2:]), "*/")
+			if end < 0 {
+				blank(i, n)
+				return out
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 2
+		case c == '`':
+			end := jsTemplateEnd(src, i+1)
+			blank(i+1, end)
+			i = end + 1
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < n && src[j] != c && src[j] != '\n' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			blank(i+1, j)
+			i = j + 1
+		case c == '/' && jsRegexAllowed(out, i):
+			j := i + 1
+			inClass := false
+			for j < n && src[j] != '\n' && (src[j] != '/' || inClass) {
+				switch src[j] {
+				case '\\':
+					j++
+				case '[':
+					inClass = true
+				case ']':
+					inClass = false
+				}
+				j++
+			}
+			if j >= n || src[j] == '\n' {
+				i++ // not a regex after all: a division
+				continue
+			}
+			blank(i+1, j)
+			i = j + 1
+		default:
+			i++
+		}
+	}
+	return out
+}
+
+// jsTemplateEnd returns the index of the '`' closing the template literal
+// whose contents start at src[i], skipping over ${} expressions that may
+// hold strings, braces and nested templates. It returns len(src) if the
+// template is unterminated.
+func jsTemplateEnd(src []byte, i int) int {
+	for i < len(src) {
+		switch src[i] {
+		case '\\':
+			i += 2
+			continue
+		case '`':
+			return i
+		case '$':
+			if i+1 < len(src) && src[i+1] == '{' {
+				i = jsExpressionEnd(src, i+2)
+			}
+		}
+		i++
+	}
+	return len(src)
+}
+
+// jsExpressionEnd returns the index of the '}' closing a template ${}
+// expression whose code starts at src[i].
+func jsExpressionEnd(src []byte, i int) int {
+	depth := 0
+	for i < len(src) {
+		switch c := src[i]; c {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case '`':
+			i = jsTemplateEnd(src, i+1)
+		case '"', '\'':
+			for i++; i < len(src) && src[i] != c && src[i] != '\n'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		}
+		i++
+	}
+	return len(src)
+}
+
+// jsRegexAllowed reports whether a '/' at out[i] can start a regex literal
+// rather than a division, judging by the code before it.
+func jsRegexAllowed(out []byte, i int) bool {
+	k := i - 1
+	for k >= 0 && (out[k] == ' ' || out[k] == '\t' || out[k] == '\r' || out[k] == '\n') {
+		k--
+	}
+	if k < 0 {
+		return true
+	}
+	if strings.IndexByte("(,=:[!&|?{};+-*%~^", out[k]) >= 0 {
+		return true
+	}
+	end := k + 1
+	for k >= 0 && isWordByte(out[k]) {
+		k--
+	}
+	switch string(out[k+1 : end]) {
+	case "return", "typeof", "case", "in", "of", "void", "yield", "await", "delete", "throw", "new", "else", "do":
+		return true
+	}
+	return false
+}
@@ -367,3 +367,283 @@ function afterTest() {
 	assertSymbol(t, byName, "test", "func", false, "")
 	assertSymbol(t, byName, "afterTest", "func", false, "")
 }
+
+const sampleModernTSSource = `import { Injectable } from '@angular/core';
+
+export { formatDate, parseDate as parse } from './dates';
+export * as validators from './validators';
+export * from './legacy';
+export type { Config } from './config';
+
+/** Declared before it is exported below. */
+const helper = (x: number) => x * 2;
+function internal() {}
+
+export {
+  helper,
+  internal as renamed,
+};
+
+export function convert(value: string): number;
+export function convert(value: number): string;
+export function convert(value: string | number): string | number {
+  return typeof value === 'string' ? Number(value) : String(value);
+}
+
+export namespace Geometry {
+  export interface Point {
+    x: number;
+    y: number;
+  }
+  export function distance(a: Point, b: Point): number {
+    return Math.hypot(a.x - b.x, a.y - b.y);
+  }
+  const origin = { x: 0, y: 0 };
+}
+
+declare module 'express' {
+  interface Request {
+    user?: string;
+  }
+}
+
+declare global {
+  interface Window {
+    app: unknown;
+  }
+}
+
+@Injectable({
+  providedIn: 'root',
+})
+export abstract class Repository<T> {
+  private cache = new Map<string, T>();
+  protected readonly pattern = /[{}]+/g;
+
+  abstract find(id: string): Promise<T>;
+
+  save(item: T): void;
+  save(items: T[]): void;
+  save(arg: T | T[]): void {
+    const message = ` + "`saving ${Array.isArray(arg) ? `${arg.length} items {` : 'one'}`" + `;
+    console.log(message);
+  }
+
+  handleChange = (event: Event): void => {
+    console.log(event);
+  };
+
+  total = (this.count + 1) * 2;
+
+  #secret(): void {}
+}
+
+export const api = {
+  baseUrl: '/api',
+  getUser: async (id: string) => {
+    return fetch(` + "`/users/${id}`" + `);
+  },
+  list(page = 1) {
+    return fetch('/users?page=' + page);
+  },
+  remove: function (id: string) {
+    return id;
+  },
+  computed: (1 + 2) * 3,
+};
+
+export default {
+  name: 'widget',
+  mounted() {
+    console.log('mounted');
+  },
+};
+
+const pattern = /}/;
+const template = ` + "`\n}\n`" + `;
+
+export function afterTricky(): string {
+  return 'still found';
+}
+`
+
+func TestTSParserModern(t *testing.T) {
+	p := &JSParser{}
+	symbols, err := p.Parse("modern.ts", []byte(sampleModernTSSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	var overloads []Symbol
+	var rest []Symbol
+	for _, s := range symbols {
+		if s.Kind == "overload" {
+			overloads = append(overloads, s)
+		} else {
+			rest = append(rest, s)
+		}
+	}
+	if len(overloads) != 4 || overloads[0].Name != "convert" || overloads[1].Name != "convert" ||
+		overloads[2].Name != "save" || overloads[3].Parent != "Repository" {
+		t.Errorf("overloads = %+v, want two each of convert and save", overloads)
+	}
+	byName := symbolsByName(rest)
+
+	// Re-exports
+	assertSymbol(t, byName, "formatDate", "reexport", true, "")
+	assertSymbol(t, byName, "parse", "reexport", true, "")
+	assertSymbol(t, byName, "validators", "reexport", true, "")
+	assertSymbol(t, byName, "Config", "reexport", true, "")
+
+	// Local declarations exported by a later export list.
+	assertSymbol(t, byName, "helper", "const", true, "")
+	assertSymbol(t, byName, "internal", "func", true, "")
+
+	assertSymbol(t, byName, "convert", "func", true, "")
+
+	// Namespaces and ambient declarations
+	assertSymbol(t, byName, "Geometry", "namespace", true, "")
+	assertSymbol(t, byName, "Point", "interface", true, "Geometry")
+	assertSymbol(t, byName, "distance", "func", true, "Geometry")
+	assertSymbol(t, byName, "origin", "const", false, "Geometry")
+	assertSymbol(t, byName, "express", "namespace", false, "")
+	assertSymbol(t, byName, "Request", "interface", true, "express")
+	assertSymbol(t, byName, "global", "namespace", false, "")
+	assertSymbol(t, byName, "Window", "interface", true, "global")
+	assertSymbol(t, byName, "x", "property", true, "Point")
+	assertSymbol(t, byName, "user", "property", true, "Request")
+
+	// Decorated abstract class
+	assertSymbol(t, byName, "Repository", "class", true, "")
+	assertSymbol(t, byName, "find", "method", true, "Repository")
+	assertSymbol(t, byName, "save", "method", true, "Repository")
+	assertSymbol(t, byName, "handleChange", "method", true, "Repository")
+	assertSymbol(t, byName, "#secret", "method", false, "Repository")
+
+	// Object literal methods
+	assertSymbol(t, byName, "api", "const", true, "")
+	assertSymbol(t, byName, "getUser", "method", true, "api")
+	assertSymbol(t, byName, "list", "method", true, "api")
+	assertSymbol(t, byName, "remove", "method", true, "api")
+	assertSymbol(t, byName, "default", "const", true, "")
+	assertSymbol(t, byName, "mounted", "method", true, "default")
+
+	// Braces in regex and template literals do not end blocks early.
+	assertSymbol(t, byName, "afterTricky", "func", true, "")
+
+	for _, name := range []string{"cache", "pattern", "total", "baseUrl", "computed", "message"} {
+		if s, ok := byName[name]; ok && s.Parent != "" {
+			t.Errorf("%q should not appear as a member", name)
+		}
+	}
+}
+
+func TestTSParserModernLines(t *testing.T) {
+	p := &JSParser{}
+	symbols, err := p.Parse("modern.ts", []byte(sampleModernTSSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"convert", 19, 21, "export function convert(value: string | number): string | number {"},
+		{"Geometry", 23, 32, "export namespace Geometry"},
+		{"Repository", 49, 69, "@Injectable({ providedIn: 'root', }) export abstract class Repository<T>"},
+		{"save", 57, 60, "save(arg: T | T[]): void {"},
+		{"handleChange", 62, 64, "handleChange = (event: Event): void => {"},
+		{"api", 71, 83, "export const api = {"},
+		{"getUser", 73, 75, "getUser: async (id: string) => {"},
+		{"afterTricky", 97, 99, "export function afterTricky(): string {"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+func TestTSParserAbstractMembers(t *testing.T) {
+	src := `export interface Animal {
+  readonly name: string;
+  speak(): string;
+  walk<T>(to: T): void;
+  onMove: (x: number) => void;
+  fly?(): void;
+  nick?: string;
+}
+
+export abstract class Shape {
+  abstract area(): number;
+  protected abstract label(): string;
+  describe(): string {
+    return 'shape';
+  }
+}
+`
+	p := &JSParser{}
+	symbols, err := p.Parse("animal.ts", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	tests := []struct {
+		name, kind, parent string
+		line               int
+		abstract           bool
+	}{
+		{"name", "property", "Animal", 2, true},
+		{"speak", "method", "Animal", 3, true},
+		{"walk", "method", "Animal", 4, true},
+		{"onMove", "method", "Animal", 5, true},
+		{"fly", "method", "Animal", 6, false},
+		{"nick", "property", "Animal", 7, false},
+		{"area", "method", "Shape", 11, true},
+		{"label", "method", "Shape", 12, true},
+		{"describe", "method", "Shape", 13, false},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Kind != tt.kind || sym.Parent != tt.parent || sym.Line != tt.line || sym.Abstract != tt.abstract {
+			t.Errorf("%s = %s in %s at line %d abstract %v, want %s in %s at line %d abstract %v",
+				tt.name, sym.Kind, sym.Parent, sym.Line, sym.Abstract, tt.kind, tt.parent, tt.line, tt.abstract)
+		}
+	}
+}
+
+func TestTSParserUnterminatedModuleName(t *testing.T) {
+	for _, src := range []string{
+		"declare module \"foo\n\nexport function after() {}\n",
+		"module '",
+		"namespace \"",
+	} {
+		p := &JSParser{}
+		symbols, err := p.Parse("broken.ts", []byte(src))
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", src, err)
+		}
+		for _, s := range symbols {
+			if s.Kind == "namespace" {
+				t.Errorf("Parse(%q) recorded namespace %q for an unterminated name", src, s.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&KotlinParser{})
+}
+
+// KotlinParser extracts packages, classes, objects, functions and
+// properties from Kotlin source files. It shares the Java parser's masking
+// and annotation handling; since Kotlin statements usually end at a newline,
+// a declaration header ends at its body, its '=' or the end of a line that
+// does not continue onto the next.
+type KotlinParser struct{}
+
+func (p *KotlinParser) Extensions() []string {
+	return []string{".kt"}
+}
+
+var (
+	// Modifiers that may precede any declaration.
+	kotlinModifiers = `^((?:(?:public|private|protected|internal|open|final|abstract|sealed|data|inner|enum|annotation|value|inline|override|lateinit|const|suspend|noinline|crossinline|tailrec|operator|infix|external|expect|actual|companion)\s+)*)`
+
+	// An optional extension receiver such as String. or List<T>?.
+	kotlinReceiver = `(?:[\w.]+(?:<[^>]*>)?\??\.)?`
+
+	kotlinPackageRe   = regexp.MustCompile(`^package\s+([\w.]+)`)
+	kotlinTypeRe      = regexp.MustCompile(kotlinModifiers + `(?:fun\s+)?(class|interface|object)\b\s*(\w*)`)
+	kotlinFunRe       = regexp.MustCompile(kotlinModifiers + `fun\s+(?:<[^>]*>\s*)?` + kotlinReceiver + `(\w+)\s*\(`)
+	kotlinPropRe      = regexp.MustCompile(kotlinModifiers + `(val|var)\s+(?:<[^>]*>\s*)?` + kotlinReceiver + `(\w+)`)
+	kotlinTypeAliasRe = regexp.MustCompile(kotlinModifiers + `typealias\s+(\w+)`)
+	kotlinHiddenRe    = regexp.MustCompile(`\b(?:private|protected|internal)\b`)
+	kotlinAbstractRe  = regexp.MustCompile(`\babstract\s`)
+)
+
+// kotlinScope is a class, interface or object body whose members are being
+// parsed.
+type kotlinScope struct {
+	name  string // parent for members; a companion's members belong to its class
+	kind  string // kind of the declaration whose body this is
+	depth int    // brace depth of the members inside the body
+	open  int    // line of the '{' opening the body
+}
+
+func (p *KotlinParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	masked := strings.Split(string(maskCLike(content, true)), "\n")
+	var symbols []Symbol
+
+	var scopes []kotlinScope
+	depth := 0
+	consumed := -1 // last line of the declaration being consumed
+
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		var scope *kotlinScope
+		itemDepth := 0
+		if len(scopes) > 0 {
+			scope = &scopes[len(scopes)-1]
+			itemDepth = scope.depth
+		}
+
+		if i > consumed && depth == itemDepth && trimmed != "" {
+			col := len(masked[i]) - len(strings.TrimLeft(masked[i], " \t"))
+			declLine, declCol := skipJVMAnnotations(masked, i, col)
+			if declLine >= len(masked) {
+				break
+			}
+			sym, ok := p.matchDecl(strings.TrimSpace(masked[declLine][declCol:]), scope)
+			if !ok {
+				consumed = declLine - 1
+			} else {
+				endLine, endCol, body := kotlinHeaderEnd(masked, declLine, declCol)
+				sym.Line = declLine + 1
+				if sym.Kind == "package" {
+					// @file: annotations apply to the file, not the package.
+					i, col = declLine, declCol
+				}
+				sym.Signature = joinSignature(lines, masked, i, col, endLine, endCol)
+				consumed = endLine
+				initialized := endCol < len(masked[endLine]) && masked[endLine][endCol] == '='
+				if body {
+					consumed = findBlockEnd(masked, endLine, endCol)
+				} else if initialized {
+					consumed = kotlinExpressionEnd(masked, endLine, endCol+1)
+				}
+				sym.EndLine = consumed + 1
+				// Members declared abstract, and interface members without a
+				// body or initializer, must be overridden.
+				if sym.Kind == "method" || sym.Kind == "property" {
+					sym.Abstract = kotlinAbstractRe.MatchString(sym.Signature) ||
+						scope != nil && scope.kind == "interface" && !body && !initialized
+				}
+				symbols = append(symbols, sym)
+
+				if body && isKotlinContainer(sym.Kind) {
+					name := sym.Name
+					if sym.Name == "Companion" && scope != nil {
+						name = scope.name
+					}
+					scopes = append(scopes, kotlinScope{name: name, kind: sym.Kind, depth: depth + 1, open: endLine})
+					consumed = endLine
+				}
+			}
+		}
+
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+		for len(scopes) > 0 && depth < scopes[len(scopes)-1].depth && i >= scopes[len(scopes)-1].open {
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+
+	return symbols, nil
+}
+
+func isKotlinContainer(kind string) bool {
+	switch kind {
+	case "class", "interface", "object", "enum", "annotation":
+		return true
+	}
+	return false
+}
+
+// matchDecl recognises a declaration at the start of a masked line with any
+// annotations already skipped. Declarations are public unless marked
+// private, protected or internal.
+func (p *KotlinParser) matchDecl(decl string, scope *kotlinScope) (Symbol, bool) {
+	if scope == nil {
+		if m := kotlinPackageRe.FindStringSubmatch(decl); m != nil {
+			return Symbol{Name: m[1], Kind: "package"}, true
+		}
+	}
+	parent := ""
+	if scope != nil {
+		parent = scope.name
+	}
+	exported := func(mods string) bool {
+		return !kotlinHiddenRe.MatchString(mods)
+	}
+
+	if m := kotlinTypeRe.FindStringSubmatch(decl); m != nil {
+		name, kind := m[3], m[2]
+		switch {
+		case strings.Contains(m[1], "companion"):
+			if name == "" {
+				name = "Companion"
+			}
+		case name == "":
+			return Symbol{}, false
+		case kind == "class" && strings.Contains(m[1], "enum"):
+			kind = "enum"
+		case kind == "class" && strings.Contains(m[1], "annotation"):
+			kind = "annotation"
+		}
+		return Symbol{Name: name, Kind: kind, Exported: exported(m[1]), Parent: parent}, true
+	}
+	if m := kotlinFunRe.FindStringSubmatch(decl); m != nil {
+		kind := "func"
+		if scope != nil {
+			kind = "method"
+		}
+		return Symbol{Name: m[2], Kind: kind, Exported: exported(m[1]), Parent: parent}, true
+	}
+	if m := kotlinPropRe.FindStringSubmatch(decl); m != nil {
+		kind := "var"
+		switch {
+		case scope != nil:
+			kind = "property"
+		case strings.Contains(m[1], "const"):
+			kind = "const"
+		}
+		return Symbol{Name: m[3], Kind: kind, Exported: exported(m[1]), Parent: parent}, true
+	}
+	if m := kotlinTypeAliasRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: m[2], Kind: "type", Exported: exported(m[1]), Parent: parent}, true
+	}
+	return Symbol{}, false
+}
+
+// kotlinHeaderEnd finds where the declaration header starting at (line, col)
+// ends: the '{' opening its body, the '=' starting an initializer or
+// expression body, a ';', or the end of a line that the next line does not
+// continue. It returns the line and column of the end and whether a body
+// follows.
+func kotlinHeaderEnd(masked []string, line, col int) (endLine, endCol int, body bool) {
+	nesting := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch c := masked[i][j]; c {
+			case '(', '[':
+				nesting++
+			case ')', ']':
+				nesting--
+			case '{', ';':
+				if nesting == 0 {
+					return i, j, c == '{'
+				}
+			case '=':
+				if nesting == 0 && isAssignment(masked[i], j) {
+					return i, j, false
+				}
+			}
+		}
+		if nesting <= 0 && !kotlinContinues(masked, i) {
+			return i, len(masked[i]), false
+		}
+	}
+	return len(masked) - 1, len(masked[len(masked)-1]), false
+}
+
+// kotlinExpressionEnd returns the last line of the expression starting at
+// (line, col): the first line end where its brackets are balanced and the
+// next line does not continue it.
+func kotlinExpressionEnd(masked []string, line, col int) int {
+	nesting := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch masked[i][j] {
+			case '(', '[', '{':
+				nesting++
+			case ')', ']', '}':
+				nesting--
+			}
+		}
+		if nesting <= 0 && (i > line || strings.TrimSpace(masked[i][col:]) != "") && !kotlinContinues(masked, i) {
+			return i
+		}
+	}
+	return len(masked) - 1
+}
+
+// kotlinContinues reports whether the statement on line i carries on to the
+// next non-blank line, because the line ends with an operator or the next
+// one starts with one (a supertype list, where clause, body or call chain).
+func kotlinContinues(masked []string, i int) bool {
+	cur := strings.TrimSpace(masked[i])
+	for _, suffix := range []string{",", ":", "=", "->", ".", "(", "&&", "||", "+", "-", "*", "/"} {
+		if strings.HasSuffix(cur, suffix) && !strings.HasSuffix(cur, "//") {
+			return true
+		}
+	}
+	for k := i + 1; k < len(masked); k++ {
+		next := strings.TrimSpace(masked[k])
+		if next == "" || next == "//" {
+			continue
+		}
+		for _, prefix := range []string{":", "{", "=", ".", "?.", "?:", "->", "&&", "||", "where "} {
+			if strings.HasPrefix(next, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package parsers
+
+import "testing"
+
+const sampleKotlinSource = `@file:JvmName("Users")
+package com.example.users
+
+import kotlinx.coroutines.flow.Flow
+
+/** Maximum number of users. */
+const val MAX_USERS = 100
+private val cache = mutableMapOf<String, User>()
+var counter: Int = 0
+
+typealias UserMap = Map<String, User>
+
+/* A block comment /* nested { */ still comment } */
+@Serializable
+data class User(
+    val name: String,
+    private val age: Int,
+) : Entity(), Comparable<User> {
+    val displayName: String
+        get() = "$name ($age)"
+
+    private var secret = "{ not a brace"
+
+    override fun compareTo(other: User): Int = age - other.age
+
+    @Throws(IOException::class)
+    suspend fun load(id: Long): User? {
+        val local = 1
+        return null
+    }
+
+    internal fun helper() {}
+
+    companion object {
+        const val DEFAULT_AGE = 18
+        fun create(name: String) = User(name, DEFAULT_AGE)
+    }
+}
+
+interface Repository<T> {
+    fun findById(id: Long): T?
+}
+
+sealed class Result {
+    object Loading : Result()
+    class Success(val value: String) : Result()
+}
+
+enum class Status(val code: Int) {
+    ACTIVE(1),
+    INACTIVE(0) {
+        override fun label() = "off"
+    };
+
+    open fun label() = name.lowercase()
+}
+
+object Registry {
+    val users = listOf(
+        "a",
+        "b",
+    )
+}
+
+fun String.isEmail(): Boolean =
+    contains("@")
+
+fun <T> List<T>.second(): T {
+    return this[1]
+}
+
+private fun main() {
+    println("hi")
+}
+
+annotation class Audited
+`
+
+func TestKotlinParserBasic(t *testing.T) {
+	p := &KotlinParser{}
+	symbols, err := p.Parse("User.kt", []byte(sampleKotlinSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	byName := symbolsByName(symbols)
+
+	assertSymbol(t, byName, "com.example.users", "package", false, "")
+
+	// Top-level declarations
+	assertSymbol(t, byName, "MAX_USERS", "const", true, "")
+	assertSymbol(t, byName, "cache", "var", false, "")
+	assertSymbol(t, byName, "counter", "var", true, "")
+	assertSymbol(t, byName, "UserMap", "type", true, "")
+	assertSymbol(t, byName, "isEmail", "func", true, "")
+	assertSymbol(t, byName, "second", "func", true, "")
+	assertSymbol(t, byName, "main", "func", false, "")
+
+	// Types
+	assertSymbol(t, byName, "User", "class", true, "")
+	assertSymbol(t, byName, "Repository", "interface", true, "")
+	assertSymbol(t, byName, "Result", "class", true, "")
+	assertSymbol(t, byName, "Loading", "object", true, "Result")
+	assertSymbol(t, byName, "Success", "class", true, "Result")
+	assertSymbol(t, byName, "Status", "enum", true, "")
+	assertSymbol(t, byName, "Registry", "object", true, "")
+	assertSymbol(t, byName, "Audited", "annotation", true, "")
+	assertSymbol(t, byName, "Companion", "object", true, "User")
+
+	// Members
+	assertSymbol(t, byName, "displayName", "property", true, "User")
+	assertSymbol(t, byName, "secret", "property", false, "User")
+	assertSymbol(t, byName, "compareTo", "method", true, "User")
+	assertSymbol(t, byName, "load", "method", true, "User")
+	assertSymbol(t, byName, "helper", "method", false, "User")
+	assertSymbol(t, byName, "findById", "method", true, "Repository")
+	assertSymbol(t, byName, "label", "method", true, "Status")
+	assertSymbol(t, byName, "users", "property", true, "Registry")
+
+	// Companion members belong to the enclosing class.
+	assertSymbol(t, byName, "DEFAULT_AGE", "property", true, "User")
+	assertSymbol(t, byName, "create", "method", true, "User")
+
+	// Constructor parameters, enum entries, accessors and locals are not
+	// declarations.
+	for _, name := range []string{"name", "age", "ACTIVE", "INACTIVE", "get", "local", "nested"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not appear as a symbol", name)
+		}
+	}
+}
+
+func TestKotlinParserSignaturesAndLines(t *testing.T) {
+	p := &KotlinParser{}
+	symbols, err := p.Parse("User.kt", []byte(sampleKotlinSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"com.example.users", 2, 2, "package com.example.users"},
+		{"MAX_USERS", 7, 7, "const val MAX_USERS"},
+		{"counter", 9, 9, "var counter: Int"},
+		{"UserMap", 11, 11, "typealias UserMap"},
+		{"User", 15, 38, "@Serializable data class User( val name: String, private val age: Int, ) : Entity(), Comparable<User>"},
+		{"displayName", 19, 19, "val displayName: String"},
+		{"compareTo", 24, 24, "override fun compareTo(other: User): Int"},
+		{"load", 27, 30, "@Throws(IOException::class) suspend fun load(id: Long): User?"},
+		{"Companion", 34, 37, "companion object"},
+		{"Loading", 45, 45, "object Loading : Result()"},
+		{"users", 59, 62, "val users"},
+		{"isEmail", 65, 66, "fun String.isEmail(): Boolean"},
+		{"second", 68, 70, "fun <T> List<T>.second(): T"},
+		{"Audited", 76, 76, "annotation class Audited"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+func TestKotlinParserAbstractMembers(t *testing.T) {
+	src := `interface Shape {
+    val sides: Int
+    val name: String get() = "shape"
+    fun area(): Double
+    fun label(): String = "shape"
+    fun describe() {
+        println(label())
+    }
+}
+
+abstract class Base : Shape {
+    abstract fun draw()
+    override fun area() = 0.0
+}
+`
+	p := &KotlinParser{}
+	symbols, err := p.Parse("Shape.kt", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	assertAbstract(t, symbols, map[string]bool{"sides": true, "area": false, "label": false, "describe": false, "draw": true})
+}
@@ -0,0 +1,52 @@
+package parsers
+
+import (
+	"bytes"
+	"strings"
+)
+
+func init() {
+	Register(&KubernetesParser{})
+}
+
+// KubernetesParser extracts the objects declared in Kubernetes manifests:
+// every YAML document with an apiVersion, a kind and a metadata.name. The
+// symbol's kind is the object's kind in lower case (deployment, service,
+// configmap) and its signature gives the kind with the namespace-qualified
+// name, e.g. "Deployment prod/api". Other YAML files yield no symbols.
+type KubernetesParser struct{}
+
+func (p *KubernetesParser) Extensions() []string {
+	return []string{".yaml", ".yml"}
+}
+
+func (p *KubernetesParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	if !bytes.Contains(content, []byte("apiVersion")) {
+		return nil, nil
+	}
+	var symbols []Symbol
+	for _, doc := range SplitDocuments(YAMLNodes(content)) {
+		values := DataValues(doc)
+		kind, name := values[DataKey("kind")], values[DataKey("metadata", "name")]
+		if values[DataKey("apiVersion")] == "" || kind == "" || name == "" {
+			continue
+		}
+		qualified := name
+		if ns := values[DataKey("metadata", "namespace")]; ns != "" {
+			qualified = ns + "/" + name
+		}
+		line, endLine := doc[0].Line, doc[0].EndLine
+		for _, n := range doc {
+			endLine = max(endLine, n.EndLine)
+		}
+		symbols = append(symbols, Symbol{
+			Name:      name,
+			Kind:      strings.ToLower(kind),
+			Line:      line,
+			EndLine:   endLine,
+			Exported:  true,
+			Signature: kind + " " + qualified,
+		})
+	}
+	return symbols, nil
+}
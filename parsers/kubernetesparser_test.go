@@ -0,0 +1,81 @@
+package parsers
+
+import "testing"
+
+const sampleKubernetesManifest = `# App manifests.
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: prod
+  labels:
+    app: api
+spec:
+  template:
+    spec:
+      containers:
+        - name: api
+          image: ghcr.io/acme/api:1.2.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  ports:
+    - port: 80
+---
+# An empty document and one without a name are skipped.
+---
+apiVersion: v1
+kind: ConfigMap
+data:
+  key: value
+`
+
+func TestKubernetesParser(t *testing.T) {
+	symbols, err := (&KubernetesParser{}).Parse("k8s/api.yaml", []byte(sampleKubernetesManifest))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	want := []Symbol{
+		{Name: "api", Kind: "deployment", Line: 2, EndLine: 14, Exported: true, Signature: "Deployment prod/api"},
+		{Name: "api", Kind: "service", Line: 16, EndLine: 22, Exported: true, Signature: "Service api"},
+	}
+	if len(symbols) != len(want) {
+		t.Fatalf("got %d symbols, want %d: %+v", len(symbols), len(want), symbols)
+	}
+	for i, sym := range symbols {
+		if sym != want[i] {
+			t.Errorf("symbol %d = %+v, want %+v", i, sym, want[i])
+		}
+	}
+}
+
+func TestYAMLParserChain(t *testing.T) {
+	// .yaml is shared by several parsers; each document goes to the one
+	// that recognises it, and other YAML yields nothing.
+	tests := []struct {
+		path, content string
+		kind          string
+	}{
+		{"k8s/api.yaml", sampleKubernetesManifest, "deployment"},
+		{"compose.yaml", sampleComposeFile, "service"},
+		{"openapi.yaml", sampleOpenAPIYAML, "path"},
+		{"config.yaml", "log_level: debug\n", ""},
+	}
+	for _, tt := range tests {
+		symbols, err := ForFile(tt.path).Parse(tt.path, []byte(tt.content))
+		if err != nil {
+			t.Errorf("Parse(%s) error: %v", tt.path, err)
+			continue
+		}
+		got := ""
+		if len(symbols) > 0 {
+			got = symbols[0].Kind
+		}
+		if got != tt.kind {
+			t.Errorf("Parse(%s) first kind = %q, want %q", tt.path, got, tt.kind)
+		}
+	}
+}
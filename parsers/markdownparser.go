@@ -0,0 +1,131 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&MarkdownParser{})
+}
+
+// MarkdownParser extracts the headings of Markdown and MDX documents, both
+// ATX ("## Install") and setext (underlined with = or -). Each heading's
+// parent is the nearest heading of a higher level, and it spans its whole
+// section, up to the next heading of the same or a higher level. Fenced
+// code blocks and front matter are skipped.
+type MarkdownParser struct{}
+
+func (p *MarkdownParser) Extensions() []string {
+	return []string{".md", ".mdx", ".markdown"}
+}
+
+var (
+	mdATXRe     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdSetextRe  = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdFenceRe   = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdBlockRe   = regexp.MustCompile(`^ {0,3}(?:[-*+>|]|\d+[.)])(?:\s|$)`)
+	mdLinkRe    = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	mdHeadingID = regexp.MustCompile(`\s*\{#[\w-]+\}$`)
+)
+
+// docHeading is a heading of a document at a level from 1 (the title)
+// down.
+type docHeading struct {
+	text  string
+	level int
+	line  int // 0-based
+}
+
+func (p *MarkdownParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	var headings []docHeading
+
+	fence := "" // the open code fence, if any
+	start := 0
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if t := strings.TrimSpace(lines[i]); t == "---" || t == "..." {
+				start = i + 1
+				break
+			}
+		}
+	}
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		if m := mdFenceRe.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) && strings.TrimSpace(line) == m[1] {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		if m := mdATXRe.FindStringSubmatch(line); m != nil {
+			headings = append(headings, docHeading{text: headingText(m[2]), level: len(m[1]), line: i})
+			continue
+		}
+		// A setext underline turns the paragraph line above into a heading.
+		if m := mdSetextRe.FindStringSubmatch(line); m != nil && i > start {
+			prev := lines[i-1]
+			if strings.TrimSpace(prev) != "" && lineIndent(prev) < 4 && !mdBlockRe.MatchString(prev) &&
+				!mdATXRe.MatchString(prev) && !mdSetextRe.MatchString(prev) && !mdFenceRe.MatchString(prev) {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				headings = append(headings, docHeading{text: headingText(prev), level: level, line: i - 1})
+			}
+		}
+	}
+	return headingSymbols(headings, lines), nil
+}
+
+// headingText returns the text of a Markdown heading as it reads when
+// rendered: links are replaced by their text, and code, emphasis and
+// explicit {#id} attributes are removed.
+func headingText(s string) string {
+	s = mdHeadingID.ReplaceAllString(strings.TrimSpace(s), "")
+	s = mdLinkRe.ReplaceAllString(s, "$1")
+	s = strings.NewReplacer("`", "", "**", "", "__", "").Replace(s)
+	return strings.TrimSpace(s)
+}
+
+// headingSymbols turns the headings of a document into symbols nested by
+// level. Each spans its section, ending on the last non-blank line before
+// the next heading of the same or a higher level.
+func headingSymbols(headings []docHeading, lines []string) []Symbol {
+	symbols := make([]Symbol, 0, len(headings))
+	for i, h := range headings {
+		end := len(lines) - 1
+		for _, next := range headings[i+1:] {
+			if next.level <= h.level {
+				end = next.line - 1
+				break
+			}
+		}
+		for end > h.line && strings.TrimSpace(lines[end]) == "" {
+			end--
+		}
+		parent := ""
+		for j := i - 1; j >= 0; j-- {
+			if headings[j].level < h.level {
+				parent = headings[j].text
+				break
+			}
+		}
+		symbols = append(symbols, Symbol{
+			Name:      h.text,
+			Kind:      "heading",
+			Line:      h.line + 1,
+			EndLine:   end + 1,
+			Exported:  true,
+			Signature: strings.Repeat("#", h.level) + " " + h.text,
+			Parent:    parent,
+		})
+	}
+	return symbols
+}
@@ -0,0 +1,98 @@
+package parsers
+
+import "testing"
+
+const sampleMarkdown = `---
+title: Guide
+---
+# Guide
+
+Intro text.
+
+## Install
+
+` + "```sh" + `
+# not a heading
+go install ./...
+` + "```" + `
+
+### From source
+
+Build it.
+
+Usage
+-----
+
+- a list item
+---
+
+## The ` + "`scan`" + ` [command](docs/scan.md) {#scan}
+
+Done.
+`
+
+func TestMarkdownParser(t *testing.T) {
+	symbols, err := (&MarkdownParser{}).Parse("README.md", []byte(sampleMarkdown))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	want := []Symbol{
+		{Name: "Guide", Kind: "heading", Line: 4, EndLine: 27, Exported: true, Signature: "# Guide"},
+		{Name: "Install", Kind: "heading", Line: 8, EndLine: 17, Exported: true, Signature: "## Install", Parent: "Guide"},
+		{Name: "From source", Kind: "heading", Line: 15, EndLine: 17, Exported: true, Signature: "### From source", Parent: "Install"},
+		{Name: "Usage", Kind: "heading", Line: 19, EndLine: 23, Exported: true, Signature: "## Usage", Parent: "Guide"},
+		{Name: "The scan command", Kind: "heading", Line: 25, EndLine: 27, Exported: true, Signature: "## The scan command", Parent: "Guide"},
+	}
+	if len(symbols) != len(want) {
+		t.Fatalf("got %d symbols, want %d: %+v", len(symbols), len(want), symbols)
+	}
+	for i, sym := range symbols {
+		if sym != want[i] {
+			t.Errorf("symbol %d = %+v, want %+v", i, sym, want[i])
+		}
+	}
+}
+
+const sampleRST = `=======
+ Guide
+=======
+
+Intro text.
+
+Install
+=======
+
+From source
+-----------
+
+::
+
+    Not a heading
+    -------------
+
+Usage
+=====
+
+Done.
+`
+
+func TestRSTParser(t *testing.T) {
+	symbols, err := (&RSTParser{}).Parse("docs/index.rst", []byte(sampleRST))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	want := []Symbol{
+		{Name: "Guide", Kind: "heading", Line: 2, EndLine: 21, Exported: true, Signature: "# Guide"},
+		{Name: "Install", Kind: "heading", Line: 7, EndLine: 16, Exported: true, Signature: "## Install", Parent: "Guide"},
+		{Name: "From source", Kind: "heading", Line: 10, EndLine: 16, Exported: true, Signature: "### From source", Parent: "Install"},
+		{Name: "Usage", Kind: "heading", Line: 18, EndLine: 21, Exported: true, Signature: "## Usage", Parent: "Guide"},
+	}
+	if len(symbols) != len(want) {
+		t.Fatalf("got %d symbols, want %d: %+v", len(symbols), len(want), symbols)
+	}
+	for i, sym := range symbols {
+		if sym != want[i] {
+			t.Errorf("symbol %d = %+v, want %+v", i, sym, want[i])
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package parsers
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	Register(&OpenAPIParser{})
+}
+
+// OpenAPIParser extracts paths, operations and component schemas from
+// OpenAPI 3 and Swagger 2 specifications written in YAML or JSON. An
+// operation is named by its operationId, or by its method and path when it
+// has none, and its signature is the method and path. Schemas are signed
+// with the $ref that points at them. Other YAML and JSON files yield no
+// symbols.
+//
+// YAML is read with YAMLNodes.
+type OpenAPIParser struct{}
+
+func (p *OpenAPIParser) Extensions() []string {
+	return []string{".yaml", ".yml", ".json"}
+}
+
+// openAPIMethods are the operation keys of a path item.
+var openAPIMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+func (p *OpenAPIParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	if !bytes.Contains(content, []byte("openapi")) && !bytes.Contains(content, []byte("swagger")) {
+		return nil, nil
+	}
+	var nodes []DataNode
+	var err error
+	if strings.EqualFold(filepath.Ext(filePath), ".json") {
+		nodes, err = jsonNodes(content)
+	} else {
+		nodes = YAMLNodes(content)
+	}
+	if !isOpenAPISpec(nodes) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return openAPISymbols(nodes), nil
+}
+
+// isOpenAPISpec reports whether the document has a top-level openapi or
+// swagger version key.
+func isOpenAPISpec(nodes []DataNode) bool {
+	for _, n := range nodes {
+		if len(n.Path) == 1 && (n.Path[0] == "openapi" || n.Path[0] == "swagger") {
+			return true
+		}
+	}
+	return false
+}
+
+// openAPISymbols returns the paths, operations and schemas among nodes.
+func openAPISymbols(nodes []DataNode) []Symbol {
+	values := DataValues(nodes)
+	var symbols []Symbol
+	for _, n := range nodes {
+		p := n.Path
+		sym := Symbol{Line: n.Line, EndLine: n.EndLine, Exported: true}
+		switch {
+		case len(p) == 2 && p[0] == "paths" && strings.HasPrefix(p[1], "/"):
+			sym.Name, sym.Kind, sym.Signature = p[1], "path", p[1]
+		case len(p) == 3 && p[0] == "paths" && openAPIMethods[strings.ToLower(p[2])]:
+			sym.Kind = "operation"
+			sym.Signature = strings.ToUpper(p[2]) + " " + p[1]
+			sym.Name = values[DataKey(p[0], p[1], p[2], "operationId")]
+			if sym.Name == "" {
+				sym.Name = sym.Signature
+			}
+		case len(p) == 3 && p[0] == "components" && p[1] == "schemas":
+			sym.Name, sym.Kind, sym.Signature = p[2], "schema", "#/components/schemas/"+p[2]
+		case len(p) == 2 && p[0] == "definitions":
+			sym.Name, sym.Kind, sym.Signature = p[1], "schema", "#/definitions/"+p[1]
+		default:
+			continue
+		}
+		symbols = append(symbols, sym)
+	}
+	return symbols
+}
@@ -0,0 +1,142 @@
+package parsers
+
+import "testing"
+
+const sampleOpenAPIYAML = `# Pet store API.
+openapi: 3.0.3
+info:
+  title: Pets
+  description: |
+    Multi-line description.
+    paths:
+      /not-a-path:
+        get: {}
+  version: "1.0"
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: limit
+          in: query
+    post:
+      summary: Create a pet # no operationId
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+  "/pets/{petId}":
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      operationId: "showPetById"
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: integer
+    Error:
+      type: object
+`
+
+func TestOpenAPIParserYAML(t *testing.T) {
+	symbols, err := (&OpenAPIParser{}).Parse("openapi.yaml", []byte(sampleOpenAPIYAML))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	tests := []struct {
+		name      string
+		kind      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"/pets", "path", 12, 24, "/pets"},
+		{"listPets", "operation", 13, 17, "GET /pets"},
+		{"POST /pets", "operation", 18, 24, "POST /pets"},
+		{"/pets/{petId}", "path", 25, 29, "/pets/{petId}"},
+		{"showPetById", "operation", 28, 29, "GET /pets/{petId}"},
+		{"Pet", "schema", 32, 36, "#/components/schemas/Pet"},
+		{"Error", "schema", 37, 38, "#/components/schemas/Error"},
+	}
+	if len(symbols) != len(tests) {
+		t.Errorf("got %d symbols, want %d: %+v", len(symbols), len(tests), symbols)
+	}
+	byName := symbolsByName(symbols)
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Kind != tt.kind || !sym.Exported || sym.Parent != "" {
+			t.Errorf("%s: kind %q exported %v parent %q, want exported %q", tt.name, sym.Kind, sym.Exported, sym.Parent, tt.kind)
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+const sampleSwaggerJSON = `{
+  "swagger": "2.0",
+  "paths": {
+    "/users/{id}": {
+      "delete": {
+        "operationId": "deleteUser",
+        "tags": ["users"]
+      }
+    }
+  },
+  "definitions": {
+    "User": {
+      "type": "object"
+    }
+  }
+}
+`
+
+func TestOpenAPIParserSwaggerJSON(t *testing.T) {
+	symbols, err := (&OpenAPIParser{}).Parse("swagger.json", []byte(sampleSwaggerJSON))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+	if len(symbols) != 3 {
+		t.Errorf("got %d symbols, want 3: %+v", len(symbols), symbols)
+	}
+	if sym := byName["/users/{id}"]; sym.Kind != "path" || sym.Line != 4 || sym.EndLine != 9 {
+		t.Errorf("path = %+v, want kind path at lines 4-9", sym)
+	}
+	if sym := byName["deleteUser"]; sym.Kind != "operation" || sym.Signature != "DELETE /users/{id}" || sym.Line != 5 || sym.EndLine != 8 {
+		t.Errorf("deleteUser = %+v, want operation DELETE /users/{id} at lines 5-8", sym)
+	}
+	if sym := byName["User"]; sym.Kind != "schema" || sym.Signature != "#/definitions/User" || sym.Line != 12 || sym.EndLine != 14 {
+		t.Errorf("User = %+v, want schema #/definitions/User at lines 12-14", sym)
+	}
+}
+
+func TestOpenAPIParserIgnoresOtherDocuments(t *testing.T) {
+	docs := map[string]string{
+		"docker-compose.yml": "services:\n  web:\n    image: swagger-ui\n",
+		"package.json":       `{"name": "app", "dependencies": {"swagger-ui": "^5.0.0"}}`,
+		"broken.json":        `{"description": "mentions openapi", `,
+		"values.yaml":        "paths:\n  /health:\n    get: {}\n",
+	}
+	for name, content := range docs {
+		symbols, err := (&OpenAPIParser{}).Parse(name, []byte(content))
+		if err != nil || len(symbols) != 0 {
+			t.Errorf("Parse(%s) = %v, %v; want no symbols", name, symbols, err)
+		}
+	}
+
+	if _, err := (&OpenAPIParser{}).Parse("api.json", []byte("{\n  \"openapi\": \"3.1.0\",\n  \"paths\": {,\n}")); err == nil {
+		t.Error("Parse() of a malformed spec should return an error")
+	}
+}
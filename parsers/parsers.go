@@ -1,14 +1,20 @@
 package parsers
 
+import (
+	"path/filepath"
+	"sync"
+)
+
 // Symbol represents a top-level symbol extracted from a source file.
 type Symbol struct {
 	Name      string `json:"name"`
-	Kind      string `json:"kind"`      // "func", "method", "type", "interface", "struct", "const", "var"
+	Kind      string `json:"kind"` // "func", "method", "type", "interface", "struct", "const", "var"
 	Line      int    `json:"line"`
 	EndLine   int    `json:"endLine"`
 	Exported  bool   `json:"exported"`
-	Signature string `json:"signature"` // e.g. "func HandleAuth(w http.ResponseWriter, r *http.Request) error"
-	Parent    string `json:"parent"`    // enclosing type for methods, empty otherwise
+	Signature string `json:"signature"`          // e.g. "func HandleAuth(w http.ResponseWriter, r *http.Request) error"
+	Parent    string `json:"parent"`             // enclosing type for methods, empty otherwise
+	Abstract  bool   `json:"abstract,omitempty"` // a member without a body that implementers must define
 }
 
 // Parser extracts symbols from a source file.
@@ -17,18 +23,105 @@ type Parser interface {
 	Extensions() []string
 }
 
-// registry maps file extensions to parsers.
+// FileMatcher is implemented by parsers that also handle files by name
+// rather than by extension, such as Dockerfiles.
+type FileMatcher interface {
+	MatchFile(base string) bool
+}
+
+// registry maps file extensions to parsers. An extension claimed by several
+// parsers, such as .yaml, maps to a parserChain of them.
 var registry = map[string]Parser{}
 
-// Register adds a parser for the given extensions.
+// named holds the registered parsers that match files by name.
+var named []Parser
+
+// external maps file extensions to parsers configured at run time, such as
+// ExternalParser plugins. They take precedence over the registry.
+var (
+	external   = map[string]Parser{}
+	externalMu sync.RWMutex
+)
+
+// Register adds a parser for the given extensions, after any parser
+// already registered for them.
 func Register(p Parser) {
 	for _, ext := range p.Extensions() {
-		registry[ext] = p
+		switch prev := registry[ext].(type) {
+		case nil:
+			registry[ext] = p
+		case parserChain:
+			registry[ext] = append(prev, p)
+		default:
+			registry[ext] = parserChain{prev, p}
+		}
+	}
+	if _, ok := p.(FileMatcher); ok {
+		named = append(named, p)
 	}
 }
 
+// parserChain handles an extension shared by parsers that each recognise
+// their own kind of document, such as OpenAPI specs and Kubernetes
+// manifests written in YAML. Parse returns the result of the first parser
+// that finds symbols or fails.
+type parserChain []Parser
+
+func (c parserChain) Extensions() []string {
+	var exts []string
+	for _, p := range c {
+		exts = append(exts, p.Extensions()...)
+	}
+	return exts
+}
+
+func (c parserChain) Parse(filePath string, content []byte) ([]Symbol, error) {
+	for _, p := range c {
+		if symbols, err := p.Parse(filePath, content); err != nil || len(symbols) > 0 {
+			return symbols, err
+		}
+	}
+	return nil, nil
+}
+
+// SetExternal replaces the run-time configured parsers with ps. A later
+// parser wins when two handle the same extension; nil clears them all.
+func SetExternal(ps []Parser) {
+	m := make(map[string]Parser)
+	for _, p := range ps {
+		for _, ext := range p.Extensions() {
+			m[ext] = p
+		}
+	}
+	externalMu.Lock()
+	external = m
+	externalMu.Unlock()
+}
+
 // ForExtension returns the parser registered for the given file extension,
 // or nil if none is available.
 func ForExtension(ext string) Parser {
+	externalMu.RLock()
+	p, ok := external[ext]
+	externalMu.RUnlock()
+	if ok {
+		return p
+	}
 	return registry[ext]
 }
+
+// ForFile returns the parser for the file at path: a parser registered for
+// its extension, or else one that matches its name. It returns nil if none
+// is available.
+func ForFile(path string) Parser {
+	if p := ForExtension(filepath.Ext(path)); p != nil {
+		return p
+	}
+	base := filepath.Base(path)
+	for _, p := range named {
+		if p.(FileMatcher).MatchFile(base) {
+			return p
+		}
+	}
+	return nil
+}
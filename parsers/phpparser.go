@@ -0,0 +1,179 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&PHPParser{})
+}
+
+// PHPParser extracts namespaces, classes, interfaces, traits, enums,
+// functions, methods, constants and properties from PHP source files.
+// Comments and literals are masked out before brace-depth tracking, and
+// members are attributed to their innermost enclosing type.
+type PHPParser struct{}
+
+func (p *PHPParser) Extensions() []string {
+	return []string{".php"}
+}
+
+var (
+	// Modifiers that may precede a type or member declaration.
+	phpModifiers = `^((?:(?:public|protected|private|static|abstract|final|readonly|var)\s+)*)`
+
+	phpNamespaceRe = regexp.MustCompile(`^namespace\s+([\w\\]+)`)
+	phpTypeRe      = regexp.MustCompile(phpModifiers + `(class|interface|trait|enum)\s+(\w+)`)
+	phpFuncRe      = regexp.MustCompile(phpModifiers + `function\s+&?\s*(\w+)\s*\(`)
+	phpConstRe     = regexp.MustCompile(phpModifiers + `const\s+(?:[\w\\?|]+\s+)?(\w+)\s*=`)
+	phpPropertyRe  = regexp.MustCompile(phpModifiers + `(?:[\w\\?|]+\s+)?\$(\w+)\s*[=;,]`)
+	phpHiddenRe    = regexp.MustCompile(`\b(?:private|protected)\b`)
+)
+
+// phpScope is a type body whose members are being parsed.
+type phpScope struct {
+	name  string
+	depth int // brace depth of the members inside the body
+	open  int // line of the '{' opening the body
+}
+
+func (p *PHPParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	masked := strings.Split(string(maskPHP(content)), "\n")
+	var symbols []Symbol
+
+	var scopes []phpScope
+	depth := 0
+	topDepth := 0 // brace depth of top-level declarations, inside a braced namespace
+	consumed := -1
+
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		var scope *phpScope
+		itemDepth := topDepth
+		if len(scopes) > 0 {
+			scope = &scopes[len(scopes)-1]
+			itemDepth = scope.depth
+		}
+
+		if i > consumed && depth == itemDepth && trimmed != "" {
+			col := len(masked[i]) - len(strings.TrimLeft(masked[i], " \t"))
+			declLine, declCol := skipPHPAttributes(masked, i, col)
+			if declLine >= len(masked) {
+				break
+			}
+			sym, ok := p.matchDecl(strings.TrimSpace(masked[declLine][declCol:]), scope)
+			if !ok {
+				consumed = declLine - 1
+			} else {
+				member := sym.Kind == "const" || sym.Kind == "property"
+				endLine, endCol, body := findHeaderEnd(masked, declLine, declCol, member)
+				declEnd := findDeclEnd(masked, endLine, endCol, body)
+				consumed = declEnd
+				sym.Line = declLine + 1
+				sym.Signature = joinSignature(lines, masked, i, col, endLine, endCol)
+				sym.EndLine = declEnd + 1
+				// Interface methods and abstract methods have no body.
+				sym.Abstract = sym.Kind == "method" && !body
+				symbols = append(symbols, sym)
+
+				switch {
+				case sym.Kind == "namespace" && body:
+					// namespace Foo { ... } holds top-level declarations.
+					consumed = endLine
+					topDepth = depth + 1
+				case body && sym.Kind != "func" && sym.Kind != "method":
+					consumed = endLine
+					scopes = append(scopes, phpScope{name: sym.Name, depth: depth + 1, open: endLine})
+				}
+			}
+		}
+
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+		for len(scopes) > 0 && depth < scopes[len(scopes)-1].depth && i >= scopes[len(scopes)-1].open {
+			scopes = scopes[:len(scopes)-1]
+		}
+		if depth < topDepth {
+			topDepth = depth
+		}
+	}
+
+	return symbols, nil
+}
+
+// matchDecl recognises a declaration at the start of a masked line with any
+// attributes already skipped. Types and functions are always visible;
+// members are public unless declared private or protected.
+func (p *PHPParser) matchDecl(decl string, scope *phpScope) (Symbol, bool) {
+	if scope == nil {
+		if m := phpNamespaceRe.FindStringSubmatch(decl); m != nil {
+			return Symbol{Name: m[1], Kind: "namespace"}, true
+		}
+		if m := phpTypeRe.FindStringSubmatch(decl); m != nil {
+			return Symbol{Name: m[3], Kind: m[2], Exported: true}, true
+		}
+		if m := phpFuncRe.FindStringSubmatch(decl); m != nil && m[1] == "" {
+			return Symbol{Name: m[2], Kind: "func", Exported: true}, true
+		}
+		if m := phpConstRe.FindStringSubmatch(decl); m != nil && m[1] == "" {
+			return Symbol{Name: m[2], Kind: "const", Exported: true}, true
+		}
+		return Symbol{}, false
+	}
+
+	if m := phpFuncRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: m[2], Kind: "method", Exported: !phpHiddenRe.MatchString(m[1]), Parent: scope.name}, true
+	}
+	if m := phpConstRe.FindStringSubmatch(decl); m != nil {
+		return Symbol{Name: m[2], Kind: "const", Exported: !phpHiddenRe.MatchString(m[1]), Parent: scope.name}, true
+	}
+	// A property needs a modifier; otherwise the line is a statement.
+	if m := phpPropertyRe.FindStringSubmatch(decl); m != nil && m[1] != "" {
+		return Symbol{Name: m[2], Kind: "property", Exported: !phpHiddenRe.MatchString(m[1]), Parent: scope.name}, true
+	}
+	return Symbol{}, false
+}
+
+// maskPHP is maskCLike for PHP, which also has # line comments. A #
+// comment is rewritten to look like a // comment so signatures drop it;
+// #[...] attributes are left alone.
+func maskPHP(src []byte) []byte {
+	out := maskCLike(src, false)
+	for i := 0; i < len(out); i++ {
+		if out[i] != '#' || i+1 < len(out) && out[i+1] == '[' {
+			continue
+		}
+		end := i
+		for end < len(out) && out[end] != '\n' {
+			out[end] = ' '
+			end++
+		}
+		if end-i >= 2 {
+			out[i], out[i+1] = '/', '/'
+		}
+		i = end
+	}
+	return out
+}
+
+// skipPHPAttributes returns the position of the first character after any
+// #[...] attributes starting at (line, col).
+func skipPHPAttributes(masked []string, line, col int) (int, int) {
+	for line < len(masked) {
+		s := masked[line]
+		for col < len(s) && (s[col] == ' ' || s[col] == '\t' || s[col] == '\r') {
+			col++
+		}
+		if col >= len(s) {
+			line, col = line+1, 0
+			continue
+		}
+		if !strings.HasPrefix(s[col:], "#[") {
+			return line, col
+		}
+		line, col = cClosing(masked, line, col+1, '[', ']')
+		col++
+	}
+	return line, col
+}
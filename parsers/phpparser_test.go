@@ -0,0 +1,197 @@
+package parsers
+
+import "testing"
+
+const samplePHPSource = `<?php
+
+declare(strict_types=1);
+
+namespace App\Http\Controllers;
+
+use App\Models\User;
+use Illuminate\Http\Request;
+
+const DEFAULT_PAGE_SIZE = 25;
+
+# Formats a user's name. {
+function format_name(User $user): string
+{
+    return "{$user->first} {$user->last}";
+}
+
+/**
+ * Handles user requests.
+ */
+#[Route('/users')]
+final class UserController extends Controller implements HasMiddleware
+{
+    use AuthorizesRequests;
+
+    public const MAX_USERS = 100;
+    private const SECRET = 'x';
+
+    protected static ?string $cache = null;
+    public readonly int $limit;
+    private array $seen = [
+        'a' => 1,
+    ];
+    var $legacy;
+
+    public function __construct(private UserService $users)
+    {
+    }
+
+    #[Get('/{id}')]
+    public function show(Request $request, int $id): User
+    {
+        if ($id < 0) {
+            abort(404);
+        }
+        $callback = function ($x) {
+            return $x;
+        };
+        return $this->users->find($id);
+    }
+
+    protected function authorizeUser(User $user): bool { return true; }
+
+    private static function &cacheRef(): array
+    {
+        return self::$cache;
+    }
+
+    function implicitPublic() {}
+}
+
+interface Repository
+{
+    public function find(int $id): ?User;
+}
+
+trait Auditable
+{
+    abstract protected function auditKey(): string;
+
+    public function audit(): void
+    {
+    }
+}
+
+enum Status: string
+{
+    case Active = 'active';
+    case Inactive = 'inactive';
+
+    public function label(): string
+    {
+        return ucfirst($this->value);
+    }
+}
+`
+
+func TestPHPParserBasic(t *testing.T) {
+	p := &PHPParser{}
+	symbols, err := p.Parse("UserController.php", []byte(samplePHPSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	assertSymbol(t, byName, `App\Http\Controllers`, "namespace", false, "")
+	assertSymbol(t, byName, "DEFAULT_PAGE_SIZE", "const", true, "")
+	assertSymbol(t, byName, "format_name", "func", true, "")
+
+	// Types
+	assertSymbol(t, byName, "UserController", "class", true, "")
+	assertSymbol(t, byName, "Repository", "interface", true, "")
+	assertSymbol(t, byName, "Auditable", "trait", true, "")
+	assertSymbol(t, byName, "Status", "enum", true, "")
+
+	// Constants and properties
+	assertSymbol(t, byName, "MAX_USERS", "const", true, "UserController")
+	assertSymbol(t, byName, "SECRET", "const", false, "UserController")
+	assertSymbol(t, byName, "cache", "property", false, "UserController")
+	assertSymbol(t, byName, "limit", "property", true, "UserController")
+	assertSymbol(t, byName, "seen", "property", false, "UserController")
+	assertSymbol(t, byName, "legacy", "property", true, "UserController")
+
+	// Methods
+	assertSymbol(t, byName, "__construct", "method", true, "UserController")
+	assertSymbol(t, byName, "show", "method", true, "UserController")
+	assertSymbol(t, byName, "authorizeUser", "method", false, "UserController")
+	assertSymbol(t, byName, "cacheRef", "method", false, "UserController")
+	assertSymbol(t, byName, "implicitPublic", "method", true, "UserController")
+	assertSymbol(t, byName, "find", "method", true, "Repository")
+	assertSymbol(t, byName, "auditKey", "method", false, "Auditable")
+	assertSymbol(t, byName, "audit", "method", true, "Auditable")
+	assertSymbol(t, byName, "label", "method", true, "Status")
+
+	// Imports, trait uses, enum cases, promoted parameters and locals are
+	// not symbols.
+	for _, name := range []string{"User", "AuthorizesRequests", "Active", "users", "callback", "x"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not appear as a symbol", name)
+		}
+	}
+}
+
+func TestPHPParserSignaturesAndLines(t *testing.T) {
+	p := &PHPParser{}
+	symbols, err := p.Parse("UserController.php", []byte(samplePHPSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{`App\Http\Controllers`, 5, 5, `namespace App\Http\Controllers;`},
+		{"DEFAULT_PAGE_SIZE", 10, 10, "const DEFAULT_PAGE_SIZE"},
+		{"format_name", 13, 16, "function format_name(User $user): string"},
+		{"UserController", 22, 60, "#[Route('/users')] final class UserController extends Controller implements HasMiddleware"},
+		{"MAX_USERS", 26, 26, "public const MAX_USERS"},
+		{"seen", 31, 33, "private array $seen"},
+		{"legacy", 34, 34, "var $legacy;"},
+		{"show", 41, 50, "#[Get('/{id}')] public function show(Request $request, int $id): User"},
+		{"authorizeUser", 52, 52, "protected function authorizeUser(User $user): bool"},
+		{"find", 64, 64, "public function find(int $id): ?User;"},
+		{"auditKey", 69, 69, "abstract protected function auditKey(): string;"},
+		{"label", 81, 84, "public function label(): string"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+func TestPHPParserAbstractMembers(t *testing.T) {
+	src := `<?php
+interface Shape {
+    public function area(): float;
+}
+
+abstract class Base implements Shape {
+    abstract protected function draw(): void;
+    public function describe(): string { return "shape"; }
+}
+`
+	p := &PHPParser{}
+	symbols, err := p.Parse("Shape.php", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	assertAbstract(t, symbols, map[string]bool{"area": true, "draw": true, "describe": false})
+}
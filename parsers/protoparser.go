@@ -0,0 +1,112 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&ProtoParser{})
+}
+
+// ProtoParser extracts the package, messages, enums, services and rpc
+// methods from Protocol Buffers schema files. Nested messages and enums get
+// the dotted chain of enclosing messages as Parent, and rpc methods their
+// service; an rpc's signature carries its request and response types.
+// Comments and string literals are blanked out first so braces inside them
+// do not affect the brace-depth tracking.
+type ProtoParser struct{}
+
+func (p *ProtoParser) Extensions() []string {
+	return []string{".proto"}
+}
+
+var (
+	protoPackageRe = regexp.MustCompile(`^package\s+([\w.]+)`)
+	protoTypeRe    = regexp.MustCompile(`^(message|enum|service)\s+(\w+)`)
+	protoRPCRe     = regexp.MustCompile(`^rpc\s+(\w+)`)
+)
+
+// protoScope is a message or service body whose declarations are recorded.
+type protoScope struct {
+	kind  string // "message" or "service"
+	path  string // dotted name of the message, or the service name
+	depth int    // brace depth of the declarations inside the body
+	open  int    // line of the '{' opening the body
+}
+
+func (p *ProtoParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	masked := strings.Split(string(maskCLike(content, false)), "\n")
+	var symbols []Symbol
+
+	var scopes []protoScope
+	depth := 0
+	headerEnd := -1 // last line of the declaration header being consumed
+
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		var scope *protoScope
+		declDepth := 0
+		if len(scopes) > 0 {
+			scope = &scopes[len(scopes)-1]
+			declDepth = scope.depth
+		}
+
+		if i > headerEnd && depth == declDepth && trimmed != "" {
+			if sym, ok := p.matchDecl(trimmed, scope); ok {
+				col := strings.Index(masked[i], trimmed)
+				endLine, endCol, body := findHeaderEnd(masked, i, col, false)
+				declEnd := findDeclEnd(masked, endLine, endCol, body)
+				headerEnd = endLine
+				if !body {
+					headerEnd = declEnd
+				}
+				sym.Line = i + 1
+				sym.EndLine = declEnd + 1
+				sym.Signature = joinSignature(lines, masked, i, col, endLine, endCol)
+				symbols = append(symbols, sym)
+
+				if body && (sym.Kind == "message" || sym.Kind == "service") {
+					path := sym.Name
+					if sym.Parent != "" {
+						path = sym.Parent + "." + sym.Name
+					}
+					scopes = append(scopes, protoScope{kind: sym.Kind, path: path, depth: depth + 1, open: endLine})
+				}
+			}
+		}
+
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+		for len(scopes) > 0 && depth < scopes[len(scopes)-1].depth && i >= scopes[len(scopes)-1].open {
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+
+	return symbols, nil
+}
+
+// matchDecl recognises a declaration at the start of a masked line, at the
+// top level or directly inside a message or service body. Fields, options
+// and enum values are not recorded.
+func (p *ProtoParser) matchDecl(trimmed string, scope *protoScope) (Symbol, bool) {
+	if scope == nil {
+		if m := protoPackageRe.FindStringSubmatch(trimmed); m != nil {
+			return Symbol{Name: m[1], Kind: "package"}, true
+		}
+	}
+	if m := protoTypeRe.FindStringSubmatch(trimmed); m != nil {
+		if scope != nil && (scope.kind == "service" || m[1] == "service") {
+			return Symbol{}, false
+		}
+		parent := ""
+		if scope != nil {
+			parent = scope.path
+		}
+		return Symbol{Name: m[2], Kind: m[1], Exported: true, Parent: parent}, true
+	}
+	if m := protoRPCRe.FindStringSubmatch(trimmed); m != nil && scope != nil && scope.kind == "service" {
+		return Symbol{Name: m[1], Kind: "rpc", Exported: true, Parent: scope.path}, true
+	}
+	return Symbol{}, false
+}
@@ -0,0 +1,131 @@
+package parsers
+
+import "testing"
+
+const sampleProtoSource = `syntax = "proto3";
+
+package acme.user.v1;
+
+import "google/protobuf/timestamp.proto";
+
+option go_package = "example.com/acme/gen/user/v1;userv1";
+
+// User is a registered account.
+message User {
+  string id = 1;
+  string name = 2; // display name { not a block
+  Address address = 3;
+
+  message Address {
+    string street = 1;
+    message Geo {
+      double lat = 1;
+    }
+  }
+
+  enum Role {
+    ROLE_UNSPECIFIED = 0;
+    ROLE_ADMIN = 1;
+  }
+
+  oneof contact {
+    string email = 4;
+    string phone = 5;
+  }
+  map<string, string> labels = 6;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+}
+
+/* message Commented {} */
+
+service UserService {
+  option (acme.auth) = "required";
+
+  // GetUser fetches one user.
+  rpc GetUser(GetUserRequest) returns (User);
+  rpc ListUsers(ListUsersRequest)
+      returns (stream User) {
+    option (google.api.http) = { get: "/v1/users" };
+  }
+  rpc Chat(stream ChatMessage) returns (stream ChatMessage) {}
+}
+
+message GetUserRequest { string id = 1; }
+`
+
+func TestProtoParser(t *testing.T) {
+	p := &ProtoParser{}
+	symbols, err := p.Parse("user.proto", []byte(sampleProtoSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	assertSymbol(t, byName, "acme.user.v1", "package", false, "")
+	assertSymbol(t, byName, "User", "message", true, "")
+	assertSymbol(t, byName, "Address", "message", true, "User")
+	assertSymbol(t, byName, "Geo", "message", true, "User.Address")
+	assertSymbol(t, byName, "Role", "enum", true, "User")
+	assertSymbol(t, byName, "Status", "enum", true, "")
+	assertSymbol(t, byName, "UserService", "service", true, "")
+	assertSymbol(t, byName, "GetUser", "rpc", true, "UserService")
+	assertSymbol(t, byName, "ListUsers", "rpc", true, "UserService")
+	assertSymbol(t, byName, "Chat", "rpc", true, "UserService")
+	assertSymbol(t, byName, "GetUserRequest", "message", true, "")
+
+	// Fields, oneofs, enum values and commented-out declarations are not
+	// symbols.
+	for _, name := range []string{"id", "contact", "labels", "ROLE_ADMIN", "STATUS_ACTIVE", "Commented"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not appear as a symbol", name)
+		}
+	}
+	if len(symbols) != 11 {
+		t.Errorf("got %d symbols, want 11: %+v", len(symbols), symbols)
+	}
+}
+
+func TestProtoParserSignaturesAndLines(t *testing.T) {
+	p := &ProtoParser{}
+	symbols, err := p.Parse("user.proto", []byte(sampleProtoSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"acme.user.v1", 3, 3, "package acme.user.v1;"},
+		{"User", 10, 32, "message User"},
+		{"Address", 15, 20, "message Address"},
+		{"Geo", 17, 19, "message Geo"},
+		{"Role", 22, 25, "enum Role"},
+		{"Status", 34, 37, "enum Status"},
+		{"UserService", 41, 51, "service UserService"},
+		{"GetUser", 45, 45, "rpc GetUser(GetUserRequest) returns (User);"},
+		{"ListUsers", 46, 49, "rpc ListUsers(ListUsersRequest) returns (stream User)"},
+		{"Chat", 50, 50, "rpc Chat(stream ChatMessage) returns (stream ChatMessage)"},
+		{"GetUserRequest", 53, 53, "message GetUserRequest"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package parsers
 
 import (
+	"bytes"
 	"regexp"
 	"strings"
 	"unicode"
@@ -10,7 +11,12 @@ func init() {
 	Register(&PythonParser{})
 }
 
-// PythonParser extracts symbols from Python source files using regex heuristics.
+// PythonParser extracts symbols from Python source files. Comments and
+// strings are masked out and physical lines are joined into logical
+// statements, so multi-line signatures and docstrings do not confuse the
+// indentation tracking. Classes and functions are recorded at any depth
+// with their enclosing scopes as a dotted Parent chain. When the module
+// defines __all__, it decides which top-level names are exported.
 type PythonParser struct{}
 
 func (p *PythonParser) Extensions() []string {
@@ -18,124 +24,442 @@ func (p *PythonParser) Extensions() []string {
 }
 
 var (
-	pyFuncRe     = regexp.MustCompile(`^(async\s+)?def\s+(\w+)\s*\((.*)`)
-	pyClassRe    = regexp.MustCompile(`^class\s+(\w+)(.*)`)
-	pyConstRe    = regexp.MustCompile(`^([A-Z][A-Z0-9_]*)\s*[=:]`)
-	pyDecoratorRe = regexp.MustCompile(`^@\S+`)
+	pyFuncRe      = regexp.MustCompile(`^(async\s+)?def\s+(\w+)\s*[\[(]`)
+	pyClassRe     = regexp.MustCompile(`^class\s+(\w+)`)
+	pyConstRe     = regexp.MustCompile(`^([A-Z][A-Z0-9_]*)\s*[=:]`)
+	pyDecoratorRe = regexp.MustCompile(`^@\s*([\w.]+)`)
+	pyAssignRe    = regexp.MustCompile(`^([A-Za-z_]\w*)\s*(?::|=(?:[^=]|$))`)
+	pyTypeAliasRe = regexp.MustCompile(`^(?:type\s+(\w+)\s*(?:\[.*\])?\s*=|(\w+)\s*:\s*(?:typing\.)?TypeAlias\b)`)
+	pyDunderAllRe = regexp.MustCompile(`^__all__\s*(?:(?::[^=]*)?(\+?=)|\.\s*(?:extend|append)\b)`)
+	pyFromRe      = regexp.MustCompile(`^from\s+\S+\s+import\s+(.*)`)
+	pyImportRe    = regexp.MustCompile(`^import\s+(.*)`)
+	pyStringRe    = regexp.MustCompile(`["']([A-Za-z_]\w*)["']`)
 )
 
+// pyKeywords are statement keywords that pyAssignRe would otherwise take
+// for an annotated name, as in "else:".
+var pyKeywords = map[string]bool{
+	"else": true, "try": true, "finally": true, "case": true, "match": true,
+	"lambda": true, "while": true, "with": true, "if": true, "elif": true,
+}
+
+// pyStatement is a logical line: a physical line plus any lines joined to
+// it by open brackets, backslashes or multi-line strings.
+type pyStatement struct {
+	line    int    // first line, 0-based
+	endLine int    // last line, 0-based
+	indent  int    // indentation of the first line
+	text    string // masked, trimmed text of the first line
+}
+
+// pyScope is a class or function body whose nested definitions are being
+// parsed.
+type pyScope struct {
+	path   string // dotted names from the module down, e.g. "Outer.Inner"
+	kind   string // "class" or "func"
+	indent int
+}
+
 func (p *PythonParser) Parse(filePath string, content []byte) ([]Symbol, error) {
 	lines := strings.Split(string(content), "\n")
+	masked, inString := maskPython(lines)
+	stmts := pyStatements(masked, inString)
+	all, hasAll := pyDunderAll(lines, masked, stmts)
+
 	var symbols []Symbol
+	defined := make(map[string]bool)
+	topExported := func(name string) bool {
+		if hasAll {
+			return all[name]
+		}
+		return !strings.HasPrefix(name, "_")
+	}
 
-	var currentClass string
-	var classIndent int
+	var scopes []pyScope
 	var decorators []string
+	for k, st := range stmts {
+		for len(scopes) > 0 && st.indent <= scopes[len(scopes)-1].indent {
+			scopes = scopes[:len(scopes)-1]
+		}
+		var scope *pyScope
+		if len(scopes) > 0 {
+			scope = &scopes[len(scopes)-1]
+		}
 
-	for i := 0; i < len(lines); i++ {
-		line := lines[i]
-
-		// Skip empty lines and comments.
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-			// Reset decorators on blank line or comment between decorator and def/class.
-			if trimmed == "" {
-				decorators = nil
-			}
+		if pyDecoratorRe.MatchString(st.text) {
+			decorators = append(decorators, pyText(lines, masked, st.line, st.indent, st.endLine, -1))
 			continue
 		}
 
-		indent := lineIndent(line)
+		fm := pyFuncRe.FindStringSubmatch(st.text)
+		cm := pyClassRe.FindStringSubmatch(st.text)
+		if fm != nil || cm != nil {
+			sym := Symbol{Kind: "class", Line: st.line + 1, EndLine: pyBlockEnd(stmts, k) + 1}
+			if fm != nil {
+				sym.Name, sym.Kind = fm[2], "func"
+			} else {
+				sym.Name = cm[1]
+			}
+			endLine, endCol := pyHeaderEnd(masked, st.line, st.indent)
+			sym.Signature = buildSignature(decorators, pyText(lines, masked, st.line, st.indent, endLine, endCol+1))
 
-		// If we're inside a class and hit a line at indent level 0 that isn't
-		// a blank/comment (already filtered above), leave the class context.
-		if currentClass != "" && indent == 0 {
-			// Check if this is a decorator — decorators at level 0 might precede
-			// a new class or a standalone function, so leave class context.
-			currentClass = ""
-		}
+			emit := true
+			switch {
+			case scope == nil:
+				sym.Exported = topExported(sym.Name)
+				defined[sym.Name] = true
+			case scope.kind == "class":
+				sym.Exported = !strings.HasPrefix(sym.Name, "_")
+				sym.Parent = scope.path
+				if sym.Kind == "func" {
+					sym.Kind = "method"
+				}
+			default:
+				// Definitions local to a function are not reachable from outside.
+				sym.Parent = scope.path
+			}
+			if sym.Kind != "class" {
+				sym.Kind, emit = pyDecoratedKind(sym.Kind, sym.Name, decorators)
+			}
+			if scope != nil && scope.kind == "class" {
+				sym.Abstract = pyAbstract(decorators)
+			}
+			if emit {
+				symbols = append(symbols, sym)
+			}
 
-		// Collect decorators.
-		if pyDecoratorRe.MatchString(trimmed) {
-			decorators = append(decorators, trimmed)
+			path := sym.Name
+			if scope != nil {
+				path = scope.path + "." + sym.Name
+			}
+			kind := "func"
+			if cm != nil {
+				kind = "class"
+			}
+			scopes = append(scopes, pyScope{path: path, kind: kind, indent: st.indent})
+			decorators = nil
 			continue
 		}
+		decorators = nil
 
-		// Class definition at indent level 0.
-		if indent == 0 {
-			if m := pyClassRe.FindStringSubmatch(trimmed); m != nil {
-				name := m[1]
-				sig := buildSignature(decorators, trimmed)
-				endLine := findPythonBlockEnd(lines, i)
-				symbols = append(symbols, Symbol{
-					Name:      name,
-					Kind:      "class",
-					Line:      i + 1,
-					EndLine:   endLine,
-					Exported:  !strings.HasPrefix(name, "_"),
-					Signature: sig,
-				})
-				currentClass = name
-				classIndent = 0
-				decorators = nil
+		switch {
+		case scope != nil && scope.kind == "class":
+			// Class attributes, including dataclass and pydantic fields.
+			m := pyAssignRe.FindStringSubmatch(st.text)
+			if m == nil || pyKeywords[m[1]] || strings.HasPrefix(m[1], "__") && strings.HasSuffix(m[1], "__") {
 				continue
 			}
-		}
-
-		// Function/method definition.
-		if m := pyFuncRe.FindStringSubmatch(trimmed); m != nil {
-			name := m[2]
-			sig := buildSignature(decorators, trimmed)
-			endLine := findPythonBlockEnd(lines, i)
+			symbols = append(symbols, Symbol{
+				Name:      m[1],
+				Kind:      "field",
+				Line:      st.line + 1,
+				EndLine:   st.endLine + 1,
+				Exported:  !strings.HasPrefix(m[1], "_"),
+				Signature: pyText(lines, masked, st.line, st.indent, st.endLine, -1),
+				Parent:    scope.path,
+			})
 
+		case scope == nil:
 			sym := Symbol{
-				Name:      name,
-				Line:      i + 1,
-				EndLine:   endLine,
-				Exported:  !strings.HasPrefix(name, "_"),
-				Signature: sig,
-			}
-
-			if indent == 0 {
-				sym.Kind = "func"
-			} else if currentClass != "" && indent > classIndent {
-				sym.Kind = "method"
-				sym.Parent = currentClass
+				Line:      st.line + 1,
+				EndLine:   st.endLine + 1,
+				Signature: pyText(lines, masked, st.line, st.indent, st.endLine, -1),
+			}
+			if m := pyTypeAliasRe.FindStringSubmatch(st.text); m != nil {
+				sym.Name, sym.Kind = m[1]+m[2], "type"
+			} else if m := pyConstRe.FindStringSubmatch(st.text); m != nil && isUpperSnakeCase(m[1]) {
+				// Single letters are skipped to avoid variables like I or X.
+				sym.Name, sym.Kind = m[1], "const"
+			} else if m := pyAssignRe.FindStringSubmatch(st.text); m != nil && hasAll && all[m[1]] {
+				// Other variables are only worth listing when __all__ exports them.
+				sym.Name, sym.Kind = m[1], "var"
 			} else {
-				// Indented function outside a class context — skip (nested function).
-				decorators = nil
 				continue
 			}
-
+			sym.Exported = !hasAll || all[sym.Name]
+			defined[sym.Name] = true
 			symbols = append(symbols, sym)
-			decorators = nil
+		}
+	}
+
+	// Names in __all__ that the module imports rather than defines are
+	// re-exports, recorded at their import.
+	if hasAll {
+		for _, imp := range pyImportedNames(masked, stmts) {
+			name, st := imp.name, imp.stmt
+			if all[name] && !defined[name] {
+				defined[name] = true
+				symbols = append(symbols, Symbol{
+					Name:      name,
+					Kind:      "reexport",
+					Line:      st.line + 1,
+					EndLine:   st.endLine + 1,
+					Exported:  true,
+					Signature: pyText(lines, masked, st.line, st.indent, st.endLine, -1),
+				})
+			}
+		}
+		sortSymbolsByLine(symbols)
+	}
+
+	return symbols, nil
+}
+
+// pyDecoratedKind returns the kind of a function given its decorators, and
+// false for property setters and deleters, which add nothing to the getter
+// already recorded.
+func pyDecoratedKind(kind, name string, decorators []string) (string, bool) {
+	for _, d := range decorators {
+		m := pyDecoratorRe.FindStringSubmatch(d)
+		if m == nil {
 			continue
 		}
+		last := m[1][strings.LastIndex(m[1], ".")+1:]
+		switch {
+		case last == "overload":
+			return "overload", true
+		case kind == "method" && (last == "property" || last == "cached_property"):
+			return "property", true
+		case kind == "method" && (m[1] == name+".setter" || m[1] == name+".deleter"):
+			return kind, false
+		}
+	}
+	return kind, true
+}
 
-		// Module-level constants (UPPER_SNAKE_CASE at indent 0).
-		if indent == 0 {
-			if m := pyConstRe.FindStringSubmatch(trimmed); m != nil {
-				name := m[1]
-				// Make sure it's truly UPPER_SNAKE_CASE (at least 2 chars to avoid
-				// matching single-letter variable like I or X used conventionally).
-				if isUpperSnakeCase(name) {
-					symbols = append(symbols, Symbol{
-						Name:      name,
-						Kind:      "const",
-						Line:      i + 1,
-						EndLine:   i + 1,
-						Exported:  true,
-						Signature: trimmed,
-					})
+// pyAbstract reports whether decorators mark a method abstract, as
+// @abstractmethod or @abc.abstractmethod and their older property, class
+// and static method variants do.
+func pyAbstract(decorators []string) bool {
+	for _, d := range decorators {
+		m := pyDecoratorRe.FindStringSubmatch(d)
+		if m == nil {
+			continue
+		}
+		switch m[1][strings.LastIndex(m[1], ".")+1:] {
+		case "abstractmethod", "abstractproperty", "abstractclassmethod", "abstractstaticmethod":
+			return true
+		}
+	}
+	return false
+}
+
+// pyDunderAll collects the names listed in the module's __all__, following
+// plain and augmented assignments and extend/append calls.
+func pyDunderAll(lines, masked []string, stmts []pyStatement) (map[string]bool, bool) {
+	all := make(map[string]bool)
+	found := false
+	for _, st := range stmts {
+		if st.indent != 0 {
+			continue
+		}
+		m := pyDunderAllRe.FindStringSubmatch(st.text)
+		if m == nil {
+			continue
+		}
+		if m[1] == "=" {
+			all = make(map[string]bool)
+		}
+		found = true
+		text := pyText(lines, masked, st.line, 0, st.endLine, -1)
+		for _, s := range pyStringRe.FindAllStringSubmatch(text, -1) {
+			all[s[1]] = true
+		}
+	}
+	return all, found
+}
+
+// pyImport is a name bound by an import statement.
+type pyImport struct {
+	name string
+	stmt pyStatement
+}
+
+// pyImportedNames returns the names bound by top-level imports, in source
+// order.
+func pyImportedNames(masked []string, stmts []pyStatement) []pyImport {
+	var names []pyImport
+	for _, st := range stmts {
+		if st.indent != 0 {
+			continue
+		}
+		text := pyText(masked, masked, st.line, 0, st.endLine, -1)
+		m := pyFromRe.FindStringSubmatch(text)
+		if m == nil {
+			m = pyImportRe.FindStringSubmatch(text)
+		}
+		if m == nil {
+			continue
+		}
+		list := strings.NewReplacer("(", " ", ")", " ", "\\", " ").Replace(m[1])
+		for _, item := range strings.Split(list, ",") {
+			fields := strings.Fields(item)
+			switch {
+			case len(fields) == 3 && fields[1] == "as":
+				names = append(names, pyImport{fields[2], st})
+			case len(fields) == 1 && fields[0] != "*":
+				// "import a.b" binds a.
+				names = append(names, pyImport{strings.Split(fields[0], ".")[0], st})
+			}
+		}
+	}
+	return names
+}
+
+// pyStatements splits masked lines into logical statements, skipping blank
+// lines and lines that continue an earlier one.
+func pyStatements(masked []string, inString []bool) []pyStatement {
+	var stmts []pyStatement
+	depth := 0
+	continued := false
+	for i, line := range masked {
+		trimmed := strings.TrimSpace(line)
+		if depth == 0 && !continued && !inString[i] && trimmed != "" && trimmed[0] != '#' {
+			stmts = append(stmts, pyStatement{line: i, indent: lineIndent(line), text: trimmed})
+		}
+		for _, c := range line {
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				if depth > 0 {
+					depth--
 				}
 			}
 		}
+		code := strings.TrimRight(line, " \t\r")
+		if k := strings.IndexByte(code, '#'); k >= 0 {
+			code = strings.TrimRight(code[:k], " \t")
+		}
+		continued = strings.HasSuffix(code, "\\")
+		if len(stmts) > 0 && trimmed != "" && trimmed[0] != '#' {
+			stmts[len(stmts)-1].endLine = i
+		}
+	}
+	return stmts
+}
 
-		// Any non-decorator, non-def, non-class line resets decorators.
-		decorators = nil
+// pyBlockEnd returns the last line, 0-based, of the block headed by
+// stmts[k]: the end of the last following statement that is indented
+// deeper.
+func pyBlockEnd(stmts []pyStatement, k int) int {
+	end := stmts[k].endLine
+	for j := k + 1; j < len(stmts) && stmts[j].indent > stmts[k].indent; j++ {
+		end = stmts[j].endLine
 	}
+	return end
+}
 
-	return symbols, nil
+// pyHeaderEnd returns the position of the ':' ending the def or class
+// header starting at (line, col), skipping colons inside brackets.
+func pyHeaderEnd(masked []string, line, col int) (int, int) {
+	depth := 0
+	for i := line; i < len(masked); i++ {
+		s := masked[i]
+		for j := col; j < len(s); j++ {
+			switch s[j] {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				depth--
+			case ':':
+				if depth == 0 {
+					return i, j
+				}
+			case '#':
+				j = len(s)
+			}
+		}
+		col = 0
+	}
+	return line, len(masked[line]) - 1
+}
+
+// pyText joins the original text from (line, col) up to (endLine, endCol),
+// or to the end of endLine when endCol is negative, dropping comments and
+// collapsing whitespace.
+func pyText(lines, masked []string, line, col, endLine, endCol int) string {
+	var parts []string
+	for i := line; i <= endLine && i < len(lines); i++ {
+		text := lines[i]
+		if i == endLine && endCol >= 0 {
+			text = text[:min(endCol, len(text))]
+		}
+		if k := strings.IndexByte(masked[i], '#'); k >= 0 && k < len(text) {
+			text = text[:k]
+		}
+		if i == line {
+			text = text[min(col, len(text)):]
+		}
+		parts = append(parts, strings.TrimSpace(text))
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// maskPython returns lines with the contents of string literals and
+// comments replaced by spaces. Quotes and the '#' opening a comment are
+// kept, so columns are preserved. inString reports the lines that begin
+// inside a triple-quoted string.
+func maskPython(lines []string) (masked []string, inString []bool) {
+	masked = make([]string, len(lines))
+	inString = make([]bool, len(lines))
+	open := "" // delimiter of a triple-quoted string spanning lines
+	for i, line := range lines {
+		b := []byte(line)
+		j := 0
+		if open != "" {
+			inString[i] = true
+			j = pyStringEnd(b, 0, open)
+			if j < 0 {
+				masked[i] = string(b)
+				continue
+			}
+			open = ""
+		}
+		for ; j < len(b); j++ {
+			switch c := b[j]; c {
+			case '#':
+				for k := j + 1; k < len(b); k++ {
+					b[k] = ' '
+				}
+				j = len(b)
+			case '"', '\'':
+				delim := string(c)
+				if strings.HasPrefix(line[j:], strings.Repeat(delim, 3)) {
+					delim = strings.Repeat(delim, 3)
+				}
+				end := pyStringEnd(b, j+len(delim), delim)
+				if end < 0 {
+					if len(delim) == 3 {
+						open = delim
+					}
+					j = len(b)
+					continue
+				}
+				j = end - 1
+			}
+		}
+		masked[i] = string(b)
+	}
+	return masked, inString
+}
+
+// pyStringEnd blanks the string contents in b from start up to the closing
+// delim and returns the index just past it, or -1 if the string does not
+// close on this line.
+func pyStringEnd(b []byte, start int, delim string) int {
+	for k := start; k < len(b); k++ {
+		if b[k] == '\\' && k+1 < len(b) {
+			b[k], b[k+1] = ' ', ' '
+			k++
+			continue
+		}
+		if bytes.HasPrefix(b[k:], []byte(delim)) {
+			return k + len(delim)
+		}
+		b[k] = ' '
+	}
+	return -1
 }
 
 // lineIndent returns the number of leading whitespace characters.
@@ -168,28 +492,6 @@ func isUpperSnakeCase(name string) bool {
 	return true
 }
 
-// findPythonBlockEnd finds the last line of a Python block starting at startLine.
-// It looks for the next line at the same or lesser indentation level (that isn't
-// blank or a comment), or the end of the file.
-func findPythonBlockEnd(lines []string, startLine int) int {
-	baseIndent := lineIndent(lines[startLine])
-	lastNonEmpty := startLine
-
-	for i := startLine + 1; i < len(lines); i++ {
-		trimmed := strings.TrimSpace(lines[i])
-		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
-			continue
-		}
-		indent := lineIndent(lines[i])
-		if indent <= baseIndent {
-			return lastNonEmpty + 1 // 1-indexed
-		}
-		lastNonEmpty = i
-	}
-
-	return lastNonEmpty + 1 // 1-indexed
-}
-
 // buildSignature creates the signature string, optionally prepending decorators.
 func buildSignature(decorators []string, defLine string) string {
 	if len(decorators) == 0 {
@@ -303,3 +303,214 @@ API_VERSION = "v2"
 		}
 	}
 }
+
+const sampleTypedPythonSource = `"""Typed package module.
+
+def not_a_function():
+    pass
+"""
+from __future__ import annotations
+
+from dataclasses import dataclass, field
+from typing import TypeAlias, overload
+from .models import (
+    User,
+    Group as Team,
+)
+
+__all__ = ["Config", "Repository", "load", "User", "Team", "registry"]
+__all__ += ["UserId"]
+
+UserId: TypeAlias = int
+type Pair[T] = tuple[T, T]
+registry = {}
+helper_cache = {}
+
+
+@dataclass(
+    frozen=True,
+)
+class Config:
+    """Settings."""
+
+    name: str
+    retries: int = 3  # attempts before giving up
+    tags: list[str] = field(
+        default_factory=list,
+    )
+    _secret: str = ""
+    __slots__ = ()
+
+    class Meta:
+        ordering = ["name"]
+
+    @property
+    def label(self) -> str:
+        return self.name
+
+    @label.setter
+    def label(self, value: str) -> None:
+        self.name = value
+
+
+class Repository:
+    @overload
+    def get(self, key: int) -> User: ...
+    @overload
+    def get(self, key: str) -> User | None: ...
+    def get(self, key):
+        def lookup(k):
+            class Cache:
+                pass
+            return k
+        return lookup(key)
+
+    async def refresh(
+        self,
+        keys: list[int] | None = None,  # all when None
+        *,
+        timeout: float = 1.5,
+    ) -> dict[int, User]:
+        return {}
+
+
+def load(
+    path: str,
+    config: Config | None = None,
+) -> Repository:
+    query = """
+SELECT *
+"""
+    return Repository()
+
+
+def _private() -> None:
+    pass
+
+
+def public_but_not_listed() -> None:
+    pass
+`
+
+func TestPythonParserTyped(t *testing.T) {
+	p := &PythonParser{}
+	symbols, err := p.Parse("typed.py", []byte(sampleTypedPythonSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	var overloads int
+	var rest []Symbol
+	for _, s := range symbols {
+		if s.Kind == "overload" {
+			overloads++
+			continue
+		}
+		rest = append(rest, s)
+	}
+	if overloads != 2 {
+		t.Errorf("got %d overloads of get, want 2", overloads)
+	}
+	byName := symbolsByName(rest)
+
+	// __all__ decides top-level exports.
+	assertSymbol(t, byName, "Config", "class", true, "")
+	assertSymbol(t, byName, "Repository", "class", true, "")
+	assertSymbol(t, byName, "load", "func", true, "")
+	assertSymbol(t, byName, "_private", "func", false, "")
+	assertSymbol(t, byName, "public_but_not_listed", "func", false, "")
+	assertSymbol(t, byName, "registry", "var", true, "")
+	assertSymbol(t, byName, "UserId", "type", true, "")
+	assertSymbol(t, byName, "Pair", "type", false, "")
+	assertSymbol(t, byName, "User", "reexport", true, "")
+	assertSymbol(t, byName, "Team", "reexport", true, "")
+
+	// Class attributes and dataclass fields
+	assertSymbol(t, byName, "name", "field", true, "Config")
+	assertSymbol(t, byName, "retries", "field", true, "Config")
+	assertSymbol(t, byName, "tags", "field", true, "Config")
+	assertSymbol(t, byName, "_secret", "field", false, "Config")
+	assertSymbol(t, byName, "Meta", "class", true, "Config")
+	assertSymbol(t, byName, "ordering", "field", true, "Config.Meta")
+	assertSymbol(t, byName, "label", "property", true, "Config")
+
+	// Nested scopes carry their parent chain.
+	assertSymbol(t, byName, "get", "method", true, "Repository")
+	assertSymbol(t, byName, "lookup", "func", false, "Repository.get")
+	assertSymbol(t, byName, "Cache", "class", false, "Repository.get.lookup")
+	assertSymbol(t, byName, "refresh", "method", true, "Repository")
+
+	for _, name := range []string{"not_a_function", "helper_cache", "__slots__", "__all__", "query", "dataclass", "k"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not be a symbol", name)
+		}
+	}
+	for _, s := range symbols {
+		if s.Name == "label" && s.Line != 42 {
+			t.Errorf("label at line %d, want only the getter at 42", s.Line)
+		}
+	}
+}
+
+func TestPythonParserTypedSignatures(t *testing.T) {
+	p := &PythonParser{}
+	symbols, err := p.Parse("typed.py", []byte(sampleTypedPythonSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"Config", 27, 47, "@dataclass( frozen=True, )\nclass Config:"},
+		{"retries", 31, 31, "retries: int = 3"},
+		{"tags", 32, 34, "tags: list[str] = field( default_factory=list, )"},
+		{"label", 42, 43, "@property\ndef label(self) -> str:"},
+		{"refresh", 62, 68, "async def refresh( self, keys: list[int] | None = None, *, timeout: float = 1.5, ) -> dict[int, User]:"},
+		{"load", 71, 78, "def load( path: str, config: Config | None = None, ) -> Repository:"},
+		{"User", 10, 13, "from .models import ( User, Group as Team, )"},
+		{"UserId", 18, 18, "UserId: TypeAlias = int"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+func TestPythonParserAbstractMembers(t *testing.T) {
+	src := `import abc
+from abc import ABC, abstractmethod
+
+
+class Shape(ABC):
+    @abstractmethod
+    def area(self):
+        ...
+
+    @property
+    @abc.abstractmethod
+    def sides(self):
+        ...
+
+    def describe(self):
+        return "shape"
+`
+	p := &PythonParser{}
+	symbols, err := p.Parse("shape.py", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	assertAbstract(t, symbols, map[string]bool{"area": true, "sides": true, "describe": false})
+}
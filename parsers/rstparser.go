@@ -0,0 +1,66 @@
+package parsers
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(&RSTParser{})
+}
+
+// RSTParser extracts the section titles of reStructuredText documents. A
+// title is underlined, and optionally overlined, with a punctuation
+// character; as in docutils, levels follow the order in which the
+// adornment styles first appear. Symbols are nested and span their
+// sections as with MarkdownParser, and their signatures use the Markdown
+// form ("## Install") to show the level.
+type RSTParser struct{}
+
+func (p *RSTParser) Extensions() []string {
+	return []string{".rst"}
+}
+
+// rstAdornment returns the character a line of at least two repeated
+// punctuation characters is made of, or 0 if line is not one.
+func rstAdornment(line string) byte {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 2 || !strings.ContainsRune("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", rune(line[0])) {
+		return 0
+	}
+	if strings.Trim(line, line[:1]) != "" {
+		return 0
+	}
+	return line[0]
+}
+
+func (p *RSTParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	var headings []docHeading
+	styles := map[string]int{} // adornment style to level
+
+	for i := 1; i < len(lines); i++ {
+		c := rstAdornment(lines[i])
+		title := strings.TrimRight(lines[i-1], " \t")
+		if c == 0 || strings.TrimSpace(title) == "" || rstAdornment(title) != 0 {
+			continue
+		}
+		// An overline starts a block of its own; a matching line right
+		// after text is the underline of the section before.
+		overlined := i >= 2 && strings.TrimSpace(lines[i-2]) == strings.TrimSpace(lines[i]) &&
+			(i < 3 || strings.TrimSpace(lines[i-3]) == "")
+		if !overlined && (lineIndent(title) > 0 || utf8.RuneCountInString(strings.TrimSpace(lines[i])) < utf8.RuneCountInString(title)) {
+			continue // indented text, or a line too short to underline it
+		}
+		style := string(c)
+		if overlined {
+			style += "/"
+		}
+		if _, ok := styles[style]; !ok {
+			styles[style] = len(styles) + 1
+		}
+		text := strings.TrimSpace(strings.ReplaceAll(title, "``", ""))
+		headings = append(headings, docHeading{text: text, level: styles[style], line: i - 1})
+	}
+	return headingSymbols(headings, lines), nil
+}
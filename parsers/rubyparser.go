@@ -0,0 +1,302 @@
+package parsers
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+func init() {
+	Register(&RubyParser{})
+}
+
+// RubyParser extracts modules, classes, methods, constants and attributes
+// from Ruby source files. Comments, strings and heredocs are masked out,
+// then blocks are tracked by pairing opening keywords and "do" with their
+// "end". Methods under a bare private or protected, or hidden afterwards
+// with private :name, are not exported.
+type RubyParser struct{}
+
+func (p *RubyParser) Extensions() []string {
+	return []string{".rb"}
+}
+
+var (
+	// Visibility prefixes that may precede a def or attr_* on the same line.
+	rubyVisibility = `^((?:(?:private|protected|public|module_function|private_class_method)\s+)?)`
+
+	rubyTypeRe      = regexp.MustCompile(`^(module|class)\s+((?:[A-Z]\w*::)*)([A-Z]\w*)`)
+	rubySingletonRe = regexp.MustCompile(`^class\s*<<\s*self\b`)
+	rubyConcernRe   = regexp.MustCompile(`^class_methods\s+(do)\b`)
+	rubyDefRe       = regexp.MustCompile(rubyVisibility + `def\s+(?:(self|[A-Z]\w*)\s*\.\s*)?([A-Za-z_]\w*[?!=]?|\[\]=?|===?|<=>|=~|!=?|\*\*|<<|>>|[-+]@|[-+*/%<>]=?|[&|^~])`)
+	rubyEndlessRe   = regexp.MustCompile(`^\s*(?:\([^)]*\))?\s*=(?:[^=~>]|$)`)
+	rubyConstRe     = regexp.MustCompile(`^([A-Z]\w*)\s*(?:\|\|)?=(?:[^=~>]|$)`)
+	rubyAttrRe      = regexp.MustCompile(rubyVisibility + `attr_(?:reader|writer|accessor)\b(.*)`)
+	rubyAccessRe    = regexp.MustCompile(`^(private|protected|public|module_function)\s*$`)
+	rubyHideRe      = regexp.MustCompile(`^(?:private|protected|private_class_method|private_constant)\b\s*\(?\s*(:.*)`)
+	rubySymbolArgRe = regexp.MustCompile(`:(\w+[?!=]?)`)
+
+	// Keywords opening a block that "end" closes: at the start of a
+	// statement, after an assignment or open bracket, or "do" anywhere.
+	rubyOpenerRe    = regexp.MustCompile(`^(?:(?:private|protected|public|module_function|private_class_method)\s+)?(module|class|def|if|unless|while|until|case|begin|for)\b`)
+	rubyValueOpenRe = regexp.MustCompile(`(?:[=(,]|\breturn)\s*(if|unless|case|begin|while|until)\b`)
+	rubyDoEndRe     = regexp.MustCompile(`\b(?:do|end)\b`)
+	rubyHeredocRe   = regexp.MustCompile(`<<[~-]?(['"]?)([A-Za-z_]\w*)`)
+)
+
+// rubyBlock is a block opened by a keyword or "do" and closed by "end".
+// Members of module, class and singleton-class blocks are recorded; the
+// contents of other blocks are skipped.
+type rubyBlock struct {
+	kind    string // "module", "class", "singleton" or "body"
+	name    string // the type whose members the block holds
+	symbol  int    // index of the symbol the block ends, or -1
+	private bool   // a bare private or protected is in effect
+}
+
+// rubyEvent is a block opening or closing keyword at a column of a masked
+// line.
+type rubyEvent struct {
+	col  int
+	open bool
+}
+
+func (p *RubyParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	masked := maskRuby(lines)
+	var symbols []Symbol
+
+	var blocks []rubyBlock
+	for i := range masked {
+		trimmed := strings.TrimSpace(masked[i])
+		if trimmed == "" {
+			continue
+		}
+
+		openAt, decl := -1, rubyBlock{}
+		if len(blocks) == 0 || blocks[len(blocks)-1].kind != "body" {
+			var scope *rubyBlock
+			if len(blocks) > 0 {
+				scope = &blocks[len(blocks)-1]
+			}
+			syms, block, at := p.matchDecl(trimmed, scope, symbols)
+			for _, sym := range syms {
+				sym.Line, sym.EndLine = i+1, i+1
+				sym.Signature = strings.TrimSpace(lines[i])
+				symbols = append(symbols, sym)
+			}
+			if block != nil {
+				block.symbol = -1
+				if len(syms) > 0 {
+					block.symbol = len(symbols) - 1
+				}
+				openAt, decl = at, *block
+			}
+		}
+
+		for _, ev := range rubyBlockEvents(trimmed) {
+			if ev.open {
+				b := rubyBlock{kind: "body", symbol: -1}
+				if ev.col == openAt {
+					b = decl
+				}
+				blocks = append(blocks, b)
+				continue
+			}
+			if len(blocks) == 0 {
+				continue
+			}
+			if b := blocks[len(blocks)-1]; b.symbol >= 0 {
+				symbols[b.symbol].EndLine = i + 1
+			}
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+	return symbols, nil
+}
+
+// matchDecl recognises a declaration on a masked line of a module or class
+// body, or at the top level. It returns the symbols declared and, if the
+// declaration opens a block, that block and the column of the keyword
+// opening it. Visibility changes are applied to scope and to symbols
+// already declared in it.
+func (p *RubyParser) matchDecl(trimmed string, scope *rubyBlock, symbols []Symbol) ([]Symbol, *rubyBlock, int) {
+	parent, private := "", false
+	if scope != nil {
+		parent, private = scope.name, scope.private
+	}
+
+	if m := rubyAccessRe.FindStringSubmatch(trimmed); m != nil {
+		if scope != nil {
+			scope.private = m[1] == "private" || m[1] == "protected"
+		}
+		return nil, nil, -1
+	}
+	if m := rubyHideRe.FindStringSubmatch(trimmed); m != nil {
+		for _, arg := range rubySymbolArgRe.FindAllStringSubmatch(m[1], -1) {
+			for k := len(symbols) - 1; k >= 0; k-- {
+				if symbols[k].Name == arg[1] && symbols[k].Parent == parent {
+					symbols[k].Exported = false
+					break
+				}
+			}
+		}
+		return nil, nil, -1
+	}
+
+	if rubySingletonRe.MatchString(trimmed) {
+		return nil, &rubyBlock{kind: "singleton", name: parent}, 0
+	}
+	if m := rubyConcernRe.FindStringSubmatchIndex(trimmed); m != nil && scope != nil {
+		// ActiveSupport::Concern: class_methods do ... end
+		return nil, &rubyBlock{kind: "singleton", name: parent}, m[2]
+	}
+	if m := rubyTypeRe.FindStringSubmatch(trimmed); m != nil {
+		symParent := parent
+		if m[2] != "" {
+			outer := strings.Split(strings.TrimSuffix(m[2], "::"), "::")
+			symParent = outer[len(outer)-1]
+		}
+		sym := Symbol{Name: m[3], Kind: m[1], Exported: true, Parent: symParent}
+		return []Symbol{sym}, &rubyBlock{kind: m[1], name: m[3]}, 0
+	}
+
+	if m := rubyDefRe.FindStringSubmatch(trimmed); m != nil {
+		prefix, receiver := strings.TrimSpace(m[1]), m[2]
+		kind := "func"
+		if scope != nil {
+			kind = "method"
+		}
+		exported := !private
+		switch {
+		case prefix == "private" || prefix == "protected" || prefix == "private_class_method":
+			exported = false
+		case receiver != "":
+			// A bare private does not apply to singleton methods.
+			exported = true
+		}
+		sym := Symbol{Name: m[3], Kind: kind, Exported: exported, Parent: parent}
+		if rubyEndlessRe.MatchString(trimmed[len(m[0]):]) {
+			return []Symbol{sym}, nil, -1
+		}
+		return []Symbol{sym}, &rubyBlock{kind: "body"}, len(m[1])
+	}
+
+	if m := rubyConstRe.FindStringSubmatch(trimmed); m != nil {
+		return []Symbol{{Name: m[1], Kind: "const", Exported: true, Parent: parent}}, nil, -1
+	}
+	if m := rubyAttrRe.FindStringSubmatch(trimmed); m != nil && scope != nil {
+		prefix := strings.TrimSpace(m[1])
+		exported := !private && prefix != "private" && prefix != "protected"
+		var syms []Symbol
+		for _, arg := range rubySymbolArgRe.FindAllStringSubmatch(m[2], -1) {
+			syms = append(syms, Symbol{Name: arg[1], Kind: "property", Exported: exported, Parent: parent})
+		}
+		return syms, nil, -1
+	}
+	return nil, nil, -1
+}
+
+// rubyBlockEvents returns the keywords on a masked, trimmed line that open
+// or close a block, in column order. Modifier forms such as "x if y" and
+// endless methods open nothing; the optional "do" of a while, until or for
+// loop is not a block of its own.
+func rubyBlockEvents(trimmed string) []rubyEvent {
+	var events []rubyEvent
+	leading := ""
+	if m := rubyOpenerRe.FindStringSubmatchIndex(trimmed); m != nil {
+		leading = trimmed[m[2]:m[3]]
+		endless := false
+		if leading == "def" {
+			if d := rubyDefRe.FindStringIndex(trimmed); d != nil {
+				endless = rubyEndlessRe.MatchString(trimmed[d[1]:])
+			}
+		}
+		if !endless {
+			events = append(events, rubyEvent{col: m[2], open: true})
+		}
+	}
+	for _, m := range rubyValueOpenRe.FindAllStringSubmatchIndex(trimmed, -1) {
+		events = append(events, rubyEvent{col: m[2], open: true})
+	}
+	loopDo := leading == "while" || leading == "until" || leading == "for"
+	for _, m := range rubyDoEndRe.FindAllStringIndex(trimmed, -1) {
+		before, after := byte(' '), byte(' ')
+		if m[0] > 0 {
+			before = trimmed[m[0]-1]
+		}
+		if m[1] < len(trimmed) {
+			after = trimmed[m[1]]
+		}
+		// Skip method calls (range.end), symbols (:end), hash keys (end:)
+		// and predicate names (end?).
+		if before == '.' || before == ':' || after == ':' || after == '?' || after == '!' {
+			continue
+		}
+		if trimmed[m[0]:m[1]] == "do" && loopDo {
+			loopDo = false
+			continue
+		}
+		events = append(events, rubyEvent{col: m[0], open: trimmed[m[0]:m[1]] == "do"})
+	}
+	sort.Slice(events, func(a, b int) bool { return events[a].col < events[b].col })
+	return events
+}
+
+// maskRuby returns lines with the contents of comments, string literals,
+// heredoc bodies and =begin/=end blocks replaced by spaces. Columns are
+// preserved.
+func maskRuby(lines []string) []string {
+	blank := func(s string) string { return strings.Repeat(" ", len(s)) }
+	out := make([]string, len(lines))
+	var heredocs []string // terminators of heredocs whose bodies follow
+	inDoc := false
+	for i, line := range lines {
+		switch {
+		case inDoc:
+			out[i] = blank(line)
+			inDoc = !strings.HasPrefix(line, "=end")
+			continue
+		case strings.HasPrefix(line, "=begin"):
+			out[i] = blank(line)
+			inDoc = true
+			continue
+		case len(heredocs) > 0:
+			out[i] = blank(line)
+			if strings.TrimSpace(line) == heredocs[0] {
+				heredocs = heredocs[1:]
+			}
+			continue
+		}
+
+		b := []byte(line)
+		for j := 0; j < len(b); j++ {
+			switch c := b[j]; c {
+			case '#':
+				for k := j; k < len(b); k++ {
+					b[k] = ' '
+				}
+			case '"', '\'', '`':
+				k := j + 1
+				for k < len(b) && b[k] != c {
+					if b[k] == '\\' && k+1 < len(b) {
+						b[k] = ' '
+						k++
+					}
+					b[k] = ' '
+					k++
+				}
+				j = k
+			}
+		}
+		// Heredoc openers are found in the original line, since a quoted
+		// terminator is masked, but only where the masked line has "<<".
+		for _, m := range rubyHeredocRe.FindAllStringSubmatchIndex(line, -1) {
+			if b[m[0]] == '<' && b[m[0]+1] == '<' {
+				heredocs = append(heredocs, line[m[4]:m[5]])
+			}
+		}
+		out[i] = string(b)
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package parsers
+
+import "testing"
+
+const sampleRubySource = `# frozen_string_literal: true
+
+require "json"
+
+module Billing
+  VERSION = "1.2.0"
+
+  # An invoice for a customer.
+  class Invoice < ApplicationRecord
+    include Comparable
+    attr_reader :number, :total
+    attr_accessor :notes
+
+    STATUSES = %w[draft sent paid].freeze
+
+    def self.build(attrs)
+      new(attrs)
+    end
+
+    def initialize(number, total)
+      @number = number
+      @total = total
+    end
+
+    def paid?
+      status == "paid" # not the end
+    end
+
+    def total_with_tax(rate = 0.2) = total * (1 + rate)
+
+    def each_line
+      lines.each do |line|
+        yield line if line.visible?
+      end
+    end
+
+    def ==(other)
+      number == other.number
+    end
+
+    def to_sql
+      <<~SQL
+        SELECT * FROM invoices
+        WHERE id = #{id}
+        end
+      SQL
+    end
+
+    protected
+
+    def compare_key
+      [number, total]
+    end
+
+    private
+
+    attr_writer :secret
+
+    def recalculate
+      result = if total > 0
+        total
+      else
+        0
+      end
+      result
+    end
+
+    def self.still_public
+    end
+
+    class << self
+      def from_json(json)
+        new(**JSON.parse(json))
+      end
+
+      private
+
+      def hidden_factory; end
+    end
+  end
+
+  class Report::Monthly
+    def generate
+      while running do
+        step
+      end
+    end
+
+    def helper; end
+    private :helper
+  end
+end
+
+=begin
+def not_a_method
+end
+=end
+
+def top_level(a, b)
+  a + b
+end
+`
+
+func TestRubyParserBasic(t *testing.T) {
+	p := &RubyParser{}
+	symbols, err := p.Parse("invoice.rb", []byte(sampleRubySource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	// Types and constants
+	assertSymbol(t, byName, "Billing", "module", true, "")
+	assertSymbol(t, byName, "VERSION", "const", true, "Billing")
+	assertSymbol(t, byName, "Invoice", "class", true, "Billing")
+	assertSymbol(t, byName, "STATUSES", "const", true, "Invoice")
+	assertSymbol(t, byName, "Monthly", "class", true, "Report")
+
+	// Attributes
+	assertSymbol(t, byName, "number", "property", true, "Invoice")
+	assertSymbol(t, byName, "total", "property", true, "Invoice")
+	assertSymbol(t, byName, "notes", "property", true, "Invoice")
+	assertSymbol(t, byName, "secret", "property", false, "Invoice")
+
+	// Methods
+	assertSymbol(t, byName, "build", "method", true, "Invoice")
+	assertSymbol(t, byName, "initialize", "method", true, "Invoice")
+	assertSymbol(t, byName, "paid?", "method", true, "Invoice")
+	assertSymbol(t, byName, "total_with_tax", "method", true, "Invoice")
+	assertSymbol(t, byName, "each_line", "method", true, "Invoice")
+	assertSymbol(t, byName, "==", "method", true, "Invoice")
+	assertSymbol(t, byName, "to_sql", "method", true, "Invoice")
+	assertSymbol(t, byName, "compare_key", "method", false, "Invoice")
+	assertSymbol(t, byName, "recalculate", "method", false, "Invoice")
+	assertSymbol(t, byName, "still_public", "method", true, "Invoice")
+	assertSymbol(t, byName, "from_json", "method", true, "Invoice")
+	assertSymbol(t, byName, "hidden_factory", "method", false, "Invoice")
+	assertSymbol(t, byName, "generate", "method", true, "Monthly")
+	assertSymbol(t, byName, "helper", "method", false, "Monthly")
+	assertSymbol(t, byName, "top_level", "func", true, "")
+
+	// Locals, block parameters, heredoc and =begin contents are not symbols.
+	for _, name := range []string{"result", "line", "not_a_method", "Comparable", "SELECT"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not appear as a symbol", name)
+		}
+	}
+}
+
+func TestRubyParserSignaturesAndLines(t *testing.T) {
+	p := &RubyParser{}
+	symbols, err := p.Parse("invoice.rb", []byte(sampleRubySource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"Billing", 5, 92, "module Billing"},
+		{"Invoice", 9, 80, "class Invoice < ApplicationRecord"},
+		{"build", 16, 18, "def self.build(attrs)"},
+		{"paid?", 25, 27, "def paid?"},
+		{"total_with_tax", 29, 29, "def total_with_tax(rate = 0.2) = total * (1 + rate)"},
+		{"each_line", 31, 35, "def each_line"},
+		{"to_sql", 41, 47, "def to_sql"},
+		{"recalculate", 59, 66, "def recalculate"},
+		{"hidden_factory", 78, 78, "def hidden_factory; end"},
+		{"Monthly", 82, 91, "class Report::Monthly"},
+		{"generate", 83, 87, "def generate"},
+		{"top_level", 99, 101, "def top_level(a, b)"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
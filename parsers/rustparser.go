@@ -0,0 +1,334 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(&RustParser{})
+}
+
+// RustParser extracts symbols from Rust source files. Comments and string
+// literals are blanked out first so braces inside them do not affect the
+// brace-depth tracking used to find items, impl blocks and end lines.
+type RustParser struct{}
+
+func (p *RustParser) Extensions() []string {
+	return []string{".rs"}
+}
+
+var (
+	// Visibility and qualifiers that may precede any item.
+	rustPrefix = `^(pub(?:\s*\([^)]*\))?\s+)?(?:(?:default|const|async|unsafe|extern(?:\s+"[^"]*")?)\s+)*`
+
+	rustFnRe     = regexp.MustCompile(rustPrefix + `fn\s+(\w+)`)
+	rustStructRe = regexp.MustCompile(rustPrefix + `(struct|union)\s+(\w+)`)
+	rustEnumRe   = regexp.MustCompile(rustPrefix + `enum\s+(\w+)`)
+	rustTraitRe  = regexp.MustCompile(rustPrefix + `(?:auto\s+)?trait\s+(\w+)`)
+	rustTypeRe   = regexp.MustCompile(rustPrefix + `type\s+(\w+)`)
+	rustConstRe  = regexp.MustCompile(rustPrefix + `const\s+([A-Za-z]\w*)\s*:`)
+	rustStaticRe = regexp.MustCompile(rustPrefix + `static\s+(?:mut\s+)?(\w+)`)
+	rustModRe    = regexp.MustCompile(rustPrefix + `mod\s+(\w+)`)
+	rustMacroRe  = regexp.MustCompile(`^macro_rules!\s*(\w+)`)
+	rustImplRe   = regexp.MustCompile(`^(?:unsafe\s+)?impl\b`)
+	rustExternRe = regexp.MustCompile(`^(?:unsafe\s+)?extern(?:\s+"[^"]*")?\s*\{`)
+)
+
+// rustScope is an item container (module, impl, trait or extern block)
+// whose body holds further items.
+type rustScope struct {
+	kind     string // "mod", "impl", "trait" or "extern"
+	name     string // impl type or trait name; empty for modules
+	exported bool   // trait items are public when the trait is
+	depth    int    // brace depth of the items inside the block
+	open     int    // line of the '{' opening the body
+}
+
+func (p *RustParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	masked := strings.Split(string(maskRust(content)), "\n")
+	var symbols []Symbol
+
+	var scopes []rustScope
+	depth := 0
+	headerEnd := -1      // last line of the item header being consumed
+	macroExport := false // a #[macro_export] attribute precedes the next item
+
+	for i := 0; i < len(masked); i++ {
+		trimmed := strings.TrimSpace(masked[i])
+		itemDepth := 0
+		if len(scopes) > 0 {
+			itemDepth = scopes[len(scopes)-1].depth
+		}
+
+		if i > headerEnd && depth == itemDepth && trimmed != "" {
+			if strings.HasPrefix(trimmed, "#[macro_export]") {
+				macroExport = true
+			}
+			if !strings.HasPrefix(trimmed, "#") {
+				var scope *rustScope
+				if len(scopes) > 0 {
+					scope = &scopes[len(scopes)-1]
+				}
+				sym, container, ok := p.matchItem(trimmed, scope, macroExport)
+				macroExport = false
+				if ok || container != nil {
+					endLine, endCol, body := findHeaderEnd(masked, i, 0, sym.Kind == "const" || sym.Kind == "static")
+					declEnd := findDeclEnd(masked, endLine, endCol, body)
+					headerEnd = endLine
+					if !body {
+						headerEnd = declEnd
+					}
+					if container != nil && container.kind == "impl" && endLine > i {
+						// The self type may be on a later line of the header.
+						container.name, container.exported = rustImplTarget(strings.Join(masked[i:endLine+1], " "))
+					}
+					if ok {
+						sym.Line = i + 1
+						sym.Signature = joinSignature(lines, masked, i, 0, endLine, endCol)
+						sym.EndLine = declEnd + 1
+						// Trait methods without a default body are required.
+						sym.Abstract = sym.Kind == "method" && !body && scope != nil && scope.kind == "trait"
+						symbols = append(symbols, sym)
+					}
+					if container != nil && body {
+						container.depth, container.open = depth+1, endLine
+						scopes = append(scopes, *container)
+					}
+				}
+			}
+		}
+
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+		for len(scopes) > 0 && depth < scopes[len(scopes)-1].depth && i >= scopes[len(scopes)-1].open {
+			scopes = scopes[:len(scopes)-1]
+		}
+	}
+
+	return symbols, nil
+}
+
+// matchItem recognises an item declaration at the start of a masked line.
+// Items that hold further items (modules, impls, traits, extern blocks) are
+// also returned as a scope to enter if they have a body.
+func (p *RustParser) matchItem(trimmed string, scope *rustScope, macroExport bool) (Symbol, *rustScope, bool) {
+	inImpl := scope != nil && (scope.kind == "impl" || scope.kind == "trait")
+	parent := ""
+	if inImpl {
+		parent = scope.name
+	}
+	exported := func(vis string) bool {
+		if scope != nil && scope.kind == "trait" {
+			return scope.exported
+		}
+		if scope != nil && scope.kind == "impl" && scope.exported {
+			return true // trait implementation: public through the trait
+		}
+		return strings.TrimSpace(vis) == "pub"
+	}
+
+	if m := rustFnRe.FindStringSubmatch(trimmed); m != nil {
+		kind := "func"
+		if inImpl {
+			kind = "method"
+		}
+		return Symbol{Name: m[2], Kind: kind, Exported: exported(m[1]), Parent: parent}, nil, true
+	}
+	if rustImplRe.MatchString(trimmed) {
+		typ, trait := rustImplTarget(trimmed)
+		return Symbol{}, &rustScope{kind: "impl", name: typ, exported: trait}, false
+	}
+	if rustExternRe.MatchString(trimmed) {
+		return Symbol{}, &rustScope{kind: "extern"}, false
+	}
+	if m := rustStructRe.FindStringSubmatch(trimmed); m != nil {
+		return Symbol{Name: m[3], Kind: "struct", Exported: exported(m[1]), Parent: parent}, nil, true
+	}
+	if m := rustEnumRe.FindStringSubmatch(trimmed); m != nil {
+		return Symbol{Name: m[2], Kind: "enum", Exported: exported(m[1]), Parent: parent}, nil, true
+	}
+	if m := rustTraitRe.FindStringSubmatch(trimmed); m != nil {
+		sym := Symbol{Name: m[2], Kind: "trait", Exported: exported(m[1]), Parent: parent}
+		return sym, &rustScope{kind: "trait", name: m[2], exported: sym.Exported}, true
+	}
+	if m := rustTypeRe.FindStringSubmatch(trimmed); m != nil {
+		return Symbol{Name: m[2], Kind: "type", Exported: exported(m[1]), Parent: parent}, nil, true
+	}
+	if m := rustConstRe.FindStringSubmatch(trimmed); m != nil {
+		return Symbol{Name: m[2], Kind: "const", Exported: exported(m[1]), Parent: parent}, nil, true
+	}
+	if m := rustStaticRe.FindStringSubmatch(trimmed); m != nil {
+		return Symbol{Name: m[2], Kind: "static", Exported: exported(m[1]), Parent: parent}, nil, true
+	}
+	if inImpl {
+		return Symbol{}, nil, false
+	}
+	if m := rustModRe.FindStringSubmatch(trimmed); m != nil {
+		sym := Symbol{Name: m[2], Kind: "module", Exported: exported(m[1])}
+		return sym, &rustScope{kind: "mod"}, true
+	}
+	if m := rustMacroRe.FindStringSubmatch(trimmed); m != nil {
+		return Symbol{Name: m[1], Kind: "macro", Exported: macroExport}, nil, true
+	}
+	return Symbol{}, nil, false
+}
+
+// rustImplTarget returns the self type named by an impl header, reduced to
+// its last path segment without generics or references, and whether the
+// impl is a trait implementation.
+func rustImplTarget(header string) (string, bool) {
+	header = strings.TrimSpace(header)
+	header = strings.TrimPrefix(header, "unsafe ")
+	header = strings.TrimSpace(strings.TrimPrefix(header, "impl"))
+	if strings.HasPrefix(header, "<") {
+		header = header[matchingAngle(header)+1:]
+	}
+	if i := strings.IndexAny(header, "{"); i >= 0 {
+		header = header[:i]
+	}
+	if i := strings.Index(header, " where "); i >= 0 {
+		header = header[:i]
+	}
+	header = strings.TrimSuffix(strings.TrimSpace(header), "where")
+	trait := false
+	if i := strings.Index(header, " for "); i >= 0 {
+		header = header[i+len(" for "):]
+		trait = true
+	}
+	header = strings.TrimLeft(strings.TrimSpace(header), "&*")
+	if strings.HasPrefix(header, "'") { // &'a T
+		if i := strings.IndexByte(header, ' '); i >= 0 {
+			header = strings.TrimSpace(header[i:])
+		}
+	}
+	header = strings.TrimPrefix(header, "mut ")
+	header = strings.TrimPrefix(header, "dyn ")
+	if i := strings.IndexByte(header, '<'); i >= 0 {
+		header = header[:i]
+	}
+	if i := strings.LastIndex(header, "::"); i >= 0 {
+		header = header[i+2:]
+	}
+	return strings.TrimSpace(header), trait
+}
+
+// matchingAngle returns the index of the '>' closing the '<' at s[0].
+func matchingAngle(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			depth++
+		case '>':
+			if i > 0 && s[i-1] == '-' {
+				continue // "->" in a bound such as Fn() -> T
+			}
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s) - 1
+}
+
+// maskRust returns a copy of src with the contents of comments, string
+// literals and character literals replaced by spaces. Newlines and byte
+// offsets are preserved, so lines and columns in the result match src.
+func maskRust(src []byte) []byte {
+	out := make([]byte, len(src))
+	copy(out, src)
+	blank := func(from, to int) {
+		for k := from; k < to && k < len(out); k++ {
+			if out[k] != '\n' {
+				out[k] = ' '
+			}
+		}
+	}
+	isIdent := func(c byte) bool {
+		return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+
+	n := len(src)
+	for i := 0; i < n; {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < n && src[i+1] == '/':
+			end := i
+			for end < n && src[end] != '\n' {
+				end++
+			}
+			blank(i+2, end) // keep the "//" so signatures can drop trailing comments
+			i = end
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			depth, j := 1, i+2
+			for j < n && depth > 0 {
+				switch {
+				case src[j] == '/' && j+1 < n && src[j+1] == '*':
+					depth++
+					j += 2
+				case src[j] == '*' && j+1 < n && src[j+1] == '/':
+					depth--
+					j += 2
+				default:
+					j++
+				}
+			}
+			blank(i, j)
+			i = j
+		case c == 'r' && i+1 < n && (src[i+1] == '"' || src[i+1] == '#') &&
+			(i == 0 || !isIdent(src[i-1]) || (src[i-1] == 'b' && (i < 2 || !isIdent(src[i-2])))):
+			hashes := 0
+			j := i + 1
+			for j < n && src[j] == '#' {
+				hashes++
+				j++
+			}
+			if j >= n || src[j] != '"' {
+				i++ // r#ident raw identifier
+				continue
+			}
+			closing := "\"" + strings.Repeat("#", hashes)
+			end := strings.Index(string(src[j+1:]), closing)
+			if end < 0 {
+				blank(j+1, n)
+				return out
+			}
+			blank(j+1, j+1+end)
+			i = j + 1 + end + len(closing)
+		case c == '"':
+			j := i + 1
+			for j < n && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			blank(i+1, j)
+			i = j + 1
+		case c == '\'':
+			// A character literal, or else a lifetime or label.
+			if i+1 < n && src[i+1] == '\\' {
+				j := i + 2
+				for j < n && src[j] != '\'' && src[j] != '\n' {
+					j++
+				}
+				blank(i+1, j)
+				i = j + 1
+				continue
+			}
+			_, size := utf8.DecodeRune(src[i+1:])
+			if i+1+size < n && src[i+1+size] == '\'' {
+				blank(i+1, i+1+size)
+				i += size + 2
+				continue
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	return out
+}
@@ -0,0 +1,267 @@
+package parsers
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleRustSource = `//! Sample crate.
+use std::fmt;
+
+/// Maximum number of retries.
+pub const MAX_RETRIES: u32 = 3;
+const TIMEOUT_MS: u64 = 500;
+pub static GREETING: &str = "hello { world";
+static mut COUNTER: u32 = 0;
+
+pub type Result<T> = std::result::Result<T, Error>;
+
+/// A user of the system.
+#[derive(Debug, Clone)]
+pub struct User {
+    pub name: String,
+    age: u32,
+}
+
+struct Point(i32, i32);
+
+pub(crate) struct Internal;
+
+pub enum Status {
+    Active,
+    Inactive { reason: String },
+}
+
+pub trait Greeter {
+    const PREFIX: &'static str;
+    fn greet(&self) -> String;
+    fn shout(&self) -> String {
+        self.greet().to_uppercase()
+    }
+}
+
+impl User {
+    pub fn new(name: &str, age: u32) -> Self {
+        let brace = '{';
+        User { name: name.to_string(), age }
+    }
+
+    fn secret(&self) -> u32 {
+        // a comment with a } brace
+        self.age
+    }
+
+    pub async fn load(
+        id: u64,
+        db: &Db,
+    ) -> Option<User>
+    where
+        Db: Send,
+    {
+        None
+    }
+}
+
+impl<'a, T: fmt::Display> fmt::Display for Wrapper<'a, T> {
+    fn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result {
+        write!(f, "{}", r#"raw } string"#)
+    }
+}
+
+pub fn helper(x: i32) -> i32 {
+    fn nested() {}
+    x + 1
+}
+
+pub(crate) fn crate_only() {}
+
+pub mod api {
+    pub fn handler() {}
+    fn private_handler() {}
+}
+
+mod tests;
+
+#[macro_export]
+macro_rules! my_vec {
+    ($($x:expr),*) => { vec![$($x),*] };
+}
+
+macro_rules! local_macro {
+    () => {};
+}
+
+extern "C" {
+    fn c_function(x: i32) -> i32;
+}
+`
+
+func TestRustParserBasic(t *testing.T) {
+	p := &RustParser{}
+	symbols, err := p.Parse("lib.rs", []byte(sampleRustSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	byName := symbolsByName(symbols)
+
+	// Consts, statics and type aliases
+	assertSymbol(t, byName, "MAX_RETRIES", "const", true, "")
+	assertSymbol(t, byName, "TIMEOUT_MS", "const", false, "")
+	assertSymbol(t, byName, "GREETING", "static", true, "")
+	assertSymbol(t, byName, "COUNTER", "static", false, "")
+	assertSymbol(t, byName, "Result", "type", true, "")
+
+	// Types
+	assertSymbol(t, byName, "User", "struct", true, "")
+	assertSymbol(t, byName, "Point", "struct", false, "")
+	assertSymbol(t, byName, "Internal", "struct", false, "")
+	assertSymbol(t, byName, "Status", "enum", true, "")
+	assertSymbol(t, byName, "Greeter", "trait", true, "")
+
+	// Trait items inherit the trait's visibility.
+	assertSymbol(t, byName, "PREFIX", "const", true, "Greeter")
+	assertSymbol(t, byName, "greet", "method", true, "Greeter")
+	assertSymbol(t, byName, "shout", "method", true, "Greeter")
+
+	// Inherent impl methods need pub; trait impl methods are public.
+	assertSymbol(t, byName, "new", "method", true, "User")
+	assertSymbol(t, byName, "secret", "method", false, "User")
+	assertSymbol(t, byName, "load", "method", true, "User")
+	assertSymbol(t, byName, "fmt", "method", true, "Wrapper")
+
+	// Functions
+	assertSymbol(t, byName, "helper", "func", true, "")
+	assertSymbol(t, byName, "crate_only", "func", false, "")
+	assertSymbol(t, byName, "c_function", "func", false, "")
+	if _, ok := byName["nested"]; ok {
+		t.Error("nested fn inside a function body should not appear")
+	}
+
+	// Modules and their items
+	assertSymbol(t, byName, "api", "module", true, "")
+	assertSymbol(t, byName, "handler", "func", true, "")
+	assertSymbol(t, byName, "private_handler", "func", false, "")
+	assertSymbol(t, byName, "tests", "module", false, "")
+
+	// Macros
+	assertSymbol(t, byName, "my_vec", "macro", true, "")
+	assertSymbol(t, byName, "local_macro", "macro", false, "")
+
+	// Struct fields and enum variants are not items.
+	for _, name := range []string{"name", "age", "Active", "Inactive"} {
+		if _, ok := byName[name]; ok {
+			t.Errorf("%q should not appear as a symbol", name)
+		}
+	}
+}
+
+func TestRustParserSignaturesAndLines(t *testing.T) {
+	p := &RustParser{}
+	symbols, err := p.Parse("lib.rs", []byte(sampleRustSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	byName := symbolsByName(symbols)
+
+	tests := []struct {
+		name      string
+		line      int
+		endLine   int
+		signature string
+	}{
+		{"MAX_RETRIES", 5, 5, "pub const MAX_RETRIES: u32"},
+		{"GREETING", 7, 7, "pub static GREETING: &str"},
+		{"Result", 10, 10, "pub type Result<T> = std::result::Result<T, Error>;"},
+		{"User", 14, 17, "pub struct User"},
+		{"Point", 19, 19, "struct Point(i32, i32);"},
+		{"Status", 23, 26, "pub enum Status"},
+		{"greet", 30, 30, "fn greet(&self) -> String;"},
+		{"shout", 31, 33, "fn shout(&self) -> String"},
+		{"new", 37, 40, "pub fn new(name: &str, age: u32) -> Self"},
+		{"secret", 42, 45, "fn secret(&self) -> u32"},
+		{"load", 47, 55, "pub async fn load( id: u64, db: &Db, ) -> Option<User> where Db: Send,"},
+		{"fmt", 59, 61, "fn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result"},
+		{"helper", 64, 67, "pub fn helper(x: i32) -> i32"},
+		{"api", 71, 74, "pub mod api"},
+		{"tests", 76, 76, "mod tests;"},
+		{"my_vec", 79, 81, "macro_rules! my_vec"},
+	}
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}
+
+func TestRustImplTarget(t *testing.T) {
+	tests := []struct {
+		header string
+		typ    string
+		trait  bool
+	}{
+		{"impl User {", "User", false},
+		{"impl<T> Stack<T> {", "Stack", false},
+		{"impl<'a, T: Into<String>> From<T> for Name<'a> {", "Name", true},
+		{"unsafe impl Send for crate::pool::Pool {}", "Pool", true},
+		{"impl<'a> Iterator for &'a mut Items where T: Clone {", "Items", true},
+		{"impl dyn Shape {", "Shape", false},
+	}
+	for _, tt := range tests {
+		typ, trait := rustImplTarget(tt.header)
+		if typ != tt.typ || trait != tt.trait {
+			t.Errorf("rustImplTarget(%q) = %q, %v; want %q, %v", tt.header, typ, trait, tt.typ, tt.trait)
+		}
+	}
+}
+
+func TestMaskRust(t *testing.T) {
+	src := "let s = \"a { b\"; // } c\nlet r = r#\"x \" }\"#; let c = '}'; let l: &'a str = b'{';\n/* { /* } */ } */ fn f() {}"
+	got := string(maskRust([]byte(src)))
+	if len(got) != len(src) {
+		t.Fatalf("masked length %d, want %d", len(got), len(src))
+	}
+	opens, closes := 0, 0
+	for _, c := range got {
+		switch c {
+		case '{':
+			opens++
+		case '}':
+			closes++
+		}
+	}
+	if opens != 1 || closes != 1 {
+		t.Errorf("masked text keeps %d '{' and %d '}', want only fn f's pair:\n%s", opens, closes, got)
+	}
+	if !strings.Contains(got, "let l: &'a str") {
+		t.Errorf("lifetime was masked:\n%s", got)
+	}
+}
+
+func TestRustParserAbstractMembers(t *testing.T) {
+	src := `pub trait Shape {
+    fn area(&self) -> f64;
+    fn describe(&self) -> String {
+        String::from("shape")
+    }
+}
+
+extern "C" {
+    fn abs(x: i32) -> i32;
+}
+`
+	p := &RustParser{}
+	symbols, err := p.Parse("shape.rs", []byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	assertAbstract(t, symbols, map[string]bool{"area": true, "describe": false, "abs": false})
+}
@@ -0,0 +1,209 @@
+package parsers
+
+import "strings"
+
+// The helpers below work on "masked" source lines: copies of the file in
+// which comments and literals have been blanked out, so that brackets and
+// terminators can be counted without tokenizing. Lines and columns in the
+// masked copy match the original.
+
+// findHeaderEnd finds where the declaration header starting at (line, col)
+// ends: the '{' opening its body or the ';' ending it, outside parentheses
+// and brackets. With stopAtEquals the header ends at an initializer's '='
+// instead. It returns the line and column of that character and whether it
+// opens a body.
+func findHeaderEnd(masked []string, line, col int, stopAtEquals bool) (endLine, endCol int, body bool) {
+	nesting := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch c := masked[i][j]; c {
+			case '(', '[':
+				nesting++
+			case ')', ']':
+				nesting--
+			case '=':
+				if stopAtEquals && nesting == 0 && isAssignment(masked[i], j) {
+					return i, j, false
+				}
+			case '{', ';':
+				if nesting == 0 {
+					return i, j, c == '{'
+				}
+			}
+		}
+	}
+	return len(masked) - 1, len(masked[len(masked)-1]), false
+}
+
+// findDeclEnd returns the last line of a declaration whose header ends at
+// (line, col) as reported by findHeaderEnd: the end of its body, the ';'
+// after its initializer, or the header's own last line.
+func findDeclEnd(masked []string, line, col int, body bool) int {
+	switch {
+	case body:
+		return findBlockEnd(masked, line, col)
+	case col < len(masked[line]) && masked[line][col] == '=':
+		return findStatementEnd(masked, line, col)
+	}
+	return line
+}
+
+// isAssignment reports whether the '=' at s[j] is a plain assignment rather
+// than part of a comparison or arrow operator.
+func isAssignment(s string, j int) bool {
+	if j+1 < len(s) && (s[j+1] == '=' || s[j+1] == '>') {
+		return false
+	}
+	return j == 0 || !strings.ContainsRune("<>!=", rune(s[j-1]))
+}
+
+// findStatementEnd returns the line of the ';' ending the statement that
+// continues at (line, col), skipping over any nested brackets or braces. An
+// unmatched '}' closing the enclosing block also ends the statement.
+func findStatementEnd(masked []string, line, col int) int {
+	nesting := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch masked[i][j] {
+			case '(', '[', '{':
+				nesting++
+			case ')', ']':
+				nesting--
+			case '}':
+				nesting--
+				if nesting < 0 {
+					return i
+				}
+			case ';':
+				if nesting <= 0 {
+					return i
+				}
+			}
+		}
+	}
+	return len(masked) - 1
+}
+
+// findBlockEnd returns the line of the '}' closing the '{' at (line, col).
+func findBlockEnd(masked []string, line, col int) int {
+	depth := 0
+	for i := line; i < len(masked); i++ {
+		from := 0
+		if i == line {
+			from = col
+		}
+		for j := from; j < len(masked[i]); j++ {
+			switch masked[i][j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					return i
+				}
+			}
+		}
+	}
+	return len(masked) - 1
+}
+
+// joinSignature joins the original text of a header from (line, col) up to
+// (endLine, endCol), collapsing whitespace. A ';' terminator is kept.
+func joinSignature(lines, masked []string, line, col, endLine, endCol int) string {
+	var parts []string
+	for i := line; i <= endLine && i < len(lines); i++ {
+		text := lines[i]
+		if i == endLine {
+			cut := endCol
+			if cut < len(masked[i]) && masked[i][cut] == ';' {
+				cut++
+			}
+			text = text[:min(cut, len(text))]
+		}
+		// Drop trailing line comments, which the masked copy shows as blanks.
+		if k := strings.Index(masked[i], "//"); k >= 0 && k < len(text) {
+			text = text[:k]
+		}
+		if i == line {
+			text = text[min(col, len(text)):]
+		}
+		parts = append(parts, strings.TrimSpace(text))
+	}
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+// maskCLike returns a copy of src with the contents of comments, string
+// literals (including """ text blocks) and character literals replaced by
+// spaces, for languages with C-style comments and quoting. nestedComments
+// makes block comments nest, as they do in Kotlin. Newlines and byte offsets
+// are preserved, so lines and columns in the result match src.
+func maskCLike(src []byte, nestedComments bool) []byte {
+	out := make([]byte, len(src))
+	copy(out, src)
+	blank := func(from, to int) {
+		for k := from; k < to && k < len(out); k++ {
+			if out[k] != '\n' {
+				out[k] = ' '
+			}
+		}
+	}
+
+	n := len(src)
+	for i := 0; i < n; {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < n && src[i+1] == '/':
+			end := i
+			for end < n && src[end] != '\n' {
+				end++
+			}
+			blank(i+2, end) // keep the "//" so signatures can drop trailing comments
+			i = end
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			depth, j := 1, i+2
+			for j < n && depth > 0 {
+				switch {
+				case nestedComments && src[j] == '/' && j+1 < n && src[j+1] == '*':
+					depth++
+					j += 2
+				case src[j] == '*' && j+1 < n && src[j+1] == '/':
+					depth--
+					j += 2
+				default:
+					j++
+				}
+			}
+			blank(i, j)
+			i = j
+		case c == '"' && strings.HasPrefix(string(src[i:min(i+3, n)]), `"""`):
+			end := strings.Index(string(src[i+3:]), `"""`)
+			if end < 0 {
+				blank(i+3, n)
+				return out
+			}
+			blank(i+3, i+3+end)
+			i += 3 + end + 3
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < n && src[j] != c && src[j] != '\n' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			blank(i+1, j)
+			i = j + 1
+		default:
+			i++
+		}
+	}
+	return out
+}
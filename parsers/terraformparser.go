@@ -0,0 +1,233 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register(&TerraformParser{})
+}
+
+// TerraformParser extracts the top-level blocks of Terraform configuration:
+// resources, data sources, modules, variables, outputs and providers, plus
+// the values declared in locals blocks. Symbols are named the way the
+// configuration refers to them (aws_instance.web, data.aws_ami.ubuntu,
+// module.vpc, var.region, local.tags), so refs finds their uses; outputs
+// and providers keep their plain names. Variables and outputs form a
+// module's interface and are exported.
+type TerraformParser struct{}
+
+func (p *TerraformParser) Extensions() []string {
+	return []string{".tf"}
+}
+
+var (
+	tfBlockRe = regexp.MustCompile(`^\s*(resource|data|module|variable|output|provider|locals)\b`)
+	tfLabelRe = regexp.MustCompile(`"([^"]*)"|([\w-]+)`)
+	tfAttrRe  = regexp.MustCompile(`^\s*([\w-]+)\s*=`)
+)
+
+func (p *TerraformParser) Parse(filePath string, content []byte) ([]Symbol, error) {
+	lines := strings.Split(string(content), "\n")
+	masked := strings.Split(string(maskHCL(content)), "\n")
+	var symbols []Symbol
+
+	depth := 0
+	for i := 0; i < len(masked); i++ {
+		if depth == 0 {
+			m := tfBlockRe.FindStringSubmatch(masked[i])
+			brace := strings.IndexByte(masked[i], '{')
+			if m != nil && brace >= 0 {
+				end := findBlockEnd(masked, i, brace)
+				kw := m[1]
+				var labels []string
+				for _, l := range tfLabelRe.FindAllStringSubmatch(lines[i][len(m[0]):brace], -1) {
+					labels = append(labels, l[1]+l[2])
+				}
+				if kw == "locals" {
+					symbols = append(symbols, tfLocals(lines, masked, i, end)...)
+				} else if name, ok := tfAddress(kw, labels); ok {
+					symbols = append(symbols, Symbol{
+						Name:      name,
+						Kind:      kw,
+						Line:      i + 1,
+						EndLine:   end + 1,
+						Exported:  kw == "variable" || kw == "output",
+						Signature: strings.TrimSpace(lines[i][:brace]),
+					})
+				}
+				i = end
+				continue
+			}
+		}
+		depth += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+	}
+	return symbols, nil
+}
+
+// tfAddress returns the name a block of the given type and labels is
+// recorded under, or false if the block lacks labels.
+func tfAddress(kw string, labels []string) (string, bool) {
+	switch kw {
+	case "resource", "data":
+		if len(labels) < 2 {
+			return "", false
+		}
+		name := labels[0] + "." + labels[1]
+		if kw == "data" {
+			name = "data." + name
+		}
+		return name, true
+	case "module", "variable":
+		if len(labels) < 1 {
+			return "", false
+		}
+		if kw == "variable" {
+			return "var." + labels[0], true
+		}
+		return "module." + labels[0], true
+	}
+	if len(labels) < 1 {
+		return "", false
+	}
+	return labels[0], true
+}
+
+// tfLocals returns the values declared in the locals block opening on line
+// open and closing on line end.
+func tfLocals(lines, masked []string, open, end int) []Symbol {
+	var locals []Symbol
+	nesting := 0 // brackets opened by values spanning several lines
+	for i := open + 1; i < end; i++ {
+		if m := tfAttrRe.FindStringSubmatch(masked[i]); m != nil && nesting == 0 {
+			last := i
+			for n := 0; last < end; last++ {
+				n += strings.Count(masked[last], "{") + strings.Count(masked[last], "[") + strings.Count(masked[last], "(")
+				n -= strings.Count(masked[last], "}") + strings.Count(masked[last], "]") + strings.Count(masked[last], ")")
+				if n <= 0 {
+					break
+				}
+			}
+			locals = append(locals, Symbol{
+				Name:      "local." + m[1],
+				Kind:      "local",
+				Line:      i + 1,
+				EndLine:   last + 1,
+				Signature: strings.TrimSpace(lines[i][:len(strings.TrimRight(masked[i], " \t"))]),
+			})
+			i = last
+			continue
+		}
+		nesting += strings.Count(masked[i], "{") - strings.Count(masked[i], "}")
+	}
+	return locals
+}
+
+var hclHeredocRe = regexp.MustCompile(`^<<-?([A-Za-z_]\w*)`)
+
+// maskHCL returns a copy of src with comments, the contents of strings
+// (including ${...} interpolations) and heredocs replaced by spaces. String
+// quotes are kept. Newlines and byte offsets are preserved.
+func maskHCL(src []byte) []byte {
+	out := make([]byte, len(src))
+	copy(out, src)
+	blank := func(from, to int) {
+		for k := from; k < to && k < len(out); k++ {
+			if out[k] != '\n' {
+				out[k] = ' '
+			}
+		}
+	}
+	lineEnd := func(i int) int {
+		for i < len(src) && src[i] != '\n' {
+			i++
+		}
+		return i
+	}
+
+	n := len(src)
+	inString := false
+	var interp []int // brace depth inside each open ${...}
+	for i := 0; i < n; {
+		c := src[i]
+		next := byte(0)
+		if i+1 < n {
+			next = src[i+1]
+		}
+		if inString {
+			switch {
+			case c == '\\':
+				blank(i, i+2)
+				i += 2
+			case c == '"' || c == '\n':
+				inString = false
+				i++
+			case (c == '$' || c == '%') && next == '{':
+				interp = append(interp, 0)
+				inString = false
+				blank(i, i+2)
+				i += 2
+			default:
+				blank(i, i+1)
+				i++
+			}
+			continue
+		}
+		switch {
+		case c == '#' || c == '/' && next == '/':
+			end := lineEnd(i)
+			blank(i, end)
+			i = end
+		case c == '/' && next == '*':
+			end := strings.Index(string(src[i+2:]), "*/")
+			if end < 0 {
+				end = n
+			} else {
+				end += i + 4
+			}
+			blank(i, end)
+			i = end
+		case c == '"':
+			inString = true
+			if len(interp) > 0 {
+				blank(i, i+1)
+			}
+			i++
+		case c == '<' && hclHeredocRe.Match(src[i:]):
+			marker := hclHeredocRe.FindSubmatch(src[i:])[1]
+			j := lineEnd(i)
+			for j < n {
+				end := lineEnd(j + 1)
+				if strings.TrimSpace(string(src[j+1:end])) == string(marker) {
+					blank(i, end)
+					i = end
+					break
+				}
+				j = end
+			}
+			if j >= n {
+				blank(i, n)
+				i = n
+			}
+		case len(interp) > 0:
+			top := len(interp) - 1
+			switch c {
+			case '{':
+				interp[top]++
+			case '}':
+				if interp[top] == 0 {
+					interp = interp[:top]
+					inString = true
+				} else {
+					interp[top]--
+				}
+			}
+			blank(i, i+1)
+			i++
+		default:
+			i++
+		}
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package parsers
+
+import "testing"
+
+const sampleTerraformSource = `# Networking for the app.
+terraform {
+  required_version = ">= 1.5"
+}
+
+provider "aws" {
+  region = var.region
+}
+
+variable "region" {
+  type    = string
+  default = "us-east-1" # { not a block
+}
+
+locals {
+  name = "app-${var.env}"
+  tags = {
+    Team = "platform"
+  }
+}
+
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  user_data     = <<-EOT
+    #!/bin/bash
+    echo "}"
+  EOT
+  tags = local.tags
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+module "vpc" {
+  source = "./modules/vpc"
+}
+
+output "instance_ip" {
+  value = aws_instance.web.public_ip
+}
+`
+
+func TestTerraformParser(t *testing.T) {
+	symbols, err := (&TerraformParser{}).Parse("main.tf", []byte(sampleTerraformSource))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	tests := []struct {
+		name      string
+		kind      string
+		line      int
+		endLine   int
+		exported  bool
+		signature string
+	}{
+		{"aws", "provider", 6, 8, false, `provider "aws"`},
+		{"var.region", "variable", 10, 13, true, `variable "region"`},
+		{"local.name", "local", 16, 16, false, `name = "app-${var.env}"`},
+		{"local.tags", "local", 17, 19, false, "tags = {"},
+		{"aws_instance.web", "resource", 22, 29, false, `resource "aws_instance" "web"`},
+		{"data.aws_ami.ubuntu", "data", 31, 33, false, `data "aws_ami" "ubuntu"`},
+		{"module.vpc", "module", 35, 37, false, `module "vpc"`},
+		{"instance_ip", "output", 39, 41, true, `output "instance_ip"`},
+	}
+	if len(symbols) != len(tests) {
+		t.Errorf("got %d symbols, want %d: %+v", len(symbols), len(tests), symbols)
+	}
+	byName := symbolsByName(symbols)
+	for _, tt := range tests {
+		sym, ok := byName[tt.name]
+		if !ok {
+			t.Errorf("symbol %q not found", tt.name)
+			continue
+		}
+		if sym.Kind != tt.kind || sym.Exported != tt.exported {
+			t.Errorf("%s: kind %q exported %v, want %q exported %v", tt.name, sym.Kind, sym.Exported, tt.kind, tt.exported)
+		}
+		if sym.Line != tt.line || sym.EndLine != tt.endLine {
+			t.Errorf("%s: lines %d-%d, want %d-%d", tt.name, sym.Line, sym.EndLine, tt.line, tt.endLine)
+		}
+		if sym.Signature != tt.signature {
+			t.Errorf("%s: signature %q, want %q", tt.name, sym.Signature, tt.signature)
+		}
+	}
+}